@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Gateway 校验 JWT 后转发给后端服务的身份头
+const (
+	HeaderUserID            = "X-User-ID"
+	HeaderUsername          = "X-Username" // URL 编码
+	HeaderTenantID          = "X-Tenant-ID"
+	HeaderUserType          = "X-User-Type"
+	HeaderIdentityTimestamp = "X-Identity-Timestamp"
+	HeaderIdentityNonce     = "X-Identity-Nonce"
+	HeaderIdentitySignature = "X-Identity-Signature"
+)
+
+// IdentityMaxSkew 身份头签名的有效时间，超过后后端不再信任
+const IdentityMaxSkew = 5 * time.Minute
+
+var identityHeaders = []string{
+	HeaderUserID, HeaderUsername, HeaderTenantID, HeaderUserType,
+	HeaderIdentityTimestamp, HeaderIdentityNonce, HeaderIdentitySignature,
+}
+
+var (
+	// ErrNoIdentity 请求中没有身份头（未经过 Gateway）
+	ErrNoIdentity = errors.New("identity headers not present")
+	// ErrNoIdentitySecret 没有配置身份头的签名密钥（IDENTITY_SECRET）
+	ErrNoIdentitySecret = errors.New("identity secret is not configured")
+)
+
+// Identity 经 Gateway 认证的用户身份，TenantID 为 0 表示没有租户
+type Identity struct {
+	UserID   uint
+	Username string
+	TenantID uint
+	UserType string
+}
+
+type identityKey struct{}
+
+// ContextWithIdentity 在请求上下文中记录已认证的身份，转发时由 SignIdentity 写入身份头
+func ContextWithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext 返回 ContextWithIdentity 记录的身份
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// StripIdentity 删除请求中的身份头，防止客户端伪造
+func StripIdentity(h http.Header) {
+	for _, name := range identityHeaders {
+		h.Del(name)
+	}
+}
+
+// SignIdentity 写入身份头并使用身份签名密钥（与 JWT 密钥分开）签名。签名同时覆盖请求方法、
+// 路径和查询参数以及一次性的 nonce，身份头不能挪用到其他请求，也不能重放
+func SignIdentity(r *http.Request, identity Identity, secret string) {
+	StripIdentity(r.Header)
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("auth: failed to generate identity nonce: %v", err))
+	}
+	h := r.Header
+	h.Set(HeaderUserID, strconv.FormatUint(uint64(identity.UserID), 10))
+	h.Set(HeaderUsername, url.QueryEscape(identity.Username))
+	h.Set(HeaderTenantID, strconv.FormatUint(uint64(identity.TenantID), 10))
+	h.Set(HeaderUserType, identity.UserType)
+	h.Set(HeaderIdentityTimestamp, strconv.FormatInt(time.Now().Unix(), 10))
+	h.Set(HeaderIdentityNonce, hex.EncodeToString(nonce))
+	h.Set(HeaderIdentitySignature, identitySignature(r, secret))
+}
+
+// VerifyIdentity 校验身份头的签名、时间戳和 nonce，没有身份头时返回 ErrNoIdentity；
+// 校验通过的 nonce 记录在 nonces 中，有效期内再次出现时拒绝
+func VerifyIdentity(r *http.Request, secret string, nonces *NonceCache) (*Identity, error) {
+	h := r.Header
+	signature := h.Get(HeaderIdentitySignature)
+	if signature == "" {
+		return nil, ErrNoIdentity
+	}
+	if secret == "" {
+		return nil, ErrNoIdentitySecret
+	}
+	if h.Get(HeaderIdentityNonce) == "" {
+		return nil, errors.New("identity nonce is missing")
+	}
+	if !hmac.Equal([]byte(signature), []byte(identitySignature(r, secret))) {
+		return nil, errors.New("invalid identity signature")
+	}
+
+	timestamp, err := strconv.ParseInt(h.Get(HeaderIdentityTimestamp), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid identity timestamp")
+	}
+	signedAt := time.Unix(timestamp, 0)
+	if age := time.Since(signedAt); age > IdentityMaxSkew || age < -IdentityMaxSkew {
+		return nil, errors.New("identity headers expired")
+	}
+	if !nonces.Use(h.Get(HeaderIdentityNonce), signedAt.Add(IdentityMaxSkew)) {
+		return nil, errors.New("identity headers replayed")
+	}
+
+	userID, err := strconv.ParseUint(h.Get(HeaderUserID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", HeaderUserID, err)
+	}
+	tenantID, err := strconv.ParseUint(h.Get(HeaderTenantID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", HeaderTenantID, err)
+	}
+	username, err := url.QueryUnescape(h.Get(HeaderUsername))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", HeaderUsername, err)
+	}
+	return &Identity{
+		UserID:   uint(userID),
+		Username: username,
+		TenantID: uint(tenantID),
+		UserType: h.Get(HeaderUserType),
+	}, nil
+}
+
+// identitySignature 对请求方法、路径和身份头计算 HMAC-SHA256，前缀区分于 JWT 签名
+func identitySignature(r *http.Request, secret string) string {
+	h := r.Header
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "addp-identity\n%s\n%s\n%s\n%s\n%s\n%s\n%s\n%s",
+		r.Method, r.URL.RequestURI(),
+		h.Get(HeaderUserID), h.Get(HeaderUsername), h.Get(HeaderTenantID),
+		h.Get(HeaderUserType), h.Get(HeaderIdentityTimestamp), h.Get(HeaderIdentityNonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NonceCache 记录签名有效期内已使用的身份头 nonce（单个服务实例内）
+type NonceCache struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time // nonce → 签名失效时间
+	sweepAt time.Time
+}
+
+func NewNonceCache() *NonceCache {
+	return &NonceCache{seen: make(map[string]time.Time)}
+}
+
+// Use 记录 nonce 直到 expiresAt，之前已记录且未失效时返回 false
+func (c *NonceCache) Use(nonce string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// 定期清理已失效的 nonce，失效后签名本身已不再被接受
+	if now.After(c.sweepAt) {
+		for seen, expires := range c.seen {
+			if now.After(expires) {
+				delete(c.seen, seen)
+			}
+		}
+		c.sweepAt = now.Add(IdentityMaxSkew)
+	}
+
+	if expires, ok := c.seen[nonce]; ok && !now.After(expires) {
+		return false
+	}
+	c.seen[nonce] = expiresAt
+	return true
+}
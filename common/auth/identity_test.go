@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testIdentitySecret = "identity-test-secret"
+
+var testIdentity = Identity{UserID: 7, Username: "张三 li", TenantID: 3, UserType: "user"}
+
+func signedRequest(method, target string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	SignIdentity(r, testIdentity, testIdentitySecret)
+	return r
+}
+
+func TestSignVerifyIdentity(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/tasks/1/run?force=true", nil)
+	// 客户端伪造的身份头在签名时被清除
+	r.Header.Set(HeaderUserType, "super_admin")
+	SignIdentity(r, testIdentity, testIdentitySecret)
+
+	got, err := VerifyIdentity(r, testIdentitySecret, NewNonceCache())
+	if err != nil {
+		t.Fatalf("VerifyIdentity: %v", err)
+	}
+	if *got != testIdentity {
+		t.Fatalf("identity = %+v, want %+v", *got, testIdentity)
+	}
+
+	if _, err := VerifyIdentity(httptest.NewRequest(http.MethodGet, "/", nil), testIdentitySecret, NewNonceCache()); !errors.Is(err, ErrNoIdentity) {
+		t.Fatalf("no headers: err = %v, want ErrNoIdentity", err)
+	}
+	if _, err := VerifyIdentity(signedRequest(http.MethodGet, "/"), "", NewNonceCache()); !errors.Is(err, ErrNoIdentitySecret) {
+		t.Fatalf("no secret: err = %v, want ErrNoIdentitySecret", err)
+	}
+}
+
+func TestVerifyIdentityRejectsTampering(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func(r *http.Request) *http.Request
+	}{
+		{"user type", func(r *http.Request) *http.Request {
+			r.Header.Set(HeaderUserType, "super_admin")
+			return r
+		}},
+		{"tenant", func(r *http.Request) *http.Request {
+			r.Header.Set(HeaderTenantID, "4")
+			return r
+		}},
+		{"timestamp", func(r *http.Request) *http.Request {
+			r.Header.Set(HeaderIdentityTimestamp, strconv.FormatInt(time.Now().Unix()+1, 10))
+			return r
+		}},
+		{"nonce", func(r *http.Request) *http.Request {
+			r.Header.Set(HeaderIdentityNonce, "00")
+			return r
+		}},
+		{"missing nonce", func(r *http.Request) *http.Request {
+			r.Header.Del(HeaderIdentityNonce)
+			return r
+		}},
+		// 身份头挪用到同一用户的其他请求
+		{"method", func(r *http.Request) *http.Request {
+			moved := httptest.NewRequest(http.MethodDelete, r.URL.RequestURI(), nil)
+			moved.Header = r.Header
+			return moved
+		}},
+		{"path", func(r *http.Request) *http.Request {
+			moved := httptest.NewRequest(r.Method, "/api/tasks/2", nil)
+			moved.Header = r.Header
+			return moved
+		}},
+		{"query", func(r *http.Request) *http.Request {
+			moved := httptest.NewRequest(r.Method, "/api/tasks/1?force=false", nil)
+			moved.Header = r.Header
+			return moved
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := tc.tamper(signedRequest(http.MethodGet, "/api/tasks/1?force=true"))
+			if _, err := VerifyIdentity(r, testIdentitySecret, NewNonceCache()); err == nil {
+				t.Fatal("tampered identity accepted")
+			}
+		})
+	}
+
+	// 使用 JWT 密钥或其他密钥签名的身份头不被接受
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	SignIdentity(r, testIdentity, "jwt-secret")
+	if _, err := VerifyIdentity(r, testIdentitySecret, NewNonceCache()); err == nil {
+		t.Fatal("identity signed with another key accepted")
+	}
+}
+
+func TestVerifyIdentitySkew(t *testing.T) {
+	cases := []struct {
+		name   string
+		offset time.Duration
+		ok     bool
+	}{
+		{"recent", -time.Minute, true},
+		{"slightly ahead", time.Minute, true},
+		{"expired", -IdentityMaxSkew - time.Minute, false},
+		{"too far ahead", IdentityMaxSkew + time.Minute, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := signedRequest(http.MethodGet, "/api/tasks")
+			// 按偏移后的时间戳重新签名，模拟网关与后端的时钟偏差
+			r.Header.Set(HeaderIdentityTimestamp, strconv.FormatInt(time.Now().Add(tc.offset).Unix(), 10))
+			r.Header.Set(HeaderIdentitySignature, identitySignature(r, testIdentitySecret))
+
+			_, err := VerifyIdentity(r, testIdentitySecret, NewNonceCache())
+			if (err == nil) != tc.ok {
+				t.Fatalf("err = %v, want ok = %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestVerifyIdentityRejectsReplay(t *testing.T) {
+	nonces := NewNonceCache()
+	r := signedRequest(http.MethodPost, "/api/tasks")
+	if _, err := VerifyIdentity(r, testIdentitySecret, nonces); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := VerifyIdentity(r, testIdentitySecret, nonces); err == nil {
+		t.Fatal("replayed identity accepted")
+	}
+	// 每次签名使用新的 nonce
+	if _, err := VerifyIdentity(signedRequest(http.MethodPost, "/api/tasks"), testIdentitySecret, nonces); err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+}
+
+func TestNonceCacheExpiry(t *testing.T) {
+	nonces := NewNonceCache()
+	if !nonces.Use("a", time.Now().Add(-time.Second)) {
+		t.Fatal("first use rejected")
+	}
+	// 签名已失效的 nonce 不再占用记录
+	if !nonces.Use("a", time.Now().Add(time.Minute)) {
+		t.Fatal("expired nonce still blocked")
+	}
+	if nonces.Use("a", time.Now().Add(time.Minute)) {
+		t.Fatal("nonce reused within validity")
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Claims System 签发的 JWT 声明
+type Claims struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	TenantID  *uint  `json:"tenant_id,omitempty"` // SuperAdmin 没有租户
+	UserType  string `json:"user_type,omitempty"`
+	ID        string `json:"jti,omitempty"` // 令牌 ID，用于吊销
+	SessionID string `json:"sid,omitempty"` // 签发令牌的登录会话
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// Identity 返回声明中的用户身份
+func (c *Claims) Identity() Identity {
+	identity := Identity{UserID: c.UserID, Username: c.Username, UserType: c.UserType}
+	if c.TenantID != nil {
+		identity.TenantID = *c.TenantID
+	}
+	return identity
+}
+
+// ParseToken 校验 HS256 签名和有效期并返回声明
+func ParseToken(token, secret string) (*Claims, error) {
+	if secret == "" {
+		return nil, errors.New("jwt secret is not configured")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	now := time.Now().Unix()
+	if claims.ExpiresAt != 0 && now >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// BearerToken 从 Authorization 头中取出 Bearer 令牌
+func BearerToken(header string) (string, bool) {
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
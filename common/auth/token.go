@@ -0,0 +1,58 @@
+package auth
+
+import "strings"
+
+// APITokenPrefix 个人访问令牌和服务账号令牌的前缀，网关和服务据此区分访问令牌和 JWT
+const APITokenPrefix = "addp_pat_"
+
+// 访问令牌的权限范围：read 只允许只读请求，write 允许全部请求；
+// 加服务名前缀（如 transfer:write）时只对该服务有效
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// TokenServices 可以在权限范围中指定的服务
+var TokenServices = []string{"system", "manager", "meta", "transfer", "gateway"}
+
+// IsAPIToken 是否为访问令牌（而不是 JWT）
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// ValidScope 校验权限范围的格式
+func ValidScope(scope string) bool {
+	service, access, ok := strings.Cut(scope, ":")
+	if !ok {
+		access, service = service, ""
+	}
+	if access != ScopeRead && access != ScopeWrite {
+		return false
+	}
+	if service == "" {
+		return !ok
+	}
+	for _, name := range TokenServices {
+		if name == service {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeAllows 权限范围是否允许对 service 发起 method 请求，write 包含 read
+func ScopeAllows(scopes []string, service, method string) bool {
+	readOnly := method == "GET" || method == "HEAD" || method == "OPTIONS"
+	for _, scope := range scopes {
+		prefix, access, ok := strings.Cut(scope, ":")
+		if !ok {
+			access = prefix
+		} else if prefix != service {
+			continue
+		}
+		if access == ScopeWrite || (access == ScopeRead && readOnly) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package auth
+
+import "testing"
+
+func TestValidScope(t *testing.T) {
+	for _, scope := range []string{"read", "write", "meta:read", "transfer:write", "gateway:read"} {
+		if !ValidScope(scope) {
+			t.Errorf("ValidScope(%q) = false", scope)
+		}
+	}
+	for _, scope := range []string{"", "admin", "meta", "meta:admin", "billing:read", ":read", "meta:read:write"} {
+		if ValidScope(scope) {
+			t.Errorf("ValidScope(%q) = true", scope)
+		}
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	cases := []struct {
+		scopes  []string
+		service string
+		method  string
+		want    bool
+	}{
+		{[]string{"read"}, "meta", "GET", true},
+		{[]string{"read"}, "meta", "HEAD", true},
+		{[]string{"read"}, "meta", "POST", false},
+		{[]string{"read"}, "system", "DELETE", false},
+		{[]string{"write"}, "system", "PUT", true},
+		{[]string{"write"}, "meta", "GET", true},
+		// 带服务名的权限范围只对该服务有效
+		{[]string{"meta:write"}, "meta", "POST", true},
+		{[]string{"meta:write"}, "transfer", "GET", false},
+		{[]string{"meta:read"}, "meta", "PATCH", false},
+		{[]string{"meta:read", "transfer:write"}, "transfer", "POST", true},
+		{[]string{"read", "meta:write"}, "meta", "POST", true},
+		{[]string{"read", "meta:write"}, "system", "POST", false},
+		{nil, "meta", "GET", false},
+	}
+	for _, tc := range cases {
+		if got := ScopeAllows(tc.scopes, tc.service, tc.method); got != tc.want {
+			t.Errorf("ScopeAllows(%v, %s, %s) = %v, want %v", tc.scopes, tc.service, tc.method, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package authz
+
+// 资源访问级别，高级别包含低级别的全部能力
+const (
+	AccessView    = "view"    // 查看资源和元数据
+	AccessPreview = "preview" // 预览数据
+	AccessConnect = "connect" // 使用连接信息（测试连接、数据传输）
+	AccessManage  = "manage"  // 修改、删除资源和管理授权
+)
+
+// AccessLevels 按从低到高排列的访问级别
+var AccessLevels = []string{AccessView, AccessPreview, AccessConnect, AccessManage}
+
+// AccessRank 返回访问级别的序号（从 1 开始），未知级别返回 0
+func AccessRank(level string) int {
+	for i, l := range AccessLevels {
+		if l == level {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// AccessAllows granted 级别是否满足 required 级别
+func AccessAllows(granted, required string) bool {
+	rank := AccessRank(granted)
+	return rank > 0 && rank >= AccessRank(required)
+}
@@ -0,0 +1,88 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FetchFunc 从 System 查询用户的有效权限
+type FetchFunc func(ctx context.Context, userID uint) ([]string, error)
+
+type cacheEntry struct {
+	permissions map[string]bool
+	expiresAt   time.Time
+}
+
+// Checker 检查用户权限，结果按用户缓存 ttl，角色变更最多延迟 ttl 生效
+type Checker struct {
+	fetch FetchFunc
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache map[uint]cacheEntry
+}
+
+// NewChecker fetch 通常为 SystemClient.GetUserPermissionsWithContext
+func NewChecker(fetch FetchFunc, ttl time.Duration) *Checker {
+	return &Checker{
+		fetch: fetch,
+		ttl:   ttl,
+		cache: make(map[uint]cacheEntry),
+	}
+}
+
+// Allowed 用户是否拥有 permission；查询 System 失败时返回错误，由调用方决定拒绝请求
+func (c *Checker) Allowed(ctx context.Context, userID uint, permission string) (bool, error) {
+	permissions, err := c.permissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return permissions[permission], nil
+}
+
+// Authorize 检查请求用户的权限，通过时返回 http.StatusOK，否则返回应答的状态码和 JSON 内容；
+// checker 为 nil（未配置权限检查）时拒绝请求
+func (c *Checker) Authorize(ctx context.Context, userID uint, permission string) (int, map[string]string) {
+	if c == nil {
+		return http.StatusServiceUnavailable, map[string]string{"error": "permission checks are not configured"}
+	}
+	allowed, err := c.Allowed(ctx, userID, permission)
+	if err != nil {
+		return http.StatusServiceUnavailable, map[string]string{"error": "failed to check permission", "details": err.Error()}
+	}
+	if !allowed {
+		return http.StatusForbidden, map[string]string{"error": "permission denied", "permission": permission}
+	}
+	return http.StatusOK, nil
+}
+
+func (c *Checker) permissions(ctx context.Context, userID uint) (map[string]bool, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.cache[userID]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	names, err := c.fetch(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make(map[string]bool, len(names))
+	for _, name := range names {
+		permissions[name] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, e := range c.cache {
+		if !now.Before(e.expiresAt) {
+			delete(c.cache, id)
+		}
+	}
+	c.cache[userID] = cacheEntry{permissions: permissions, expiresAt: now.Add(c.ttl)}
+	return permissions, nil
+}
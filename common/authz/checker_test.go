@@ -0,0 +1,61 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheckerAuthorize(t *testing.T) {
+	ctx := context.Background()
+	permissions := map[uint][]string{1: {MetaRead, MetaScan}, 2: {MetaRead}}
+	var fetches int
+	var fetchErr error
+	checker := NewChecker(func(ctx context.Context, userID uint) ([]string, error) {
+		fetches++
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return permissions[userID], nil
+	}, time.Minute)
+
+	if code, _ := checker.Authorize(ctx, 1, MetaScan); code != http.StatusOK {
+		t.Fatalf("user 1 meta:scan = %d, want 200", code)
+	}
+	code, body := checker.Authorize(ctx, 2, MetaScan)
+	if code != http.StatusForbidden || body["permission"] != MetaScan {
+		t.Fatalf("user 2 meta:scan = %d %v, want 403", code, body)
+	}
+	if code, _ := checker.Authorize(ctx, 3, MetaRead); code != http.StatusForbidden {
+		t.Fatalf("user without permissions = %d, want 403", code)
+	}
+
+	// 权限按用户缓存 ttl，期间角色变更不生效
+	permissions[2] = []string{MetaRead, MetaScan}
+	if allowed, _ := checker.Allowed(ctx, 2, MetaScan); allowed {
+		t.Fatal("cached permissions not used")
+	}
+	if fetches != 3 {
+		t.Fatalf("fetches = %d, want 3", fetches)
+	}
+
+	// 缓存过期后重新查询；查询失败时拒绝而不是放行
+	checker.cache[2] = cacheEntry{expiresAt: time.Now().Add(-time.Second)}
+	if allowed, _ := checker.Allowed(ctx, 2, MetaScan); !allowed {
+		t.Fatal("permissions not refreshed after ttl")
+	}
+	delete(checker.cache, 1)
+	fetchErr = errors.New("system unavailable")
+	if code, _ := checker.Authorize(ctx, 1, MetaScan); code != http.StatusServiceUnavailable {
+		t.Fatalf("fetch error = %d, want 503", code)
+	}
+}
+
+func TestNilCheckerDenies(t *testing.T) {
+	var checker *Checker
+	if code, _ := checker.Authorize(context.Background(), 1, MetaRead); code != http.StatusServiceUnavailable {
+		t.Fatalf("nil checker = %d, want 503", code)
+	}
+}
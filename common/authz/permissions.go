@@ -0,0 +1,98 @@
+package authz
+
+// 权限名，格式为 <模块或对象>:<操作>；角色由权限组合而成，按租户定义
+const (
+	// 平台级权限，只有超级管理员拥有
+	TenantManage = "tenant:manage"
+
+	// System
+	UserRead       = "user:read"
+	UserManage     = "user:manage"
+	RoleManage     = "role:manage"
+	ResourceRead   = "resource:read"
+	ResourceManage = "resource:manage"
+	ResourceConn   = "resource:connect"
+	LogRead        = "log:read"
+
+	// Meta
+	MetaRead = "meta:read"
+	MetaScan = "meta:scan"
+
+	// Manager
+	ManagerRead    = "manager:read"
+	ManagerPreview = "manager:preview"
+	ManagerManage  = "manager:manage"
+
+	// Transfer
+	TransferRead   = "transfer:read"
+	TransferManage = "transfer:manage"
+	TransferRun    = "transfer:run"
+)
+
+// Permission 权限说明
+type Permission struct {
+	Name        string `json:"name"`
+	Module      string `json:"module"`
+	Description string `json:"description"`
+	Platform    bool   `json:"platform,omitempty"` // 平台级权限不能分配给租户角色
+}
+
+// Catalog 所有权限
+var Catalog = []Permission{
+	{Name: TenantManage, Module: "system", Description: "管理租户", Platform: true},
+	{Name: UserRead, Module: "system", Description: "查看本租户用户"},
+	{Name: UserManage, Module: "system", Description: "创建、修改、删除用户和强制下线"},
+	{Name: RoleManage, Module: "system", Description: "管理角色和分配角色"},
+	{Name: ResourceRead, Module: "system", Description: "查看数据资源"},
+	{Name: ResourceManage, Module: "system", Description: "创建、修改、删除数据资源"},
+	{Name: ResourceConn, Module: "system", Description: "测试资源连接"},
+	{Name: LogRead, Module: "system", Description: "查看审计日志"},
+	{Name: MetaRead, Module: "meta", Description: "查看元数据"},
+	{Name: MetaScan, Module: "meta", Description: "扫描数据源元数据"},
+	{Name: ManagerRead, Module: "manager", Description: "浏览数据目录"},
+	{Name: ManagerPreview, Module: "manager", Description: "预览表和对象数据"},
+	{Name: ManagerManage, Module: "manager", Description: "纳管表、上传文件"},
+	{Name: TransferRead, Module: "transfer", Description: "查看传输任务和执行记录"},
+	{Name: TransferManage, Module: "transfer", Description: "创建、修改、删除传输任务"},
+	{Name: TransferRun, Module: "transfer", Description: "运行、暂停、取消传输任务"},
+}
+
+// Valid 是否为已定义的权限
+func Valid(name string) bool {
+	for _, p := range Catalog {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPlatform 是否为平台级权限
+func IsPlatform(name string) bool {
+	for _, p := range Catalog {
+		if p.Name == name {
+			return p.Platform
+		}
+	}
+	return false
+}
+
+// All 返回所有权限名
+func All() []string {
+	names := make([]string, 0, len(Catalog))
+	for _, p := range Catalog {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// TenantScoped 返回可分配给租户角色的权限名
+func TenantScoped() []string {
+	names := make([]string, 0, len(Catalog))
+	for _, p := range Catalog {
+		if !p.Platform {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
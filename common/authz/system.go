@@ -0,0 +1,21 @@
+package authz
+
+import (
+	"errors"
+
+	"github.com/addp/common/client"
+	"github.com/addp/common/config"
+)
+
+// ErrNoInternalKey 未配置 Internal API Key，无法向 System 查询权限
+var ErrNoInternalKey = errors.New("INTERNAL_API_KEY not configured, permission checks cannot be enforced")
+
+// NewSystemChecker 通过 System 的内部接口查询用户权限（结果缓存 PERMISSION_CACHE_TTL，默认 30s）；
+// 没有 Internal API Key 时返回 ErrNoInternalKey，服务应拒绝启动而不是跳过权限检查
+func NewSystemChecker(systemServiceURL, internalAPIKey string) (*Checker, error) {
+	if internalAPIKey == "" {
+		return nil, ErrNoInternalKey
+	}
+	systemClient := client.NewSystemClientWithInternalKey(systemServiceURL, internalAPIKey)
+	return NewChecker(systemClient.GetUserPermissionsWithContext, config.GetEnvDuration("PERMISSION_CACHE_TTL", "30s")), nil
+}
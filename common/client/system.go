@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/addp/common/models"
+	"github.com/addp/common/tracing"
 )
 
 // SystemClient 系统服务客户端
@@ -54,15 +56,34 @@ func (c *SystemClient) addAuth(req *http.Request) {
 
 // GetResource 获取资源详情
 func (c *SystemClient) GetResource(resourceID uint) (*models.Resource, error) {
+	return c.GetResourceWithContext(context.Background(), resourceID)
+}
+
+// GetResourceWithContext 获取资源详情，并把 ctx 中的请求 ID 和 trace 传给 System
+func (c *SystemClient) GetResourceWithContext(ctx context.Context, resourceID uint) (*models.Resource, error) {
+	return c.GetResourceForUserWithContext(ctx, resourceID, 0, "")
+}
+
+// GetResourceForUser 获取资源详情，并要求用户对资源的访问级别不低于 level（authz.AccessConnect 等）
+func (c *SystemClient) GetResourceForUser(resourceID, userID uint, level string) (*models.Resource, error) {
+	return c.GetResourceForUserWithContext(context.Background(), resourceID, userID, level)
+}
+
+// GetResourceForUserWithContext 同 GetResourceForUser；userID 为 0 时不检查访问级别。
+// 使用用户令牌时 System 按令牌中的用户检查，忽略 userID 和 level
+func (c *SystemClient) GetResourceForUserWithContext(ctx context.Context, resourceID, userID uint, level string) (*models.Resource, error) {
 	var url string
 	// 如果使用内部 API Key，调用内部 API
 	if c.internalKey != "" {
 		url = fmt.Sprintf("%s/internal/resources/%d", c.baseURL, resourceID)
+		if userID > 0 {
+			url += fmt.Sprintf("?user_id=%d&level=%s", userID, level)
+		}
 	} else {
 		url = fmt.Sprintf("%s/api/resources/%d", c.baseURL, resourceID)
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -70,7 +91,7 @@ func (c *SystemClient) GetResource(resourceID uint) (*models.Resource, error) {
 	c.addAuth(req)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -91,6 +112,16 @@ func (c *SystemClient) GetResource(resourceID uint) (*models.Resource, error) {
 
 // ListResources 获取资源列表
 func (c *SystemClient) ListResources(resourceType string, tenantID uint) ([]models.Resource, error) {
+	return c.ListResourcesWithContext(context.Background(), resourceType, tenantID)
+}
+
+// ListResourcesWithContext 获取资源列表，并把 ctx 中的请求 ID 和 trace 传给 System
+func (c *SystemClient) ListResourcesWithContext(ctx context.Context, resourceType string, tenantID uint) ([]models.Resource, error) {
+	return c.ListResourcesForUserWithContext(ctx, resourceType, tenantID, 0, "")
+}
+
+// ListResourcesForUserWithContext 获取用户访问级别不低于 level 的资源列表（需要 Internal API Key）；userID 为 0 时不过滤
+func (c *SystemClient) ListResourcesForUserWithContext(ctx context.Context, resourceType string, tenantID, userID uint, level string) ([]models.Resource, error) {
 	var url string
 	// 如果使用内部 API Key，调用内部 API
 	if c.internalKey != "" {
@@ -111,8 +142,15 @@ func (c *SystemClient) ListResources(resourceType string, tenantID uint) ([]mode
 		}
 		url += fmt.Sprintf("%stenant_id=%d", prefix, tenantID)
 	}
+	if userID > 0 && c.internalKey != "" {
+		prefix := "?"
+		if strings.Contains(url, "?") {
+			prefix = "&"
+		}
+		url += fmt.Sprintf("%suser_id=%d&level=%s", prefix, userID, level)
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -120,7 +158,7 @@ func (c *SystemClient) ListResources(resourceType string, tenantID uint) ([]mode
 	c.addAuth(req)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -138,3 +176,53 @@ func (c *SystemClient) ListResources(resourceType string, tenantID uint) ([]mode
 
 	return resources, nil
 }
+
+// GetUserPermissionsWithContext 查询用户的有效权限（需要 Internal API Key），供 authz.Checker 使用
+func (c *SystemClient) GetUserPermissionsWithContext(ctx context.Context, userID uint) ([]string, error) {
+	if c.internalKey == "" {
+		return nil, fmt.Errorf("internal API key is required to query permissions")
+	}
+
+	url := fmt.Sprintf("%s/internal/users/%d/permissions", c.baseURL, userID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.addAuth(req)
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("system api returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Permissions, nil
+}
+
+// do 在单独的 client span 中发送请求
+func (c *SystemClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.StartSpan(ctx, req.Method+" system", tracing.KindClient)
+	defer span.End()
+	span.SetAttribute("url.path", req.URL.Path)
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.SetError(err.Error())
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	return resp, nil
+}
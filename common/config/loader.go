@@ -14,6 +14,7 @@ import (
 // SharedConfig 从 System 服务获取的共享配置
 type SharedConfig struct {
 	JWTSecret      string `json:"jwt_secret"`
+	IdentitySecret string `json:"identity_secret"`
 	EncryptionKey  string `json:"encryption_key"`
 	InternalAPIKey string `json:"internal_api_key"`
 	Database       struct {
@@ -39,6 +40,8 @@ type BaseConfig struct {
 	DBUser     string
 	DBPassword string
 	JWTSecret  string
+	// Gateway 签名、后端服务校验身份头的密钥，与 JWT 密钥分开
+	IdentitySecret string
 
 	// 通用配置
 	SystemServiceURL  string
@@ -85,6 +88,7 @@ func LoadSharedConfig(systemURL string, target *BaseConfig) error {
 
 	// 应用共享配置
 	target.JWTSecret = shared.JWTSecret
+	target.IdentitySecret = shared.IdentitySecret
 	target.DBHost = shared.Database.Host
 	target.DBPort = shared.Database.Port
 	target.DBUser = shared.Database.User
@@ -114,6 +118,7 @@ func LoadSharedConfig(systemURL string, target *BaseConfig) error {
 // LoadLocalConfig 从本地环境变量加载配置（降级方案）
 func LoadLocalConfig(target *BaseConfig) {
 	target.JWTSecret = GetEnv("JWT_SECRET", "")
+	target.IdentitySecret = GetEnv("IDENTITY_SECRET", "")
 	target.DBHost = GetEnv("DB_HOST", "localhost")
 	target.DBPort = GetEnv("DB_PORT", "5432")
 	target.DBUser = GetEnv("DB_USER", "addp")
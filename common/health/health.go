@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 健康状态：关键依赖不可用时为 down，只有非关键依赖不可用时为 degraded
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// Result 单个依赖的检查结果
+type Result struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report 服务的健康报告
+type Report struct {
+	Service      string            `json:"service"`
+	Status       string            `json:"status"`
+	Dependencies map[string]Result `json:"dependencies"`
+	CheckedAt    time.Time         `json:"checked_at"`
+}
+
+// HTTPStatus 关键依赖不可用时返回 503，供负载均衡器判断就绪
+func (r *Report) HTTPStatus() int {
+	if r.Status == StatusDown {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+type check struct {
+	name     string
+	critical bool
+	run      func(ctx context.Context) error
+}
+
+// Checker 并发检查服务的各项依赖
+type Checker struct {
+	service string
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker 创建健康检查，timeout 为每项检查的超时
+func NewChecker(service string, timeout time.Duration) *Checker {
+	return &Checker{service: service, timeout: timeout}
+}
+
+// Add 添加一项依赖检查，critical 表示该依赖不可用时服务无法工作
+func (c *Checker) Add(name string, critical bool, run func(ctx context.Context) error) *Checker {
+	c.checks = append(c.checks, check{name: name, critical: critical, run: run})
+	return c
+}
+
+// Run 并发执行所有检查
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		Service:      c.service,
+		Status:       StatusOK,
+		Dependencies: make(map[string]Result, len(c.checks)),
+		CheckedAt:    time.Now(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func(chk check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := chk.run(checkCtx)
+			result := Result{
+				Status:    StatusOK,
+				Critical:  chk.critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[chk.name] = result
+			switch {
+			case err == nil:
+			case chk.critical:
+				report.Status = StatusDown
+			case report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}(chk)
+	}
+	wg.Wait()
+	return report
+}
+
+// HTTPCheck 请求 url，2xx 视为可用
+func HTTPCheck(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync"
+)
+
+var (
+	dbMu    sync.Mutex
+	dbPools = make(map[string]*sql.DB)
+	dbOnce  sync.Once
+)
+
+// RegisterDB 输出连接池状态，name 用于区分同一服务的多个连接池
+func RegisterDB(name string, db *sql.DB) {
+	dbMu.Lock()
+	dbPools[name] = db
+	dbMu.Unlock()
+
+	dbOnce.Do(func() {
+		NewGaugeFunc("db_connections_open", "连接池中的连接数（使用中和空闲）", dbSamples(func(s sql.DBStats) float64 {
+			return float64(s.OpenConnections)
+		}), "db")
+		NewGaugeFunc("db_connections_in_use", "使用中的连接数", dbSamples(func(s sql.DBStats) float64 {
+			return float64(s.InUse)
+		}), "db")
+		NewGaugeFunc("db_connections_idle", "空闲连接数", dbSamples(func(s sql.DBStats) float64 {
+			return float64(s.Idle)
+		}), "db")
+		NewGaugeFunc("db_connections_max_open", "最大连接数，0 表示不限制", dbSamples(func(s sql.DBStats) float64 {
+			return float64(s.MaxOpenConnections)
+		}), "db")
+		NewCounterFunc("db_wait_count_total", "等待空闲连接的累计次数", dbSamples(func(s sql.DBStats) float64 {
+			return float64(s.WaitCount)
+		}), "db")
+		NewCounterFunc("db_wait_duration_seconds_total", "等待空闲连接的累计时间（秒）", dbSamples(func(s sql.DBStats) float64 {
+			return s.WaitDuration.Seconds()
+		}), "db")
+	})
+}
+
+func dbSamples(value func(sql.DBStats) float64) func() []Sample {
+	return func() []Sample {
+		dbMu.Lock()
+		defer dbMu.Unlock()
+		samples := make([]Sample, 0, len(dbPools))
+		for name, db := range dbPools {
+			samples = append(samples, Sample{LabelValues: []string{name}, Value: value(db.Stats())})
+		}
+		return samples
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 各服务统一的 HTTP 指标
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"HTTP 请求数", "method", "route", "status")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP 请求耗时（秒）", nil, "method", "route", "status")
+)
+
+// RouteUnmatched 没有匹配到路由的请求使用的 route 标签
+const RouteUnmatched = "unmatched"
+
+// ObserveHTTP 记录一次请求；route 为路由模板（如 /api/users/:id），不使用原始路径以免序列过多
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = RouteUnmatched
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+	default:
+		method = "OTHER"
+	}
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
@@ -0,0 +1,316 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets 请求耗时直方图的默认分桶（秒）
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector 一个指标族，按 Prometheus 文本格式输出
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]bool
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// DefaultRegistry 各服务 /metrics 输出的注册表
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[c.name()] {
+		panic(fmt.Sprintf("metrics: %s registered twice", c.name()))
+	}
+	r.names[c.name()] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// ServeHTTP 以 Prometheus 文本格式（0.0.4）输出所有指标
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	bw.Flush()
+}
+
+// Handler 返回 DefaultRegistry 的 /metrics 处理器
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+
+// desc 指标名、说明和标签名
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d *desc) name() string {
+	return d.metricName
+}
+
+func (d *desc) writeHeader(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, escapeHelp(d.help), d.metricName, kind)
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs 格式化标签，extra 为直方图的 le 等附加标签
+func (d *desc) labelPairs(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(d.labels)+len(extra)/2)
+	for i, label := range d.labels {
+		pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// series 一组标签值对应的数据
+type series[T any] struct {
+	values []string
+	data   T
+}
+
+// family 按标签值保存各序列
+type family[T any] struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series[T]
+	create func() T
+}
+
+func (f *family[T]) get(values []string) T {
+	key := f.key(values)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series[T]{values: append([]string(nil), values...), data: f.create()}
+		f.series[key] = s
+	}
+	return s.data
+}
+
+// sorted 返回按标签值排序的序列，保证输出稳定
+func (f *family[T]) sorted() []*series[T] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*series[T], len(keys))
+	for i, key := range keys {
+		result[i] = f.series[key]
+	}
+	return result
+}
+
+// Counter 只增不减的计数
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc 加 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 增加 v，v 不能为负数
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) get() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// CounterVec 按标签区分的计数器
+type CounterVec struct {
+	family[*Counter]
+}
+
+// NewCounterVec 创建计数器并注册到 DefaultRegistry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{family[*Counter]{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		series: make(map[string]*series[*Counter]),
+		create: func() *Counter { return &Counter{} },
+	}}
+	DefaultRegistry.register(v)
+	return v
+}
+
+// WithLabelValues 返回标签值对应的计数器，标签值按创建时的标签顺序传入
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.get(values)
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.writeHeader(w, "counter")
+	for _, s := range v.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", v.metricName, v.labelPairs(s.values), formatFloat(s.data.get()))
+	}
+}
+
+// Histogram 按分桶统计观测值
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec 按标签区分的直方图
+type HistogramVec struct {
+	family[*Histogram]
+}
+
+// NewHistogramVec 创建直方图并注册到 DefaultRegistry，buckets 为空时使用 DefaultBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	v := &HistogramVec{family[*Histogram]{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		series: make(map[string]*series[*Histogram]),
+		create: func() *Histogram {
+			return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+		},
+	}}
+	DefaultRegistry.register(v)
+	return v
+}
+
+// WithLabelValues 返回标签值对应的直方图
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.get(values)
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.writeHeader(w, "histogram")
+	for _, s := range v.sorted() {
+		h := s.data
+		h.mu.Lock()
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.metricName, v.labelPairs(s.values, "le", formatFloat(upper)), h.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.metricName, v.labelPairs(s.values, "le", "+Inf"), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.metricName, v.labelPairs(s.values), formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.metricName, v.labelPairs(s.values), h.count)
+		h.mu.Unlock()
+	}
+}
+
+// Sample 采集时计算出的一个值
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// funcCollector 每次采集时调用函数获取当前值（如连接池状态）
+type funcCollector struct {
+	desc
+	kind    string
+	collect func() []Sample
+}
+
+// NewGaugeFunc 注册采集时计算的 gauge
+func NewGaugeFunc(name, help string, collect func() []Sample, labels ...string) {
+	DefaultRegistry.register(&funcCollector{desc: desc{metricName: name, help: help, labels: labels}, kind: "gauge", collect: collect})
+}
+
+// NewCounterFunc 注册采集时读取的累计值（如连接池累计等待次数）
+func NewCounterFunc(name, help string, collect func() []Sample, labels ...string) {
+	DefaultRegistry.register(&funcCollector{desc: desc{metricName: name, help: help, labels: labels}, kind: "counter", collect: collect})
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	f.writeHeader(w, f.kind)
+	for _, sample := range f.collect() {
+		f.key(sample.LabelValues)
+		fmt.Fprintf(w, "%s%s %s\n", f.metricName, f.labelPairs(sample.LabelValues), formatFloat(sample.Value))
+	}
+}
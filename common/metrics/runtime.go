@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// 进程和 Go 运行时指标
+func init() {
+	start := float64(time.Now().Unix())
+	NewGaugeFunc("process_start_time_seconds", "进程启动时间（Unix 秒）", func() []Sample {
+		return []Sample{{Value: start}}
+	})
+	NewGaugeFunc("go_goroutines", "当前 goroutine 数", func() []Sample {
+		return []Sample{{Value: float64(runtime.NumGoroutine())}}
+	})
+	NewGaugeFunc("go_memstats_heap_alloc_bytes", "堆上已分配且仍在使用的字节数", func() []Sample {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return []Sample{{Value: float64(stats.HeapAlloc)}}
+	})
+}
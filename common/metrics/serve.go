@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Serve 在单独的内部端口上提供 /metrics，不经过对外的服务端口暴露；port 为空时不监听，
+// 只写端口号时监听所有地址
+func Serve(service, port string) {
+	if port == "" {
+		return
+	}
+	addr := port
+	if !strings.Contains(addr, ":") {
+		addr = ":" + addr
+	}
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", Handler())
+		log.Printf("%s metrics listening on %s", service, addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("%s metrics server stopped: %v", service, err)
+		}
+	}()
+}
@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	exportInterval  = 5 * time.Second
+	exportBatchSize = 512
+	maxQueuedSpans  = 4096
+)
+
+var globalExporter atomic.Pointer[exporter]
+
+func currentExporter() *exporter {
+	return globalExporter.Load()
+}
+
+// exporter 以 OTLP/HTTP（JSON 编码）批量导出 span
+type exporter struct {
+	service  string
+	endpoint string
+	client   *http.Client
+
+	mu      sync.Mutex
+	spans   []*Span
+	dropped int
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+// Init 配置了 OTEL_EXPORTER_OTLP_ENDPOINT 时把 span 导出到该地址的 /v1/traces，
+// 未配置时只在服务间传递请求 ID 和 traceparent；返回的函数在退出前导出剩余的 span
+func Init(service string) func() {
+	endpoint := strings.TrimRight(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	if endpoint == "" {
+		return func() {}
+	}
+
+	e := &exporter{
+		service:  service,
+		endpoint: endpoint + "/v1/traces",
+		client:   &http.Client{Timeout: 10 * time.Second},
+		flushCh:  make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	globalExporter.Store(e)
+	go e.loop()
+	log.Printf("Tracing enabled, exporting spans to %s", e.endpoint)
+
+	return func() {
+		globalExporter.CompareAndSwap(e, nil)
+		close(e.stopCh)
+		<-e.doneCh
+	}
+}
+
+func (e *exporter) add(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.spans) >= maxQueuedSpans {
+		// collector 不可用时丢弃，避免占用过多内存
+		e.dropped++
+		return
+	}
+	e.spans = append(e.spans, span)
+	if len(e.spans) >= exportBatchSize {
+		select {
+		case e.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *exporter) loop() {
+	defer close(e.doneCh)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flushCh:
+		case <-e.stopCh:
+			e.flush()
+			return
+		}
+		e.flush()
+	}
+}
+
+func (e *exporter) flush() {
+	e.mu.Lock()
+	spans, dropped := e.spans, e.dropped
+	e.spans, e.dropped = nil, 0
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Tracing: dropped %d spans, exporter queue is full", dropped)
+	}
+	for len(spans) > 0 {
+		n := min(len(spans), exportBatchSize)
+		if err := e.export(spans[:n]); err != nil {
+			log.Printf("Tracing: failed to export %d spans: %v", n, err)
+		}
+		spans = spans[n:]
+	}
+}
+
+func (e *exporter) export(spans []*Span) error {
+	data, err := json.Marshal(e.payload(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector returned status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+// OTLP JSON 编码（见 opentelemetry-proto 的 JSON 映射），trace ID 和 span ID 为十六进制
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+func (e *exporter) payload(spans []*Span) map[string]interface{} {
+	items := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		items = append(items, encodeSpan(span))
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{attribute("service.name", e.service)},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "github.com/addp/common/tracing"},
+				"spans": items,
+			}},
+		}},
+	}
+}
+
+func encodeSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	item := otlpSpan{
+		TraceID:           hex.EncodeToString(span.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(span.sc.SpanID[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+	}
+	if span.parent != [8]byte{} {
+		item.ParentSpanID = hex.EncodeToString(span.parent[:])
+	}
+	for key, value := range span.attributes {
+		item.Attributes = append(item.Attributes, attribute(key, value))
+	}
+	if span.errMessage != "" {
+		item.Status = otlpStatus{Code: 2, Message: span.errMessage}
+	}
+	return item
+}
+
+func attribute(key string, value interface{}) otlpAttribute {
+	var v map[string]interface{}
+	switch val := value.(type) {
+	case bool:
+		v = map[string]interface{}{"boolValue": val}
+	case int:
+		v = map[string]interface{}{"intValue": strconv.Itoa(val)}
+	case int64:
+		v = map[string]interface{}{"intValue": strconv.FormatInt(val, 10)}
+	case float64:
+		v = map[string]interface{}{"doubleValue": val}
+	default:
+		v = map[string]interface{}{"stringValue": fmt.Sprint(val)}
+	}
+	return otlpAttribute{Key: key, Value: v}
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StartServerRequest 使用上游传入的请求 ID（没有时生成）并开始本次请求的 span，请求 ID 写入响应头。
+// route 为路由模板（如 /api/users/:id），没有匹配的路由时为空；返回带 span 的请求和结束 span 的函数，
+// 请求处理完成后以响应状态码调用
+func StartServerRequest(w http.ResponseWriter, r *http.Request, route string) (*http.Request, func(status int)) {
+	name := r.Method
+	if route != "" {
+		name += " " + route
+	}
+	ctx := Extract(r.Context(), r.Header)
+	ctx, span := StartSpan(ctx, name, KindServer)
+	span.SetAttribute("url.path", r.URL.Path)
+	w.Header().Set(RequestIDHeader, RequestID(ctx))
+
+	return r.WithContext(ctx), func(status int) {
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetError(http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
+// AccessLog 格式化一行访问日志，格式与 gin 默认的访问日志一致并附带请求 ID
+func AccessLog(at time.Time, status int, latency time.Duration, clientIP, method, path, requestID, errorMessage string) string {
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | request_id=%s\n%s",
+		at.Format("2006/01/02 - 15:04:05"),
+		status,
+		latency,
+		clientIP,
+		method,
+		path,
+		requestID,
+		errorMessage,
+	)
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 请求 ID 和 W3C Trace Context 请求头
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceparentHeader = "traceparent"
+)
+
+// maxRequestIDLength 客户端传入的请求 ID 超过该长度时重新生成
+const maxRequestIDLength = 128
+
+// SpanKind 与 OTLP 的 span kind 取值一致
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+type requestIDKey struct{}
+type spanKey struct{}
+type remoteKey struct{}
+
+// NewRequestID 生成 32 位十六进制的请求 ID
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID 只接受字母、数字和 -_.: 组成的请求 ID，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID 在 context 中保存请求 ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 context 中的请求 ID，没有时返回空串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// SpanContext 跨服务传递的 trace 标识
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid trace ID 和 span ID 都不能全为 0
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent 格式化为 traceparent 请求头
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%x-%x-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent 解析 traceparent 请求头（version 00）
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// Extract 从请求头读取请求 ID（没有或不合法时生成）和上游的 traceparent
+func Extract(ctx context.Context, h http.Header) context.Context {
+	id := h.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = NewRequestID()
+	}
+	ctx = WithRequestID(ctx, id)
+	if sc, ok := ParseTraceparent(h.Get(TraceparentHeader)); ok {
+		ctx = context.WithValue(ctx, remoteKey{}, sc)
+	}
+	return ctx
+}
+
+// Inject 把请求 ID 和当前 span 写入发往其他服务的请求头
+func Inject(ctx context.Context, h http.Header) {
+	if id := RequestID(ctx); id != "" {
+		h.Set(RequestIDHeader, id)
+	}
+	if sc, ok := spanContext(ctx); ok {
+		h.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// spanContext 返回当前 span，没有时返回上游传入的 trace
+func spanContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc, true
+	}
+	sc, ok := ctx.Value(remoteKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Span 一次操作的耗时和属性，End 时交给 exporter 导出
+type Span struct {
+	name   string
+	kind   SpanKind
+	sc     SpanContext
+	parent [8]byte
+	start  time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]interface{}
+	errMessage string
+	ended      bool
+}
+
+// StartSpan 创建 context 中当前 span 的子 span，没有父 span 时开始新的 trace
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	span := &Span{name: name, kind: kind, start: time.Now(), attributes: make(map[string]interface{})}
+	if parent, ok := spanContext(ctx); ok {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		rand.Read(span.sc.TraceID[:])
+		span.sc.Sampled = true
+	}
+	rand.Read(span.sc.SpanID[:])
+	if id := RequestID(ctx); id != "" {
+		span.attributes["request.id"] = id
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext 返回 context 中的当前 span
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Context 返回 span 的 trace 标识
+func (s *Span) Context() SpanContext {
+	return s.sc
+}
+
+// SetAttribute 设置属性，值为 string、int、int64、bool 或 float64
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError 标记 span 失败
+func (s *Span) SetError(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = message
+}
+
+// End 结束 span，重复调用只导出一次
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.sc.Sampled {
+		if e := currentExporter(); e != nil {
+			e.add(s)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"log"
+	"net/http"
 
+	"github.com/addp/common/tracing"
 	"github.com/addp/gateway/internal/config"
 	"github.com/addp/gateway/internal/router"
 	"github.com/gin-gonic/gin"
@@ -12,13 +14,29 @@ func main() {
 	// 加载配置
 	cfg := config.Load()
 
+	// 配置 OTEL_EXPORTER_OTLP_ENDPOINT 时导出 trace
+	defer tracing.Init("gateway")()
+
 	// 设置 Gin 模式
 	if cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	// 创建路由
-	r := router.SetupRouter(cfg)
+	r, internal, err := router.SetupRouter(cfg)
+	if err != nil {
+		log.Fatalf("Gateway 初始化失败: %v", err)
+	}
+
+	// Prometheus 指标和就绪检查使用单独的内部端口，不经过对外的网关端口暴露
+	if cfg.MetricsPort != "" {
+		go func() {
+			log.Printf("Gateway 内部端口（/metrics、/health/deep）监听在 %s", cfg.MetricsPort)
+			if err := http.ListenAndServe(cfg.MetricsPort, internal); err != nil {
+				log.Printf("Gateway 内部端口服务停止: %v", err)
+			}
+		}()
+	}
 
 	// 启动服务器
 	log.Printf("Gateway 启动在 %s", cfg.Port)
@@ -0,0 +1,152 @@
+package apitoken
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/addp/common/auth"
+)
+
+// maxCacheEntries 缓存条目超过该数量时清理过期条目
+const maxCacheEntries = 10000
+
+// ErrInvalidToken System 认定令牌无效、过期、已吊销或用户已禁用
+var ErrInvalidToken = errors.New("invalid api token")
+
+// Token System /internal/auth/tokens/verify 返回的令牌信息
+type Token struct {
+	TokenID  uint     `json:"token_id"`
+	UserID   uint     `json:"user_id"`
+	Username string   `json:"username"`
+	TenantID *uint    `json:"tenant_id"`
+	UserType string   `json:"user_type"`
+	Scopes   []string `json:"scopes"`
+}
+
+// Identity 令牌所属用户的身份
+func (t *Token) Identity() auth.Identity {
+	identity := auth.Identity{UserID: t.UserID, Username: t.Username, UserType: t.UserType}
+	if t.TenantID != nil {
+		identity.TenantID = *t.TenantID
+	}
+	return identity
+}
+
+type cacheEntry struct {
+	token     *Token // 为 nil 表示令牌无效
+	expiresAt time.Time
+}
+
+// Verifier 通过 System 校验访问令牌，结果（包括无效结果）缓存 ttl，
+// 吊销令牌或禁用用户最多 ttl 后在网关生效
+type Verifier struct {
+	systemURLs []string
+	apiKey     string
+	ttl        time.Duration
+	client     *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry // 令牌 SHA-256 → 校验结果
+}
+
+// NewVerifier systemURLs 为 System 实例地址，依次尝试直到校验成功
+func NewVerifier(systemURLs []string, apiKey string, ttl time.Duration) *Verifier {
+	return &Verifier{
+		systemURLs: systemURLs,
+		apiKey:     apiKey,
+		ttl:        ttl,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// Verify 返回令牌信息；令牌无效时返回 ErrInvalidToken，System 不可用时返回其他错误
+func (v *Verifier) Verify(ctx context.Context, token, clientIP string) (*Token, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+
+	if entry, ok := v.cached(key); ok {
+		if entry == nil {
+			return nil, ErrInvalidToken
+		}
+		return entry, nil
+	}
+
+	var lastErr error
+	for _, systemURL := range v.systemURLs {
+		result, err := v.fetch(ctx, systemURL, token, clientIP)
+		if err != nil && !errors.Is(err, ErrInvalidToken) {
+			lastErr = err
+			continue
+		}
+		v.store(key, result)
+		return result, err
+	}
+	return nil, lastErr
+}
+
+func (v *Verifier) cached(key string) (*Token, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	entry, ok := v.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.token, true
+}
+
+func (v *Verifier) store(key string, token *Token) {
+	now := time.Now()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.cache) >= maxCacheEntries {
+		for k, entry := range v.cache {
+			if now.After(entry.expiresAt) {
+				delete(v.cache, k)
+			}
+		}
+	}
+	v.cache[key] = cacheEntry{token: token, expiresAt: now.Add(v.ttl)}
+}
+
+func (v *Verifier) fetch(ctx context.Context, systemURL, token, clientIP string) (*Token, error) {
+	body, err := json.Marshal(map[string]string{"token": token, "ip_address": clientIP})
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimRight(systemURL, "/") + "/internal/auth/tokens/verify"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-API-Key", v.apiKey)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidToken
+	default:
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	var result Token
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,148 @@
+package apitoken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSystem 模拟 System 的 /internal/auth/tokens/verify，记录调用次数和客户端地址
+type fakeSystem struct {
+	mu     sync.Mutex
+	tokens map[string]Token
+	calls  int
+	ips    []string
+	status int
+}
+
+func (f *fakeSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method != http.MethodPost || r.URL.Path != "/internal/auth/tokens/verify" || r.Header.Get("X-Internal-API-Key") != "key" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.calls++
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	var req struct {
+		Token     string `json:"token"`
+		IPAddress string `json:"ip_address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.ips = append(f.ips, req.IPAddress)
+	token, ok := f.tokens[req.Token]
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	json.NewEncoder(w).Encode(token)
+}
+
+func (f *fakeSystem) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestVerifierVerify(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uint(3)
+	system := &fakeSystem{tokens: map[string]Token{
+		"addp_pat_valid": {TokenID: 9, UserID: 7, Username: "alice", TenantID: &tenantID, UserType: "user", Scopes: []string{"meta:write"}},
+	}}
+	server := httptest.NewServer(system)
+	defer server.Close()
+	v := NewVerifier([]string{server.URL}, "key", time.Minute)
+
+	token, err := v.Verify(ctx, "addp_pat_valid", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if token.TokenID != 9 || len(token.Scopes) != 1 || token.Scopes[0] != "meta:write" {
+		t.Fatalf("token = %+v", token)
+	}
+	if identity := token.Identity(); identity.UserID != 7 || identity.Username != "alice" || identity.TenantID != 3 || identity.UserType != "user" {
+		t.Fatalf("identity = %+v", identity)
+	}
+	if len(system.ips) != 1 || system.ips[0] != "10.0.0.1" {
+		t.Fatalf("client ip sent to System = %v", system.ips)
+	}
+
+	if _, err := v.Verify(ctx, "addp_pat_unknown", "10.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unknown token: err = %v, want ErrInvalidToken", err)
+	}
+
+	// 有效和无效结果都在 ttl 内缓存
+	v.Verify(ctx, "addp_pat_valid", "10.0.0.1")
+	if _, err := v.Verify(ctx, "addp_pat_unknown", "10.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("cached unknown token: err = %v", err)
+	}
+	if calls := system.callCount(); calls != 2 {
+		t.Fatalf("System called %d times, want 2", calls)
+	}
+	// 缓存只保存令牌哈希
+	for key := range v.cache {
+		if key == "addp_pat_valid" || key == "addp_pat_unknown" {
+			t.Fatal("raw token used as cache key")
+		}
+	}
+
+	// 过期后重新校验，吊销在 ttl 后生效
+	system.mu.Lock()
+	delete(system.tokens, "addp_pat_valid")
+	system.mu.Unlock()
+	for key, entry := range v.cache {
+		entry.expiresAt = time.Now().Add(-time.Second)
+		v.cache[key] = entry
+	}
+	if _, err := v.Verify(ctx, "addp_pat_valid", "10.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("revoked token: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifierSystemUnavailable(t *testing.T) {
+	ctx := context.Background()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	system := &fakeSystem{tokens: map[string]Token{"addp_pat_valid": {TokenID: 1, UserID: 1, Scopes: []string{"read"}}}}
+	up := httptest.NewServer(system)
+	defer up.Close()
+
+	// 依次尝试 System 实例
+	v := NewVerifier([]string{down.URL, up.URL}, "key", time.Minute)
+	if _, err := v.Verify(ctx, "addp_pat_valid", "10.0.0.1"); err != nil {
+		t.Fatalf("Verify with one instance up: %v", err)
+	}
+
+	// System 出错时返回错误而不是 ErrInvalidToken，结果不缓存
+	system.mu.Lock()
+	system.status = http.StatusInternalServerError
+	system.mu.Unlock()
+	failing := NewVerifier([]string{down.URL, up.URL}, "key", time.Minute)
+	for i := 0; i < 2; i++ {
+		_, err := failing.Verify(ctx, "addp_pat_valid", "10.0.0.1")
+		if err == nil || errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("System error: err = %v", err)
+		}
+	}
+	if len(failing.cache) != 0 {
+		t.Fatalf("failed verification cached: %v", failing.cache)
+	}
+
+	// Internal API Key 错误时同样视为不可用，而不是令牌无效
+	wrongKey := NewVerifier([]string{up.URL}, "wrong", time.Minute)
+	if _, err := wrongKey.Verify(ctx, "addp_pat_valid", "10.0.0.1"); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("wrong key: err = %v", err)
+	}
+}
@@ -1,30 +1,136 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	commonConfig "github.com/addp/common/config"
+)
 
 type Config struct {
+	commonConfig.BaseConfig
+
 	Port               string
 	Env                string
-	SystemServiceURL   string
 	ManagerServiceURL  string
 	MetaServiceURL     string
 	TransferServiceURL string
+
+	// 代理超时（等待后端响应头的时间），文件上传和预览使用单独的超时
+	ProxyTimeout     time.Duration
+	FileProxyTimeout time.Duration
+
+	// 上游实例（服务地址可用逗号分隔多个实例）：负载均衡、健康检查和重试
+	LoadBalance         string
+	ProxyRetries        int
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+	MaxFails            int
+	EjectDuration       time.Duration
+
+	// 可信的前置代理（IP 或 CIDR，逗号分隔），只采信来自这些地址的 X-Forwarded-For 等头；默认不信任任何代理
+	TrustedProxies []string
+
+	// /health/deep 检查各服务实例 /health 的超时和结果的缓存时间
+	DeepHealthTimeout  time.Duration
+	DeepHealthCacheTTL time.Duration
+
+	// 限流：memory（单实例）、redis（多实例共享限额）或 none
+	RateLimitBackend string
+	RedisHost        string
+	RedisPort        string
+	RedisPassword    string
+	RedisDB          int
+
+	// 从 System 同步令牌黑名单的间隔，0 表示不同步
+	RevocationSyncInterval time.Duration
+
+	// 访问令牌校验结果的缓存时间，吊销令牌最多经过该时间后在网关生效
+	APITokenCacheTTL time.Duration
+
+	// 路由表文件（YAML 或 JSON），文件变化时自动重新加载
+	RoutesFile           string
+	RoutesReloadInterval time.Duration
+
+	// 内部监听端口（Prometheus 指标和 /health/deep），只在内部网络开放，为空时不提供
+	MetricsPort string
 }
 
 func Load() *Config {
-	port := getEnv("PORT", ":8000")
-	if len(port) > 0 && port[0] != ':' {
-		port = ":" + port
-	}
-
-	return &Config{
-		Port:               port,
+	cfg := &Config{
+		Port:               listenAddr(getEnv("PORT", ":8000")),
 		Env:                getEnv("ENV", "development"),
-		SystemServiceURL:   getEnv("SYSTEM_SERVICE_URL", "http://localhost:8080"),
+		MetricsPort:        listenAddr(getEnv("METRICS_PORT", ":9094")),
 		ManagerServiceURL:  getEnv("MANAGER_SERVICE_URL", "http://localhost:8081"),
 		MetaServiceURL:     getEnv("META_SERVICE_URL", "http://localhost:8082"),
 		TransferServiceURL: getEnv("TRANSFER_SERVICE_URL", "http://localhost:8083"),
+		ProxyTimeout:       commonConfig.GetEnvDuration("PROXY_TIMEOUT", "30s"),
+		FileProxyTimeout:   commonConfig.GetEnvDuration("FILE_PROXY_TIMEOUT", "5m"),
+
+		LoadBalance:         getEnv("LOAD_BALANCE", "round_robin"),
+		ProxyRetries:        commonConfig.GetEnvInt("PROXY_RETRIES", 2),
+		HealthCheckInterval: commonConfig.GetEnvDuration("HEALTH_CHECK_INTERVAL", "10s"),
+		HealthCheckTimeout:  commonConfig.GetEnvDuration("HEALTH_CHECK_TIMEOUT", "3s"),
+		MaxFails:            commonConfig.GetEnvInt("UPSTREAM_MAX_FAILS", 3),
+		EjectDuration:       commonConfig.GetEnvDuration("UPSTREAM_EJECT_DURATION", "30s"),
+		DeepHealthTimeout:   commonConfig.GetEnvDuration("DEEP_HEALTH_TIMEOUT", "5s"),
+		DeepHealthCacheTTL:  commonConfig.GetEnvDuration("DEEP_HEALTH_CACHE_TTL", "5s"),
+		TrustedProxies:      SplitURLs(getEnv("TRUSTED_PROXIES", "")),
+
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RedisHost:        getEnv("REDIS_HOST", "localhost"),
+		RedisPort:        getEnv("REDIS_PORT", "6379"),
+		RedisPassword:    getEnv("REDIS_PASSWORD", ""),
+		RedisDB:          commonConfig.GetEnvInt("REDIS_DB", 0),
+
+		RevocationSyncInterval: commonConfig.GetEnvDuration("REVOCATION_SYNC_INTERVAL", "10s"),
+		APITokenCacheTTL:       commonConfig.GetEnvDuration("API_TOKEN_CACHE_TTL", "30s"),
+
+		RoutesFile:           getEnv("ROUTES_FILE", "configs/routes.yaml"),
+		RoutesReloadInterval: commonConfig.GetEnvDuration("ROUTES_RELOAD_INTERVAL", "5s"),
+	}
+
+	cfg.SystemServiceURL = getEnv("SYSTEM_SERVICE_URL", "http://localhost:8080")
+	cfg.EnableIntegration = commonConfig.GetEnvBool("ENABLE_SERVICE_INTEGRATION", true)
+
+	// 从 System 获取共享配置（JWT 密钥用于在网关校验令牌并签名身份头），有多个实例时使用第一个
+	if cfg.EnableIntegration {
+		log.Println("🔄 Attempting to load shared config from System service...")
+		systemURL := strings.TrimSpace(strings.Split(cfg.SystemServiceURL, ",")[0])
+		if err := commonConfig.LoadSharedConfig(systemURL, &cfg.BaseConfig); err != nil {
+			log.Printf("⚠️  Warning: Failed to load shared config from System: %v", err)
+			log.Printf("⚠️  Falling back to local environment variables...")
+			commonConfig.LoadLocalConfig(&cfg.BaseConfig)
+		} else {
+			log.Println("✅ Successfully loaded shared config from System service")
+		}
+	} else {
+		log.Println("ℹ️  Service integration disabled, using local config")
+		commonConfig.LoadLocalConfig(&cfg.BaseConfig)
+	}
+
+	return cfg
+}
+
+// listenAddr 只写端口号时补全为 :port
+func listenAddr(port string) string {
+	if len(port) > 0 && !strings.Contains(port, ":") {
+		port = ":" + port
+	}
+	return port
+}
+
+// SplitURLs 拆分逗号分隔的多个实例地址（也用于其他逗号分隔的列表）
+func SplitURLs(value string) []string {
+	var urls []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			urls = append(urls, item)
+		}
 	}
+	return urls
 }
 
 func getEnv(key, defaultValue string) string {
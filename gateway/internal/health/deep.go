@@ -0,0 +1,195 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	commonHealth "github.com/addp/common/health"
+)
+
+// InstanceResult 单个服务实例 /health 的检查结果
+type InstanceResult struct {
+	URL        string               `json:"url"`
+	Status     string               `json:"status"`
+	HTTPStatus int                  `json:"http_status,omitempty"`
+	LatencyMS  float64              `json:"latency_ms"`
+	Error      string               `json:"error,omitempty"`
+	Report     *commonHealth.Report `json:"report,omitempty"`
+}
+
+// ServiceResult 服务的汇总状态：所有实例不可用时为 down，部分实例不可用或依赖降级时为 degraded
+type ServiceResult struct {
+	Status    string           `json:"status"`
+	Instances []InstanceResult `json:"instances"`
+}
+
+// DeepReport 网关汇总的健康报告
+type DeepReport struct {
+	Status    string                   `json:"status"`
+	Services  map[string]ServiceResult `json:"services"`
+	CheckedAt time.Time                `json:"checked_at"`
+}
+
+// Deep 并发检查所有后端服务实例的 /health，结果缓存 ttl，避免每个请求都向所有实例发起检查
+type Deep struct {
+	services func() map[string][]string
+	timeout  time.Duration
+	ttl      time.Duration
+	client   *http.Client
+
+	mu   sync.Mutex
+	last *DeepReport
+}
+
+// NewDeep services 返回当前的服务名和实例地址（路由表热更新后随之变化）
+func NewDeep(services func() map[string][]string, timeout, ttl time.Duration) *Deep {
+	return &Deep{
+		services: services,
+		timeout:  timeout,
+		ttl:      ttl,
+		client:   &http.Client{},
+	}
+}
+
+// ServeHTTP 任一服务不可用时返回 503，供负载均衡器判断就绪
+func (d *Deep) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := d.Report(r.Context())
+	code := http.StatusOK
+	if report.Status == commonHealth.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Report 返回 ttl 内的上一次检查结果，过期后重新检查；并发的请求等待同一次检查
+func (d *Deep) Report(ctx context.Context) *DeepReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.last != nil && time.Since(d.last.CheckedAt) < d.ttl {
+		return d.last
+	}
+	// 结果由多个请求共用，不随发起检查的请求取消
+	d.last = d.Run(context.WithoutCancel(ctx))
+	return d.last
+}
+
+// Run 检查所有服务实例
+func (d *Deep) Run(ctx context.Context) *DeepReport {
+	services := d.services()
+	report := &DeepReport{
+		Status:    commonHealth.StatusOK,
+		Services:  make(map[string]ServiceResult, len(services)),
+		CheckedAt: time.Now(),
+	}
+
+	results := make(map[string][]InstanceResult, len(services))
+	var wg sync.WaitGroup
+	for name, urls := range services {
+		instances := make([]InstanceResult, len(urls))
+		results[name] = instances
+		for i, url := range urls {
+			wg.Add(1)
+			go func(result *InstanceResult, url string) {
+				defer wg.Done()
+				*result = d.check(ctx, url)
+			}(&instances[i], url)
+		}
+	}
+	wg.Wait()
+
+	for name, instances := range results {
+		service := ServiceResult{Status: summarize(instances), Instances: instances}
+		report.Services[name] = service
+		report.Status = worse(report.Status, service.Status)
+	}
+	return report
+}
+
+// check 请求实例的 /health，能解析出健康报告时以报告中的状态为准
+func (d *Deep) check(ctx context.Context, url string) InstanceResult {
+	result := InstanceResult{URL: url, Status: commonHealth.StatusDown}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(url, "/")+"/health", nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.HTTPStatus = resp.StatusCode
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	var report commonHealth.Report
+	if json.Unmarshal(body, &report) == nil && report.Status != "" {
+		result.Report = &report
+	}
+
+	switch {
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		result.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	case result.Report != nil:
+		result.Status = report.Status
+	default:
+		result.Status = commonHealth.StatusOK
+	}
+	return result
+}
+
+// summarize 至少一个实例可用时服务可用
+func summarize(instances []InstanceResult) string {
+	status := commonHealth.StatusDown
+	down := false
+	for _, instance := range instances {
+		switch instance.Status {
+		case commonHealth.StatusOK:
+			if status == commonHealth.StatusDown {
+				status = commonHealth.StatusOK
+			}
+		case commonHealth.StatusDegraded:
+			status = commonHealth.StatusDegraded
+		default:
+			down = true
+		}
+	}
+	if down && status == commonHealth.StatusOK {
+		return commonHealth.StatusDegraded
+	}
+	return status
+}
+
+var statusRank = map[string]int{
+	commonHealth.StatusOK:       0,
+	commonHealth.StatusDegraded: 1,
+	commonHealth.StatusDown:     2,
+}
+
+func worse(a, b string) string {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	commonHealth "github.com/addp/common/health"
+)
+
+// countingBackend 记录收到的 /health 请求数
+func countingBackend(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestDeepCachesReport(t *testing.T) {
+	backend, hits := countingBackend(t, http.StatusOK)
+	services := func() map[string][]string { return map[string][]string{"system": {backend.URL}} }
+	deep := NewDeep(services, time.Second, time.Minute)
+
+	// 并发请求共用同一次检查，缓存期内不再请求后端
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			deep.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/deep", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d", rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("backend checked %d times, want 1", got)
+	}
+
+	// 缓存过期后重新检查
+	deep.ttl = 0
+	deep.Report(context.Background())
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("backend checked %d times after expiry, want 2", got)
+	}
+}
+
+func TestDeepReportsDown(t *testing.T) {
+	up, _ := countingBackend(t, http.StatusOK)
+	down, _ := countingBackend(t, http.StatusServiceUnavailable)
+	services := func() map[string][]string {
+		return map[string][]string{"system": {up.URL, down.URL}, "meta": {down.URL}}
+	}
+	deep := NewDeep(services, time.Second, 0)
+
+	report := deep.Run(context.Background())
+	if got := report.Services["system"].Status; got != commonHealth.StatusDegraded {
+		t.Fatalf("system = %s, want degraded with one instance down", got)
+	}
+	if got := report.Services["meta"].Status; got != commonHealth.StatusDown {
+		t.Fatalf("meta = %s, want down", got)
+	}
+
+	rec := httptest.NewRecorder()
+	deep.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/deep", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 when a service is down", rec.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/addp/common/auth"
+	"github.com/addp/gateway/internal/apitoken"
+	"github.com/addp/gateway/internal/revocation"
+	"github.com/gin-gonic/gin"
+)
+
+// StripIdentity 删除客户端传入的身份头，只有网关校验令牌后写入的身份头才会被转发
+func StripIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth.StripIdentity(c.Request.Header)
+		c.Next()
+	}
+}
+
+// JWTAuth 在网关校验 JWT 或访问令牌，无效或已吊销的令牌直接拒绝
+func JWTAuth(secret string, denylist *revocation.Denylist, tokens *apitoken.Verifier, service string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Authenticate(c, secret, denylist, tokens, service) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authenticate 校验请求的 JWT 或访问令牌并在请求上下文中记录身份（转发时由代理签名写入身份头），失败时返回 401 并中止请求；denylist 为 nil 时不检查吊销，
+// tokens 为 nil 时不接受访问令牌；service 为目标服务名，用于检查访问令牌的权限范围
+func Authenticate(c *gin.Context, secret string, denylist *revocation.Denylist, tokens *apitoken.Verifier, service string) bool {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+		return false
+	}
+
+	token, ok := auth.BearerToken(authHeader)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+		return false
+	}
+
+	if auth.IsAPIToken(token) {
+		return authenticateAPIToken(c, tokens, token, service)
+	}
+
+	claims, err := auth.ParseToken(token, secret)
+	if err != nil {
+		message := "invalid token"
+		if errors.Is(err, auth.ErrTokenExpired) {
+			message = "token expired"
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+		return false
+	}
+	if denylist.Revoked(claims.ID) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+		return false
+	}
+
+	setIdentity(c, claims.Identity())
+	return true
+}
+
+// authenticateAPIToken 通过 System 校验访问令牌，并检查令牌对目标服务和请求方法的权限范围
+func authenticateAPIToken(c *gin.Context, tokens *apitoken.Verifier, token, service string) bool {
+	if tokens == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api tokens are not enabled"})
+		return false
+	}
+
+	result, err := tokens.Verify(c.Request.Context(), token, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, apitoken.ErrInvalidToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		} else {
+			log.Printf("校验访问令牌失败: %v", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "token verification unavailable"})
+		}
+		return false
+	}
+	if !auth.ScopeAllows(result.Scopes, service, c.Request.Method) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token scope does not allow this request"})
+		return false
+	}
+
+	setIdentity(c, result.Identity())
+	c.Set("token_id", result.TokenID)
+	return true
+}
+
+func setIdentity(c *gin.Context, identity auth.Identity) {
+	c.Request = c.Request.WithContext(auth.ContextWithIdentity(c.Request.Context(), identity))
+
+	c.Set("user_id", identity.UserID)
+	c.Set("username", identity.Username)
+	c.Set("tenant_id", identity.TenantID)
+	c.Set("user_type", identity.UserType)
+}
+
+// RequireUserType 只允许指定类型的用户访问，需在 JWTAuth 之后使用
+func RequireUserType(userTypes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userType := c.GetString("user_type")
+		for _, allowed := range userTypes {
+			if userType == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+	}
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/addp/common/auth"
+	"github.com/addp/gateway/internal/apitoken"
+	"github.com/addp/gateway/internal/revocation"
+	"github.com/gin-gonic/gin"
+)
+
+const testJWTSecret = "jwt-test-secret"
+
+// signJWT 按 System 的格式签发 HS256 令牌
+func signJWT(t *testing.T, claims auth.Claims, secret string) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newTestDenylist 从测试 System 同步一次黑名单，jtis 为已吊销的令牌
+func newTestDenylist(t *testing.T, jtis ...string) *revocation.Denylist {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens := make([]gin.H, 0, len(jtis))
+		for _, jti := range jtis {
+			tokens = append(tokens, gin.H{"jti": jti, "expires_at": time.Now().Add(time.Hour)})
+		}
+		json.NewEncoder(w).Encode(gin.H{"tokens": tokens, "server_time": time.Now().Unix()})
+	}))
+	defer server.Close()
+	denylist := revocation.NewDenylist([]string{server.URL}, "key")
+	if err := denylist.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return denylist
+}
+
+// authenticate 以 Authorization 头调用 Authenticate，返回结果、状态码和请求上下文中的身份
+func authenticate(denylist *revocation.Denylist, tokens *apitoken.Verifier, service, method, authorization string) (bool, int, *gin.Context) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/items", nil)
+	if authorization != "" {
+		c.Request.Header.Set("Authorization", authorization)
+	}
+	ok := Authenticate(c, testJWTSecret, denylist, tokens, service)
+	return ok, w.Code, c
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenantID := uint(3)
+	claims := auth.Claims{
+		UserID: 7, Username: "alice", TenantID: &tenantID, UserType: "tenant_admin",
+		ID: "jti-active", ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+	denylist := newTestDenylist(t, "jti-revoked")
+
+	ok, _, c := authenticate(denylist, nil, "meta", http.MethodGet, "Bearer "+signJWT(t, claims, testJWTSecret))
+	if !ok {
+		t.Fatal("valid token rejected")
+	}
+	identity, found := auth.IdentityFromContext(c.Request.Context())
+	want := auth.Identity{UserID: 7, Username: "alice", TenantID: 3, UserType: "tenant_admin"}
+	if !found || identity != want {
+		t.Fatalf("identity = %+v (%v), want %+v", identity, found, want)
+	}
+	if c.GetUint("user_id") != 7 || c.GetUint("tenant_id") != 3 || c.GetString("user_type") != "tenant_admin" {
+		t.Fatalf("context keys = %v", c.Keys)
+	}
+
+	revoked := claims
+	revoked.ID = "jti-revoked"
+	expired := claims
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	cases := map[string]string{
+		"missing header": "",
+		"not bearer":     "Basic " + signJWT(t, claims, testJWTSecret),
+		"wrong secret":   "Bearer " + signJWT(t, claims, "other-secret"),
+		"expired":        "Bearer " + signJWT(t, expired, testJWTSecret),
+		"revoked":        "Bearer " + signJWT(t, revoked, testJWTSecret),
+	}
+	for name, authorization := range cases {
+		ok, code, c := authenticate(denylist, nil, "meta", http.MethodGet, authorization)
+		if ok || code != http.StatusUnauthorized {
+			t.Errorf("%s: ok = %v, status = %d, want 401", name, ok, code)
+		}
+		if _, found := auth.IdentityFromContext(c.Request.Context()); found {
+			t.Errorf("%s: identity recorded for rejected token", name)
+		}
+	}
+}
+
+// newTestVerifier 校验访问令牌的测试 System，tokens 以外的令牌无效；status 不为 0 时所有请求返回该状态码
+func newTestVerifier(t *testing.T, status int, tokens map[string]apitoken.Token) *apitoken.Verifier {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		var req struct {
+			Token string `json:"token"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		token, ok := tokens[req.Token]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(token)
+	}))
+	t.Cleanup(server.Close)
+	return apitoken.NewVerifier([]string{server.URL}, "key", time.Minute)
+}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenantID := uint(3)
+	tokens := newTestVerifier(t, 0, map[string]apitoken.Token{
+		"addp_pat_meta": {TokenID: 9, UserID: 7, Username: "alice", TenantID: &tenantID, UserType: "user", Scopes: []string{"meta:read"}},
+	})
+
+	ok, _, c := authenticate(nil, tokens, "meta", http.MethodGet, "Bearer addp_pat_meta")
+	if !ok {
+		t.Fatal("valid token rejected")
+	}
+	identity, found := auth.IdentityFromContext(c.Request.Context())
+	if want := (auth.Identity{UserID: 7, Username: "alice", TenantID: 3, UserType: "user"}); !found || identity != want {
+		t.Fatalf("identity = %+v (%v), want %+v", identity, found, want)
+	}
+	if c.GetUint("token_id") != 9 || c.GetUint("user_id") != 7 {
+		t.Fatalf("context keys = %v", c.Keys)
+	}
+
+	cases := []struct {
+		name          string
+		tokens        *apitoken.Verifier
+		service       string
+		method        string
+		authorization string
+		want          int
+	}{
+		{"write with read scope", tokens, "meta", http.MethodPost, "Bearer addp_pat_meta", http.StatusForbidden},
+		{"other service", tokens, "transfer", http.MethodGet, "Bearer addp_pat_meta", http.StatusForbidden},
+		{"unknown token", tokens, "meta", http.MethodGet, "Bearer addp_pat_unknown", http.StatusUnauthorized},
+		{"tokens disabled", nil, "meta", http.MethodGet, "Bearer addp_pat_meta", http.StatusUnauthorized},
+		{"system unavailable", newTestVerifier(t, http.StatusBadGateway, nil), "meta", http.MethodGet, "Bearer addp_pat_meta", http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		ok, code, c := authenticate(nil, tc.tokens, tc.service, tc.method, tc.authorization)
+		if ok || code != tc.want {
+			t.Errorf("%s: ok = %v, status = %d, want %d", tc.name, ok, code, tc.want)
+		}
+		if _, found := auth.IdentityFromContext(c.Request.Context()); found {
+			t.Errorf("%s: identity recorded for rejected token", tc.name)
+		}
+	}
+}
+
+func TestStripIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(StripIdentity())
+	var forwarded http.Header
+	engine.GET("/", func(c *gin.Context) { forwarded = c.Request.Header.Clone() })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(auth.HeaderUserID, "1")
+	req.Header.Set(auth.HeaderUserType, "super_admin")
+	req.Header.Set(auth.HeaderIdentitySignature, "forged")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	for _, name := range []string{auth.HeaderUserID, auth.HeaderUserType, auth.HeaderIdentitySignature} {
+		if forwarded.Get(name) != "" {
+			t.Errorf("client header %s forwarded", name)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/addp/common/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// RouteKey 转发的请求没有 gin 路由模板，由路由表在 gin 上下文中写入匹配的路由前缀
+const RouteKey = "gateway_route"
+
+// Metrics 按路由和状态码统计请求数和耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.GetString(RouteKey)
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
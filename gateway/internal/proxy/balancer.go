@@ -0,0 +1,329 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/addp/common/auth"
+	"github.com/addp/common/tracing"
+)
+
+// 负载均衡策略
+const (
+	BalanceRoundRobin = "round_robin"
+	BalanceLeastConn  = "least_conn"
+)
+
+// healthPath 主动健康检查访问的路径
+const healthPath = "/health"
+
+// ValidBalance 检查负载均衡策略，空值表示轮询
+func ValidBalance(balance string) bool {
+	switch balance {
+	case "", BalanceRoundRobin, BalanceLeastConn:
+		return true
+	}
+	return false
+}
+
+// instance 服务的一个后端实例
+type instance struct {
+	url          *url.URL
+	healthy      atomic.Bool  // 主动健康检查结果
+	active       atomic.Int64 // 进行中的请求数
+	failures     atomic.Int32 // 连续失败次数
+	ejectedUntil atomic.Int64 // 被动摘除的截止时间（UnixNano）
+}
+
+func (i *instance) available(now time.Time) bool {
+	return i.healthy.Load() && now.UnixNano() >= i.ejectedUntil.Load()
+}
+
+// InstanceStatus 实例状态，用于查看路由表
+type InstanceStatus struct {
+	URL       string     `json:"url"`
+	Healthy   bool       `json:"healthy"`
+	Ejected   bool       `json:"ejected"`
+	EjectedTo *time.Time `json:"ejected_until,omitempty"`
+	Active    int64      `json:"active"`
+	Failures  int32      `json:"failures"`
+}
+
+// balancer 在多个实例间分配请求：主动健康检查定期访问 /health，被动检查在连续失败后暂时摘除实例，
+// 幂等且没有请求体的请求在连接失败时换一个实例重试
+type balancer struct {
+	instances []*instance
+	balance   string
+	options   Options
+	base      http.RoundTripper
+	next      atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newBalancer(targets []string, options Options, base http.RoundTripper) (*balancer, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("no upstream instances")
+	}
+	if !ValidBalance(options.Balance) {
+		return nil, fmt.Errorf("unsupported balance %q", options.Balance)
+	}
+
+	b := &balancer{balance: options.Balance, options: options, base: base, stop: make(chan struct{})}
+	for _, target := range targets {
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid upstream url %q", target)
+		}
+		inst := &instance{url: u}
+		inst.healthy.Store(true)
+		b.instances = append(b.instances, inst)
+	}
+	if options.HealthInterval > 0 {
+		go b.healthLoop()
+	}
+	return b, nil
+}
+
+// pick 选择一个未尝试过的可用实例；全部不可用时仍在未尝试的实例中选择，避免直接拒绝请求
+func (b *balancer) pick(tried map[*instance]bool) *instance {
+	now := time.Now()
+	candidates := make([]*instance, 0, len(b.instances))
+	for _, inst := range b.instances {
+		if !tried[inst] && inst.available(now) {
+			candidates = append(candidates, inst)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, inst := range b.instances {
+			if !tried[inst] {
+				candidates = append(candidates, inst)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	start := int(b.next.Add(1) - 1)
+	if b.balance != BalanceLeastConn {
+		return candidates[start%len(candidates)]
+	}
+	// 连接数相同时按轮询顺序选择
+	var best *instance
+	for i := range candidates {
+		inst := candidates[(start+i)%len(candidates)]
+		if best == nil || inst.active.Load() < best.active.Load() {
+			best = inst
+		}
+	}
+	return best
+}
+
+// RoundTrip 把请求发送到选中的实例
+func (b *balancer) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if retryable(req) {
+		attempts = min(b.options.Retries+1, len(b.instances))
+	}
+
+	tried := make(map[*instance]bool, attempts)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		inst := b.pick(tried)
+		if inst == nil {
+			break
+		}
+		tried[inst] = true
+
+		resp, err := b.send(req, inst)
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if req.Context().Err() != nil {
+			break
+		}
+		if attempt+1 < attempts {
+			log.Printf("请求 %s 失败，换一个实例重试: %v (request_id: %s)", inst.url.Host, err, tracing.RequestID(req.Context()))
+		}
+	}
+	return nil, lastErr
+}
+
+func (b *balancer) send(req *http.Request, inst *instance) (*http.Response, error) {
+	// 每次尝试一个 client span，响应体读完时结束
+	ctx, span := tracing.StartSpan(req.Context(), req.Method+" "+inst.url.Host, tracing.KindClient)
+	span.SetAttribute("server.address", inst.url.Host)
+	span.SetAttribute("url.path", req.URL.Path)
+
+	out := req.Clone(ctx)
+	out.URL.Scheme = inst.url.Scheme
+	out.URL.Host = inst.url.Host
+	out.Host = ""
+	if base := strings.TrimRight(inst.url.Path, "/"); base != "" {
+		out.URL.Path = base + req.URL.Path
+		out.URL.RawPath = ""
+	}
+	// 身份头按实际发往实例的方法和路径签名，每次尝试使用新的 nonce
+	if identity, ok := auth.IdentityFromContext(ctx); ok {
+		auth.SignIdentity(out, identity, b.options.IdentitySecret)
+	}
+	tracing.Inject(ctx, out.Header)
+
+	inst.active.Add(1)
+	resp, err := b.base.RoundTrip(out)
+	if err != nil {
+		inst.active.Add(-1)
+		if !clientGone(req.Context()) {
+			b.fail(inst)
+		}
+		upstreamErrors.WithLabelValues(inst.url.Host, errorReason(req.Context())).Inc()
+		span.SetError(err.Error())
+		span.End()
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		upstreamErrors.WithLabelValues(inst.url.Host, statusReason(resp.StatusCode)).Inc()
+		b.fail(inst)
+	default:
+		inst.failures.Store(0)
+	}
+	resp.Body = trackBody(resp.Body, func() {
+		inst.active.Add(-1)
+		span.End()
+	})
+	return resp, nil
+}
+
+// fail 记录一次失败，连续失败达到上限时摘除实例一段时间
+func (b *balancer) fail(inst *instance) {
+	if b.options.MaxFails <= 0 {
+		return
+	}
+	if inst.failures.Add(1) >= int32(b.options.MaxFails) {
+		inst.failures.Store(0)
+		inst.ejectedUntil.Store(time.Now().Add(b.options.EjectDuration).UnixNano())
+		log.Printf("实例 %s 连续失败 %d 次，摘除 %s", inst.url, b.options.MaxFails, b.options.EjectDuration)
+	}
+}
+
+// retryable 幂等方法且请求体可以重复发送时才重试
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if req.Header.Get("Upgrade") != "" {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0
+}
+
+// clientGone 客户端断开导致的失败不计入实例的失败次数
+func clientGone(ctx context.Context) bool {
+	return ctx.Err() != nil && !errors.Is(context.Cause(ctx), errUpstreamTimeout)
+}
+
+func (b *balancer) healthLoop() {
+	ticker := time.NewTicker(b.options.HealthInterval)
+	defer ticker.Stop()
+
+	client := &http.Client{Transport: b.base, Timeout: b.options.HealthTimeout}
+	for {
+		for _, inst := range b.instances {
+			go b.check(client, inst)
+		}
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *balancer) check(client *http.Client, inst *instance) {
+	healthy := false
+	resp, err := client.Get(strings.TrimRight(inst.url.String(), "/") + healthPath)
+	if err == nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+
+	if previous := inst.healthy.Swap(healthy); previous != healthy {
+		if healthy {
+			log.Printf("实例 %s 恢复健康", inst.url)
+		} else if err != nil {
+			log.Printf("实例 %s 健康检查失败: %v", inst.url, err)
+		} else {
+			log.Printf("实例 %s 健康检查失败: status %d", inst.url, resp.StatusCode)
+		}
+	}
+}
+
+func (b *balancer) close() {
+	b.stopOnce.Do(func() { close(b.stop) })
+}
+
+func (b *balancer) status() []InstanceStatus {
+	now := time.Now()
+	statuses := make([]InstanceStatus, len(b.instances))
+	for i, inst := range b.instances {
+		statuses[i] = InstanceStatus{
+			URL:      inst.url.String(),
+			Healthy:  inst.healthy.Load(),
+			Active:   inst.active.Load(),
+			Failures: inst.failures.Load(),
+		}
+		if until := time.Unix(0, inst.ejectedUntil.Load()); until.After(now) {
+			statuses[i].Ejected = true
+			statuses[i].EjectedTo = &until
+		}
+	}
+	return statuses
+}
+
+// trackBody 响应体关闭时回调一次；协议升级的响应体需要保留写入能力
+func trackBody(body io.ReadCloser, done func()) io.ReadCloser {
+	var once sync.Once
+	release := func() { once.Do(done) }
+	if rwc, ok := body.(io.ReadWriteCloser); ok {
+		return &trackedReadWriteCloser{ReadWriteCloser: rwc, release: release}
+	}
+	return &trackedReadCloser{ReadCloser: body, release: release}
+}
+
+type trackedReadCloser struct {
+	io.ReadCloser
+	release func()
+}
+
+func (t *trackedReadCloser) Close() error {
+	defer t.release()
+	return t.ReadCloser.Close()
+}
+
+type trackedReadWriteCloser struct {
+	io.ReadWriteCloser
+	release func()
+}
+
+func (t *trackedReadWriteCloser) Close() error {
+	defer t.release()
+	return t.ReadWriteCloser.Close()
+}
@@ -0,0 +1,261 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpstreams 按实例地址返回响应或连接错误，并记录每次请求发往的实例
+type fakeUpstreams struct {
+	mu     sync.Mutex
+	down   map[string]bool // 连接失败的实例
+	status map[string]int  // 实例返回的状态码，默认 200
+	hosts  []string
+	paths  []string
+}
+
+func (f *fakeUpstreams) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hosts = append(f.hosts, req.URL.Host)
+	f.paths = append(f.paths, req.URL.Path)
+	if f.down[req.URL.Host] {
+		return nil, errors.New("connection refused")
+	}
+	code := http.StatusOK
+	if status, ok := f.status[req.URL.Host]; ok {
+		code = status
+	}
+	return &http.Response{StatusCode: code, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func (f *fakeUpstreams) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hosts := f.hosts
+	f.hosts = nil
+	return hosts
+}
+
+func newTestBalancer(t *testing.T, options Options, upstreams *fakeUpstreams, targets ...string) *balancer {
+	t.Helper()
+	b, err := newBalancer(targets, options, upstreams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.close)
+	return b
+}
+
+// roundTrip 发送请求并关闭响应体
+func roundTrip(b *balancer, method string, body io.Reader) (*http.Response, error) {
+	resp, err := b.RoundTrip(httptest.NewRequest(method, "http://gateway/api/items", body))
+	if err == nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	upstreams := &fakeUpstreams{}
+	b := newTestBalancer(t, Options{}, upstreams, "http://a:1", "http://b:1", "http://c:1")
+	for i := 0; i < 6; i++ {
+		if _, err := roundTrip(b, http.MethodGet, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := strings.Join(upstreams.sent(), ",")
+	if got != "a:1,b:1,c:1,a:1,b:1,c:1" {
+		t.Fatalf("round robin order = %s", got)
+	}
+}
+
+func TestBalancerLeastConn(t *testing.T) {
+	upstreams := &fakeUpstreams{}
+	b := newTestBalancer(t, Options{Balance: BalanceLeastConn}, upstreams, "http://a:1", "http://b:1", "http://c:1")
+	b.instances[0].active.Store(3)
+	b.instances[1].active.Store(1)
+	b.instances[2].active.Store(2)
+	if inst := b.pick(nil); inst != b.instances[1] {
+		t.Fatalf("least_conn picked %s, want b:1", inst.url.Host)
+	}
+
+	// 响应体关闭前计入进行中的请求
+	resp, err := b.RoundTrip(httptest.NewRequest(http.MethodGet, "http://gateway/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active := b.instances[1].active.Load(); active != 2 {
+		t.Fatalf("active = %d while response is open, want 2", active)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if active := b.instances[1].active.Load(); active != 1 {
+		t.Fatalf("active = %d after close, want 1", active)
+	}
+}
+
+func TestBalancerRetries(t *testing.T) {
+	upstreams := &fakeUpstreams{down: map[string]bool{"a:1": true, "b:1": true}}
+	b := newTestBalancer(t, Options{Retries: 2}, upstreams, "http://a:1", "http://b:1", "http://c:1")
+
+	// 幂等请求连接失败时换实例重试，每个实例只尝试一次
+	if _, err := roundTrip(b, http.MethodGet, nil); err != nil {
+		t.Fatalf("GET with a healthy instance failed: %v", err)
+	}
+	attempts := upstreams.sent()
+	seen := make(map[string]bool, len(attempts))
+	for _, host := range attempts {
+		if seen[host] {
+			t.Fatalf("GET attempts = %v, instance retried twice", attempts)
+		}
+		seen[host] = true
+	}
+	if attempts[0] != "a:1" || attempts[len(attempts)-1] != "c:1" {
+		t.Fatalf("GET attempts = %v, want a:1 first and c:1 last", attempts)
+	}
+
+	// 非幂等请求和带请求体的请求不重试
+	b.next.Store(0)
+	if _, err := roundTrip(b, http.MethodPost, nil); err == nil {
+		t.Fatal("POST to a down instance succeeded")
+	}
+	if got := upstreams.sent(); len(got) != 1 {
+		t.Fatalf("POST attempts = %v, want 1", got)
+	}
+	b.next.Store(0)
+	if _, err := roundTrip(b, http.MethodPut, strings.NewReader("{}")); err == nil {
+		t.Fatal("PUT with body to a down instance succeeded")
+	}
+	if got := upstreams.sent(); len(got) != 1 {
+		t.Fatalf("PUT with body attempts = %v, want 1", got)
+	}
+
+	// 重试次数受 Retries 限制
+	upstreams.down["c:1"] = true
+	b.options.Retries = 1
+	b.next.Store(0)
+	if _, err := roundTrip(b, http.MethodGet, nil); err == nil {
+		t.Fatal("GET with all instances down succeeded")
+	}
+	if got := upstreams.sent(); len(got) != 2 {
+		t.Fatalf("attempts with Retries=1 = %v, want 2", got)
+	}
+
+	// 后端返回的错误响应不重试
+	upstreams.down = nil
+	upstreams.status = map[string]int{"a:1": http.StatusServiceUnavailable}
+	b.next.Store(0)
+	resp, err := roundTrip(b, http.MethodGet, nil)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("503 response = %v, %v", resp, err)
+	}
+	if got := upstreams.sent(); len(got) != 1 {
+		t.Fatalf("attempts after 503 = %v, want 1", got)
+	}
+}
+
+func TestBalancerPassiveEjection(t *testing.T) {
+	upstreams := &fakeUpstreams{down: map[string]bool{"a:1": true}}
+	b := newTestBalancer(t, Options{MaxFails: 2, EjectDuration: time.Minute}, upstreams, "http://a:1", "http://b:1")
+
+	// a 连续失败 2 次后被摘除
+	for i := 0; i < 2; i++ {
+		b.next.Store(0)
+		roundTrip(b, http.MethodPost, nil)
+	}
+	if status := b.status(); !status[0].Ejected || status[1].Ejected {
+		t.Fatalf("status = %+v, want a ejected", status)
+	}
+	upstreams.sent()
+	for i := 0; i < 4; i++ {
+		if _, err := roundTrip(b, http.MethodPost, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, host := range upstreams.sent() {
+		if host != "b:1" {
+			t.Fatalf("request sent to ejected instance %s", host)
+		}
+	}
+
+	// 所有实例都不可用时仍然尝试，而不是直接拒绝
+	b.instances[1].ejectedUntil.Store(time.Now().Add(time.Minute).UnixNano())
+	if inst := b.pick(nil); inst == nil {
+		t.Fatal("no instance picked when all are ejected")
+	}
+
+	// 摘除到期后恢复
+	b.instances[0].ejectedUntil.Store(0)
+	b.instances[1].ejectedUntil.Store(0)
+	if status := b.status(); status[0].Ejected || status[1].Ejected {
+		t.Fatalf("status after eject duration = %+v", status)
+	}
+}
+
+func TestBalancerHealthCheck(t *testing.T) {
+	healthy := true
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != healthPath || !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	upstreams := &fakeUpstreams{}
+	b := newTestBalancer(t, Options{}, upstreams, server.URL, "http://b:1")
+	client := &http.Client{Timeout: time.Second}
+
+	mu.Lock()
+	healthy = false
+	mu.Unlock()
+	b.check(client, b.instances[0])
+	if b.instances[0].healthy.Load() {
+		t.Fatal("instance still healthy after failed check")
+	}
+	for i := 0; i < 3; i++ {
+		if inst := b.pick(nil); inst != b.instances[1] {
+			t.Fatalf("picked unhealthy instance %s", inst.url)
+		}
+	}
+
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	b.check(client, b.instances[0])
+	if !b.instances[0].healthy.Load() {
+		t.Fatal("instance not restored after successful check")
+	}
+}
+
+func TestBalancerInstanceBasePath(t *testing.T) {
+	upstreams := &fakeUpstreams{}
+	b := newTestBalancer(t, Options{}, upstreams, "http://a:1/base/")
+	if _, err := roundTrip(b, http.MethodGet, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := upstreams.paths[0]; got != "/base/api/items" {
+		t.Fatalf("upstream path = %q, want /base/api/items", got)
+	}
+}
+
+func TestNewBalancerValidation(t *testing.T) {
+	if _, err := newBalancer(nil, Options{}, &fakeUpstreams{}); err == nil {
+		t.Error("no instances accepted")
+	}
+	if _, err := newBalancer([]string{"http://a:1"}, Options{Balance: "random"}, &fakeUpstreams{}); err == nil {
+		t.Error("unknown balance accepted")
+	}
+	if _, err := newBalancer([]string{"a:1"}, Options{}, &fakeUpstreams{}); err == nil {
+		t.Error("url without scheme accepted")
+	}
+}
@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http/httputil"
+	"strings"
+)
+
+// ParseTrustedProxies 解析可信的前置代理地址，支持单个 IP 和 CIDR
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+// trustedPeer 连接的对端是否为可信的前置代理
+func trustedPeer(remoteAddr string, trusted []*net.IPNet) bool {
+	if len(trusted) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// setForwarded 设置转发给后端的 X-Forwarded-* 头。对端是可信代理（如 nginx）时保留其传入的转发链
+// 再追加对端地址，并沿用其记录的 Host 和协议；否则客户端自带的值一律丢弃，按连接重新生成
+func setForwarded(r *httputil.ProxyRequest, trusted []*net.IPNet) {
+	if !trustedPeer(r.In.RemoteAddr, trusted) {
+		r.Out.Header.Del("X-Real-Ip")
+		r.SetXForwarded()
+		return
+	}
+	r.Out.Header["X-Forwarded-For"] = r.In.Header["X-Forwarded-For"]
+	r.SetXForwarded()
+	for _, name := range []string{"X-Forwarded-Host", "X-Forwarded-Proto"} {
+		if value := r.In.Header.Get(name); value != "" {
+			r.Out.Header.Set(name, value)
+		}
+	}
+}
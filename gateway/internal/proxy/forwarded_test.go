@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := ParseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"10.0.0.1:5000", true},
+		{"10.0.0.2:5000", false},
+		{"192.168.3.4:80", true},
+		{"[::1]:80", true},
+		{"203.0.113.7:443", false},
+		{"not-an-ip", false},
+	}
+	for _, tc := range cases {
+		if got := trustedPeer(tc.addr, nets); got != tc.want {
+			t.Errorf("trustedPeer(%q) = %v, want %v", tc.addr, got, tc.want)
+		}
+	}
+	if trustedPeer("10.0.0.1:5000", nil) {
+		t.Error("peer trusted without TRUSTED_PROXIES")
+	}
+	for _, bad := range []string{"10.0.0", "10.0.0.0/33", "proxy.local"} {
+		if _, err := ParseTrustedProxies([]string{bad}); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) succeeded", bad)
+		}
+	}
+}
+
+// TestForwardedHeaders 客户端自带的 X-Forwarded-* 只有经可信代理转发时才传给后端
+func TestForwardedHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer backend.Close()
+
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewServiceProxy([]string{backend.URL}, Options{TrustedProxies: trusted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	send := func(remoteAddr string) {
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.example.com/api/users", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		req.Header.Set("X-Forwarded-Host", "app.example.com")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Real-IP", "1.2.3.4")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		p.Handle(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d", w.Code)
+		}
+	}
+
+	// 直连的客户端：丢弃伪造的头，按连接生成
+	send("203.0.113.7:5000")
+	if got.Get("X-Forwarded-For") != "203.0.113.7" || got.Get("X-Forwarded-Host") != "gateway.example.com" ||
+		got.Get("X-Forwarded-Proto") != "http" || got.Get("X-Real-Ip") != "" {
+		t.Fatalf("untrusted peer headers = %v", got)
+	}
+
+	// 可信代理：保留其转发链并追加代理地址
+	send("10.1.2.3:5000")
+	if got.Get("X-Forwarded-For") != "1.2.3.4, 10.1.2.3" || got.Get("X-Forwarded-Host") != "app.example.com" ||
+		got.Get("X-Forwarded-Proto") != "https" {
+		t.Fatalf("trusted peer headers = %v", got)
+	}
+}
@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/addp/common/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// TestIdentitySignedForUpstreamPath 身份头按去掉路由前缀、加上实例基础路径后的实际路径签名，后端能直接校验
+func TestIdentitySignedForUpstreamPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "identity-secret"
+	identity := auth.Identity{UserID: 7, Username: "alice", TenantID: 3, UserType: "user"}
+
+	nonces := auth.NewNonceCache()
+	var verified *auth.Identity
+	var verifyErr error
+	var path string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.RequestURI()
+		verified, verifyErr = auth.VerifyIdentity(r, secret, nonces)
+	}))
+	defer backend.Close()
+
+	p, err := NewServiceProxy([]string{backend.URL + "/base"}, Options{IdentitySecret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	send := func(withIdentity bool) {
+		req := httptest.NewRequest(http.MethodPost, "http://gateway.example.com/svc/api/items?page=2", nil)
+		if withIdentity {
+			req = req.WithContext(auth.ContextWithIdentity(req.Context(), identity))
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		p.HandleWithPathRewrite("/svc", time.Second)(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d", w.Code)
+		}
+	}
+
+	send(true)
+	if path != "/base/api/items?page=2" {
+		t.Fatalf("upstream path = %q", path)
+	}
+	if verifyErr != nil {
+		t.Fatalf("VerifyIdentity: %v", verifyErr)
+	}
+	if *verified != identity {
+		t.Fatalf("identity = %+v, want %+v", *verified, identity)
+	}
+
+	// 每次转发使用新的 nonce，同一身份的后续请求不会被当作重放
+	send(true)
+	if verifyErr != nil {
+		t.Fatalf("second request: %v", verifyErr)
+	}
+
+	// 没有认证的请求（公开路由）不写入身份头
+	send(false)
+	if !errors.Is(verifyErr, auth.ErrNoIdentity) {
+		t.Fatalf("anonymous request: err = %v, want ErrNoIdentity", verifyErr)
+	}
+}
@@ -0,0 +1,29 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/addp/common/metrics"
+)
+
+// upstreamErrors 按实例和原因统计转发失败
+var upstreamErrors = metrics.NewCounterVec("gateway_upstream_errors_total",
+	"转发到后端实例失败的次数（timeout、canceled、error 或 502/503/504 状态码）", "upstream", "reason")
+
+// errorReason 区分路由超时、客户端断开和连接失败
+func errorReason(ctx context.Context) string {
+	switch {
+	case errors.Is(context.Cause(ctx), errUpstreamTimeout):
+		return "timeout"
+	case ctx.Err() != nil:
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+func statusReason(status int) string {
+	return "status_" + strconv.Itoa(status)
+}
@@ -1,92 +1,175 @@
 package proxy
 
 import (
-	"bytes"
-	"io"
+	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httputil"
 	"strings"
+	"time"
 
+	"github.com/addp/common/tracing"
 	"github.com/gin-gonic/gin"
 )
 
+// errUpstreamTimeout 在路由超时时间内没有收到后端响应头
+var errUpstreamTimeout = errors.New("upstream response timeout")
+
+// Options 代理选项
+type Options struct {
+	Balance        string        // 负载均衡策略：round_robin（默认）、least_conn
+	Timeout        time.Duration // 等待后端响应头的默认超时，0 表示不限制
+	Retries        int           // 幂等请求连接失败时换实例重试的次数
+	HealthInterval time.Duration // 主动健康检查间隔，0 表示不检查
+	HealthTimeout  time.Duration
+	MaxFails       int           // 连续失败多少次后被动摘除实例，0 表示不摘除
+	EjectDuration  time.Duration // 被动摘除的时长
+	TrustedProxies []*net.IPNet  // 可信的前置代理，只采信来自这些地址的 X-Forwarded-*
+	IdentitySecret string        // 身份头的签名密钥（IDENTITY_SECRET）
+}
+
 type ServiceProxy struct {
 	targetURL string
-	client    *http.Client
+	timeout   time.Duration
+	balancer  *balancer
+	transport *http.Transport
+	proxy     *httputil.ReverseProxy
 }
 
-func NewServiceProxy(targetURL string) *ServiceProxy {
-	return &ServiceProxy{
-		targetURL: targetURL,
-		client:    &http.Client{},
+// NewServiceProxy 创建流式反向代理，请求按负载均衡策略分配到各个实例
+func NewServiceProxy(targets []string, options Options) (*ServiceProxy, error) {
+	transport := newTransport()
+	balancer, err := newBalancer(targets, options, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ServiceProxy{
+		targetURL: strings.Join(targets, ","),
+		timeout:   options.Timeout,
+		balancer:  balancer,
+		transport: transport,
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			// 目标实例由 balancer 在发送时选择
+			r.Out.Host = ""
+			setForwarded(r, options.TrustedProxies)
+		},
+		Transport:      balancer,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler:   p.handleError,
 	}
+	return p, nil
 }
 
-func (p *ServiceProxy) Handle(c *gin.Context) {
-	// 构建目标 URL
-	path := c.Request.URL.Path
-	targetURL := p.targetURL + path
+// Status 返回各实例的健康状态
+func (p *ServiceProxy) Status() []InstanceStatus {
+	return p.balancer.status()
+}
 
-	// 保留查询参数
-	if c.Request.URL.RawQuery != "" {
-		targetURL += "?" + c.Request.URL.RawQuery
-	}
+// Close 停止健康检查并关闭空闲连接，进行中的请求不受影响
+func (p *ServiceProxy) Close() {
+	p.balancer.close()
+	p.transport.CloseIdleConnections()
+}
 
-	// 读取请求体
-	var bodyBytes []byte
-	if c.Request.Body != nil {
-		bodyBytes, _ = io.ReadAll(c.Request.Body)
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   50,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
 	}
+}
 
-	// 创建新请求
-	req, err := http.NewRequest(c.Request.Method, targetURL, bytes.NewReader(bodyBytes))
-	if err != nil {
-		log.Printf("创建请求失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create proxy request",
-		})
-		return
+// Handle 使用默认超时转发请求
+func (p *ServiceProxy) Handle(c *gin.Context) {
+	p.serve(c, p.timeout)
+}
+
+// HandleWithTimeout 使用路由自己的超时转发请求（如文件上传、大文件预览）
+func (p *ServiceProxy) HandleWithTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p.serve(c, timeout)
 	}
+}
 
-	// 复制请求头
-	for key, values := range c.Request.Header {
-		for _, value := range values {
-			req.Header.Add(key, value)
+// HandleWithPathRewrite 去掉路径前缀后转发
+func (p *ServiceProxy) HandleWithPathRewrite(prefix string, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 移除路径前缀
+		newPath := strings.TrimPrefix(c.Request.URL.Path, prefix)
+		if !strings.HasPrefix(newPath, "/") {
+			newPath = "/" + newPath
 		}
+		c.Request.URL.Path = newPath
+		c.Request.URL.RawPath = ""
+		p.serve(c, timeout)
 	}
+}
 
-	// 发送请求
-	resp, err := p.client.Do(req)
-	if err != nil {
-		log.Printf("代理请求失败: %v (target: %s)", err, targetURL)
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":   "Service unavailable",
-			"service": p.targetURL,
-		})
-		return
+// serve 请求体和响应体均以流的方式转发（支持分块传输、SSE 和 WebSocket 升级），客户端断开时取消后端请求；
+// 超时只限制等待响应头的时间，收到响应头后的流式响应不受限制
+func (p *ServiceProxy) serve(c *gin.Context, timeout time.Duration) {
+	ctx, cancel := context.WithCancelCause(c.Request.Context())
+	defer cancel(nil)
+
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() { cancel(errUpstreamTimeout) })
+		ctx = context.WithValue(ctx, timerKey{}, timer)
+		defer timer.Stop()
 	}
-	defer resp.Body.Close()
 
-	// 复制响应头
-	for key, values := range resp.Header {
-		for _, value := range values {
-			c.Writer.Header().Add(key, value)
+	p.proxy.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+type timerKey struct{}
+
+// modifyResponse 收到响应头后停止超时计时；跨域头由网关统一设置，去掉后端返回的重复值
+func (p *ServiceProxy) modifyResponse(resp *http.Response) error {
+	if timer, ok := resp.Request.Context().Value(timerKey{}).(*time.Timer); ok {
+		timer.Stop()
+	}
+	for name := range resp.Header {
+		if strings.HasPrefix(name, "Access-Control-") {
+			resp.Header.Del(name)
 		}
 	}
+	// 网关已写入请求 ID 响应头
+	resp.Header.Del(tracing.RequestIDHeader)
+	return nil
+}
 
-	// 复制响应体
-	c.Status(resp.StatusCode)
-	respBody, _ := io.ReadAll(resp.Body)
-	c.Writer.Write(respBody)
+func (p *ServiceProxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(context.Cause(r.Context()), errUpstreamTimeout):
+		log.Printf("代理请求超时: %s %s (target: %s, request_id: %s)", r.Method, r.URL.Path, p.targetURL, tracing.RequestID(r.Context()))
+		writeError(w, http.StatusGatewayTimeout, "Service timeout", p.targetURL)
+	case errors.Is(err, context.Canceled):
+		// 客户端已断开，无需响应
+		log.Printf("客户端断开，取消代理请求: %s %s", r.Method, r.URL.Path)
+	default:
+		log.Printf("代理请求失败: %v (target: %s, request_id: %s)", err, p.targetURL, tracing.RequestID(r.Context()))
+		writeError(w, http.StatusBadGateway, "Service unavailable", p.targetURL)
+	}
 }
 
-// HandleWithPathRewrite 处理带路径重写的代理
-func (p *ServiceProxy) HandleWithPathRewrite(prefix string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 移除路径前缀
-		newPath := strings.TrimPrefix(c.Request.URL.Path, prefix)
-		c.Request.URL.Path = newPath
-		p.Handle(c)
+func writeError(w http.ResponseWriter, status int, message, service string) {
+	if c, ok := w.(gin.ResponseWriter); ok && c.Written() {
+		return
 	}
-}
\ No newline at end of file
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	body, _ := json.Marshal(gin.H{"error": message, "service": service})
+	w.Write(body)
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newGateway 通过 handler 转发到 upstream 的测试网关
+func newGateway(t *testing.T, upstream http.Handler, handler func(p *ServiceProxy) gin.HandlerFunc) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	backend := httptest.NewServer(upstream)
+	t.Cleanup(backend.Close)
+
+	p, err := NewServiceProxy([]string{backend.URL}, Options{Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Close)
+
+	engine := gin.New()
+	engine.NoRoute(handler(p))
+	gateway := httptest.NewServer(engine)
+	t.Cleanup(gateway.Close)
+	return gateway.URL
+}
+
+func TestProxyStreamsResponse(t *testing.T) {
+	release := make(chan struct{})
+	gateway := newGateway(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "data: second\n\n")
+	}), func(p *ServiceProxy) gin.HandlerFunc { return p.Handle })
+
+	resp, err := http.Get(gateway + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// 后端还没有写完时客户端已经收到第一条事件
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "data: first\n" {
+		close(release)
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	close(release)
+	rest, err := io.ReadAll(reader)
+	if err != nil || string(rest) != "\ndata: second\n\n" {
+		t.Fatalf("rest = %q, %v", rest, err)
+	}
+}
+
+func TestProxyStreamsRequestBody(t *testing.T) {
+	gateway := newGateway(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		fmt.Fprintf(w, "%s %d", r.Method, n)
+	}), func(p *ServiceProxy) gin.HandlerFunc { return p.Handle })
+
+	// 分块上传的大请求体完整转发
+	body := io.MultiReader(strings.NewReader(strings.Repeat("a", 1<<20)), strings.NewReader(strings.Repeat("b", 1<<20)))
+	req, err := http.NewRequest(http.MethodPost, gateway+"/api/upload", io.NopCloser(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if string(got) != "POST 2097152" {
+		t.Fatalf("upstream saw %q", got)
+	}
+}
+
+func TestProxyTimeoutOnlyLimitsHeaders(t *testing.T) {
+	gateway := newGateway(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		// 响应头及时返回，之后的流式响应超过超时时间
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for i := 0; i < 3; i++ {
+			time.Sleep(50 * time.Millisecond)
+			io.WriteString(w, "chunk\n")
+			w.(http.Flusher).Flush()
+		}
+	}), func(p *ServiceProxy) gin.HandlerFunc { return p.HandleWithTimeout(100 * time.Millisecond) })
+
+	resp, err := http.Get(gateway + "/slow-headers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("slow headers status = %d, want 504", resp.StatusCode)
+	}
+
+	resp, err = http.Get(gateway + "/slow-body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "chunk\nchunk\nchunk\n" {
+		t.Fatalf("slow body = %q, %v", body, err)
+	}
+}
+
+func TestProxyUpstreamErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	backend := httptest.NewServer(http.NotFoundHandler())
+	backend.Close()
+	p, err := NewServiceProxy([]string{backend.URL}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/items", nil)
+	p.Handle(c)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", w.Code)
+	}
+}
+
+func TestProxyStripsUpstreamCORSHeaders(t *testing.T) {
+	gateway := newGateway(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("X-Request-ID", "upstream")
+		w.Header().Set("X-Custom", "kept")
+	}), func(p *ServiceProxy) gin.HandlerFunc { return p.Handle })
+
+	resp, err := http.Get(gateway + "/api/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("Access-Control-Allow-Origin") != "" || resp.Header.Get("X-Request-ID") != "" {
+		t.Fatalf("upstream CORS or request ID header forwarded: %v", resp.Header)
+	}
+	if resp.Header.Get("X-Custom") != "kept" {
+		t.Fatalf("upstream header dropped: %v", resp.Header)
+	}
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate 令牌桶参数：每秒补充 PerSecond 个令牌，最多积累 Burst 个
+type Rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+// ParseRate 解析 "10/s"、"100/m"、"1000/h"、"10000/d" 形式的速率，burst 为 0 时等于每个周期的请求数
+func ParseRate(text string, burst int) (Rate, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(text), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q, expected <count>/<s|m|h|d>", text)
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q", text)
+	}
+
+	var period time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	case "d":
+		period = 24 * time.Hour
+	default:
+		return Rate{}, fmt.Errorf("invalid rate unit in %q, expected s, m, h or d", text)
+	}
+
+	if burst < 0 {
+		return Rate{}, fmt.Errorf("burst must not be negative")
+	}
+	if burst == 0 {
+		burst = max(1, int(math.Ceil(n)))
+	}
+	return Rate{PerSecond: n / period.Seconds(), Burst: burst}, nil
+}
+
+// Limiter 按 key 限流
+type Limiter interface {
+	// Allow 取一个令牌，没有令牌时返回 false 和需要等待的时间
+	Allow(ctx context.Context, key string, rate Rate) (bool, time.Duration, error)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	cases := []struct {
+		text  string
+		burst int
+		want  Rate
+	}{
+		{"10/s", 0, Rate{PerSecond: 10, Burst: 10}},
+		{"120/m", 5, Rate{PerSecond: 2, Burst: 5}},
+		{"3600/h", 0, Rate{PerSecond: 1, Burst: 3600}},
+		{" 0.5 / s ", 0, Rate{PerSecond: 0.5, Burst: 1}},
+	}
+	for _, tc := range cases {
+		got, err := ParseRate(tc.text, tc.burst)
+		if err != nil {
+			t.Fatalf("ParseRate(%q): %v", tc.text, err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseRate(%q, %d) = %+v, want %+v", tc.text, tc.burst, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"10", "0/s", "-1/s", "x/s", "10/w"} {
+		if _, err := ParseRate(bad, 0); err == nil {
+			t.Errorf("ParseRate(%q) succeeded", bad)
+		}
+	}
+	if _, err := ParseRate("10/s", -1); err == nil {
+		t.Error("negative burst accepted")
+	}
+}
+
+// rewind 把令牌桶的上次补充时间往前拨，模拟经过了 d
+func rewind(l *MemoryLimiter, key string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets[key].last = l.buckets[key].last.Add(-d)
+}
+
+func TestMemoryLimiterRefill(t *testing.T) {
+	ctx := context.Background()
+	l := &MemoryLimiter{buckets: make(map[string]*bucket)}
+	rate := Rate{PerSecond: 2, Burst: 3}
+
+	// 新的令牌桶是满的，可以突发 Burst 个请求
+	for i := 0; i < rate.Burst; i++ {
+		if allowed, _, _ := l.Allow(ctx, "user:1", rate); !allowed {
+			t.Fatalf("request %d rejected within burst", i+1)
+		}
+	}
+	allowed, wait, err := l.Allow(ctx, "user:1", rate)
+	if err != nil || allowed {
+		t.Fatalf("request beyond burst: allowed = %v, err = %v", allowed, err)
+	}
+	// 每秒补充 2 个令牌，等待不超过半秒
+	if wait <= 0 || wait > 500*time.Millisecond {
+		t.Fatalf("wait = %s, want (0, 500ms]", wait)
+	}
+
+	// 其他 key 使用独立的令牌桶
+	if allowed, _, _ := l.Allow(ctx, "user:2", rate); !allowed {
+		t.Fatal("independent key rejected")
+	}
+
+	// 经过 1 秒补充 2 个令牌
+	rewind(l, "user:1", time.Second)
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := l.Allow(ctx, "user:1", rate); !allowed {
+			t.Fatalf("refilled request %d rejected", i+1)
+		}
+	}
+	if allowed, _, _ := l.Allow(ctx, "user:1", rate); allowed {
+		t.Fatal("more tokens than refilled")
+	}
+
+	// 长时间空闲后最多积累 Burst 个令牌
+	rewind(l, "user:1", time.Hour)
+	for i := 0; i < rate.Burst; i++ {
+		if allowed, _, _ := l.Allow(ctx, "user:1", rate); !allowed {
+			t.Fatalf("request %d rejected after idle", i+1)
+		}
+	}
+	if allowed, _, _ := l.Allow(ctx, "user:1", rate); allowed {
+		t.Fatal("tokens accumulated beyond burst")
+	}
+}
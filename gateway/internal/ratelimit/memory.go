@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// idleBucketTTL 令牌桶空闲多久后被清理
+const idleBucketTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryLimiter 进程内令牌桶，只适用于单实例网关
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryLimiter 创建进程内限流器并定期清理空闲的令牌桶
+func NewMemoryLimiter() *MemoryLimiter {
+	l := &MemoryLimiter{buckets: make(map[string]*bucket)}
+	go l.cleanup()
+	return l
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, rate Rate) (bool, time.Duration, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(float64(rate.Burst), b.tokens+now.Sub(b.last).Seconds()*rate.PerSecond)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / rate.PerSecond * float64(time.Second))
+	return false, wait, nil
+}
+
+func (l *MemoryLimiter) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.mu.Lock()
+		for key, b := range l.buckets {
+			if time.Since(b.last) > idleBucketTTL {
+				delete(l.buckets, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,137 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syncOverlap 每次同步多取一段时间内的记录，避免 System 各实例时钟差异或事务提交顺序导致遗漏
+const syncOverlap = time.Minute
+
+// ErrNoInternalKey 未配置 Internal API Key，无法从 System 同步黑名单
+var ErrNoInternalKey = errors.New("INTERNAL_API_KEY not configured, revoked tokens cannot be rejected by the gateway")
+
+// revokedToken System /internal/auth/revoked-tokens 返回的黑名单记录
+type revokedToken struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type revokedTokensResponse struct {
+	Tokens     []revokedToken `json:"tokens"`
+	ServerTime int64          `json:"server_time"`
+}
+
+// Denylist 从 System 定期同步的访问令牌黑名单（注销、强制下线、禁用用户或修改密码后吊销的令牌），
+// 网关据此拒绝已吊销但尚未过期的令牌；同步失败时继续使用已有的黑名单
+type Denylist struct {
+	systemURLs []string
+	apiKey     string
+	client     *http.Client
+
+	mu     sync.RWMutex
+	tokens map[string]time.Time // jti → 令牌过期时间
+	since  int64                // 下次同步的起始时间（System 时钟，Unix 秒）
+}
+
+// NewDenylist systemURLs 为 System 实例地址，依次尝试直到同步成功
+func NewDenylist(systemURLs []string, apiKey string) *Denylist {
+	return &Denylist{
+		systemURLs: systemURLs,
+		apiKey:     apiKey,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		tokens:     make(map[string]time.Time),
+	}
+}
+
+// Revoked 令牌是否已被吊销；Denylist 为 nil（未启用）时总是返回 false
+func (d *Denylist) Revoked(jti string) bool {
+	if d == nil || jti == "" {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	expiresAt, ok := d.tokens[jti]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Watch 立即同步一次，之后每隔 interval 增量同步
+func (d *Denylist) Watch(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := d.Sync(context.Background()); err != nil {
+				log.Printf("同步令牌黑名单失败，继续使用当前黑名单: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Sync 拉取上次同步之后吊销的令牌，并清理已过期的记录
+func (d *Denylist) Sync(ctx context.Context) error {
+	d.mu.RLock()
+	since := d.since
+	d.mu.RUnlock()
+
+	var lastErr error
+	for _, systemURL := range d.systemURLs {
+		resp, err := d.fetch(ctx, systemURL, since)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		d.apply(resp)
+		return nil
+	}
+	return lastErr
+}
+
+func (d *Denylist) fetch(ctx context.Context, systemURL string, since int64) (*revokedTokensResponse, error) {
+	url := fmt.Sprintf("%s/internal/auth/revoked-tokens?since=%d", strings.TrimRight(systemURL, "/"), since)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-API-Key", d.apiKey)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	var result revokedTokensResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (d *Denylist) apply(resp *revokedTokensResponse) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, token := range resp.Tokens {
+		d.tokens[token.JTI] = token.ExpiresAt
+	}
+	for jti, expiresAt := range d.tokens {
+		if !now.Before(expiresAt) {
+			delete(d.tokens, jti)
+		}
+	}
+	if resp.ServerTime > 0 {
+		d.since = resp.ServerTime - int64(syncOverlap/time.Second)
+	}
+}
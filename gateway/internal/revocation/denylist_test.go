@@ -0,0 +1,123 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSystem 模拟 System 的 /internal/auth/revoked-tokens，记录每次同步的 since
+type fakeSystem struct {
+	mu         sync.Mutex
+	tokens     []revokedToken
+	serverTime int64
+	since      []string
+	status     int
+}
+
+func (f *fakeSystem) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != "/internal/auth/revoked-tokens" || r.Header.Get("X-Internal-API-Key") != "key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	f.since = append(f.since, r.URL.Query().Get("since"))
+	json.NewEncoder(w).Encode(revokedTokensResponse{Tokens: f.tokens, ServerTime: f.serverTime})
+}
+
+func TestDenylistSync(t *testing.T) {
+	ctx := context.Background()
+	system := &fakeSystem{
+		tokens: []revokedToken{
+			{JTI: "revoked", ExpiresAt: time.Now().Add(time.Hour)},
+			{JTI: "expired", ExpiresAt: time.Now().Add(-time.Second)},
+		},
+		serverTime: 1000,
+	}
+	server := httptest.NewServer(system)
+	defer server.Close()
+
+	d := NewDenylist([]string{server.URL}, "key")
+	if d.Revoked("revoked") {
+		t.Fatal("token revoked before sync")
+	}
+	if err := d.Sync(ctx); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if !d.Revoked("revoked") {
+		t.Fatal("synced token not revoked")
+	}
+	// 令牌过期后不再保留
+	if d.Revoked("expired") || len(d.tokens) != 1 {
+		t.Fatalf("expired token kept: %v", d.tokens)
+	}
+	if d.Revoked("active") || d.Revoked("") {
+		t.Fatal("unrevoked token rejected")
+	}
+
+	// 增量同步从上次的服务器时间往前多取一段，新增的记录与已有记录合并
+	system.mu.Lock()
+	system.tokens = []revokedToken{{JTI: "later", ExpiresAt: time.Now().Add(time.Hour)}}
+	system.serverTime = 2000
+	system.mu.Unlock()
+	if err := d.Sync(ctx); err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if !d.Revoked("revoked") || !d.Revoked("later") {
+		t.Fatalf("tokens after incremental sync = %v", d.tokens)
+	}
+	if want := []string{"0", "940"}; len(system.since) != 2 || system.since[0] != want[0] || system.since[1] != want[1] {
+		t.Fatalf("since = %v, want %v", system.since, want)
+	}
+}
+
+func TestDenylistSyncFailover(t *testing.T) {
+	ctx := context.Background()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	system := &fakeSystem{tokens: []revokedToken{{JTI: "revoked", ExpiresAt: time.Now().Add(time.Hour)}}, serverTime: 1000}
+	up := httptest.NewServer(system)
+	defer up.Close()
+
+	// 依次尝试 System 实例
+	d := NewDenylist([]string{down.URL, up.URL}, "key")
+	if err := d.Sync(ctx); err != nil {
+		t.Fatalf("Sync with one instance up: %v", err)
+	}
+	if !d.Revoked("revoked") {
+		t.Fatal("token not revoked after failover")
+	}
+
+	// 同步失败时保留已有的黑名单
+	system.mu.Lock()
+	system.status = http.StatusInternalServerError
+	system.mu.Unlock()
+	if err := d.Sync(ctx); err == nil {
+		t.Fatal("Sync succeeded with all instances failing")
+	}
+	if !d.Revoked("revoked") {
+		t.Fatal("denylist dropped after failed sync")
+	}
+
+	// Internal API Key 错误时同步失败
+	wrongKey := NewDenylist([]string{up.URL}, "wrong")
+	if err := wrongKey.Sync(ctx); err == nil {
+		t.Fatal("Sync with wrong internal key succeeded")
+	}
+}
+
+func TestNilDenylist(t *testing.T) {
+	var d *Denylist
+	if d.Revoked("any") {
+		t.Fatal("nil denylist revoked a token")
+	}
+}
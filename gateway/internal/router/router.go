@@ -1,19 +1,40 @@
 package router
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/addp/common/metrics"
+	"github.com/addp/gateway/internal/apitoken"
 	"github.com/addp/gateway/internal/config"
+	"github.com/addp/gateway/internal/health"
 	"github.com/addp/gateway/internal/middleware"
-	"github.com/addp/gateway/internal/proxy"
+	"github.com/addp/gateway/internal/ratelimit"
+	"github.com/addp/gateway/internal/revocation"
+	"github.com/addp/gateway/internal/routing"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(cfg *config.Config) *gin.Engine {
-	router := gin.Default()
+// SetupRouter 返回对外的网关路由和只在内部端口（METRICS_PORT）提供的指标、就绪检查
+func SetupRouter(cfg *config.Config) (*gin.Engine, *http.ServeMux, error) {
+	router := gin.New()
+
+	// 只有来自可信前置代理的 X-Forwarded-For 才用于确定客户端地址（按 IP 限流、访问日志）
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	// 访问日志附带请求 ID，请求 ID 和 trace 随调用传递给其他服务
+	router.Use(middleware.Logger(), gin.Recovery(), middleware.RequestID(), middleware.Metrics())
 
 	// CORS 中间件
 	router.Use(middleware.CORS())
 
-	// 健康检查
+	// 客户端不能自行携带身份头
+	router.Use(middleware.StripIdentity())
+
+	// 存活检查（只表示网关进程可用）
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"status":  "ok",
@@ -35,38 +56,84 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 		})
 	})
 
-	// 创建代理
-	systemProxy := proxy.NewServiceProxy(cfg.SystemServiceURL)
-	managerProxy := proxy.NewServiceProxy(cfg.ManagerServiceURL)
-	metaProxy := proxy.NewServiceProxy(cfg.MetaServiceURL)
-	transferProxy := proxy.NewServiceProxy(cfg.TransferServiceURL)
+	// 限流器（规则在路由表中配置）
+	limiter, err := newLimiter(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 后端服务只信任签名的身份头，没有签名密钥时转发的请求都无法通过认证
+	if cfg.IdentitySecret == "" {
+		return nil, nil, errors.New("IDENTITY_SECRET is not configured")
+	}
+
+	// 令牌黑名单（从 System 同步）
+	denylist, err := newDenylist(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 访问令牌校验（通过 System）
+	tokens := newVerifier(cfg)
+
+	// 路由表（由配置文件加载，可热更新）
+	dispatcher, err := routing.NewDispatcher(cfg, limiter, denylist, tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	dispatcher.Watch(cfg.RoutesReloadInterval)
+
+	// 内部端口：就绪检查会向路由表中所有服务实例发起请求并返回实例地址和错误，不对外提供
+	internal := http.NewServeMux()
+	internal.Handle("/metrics", metrics.Handler())
+	internal.Handle("/health/deep", health.NewDeep(dispatcher.Services, cfg.DeepHealthTimeout, cfg.DeepHealthCacheTTL))
 
-	// 路由规则
-	api := router.Group("/api")
+	// 网关管理接口（仅超级管理员）
+	admin := router.Group("/gateway")
+	admin.Use(middleware.JWTAuth(cfg.JWTSecret, denylist, tokens, "gateway"), middleware.RequireUserType("super_admin"))
 	{
-		// System 模块路由（认证、用户、日志、资源）
-		api.Any("/auth/*path", systemProxy.Handle)
-		api.Any("/users/*path", systemProxy.Handle)
-		api.Any("/logs/*path", systemProxy.Handle)
-		api.Any("/resources/*path", systemProxy.Handle)
-
-		// Manager 模块路由（配置、数据源、目录、预览）
-		api.Any("/config/*path", managerProxy.Handle)
-		api.Any("/datasources/*path", managerProxy.Handle)
-		api.Any("/directories/*path", managerProxy.Handle)
-		api.Any("/preview/*path", managerProxy.Handle)
-		api.Any("/upload/*path", managerProxy.Handle)
-
-		// Meta 模块路由（元数据、血缘）
-		api.Any("/meta/*path", metaProxy.Handle)
-		api.Any("/metadata/*path", metaProxy.Handle)
-		api.Any("/datasets/*path", metaProxy.Handle)
-		api.Any("/lineage/*path", metaProxy.Handle)
-
-		// Transfer 模块路由（任务、传输）
-		api.Any("/tasks/*path", transferProxy.Handle)
-		api.Any("/executions/*path", transferProxy.Handle)
+		admin.GET("/routes", dispatcher.ListRoutes)
+	}
+
+	// 其余请求按路由表转发（在网关校验令牌，后端服务信任签名的身份头）
+	router.NoRoute(dispatcher.Handle)
+
+	return router, internal, nil
+}
+
+// newLimiter 按 RATE_LIMIT_BACKEND 创建限流器，none 时不限流
+func newLimiter(cfg *config.Config) (ratelimit.Limiter, error) {
+	switch cfg.RateLimitBackend {
+	case "none":
+		return nil, nil
+	case "memory":
+		return ratelimit.NewMemoryLimiter(), nil
+	case "redis":
+		limiter, err := ratelimit.NewRedisLimiter(cfg.RedisHost+":"+cfg.RedisPort, cfg.RedisPassword, cfg.RedisDB)
+		if err != nil {
+			return nil, err
+		}
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unsupported RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+	}
+}
+
+// newDenylist 定期从 System 同步已吊销的令牌；后端服务信任网关签名的身份，
+// 无法同步黑名单时已吊销的令牌会一直可用，因此拒绝启动而不是跳过检查
+func newDenylist(cfg *config.Config) (*revocation.Denylist, error) {
+	if cfg.InternalAPIKey == "" {
+		return nil, revocation.ErrNoInternalKey
+	}
+	if cfg.RevocationSyncInterval <= 0 {
+		return nil, fmt.Errorf("REVOCATION_SYNC_INTERVAL must be positive, got %s", cfg.RevocationSyncInterval)
 	}
+	denylist := revocation.NewDenylist(config.SplitURLs(cfg.SystemServiceURL), cfg.InternalAPIKey)
+	denylist.Watch(cfg.RevocationSyncInterval)
+	return denylist, nil
+}
 
-	return router
+// newVerifier 通过 System 校验访问令牌（newDenylist 已确认配置了内部 API Key）
+func newVerifier(cfg *config.Config) *apitoken.Verifier {
+	return apitoken.NewVerifier(config.SplitURLs(cfg.SystemServiceURL), cfg.InternalAPIKey, cfg.APITokenCacheTTL)
 }
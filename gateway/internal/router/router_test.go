@@ -0,0 +1,31 @@
+package router
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/addp/gateway/internal/config"
+	"github.com/addp/gateway/internal/revocation"
+)
+
+func TestNewDenylistFailsClosed(t *testing.T) {
+	// 没有内部 API Key 时无法同步黑名单，网关拒绝启动
+	if _, err := newDenylist(&config.Config{RevocationSyncInterval: 10 * time.Second}); !errors.Is(err, revocation.ErrNoInternalKey) {
+		t.Fatalf("err = %v, want ErrNoInternalKey", err)
+	}
+	cfg := &config.Config{}
+	cfg.InternalAPIKey = "key"
+	if _, err := newDenylist(cfg); err == nil {
+		t.Fatal("expected zero REVOCATION_SYNC_INTERVAL to fail")
+	}
+}
+
+func TestSetupRouterRequiresIdentitySecret(t *testing.T) {
+	// 后端服务只接受签名的身份头，没有签名密钥时网关拒绝启动
+	cfg := &config.Config{RateLimitBackend: "none"}
+	cfg.InternalAPIKey = "key"
+	if _, _, err := SetupRouter(cfg); err == nil {
+		t.Fatal("expected missing IDENTITY_SECRET to fail")
+	}
+}
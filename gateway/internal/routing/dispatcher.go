@@ -0,0 +1,332 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/addp/common/tracing"
+	"github.com/addp/gateway/internal/apitoken"
+	"github.com/addp/gateway/internal/config"
+	"github.com/addp/gateway/internal/middleware"
+	"github.com/addp/gateway/internal/proxy"
+	"github.com/addp/gateway/internal/ratelimit"
+	"github.com/addp/gateway/internal/revocation"
+	"github.com/gin-gonic/gin"
+)
+
+// sourceBuiltin 使用内置路由表
+const sourceBuiltin = "builtin"
+
+// Dispatcher 按路由表转发请求，路由表可在运行时重新加载
+type Dispatcher struct {
+	cfg      *config.Config
+	path     string
+	limiter  ratelimit.Limiter    // 为 nil 时不限流
+	denylist *revocation.Denylist // 为 nil 时不检查令牌吊销
+	tokens   *apitoken.Verifier   // 为 nil 时不接受访问令牌
+	trusted  []*net.IPNet         // 可信的前置代理（TRUSTED_PROXIES）
+	state    atomic.Pointer[routeState]
+
+	mu      sync.Mutex                     // 串行化重新加载
+	proxies map[string]*proxy.ServiceProxy // Service.key() → 代理
+	modTime time.Time
+	size    int64
+}
+
+// routeState 一次加载生成的只读路由状态
+type routeState struct {
+	source   string
+	loadedAt time.Time
+	services map[string]Service
+	proxies  map[string]*proxy.ServiceProxy // 服务名 → 代理
+	routes   []compiledRoute
+	limits   []compiledLimit
+}
+
+type compiledRoute struct {
+	Route
+	methods map[string]bool
+	handler gin.HandlerFunc
+}
+
+// NewDispatcher 加载路由表；文件不存在时使用内置路由
+func NewDispatcher(cfg *config.Config, limiter ratelimit.Limiter, denylist *revocation.Denylist, tokens *apitoken.Verifier) (*Dispatcher, error) {
+	trusted, err := proxy.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{
+		cfg:      cfg,
+		path:     cfg.RoutesFile,
+		limiter:  limiter,
+		denylist: denylist,
+		tokens:   tokens,
+		trusted:  trusted,
+		proxies:  make(map[string]*proxy.ServiceProxy),
+	}
+	if err := d.Reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Reload 重新加载路由表，失败时保留当前路由
+func (d *Dispatcher) Reload() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	table, source := DefaultTable(d.cfg), sourceBuiltin
+	if d.path != "" {
+		info, err := os.Stat(d.path)
+		switch {
+		case err == nil:
+			d.modTime, d.size = info.ModTime(), info.Size()
+			if table, err = LoadTable(d.path); err != nil {
+				return err
+			}
+			source = d.path
+		case errors.Is(err, os.ErrNotExist):
+			d.modTime, d.size = time.Time{}, 0
+			if current := d.state.Load(); current != nil {
+				// 文件被删除时保留当前路由
+				return nil
+			}
+			log.Printf("路由表文件 %s 不存在，使用内置路由", d.path)
+		default:
+			return err
+		}
+	}
+	if err := table.normalize(d.cfg); err != nil {
+		return err
+	}
+	limits, err := compileRateLimits(table.RateLimits)
+	if err != nil {
+		return err
+	}
+
+	state := &routeState{
+		source:   source,
+		loadedAt: time.Now(),
+		services: table.Services,
+		proxies:  make(map[string]*proxy.ServiceProxy, len(table.Services)),
+		limits:   limits,
+	}
+	proxies := make(map[string]*proxy.ServiceProxy, len(table.Services))
+	for name, service := range table.Services {
+		key := service.key()
+		p, ok := proxies[key]
+		if !ok {
+			// 实例和策略未变的服务复用原有代理（连接池和健康状态）
+			if p, ok = d.proxies[key]; !ok {
+				var err error
+				if p, err = proxy.NewServiceProxy(service.URLs, d.proxyOptions(service)); err != nil {
+					closeUnused(proxies, d.proxies)
+					return fmt.Errorf("service %s: %w", name, err)
+				}
+			}
+			proxies[key] = p
+		}
+		state.proxies[name] = p
+	}
+
+	for _, route := range table.Routes {
+		p := state.proxies[route.Service]
+		compiled := compiledRoute{Route: route}
+		if len(route.Methods) > 0 {
+			compiled.methods = make(map[string]bool, len(route.Methods))
+			for _, method := range route.Methods {
+				compiled.methods[method] = true
+			}
+		}
+		timeout := d.timeout(route)
+		if route.StripPrefix {
+			compiled.handler = p.HandleWithPathRewrite(route.Prefix, timeout)
+		} else {
+			compiled.handler = p.HandleWithTimeout(timeout)
+		}
+		state.routes = append(state.routes, compiled)
+	}
+
+	closeUnused(d.proxies, proxies)
+	d.proxies = proxies
+	d.state.Store(state)
+	log.Printf("已加载 %d 条路由（来源: %s）", len(state.routes), source)
+	return nil
+}
+
+func (d *Dispatcher) proxyOptions(service Service) proxy.Options {
+	return proxy.Options{
+		Balance:        service.Balance,
+		Timeout:        d.cfg.ProxyTimeout,
+		Retries:        d.cfg.ProxyRetries,
+		HealthInterval: d.cfg.HealthCheckInterval,
+		HealthTimeout:  d.cfg.HealthCheckTimeout,
+		MaxFails:       d.cfg.MaxFails,
+		EjectDuration:  d.cfg.EjectDuration,
+		TrustedProxies: d.trusted,
+		IdentitySecret: d.cfg.IdentitySecret,
+	}
+}
+
+// timeout 路由未配置超时时使用 PROXY_TIMEOUT
+func (d *Dispatcher) timeout(route Route) time.Duration {
+	if route.Timeout > 0 {
+		return time.Duration(route.Timeout)
+	}
+	return d.cfg.ProxyTimeout
+}
+
+// closeUnused 关闭 proxies 中没有继续使用的代理
+func closeUnused(proxies, keep map[string]*proxy.ServiceProxy) {
+	for key, p := range proxies {
+		if keep[key] != p {
+			p.Close()
+		}
+	}
+}
+
+// Watch 定期检查路由表文件的变化并在收到 SIGHUP 时重新加载
+func (d *Dispatcher) Watch(interval time.Duration) {
+	if d.path == "" {
+		return
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	go func() {
+		for {
+			select {
+			case <-hangup:
+			case <-tick:
+				if !d.changed() {
+					continue
+				}
+			}
+			if err := d.Reload(); err != nil {
+				log.Printf("重新加载路由表失败，继续使用当前路由: %v", err)
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) changed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist) && !d.modTime.IsZero()
+	}
+	return !info.ModTime().Equal(d.modTime) || info.Size() != d.size
+}
+
+// Handle 按最长前缀匹配路由并转发
+func (d *Dispatcher) Handle(c *gin.Context) {
+	state := d.state.Load()
+	path := c.Request.URL.Path
+
+	var route *compiledRoute
+	methodMismatch := false
+	for i := range state.routes {
+		candidate := &state.routes[i]
+		if !candidate.matches(path) {
+			continue
+		}
+		if candidate.methods != nil && !candidate.methods[c.Request.Method] {
+			methodMismatch = true
+			continue
+		}
+		route = candidate
+		break
+	}
+
+	if route == nil {
+		if methodMismatch {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "method not allowed"})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+		}
+		return
+	}
+	c.Set(middleware.RouteKey, route.Prefix)
+	if span := tracing.SpanFromContext(c.Request.Context()); span != nil {
+		span.SetAttribute("gateway.route", route.Prefix)
+		span.SetAttribute("gateway.service", route.Service)
+	}
+
+	if route.RequiresAuth() && !middleware.Authenticate(c, d.cfg.JWTSecret, d.denylist, d.tokens, route.Service) {
+		return
+	}
+	if !d.allowRequest(c, state.limits) {
+		return
+	}
+	route.handler(c)
+}
+
+// Services 返回当前路由表中各服务的实例地址
+func (d *Dispatcher) Services() map[string][]string {
+	state := d.state.Load()
+	services := make(map[string][]string, len(state.services))
+	for name, service := range state.services {
+		services[name] = service.URLs
+	}
+	return services
+}
+
+// ListRoutes 返回当前生效的路由表和各服务实例的状态
+func (d *Dispatcher) ListRoutes(c *gin.Context) {
+	state := d.state.Load()
+
+	services := make(gin.H, len(state.services))
+	for name, service := range state.services {
+		services[name] = gin.H{
+			"balance":   service.Balance,
+			"instances": state.proxies[name].Status(),
+		}
+	}
+
+	routes := make([]gin.H, 0, len(state.routes))
+	for _, route := range state.routes {
+		methods := route.Methods
+		if len(methods) == 0 {
+			methods = []string{"*"}
+		}
+		routes = append(routes, gin.H{
+			"prefix":       route.Prefix,
+			"service":      route.Service,
+			"strip_prefix": route.StripPrefix,
+			"methods":      methods,
+			"auth":         route.RequiresAuth(),
+			"timeout":      d.timeout(route.Route).String(),
+		})
+	}
+
+	limits := make([]gin.H, 0, len(state.limits))
+	for _, limit := range state.limits {
+		limits = append(limits, rateLimitView(limit))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source":      state.source,
+		"loaded_at":   state.loadedAt,
+		"services":    services,
+		"routes":      routes,
+		"rate_limits": limits,
+	})
+}
@@ -0,0 +1,243 @@
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/addp/gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// upstreamHit 测试后端收到的请求
+type upstreamHit struct {
+	Service string `json:"service"`
+	Path    string `json:"path"`
+}
+
+// newUpstream 返回自身服务名和收到的路径的测试后端
+func newUpstream(t *testing.T, service string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(upstreamHit{Service: service, Path: r.URL.RequestURI()})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// newTestDispatcher 各服务指向测试后端，路由表文件为 routes（为空时不创建文件）
+func newTestDispatcher(t *testing.T, routes string) (*Dispatcher, *config.Config) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		ManagerServiceURL:  newUpstream(t, "manager"),
+		MetaServiceURL:     newUpstream(t, "meta"),
+		TransferServiceURL: newUpstream(t, "transfer"),
+		LoadBalance:        "round_robin",
+		ProxyTimeout:       5 * time.Second,
+		RoutesFile:         filepath.Join(t.TempDir(), "routes.yaml"),
+	}
+	cfg.SystemServiceURL = newUpstream(t, "system")
+	if routes != "" {
+		writeRoutes(t, cfg.RoutesFile, routes)
+	}
+	d, err := NewDispatcher(cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+	return d, cfg
+}
+
+// writeRoutes 写入路由表并推进修改时间，确保 changed 能发现变化
+func writeRoutes(t *testing.T, path, routes string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(routes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		next := info.ModTime().Add(time.Second)
+		if err := os.Chtimes(path, next, next); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// dispatch 通过 Dispatcher 发送请求，返回状态码和命中的后端
+func dispatch(t *testing.T, d *Dispatcher, method, target string) (int, upstreamHit) {
+	t.Helper()
+	engine := gin.New()
+	engine.NoRoute(d.Handle)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+
+	var hit upstreamHit
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &hit); err != nil {
+			t.Fatalf("%s %s: invalid upstream response %q", method, target, w.Body.String())
+		}
+	}
+	return w.Code, hit
+}
+
+const testRoutes = `
+routes:
+  - {prefix: /api, service: system, auth: false}
+  - {prefix: /api/meta, service: meta, auth: false}
+  - {prefix: /api/tasks/, service: transfer, methods: [get, post], auth: false}
+  - {prefix: /files, service: manager, strip_prefix: true, auth: false}
+  - {prefix: /private, service: manager}
+`
+
+func TestDispatcherRouteMatching(t *testing.T) {
+	d, _ := newTestDispatcher(t, testRoutes)
+
+	cases := []struct {
+		method  string
+		target  string
+		code    int
+		service string
+		path    string
+	}{
+		// 最长前缀优先
+		{http.MethodGet, "/api/meta/tables", http.StatusOK, "meta", "/api/meta/tables"},
+		{http.MethodGet, "/api/meta", http.StatusOK, "meta", "/api/meta"},
+		// 前缀按路径段匹配，/api/meta 不匹配 /api/metadata
+		{http.MethodGet, "/api/metadata", http.StatusOK, "system", "/api/metadata"},
+		// 方法名和前缀末尾的 / 已规范化
+		{http.MethodPost, "/api/tasks/1/run?force=true", http.StatusOK, "transfer", "/api/tasks/1/run?force=true"},
+		// 方法不匹配时继续匹配更短的前缀
+		{http.MethodDelete, "/api/tasks/1", http.StatusOK, "system", "/api/tasks/1"},
+		// strip_prefix 去掉前缀后转发
+		{http.MethodGet, "/files/a/b.csv", http.StatusOK, "manager", "/a/b.csv"},
+		{http.MethodGet, "/files", http.StatusOK, "manager", "/"},
+		{http.MethodGet, "/filesystem", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/other", http.StatusNotFound, "", ""},
+		// 默认需要认证
+		{http.MethodGet, "/private/x", http.StatusUnauthorized, "", ""},
+	}
+	for _, tc := range cases {
+		code, hit := dispatch(t, d, tc.method, tc.target)
+		if code != tc.code || hit.Service != tc.service || hit.Path != tc.path {
+			t.Errorf("%s %s = %d %+v, want %d {%s %s}", tc.method, tc.target, code, hit, tc.code, tc.service, tc.path)
+		}
+	}
+}
+
+func TestDispatcherMethodNotAllowed(t *testing.T) {
+	d, _ := newTestDispatcher(t, `
+routes:
+  - {prefix: /api/tasks, service: transfer, methods: [GET], auth: false}
+`)
+	if code, _ := dispatch(t, d, http.MethodGet, "/api/tasks"); code != http.StatusOK {
+		t.Fatalf("GET status = %d", code)
+	}
+	if code, _ := dispatch(t, d, http.MethodDelete, "/api/tasks/1"); code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE status = %d, want 405", code)
+	}
+}
+
+func TestDispatcherBuiltinRoutes(t *testing.T) {
+	d, _ := newTestDispatcher(t, "")
+	if state := d.state.Load(); state.source != sourceBuiltin {
+		t.Fatalf("source = %q, want builtin", state.source)
+	}
+	// 登录不需要认证，其他接口需要
+	if code, hit := dispatch(t, d, http.MethodPost, "/api/auth/login"); code != http.StatusOK || hit.Service != "system" {
+		t.Fatalf("login = %d %+v", code, hit)
+	}
+	if code, _ := dispatch(t, d, http.MethodGet, "/api/tasks"); code != http.StatusUnauthorized {
+		t.Fatalf("tasks status = %d, want 401", code)
+	}
+}
+
+func TestDispatcherHotReload(t *testing.T) {
+	d, cfg := newTestDispatcher(t, `
+routes:
+  - {prefix: /api/tasks, service: transfer, auth: false}
+`)
+	if d.changed() {
+		t.Fatal("unchanged file reported as changed")
+	}
+	before := d.state.Load().proxies["transfer"]
+
+	writeRoutes(t, cfg.RoutesFile, `
+routes:
+  - {prefix: /api/tasks, service: meta, auth: false}
+  - {prefix: /api/jobs, service: transfer, auth: false}
+`)
+	if !d.changed() {
+		t.Fatal("modified file not detected")
+	}
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if _, hit := dispatch(t, d, http.MethodGet, "/api/tasks"); hit.Service != "meta" {
+		t.Fatalf("after reload /api/tasks -> %q, want meta", hit.Service)
+	}
+	if _, hit := dispatch(t, d, http.MethodGet, "/api/jobs"); hit.Service != "transfer" {
+		t.Fatalf("after reload /api/jobs -> %q, want transfer", hit.Service)
+	}
+	// 实例未变的服务复用原有代理
+	if d.state.Load().proxies["transfer"] != before {
+		t.Fatal("proxy for unchanged service was recreated")
+	}
+
+	// 无效的路由表不生效，继续使用当前路由
+	writeRoutes(t, cfg.RoutesFile, `
+routes:
+  - {prefix: /api/tasks, service: unknown}
+`)
+	if err := d.Reload(); err == nil {
+		t.Fatal("invalid routing table accepted")
+	}
+	if _, hit := dispatch(t, d, http.MethodGet, "/api/tasks"); hit.Service != "meta" {
+		t.Fatalf("after failed reload /api/tasks -> %q, want meta", hit.Service)
+	}
+
+	// 文件被删除时保留当前路由
+	if err := os.Remove(cfg.RoutesFile); err != nil {
+		t.Fatal(err)
+	}
+	if !d.changed() {
+		t.Fatal("removed file not detected")
+	}
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload after remove: %v", err)
+	}
+	if _, hit := dispatch(t, d, http.MethodGet, "/api/tasks"); hit.Service != "meta" {
+		t.Fatalf("after remove /api/tasks -> %q, want meta", hit.Service)
+	}
+}
+
+func TestLoadTableRejectsInvalidRoutes(t *testing.T) {
+	cfg := &config.Config{
+		ManagerServiceURL:  "http://manager:8081",
+		MetaServiceURL:     "http://meta:8082",
+		TransferServiceURL: "http://transfer:8083",
+	}
+	cfg.SystemServiceURL = "http://system:8080"
+	cases := map[string]string{
+		"unknown field":    "routes:\n  - {prefix: /api, service: system, stripprefix: true}\n",
+		"duplicate prefix": "routes:\n  - {prefix: /api, service: system}\n  - {prefix: /api/, service: meta}\n",
+		"relative prefix":  "routes:\n  - {prefix: api, service: system}\n",
+		"bad method":       "routes:\n  - {prefix: /api, service: system, methods: [FETCH]}\n",
+		"bad balance":      "services:\n  meta: {balance: random}\nroutes:\n  - {prefix: /api, service: meta}\n",
+		"bad url":          "services:\n  meta: meta:8082\nroutes:\n  - {prefix: /api, service: meta}\n",
+		"no routes":        "routes: []\n",
+	}
+	for name, routes := range cases {
+		path := filepath.Join(t.TempDir(), "routes.yaml")
+		writeRoutes(t, path, routes)
+		table, err := LoadTable(path)
+		if err == nil {
+			err = table.normalize(cfg)
+		}
+		if err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
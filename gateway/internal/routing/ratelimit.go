@@ -0,0 +1,186 @@
+package routing
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/addp/gateway/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// 限流维度
+const (
+	scopeUser   = "user"
+	scopeTenant = "tenant"
+	scopeIP     = "ip"
+)
+
+// RateLimit 一组路由的限流规则，按最长前缀匹配，每个请求只使用一条规则
+type RateLimit struct {
+	Prefix  string     `json:"prefix" yaml:"prefix"`
+	Methods []string   `json:"methods,omitempty" yaml:"methods"` // 为空时限制所有方法
+	User    *LimitSpec `json:"user,omitempty" yaml:"user"`       // 每个用户
+	Tenant  *LimitSpec `json:"tenant,omitempty" yaml:"tenant"`   // 每个租户（没有租户的用户不受限）
+	IP      *LimitSpec `json:"ip,omitempty" yaml:"ip"`           // 每个客户端 IP，也适用于无需认证的路由
+}
+
+// LimitSpec 令牌桶限额，如 rate: 10/m, burst: 5
+type LimitSpec struct {
+	Rate  string `json:"rate" yaml:"rate"`
+	Burst int    `json:"burst,omitempty" yaml:"burst"` // 默认等于每个周期的请求数
+}
+
+type compiledLimit struct {
+	prefix  string
+	methods map[string]bool
+	scopes  []scopeLimit
+}
+
+type scopeLimit struct {
+	scope string
+	rate  ratelimit.Rate
+}
+
+func (t *Table) normalizeRateLimits() error {
+	seen := make(map[string]bool, len(t.RateLimits))
+	for i := range t.RateLimits {
+		limit := &t.RateLimits[i]
+		prefix, err := normalizePrefix(limit.Prefix)
+		if err != nil {
+			return fmt.Errorf("rate limit %d: %w", i+1, err)
+		}
+		limit.Prefix = prefix
+		if err := normalizeMethods(limit.Methods); err != nil {
+			return fmt.Errorf("rate limit %s: %w", limit.Prefix, err)
+		}
+		key := limit.Prefix + fmt.Sprint(limit.Methods)
+		if seen[key] {
+			return fmt.Errorf("rate limit %s: duplicate rule", limit.Prefix)
+		}
+		seen[key] = true
+		if limit.User == nil && limit.Tenant == nil && limit.IP == nil {
+			return fmt.Errorf("rate limit %s: no user, tenant or ip limit configured", limit.Prefix)
+		}
+	}
+
+	// 前缀相同时有方法限定的规则优先
+	sort.SliceStable(t.RateLimits, func(i, j int) bool {
+		a, b := t.RateLimits[i], t.RateLimits[j]
+		if len(a.Prefix) != len(b.Prefix) {
+			return len(a.Prefix) > len(b.Prefix)
+		}
+		return len(a.Methods) > 0 && len(b.Methods) == 0
+	})
+	return nil
+}
+
+// compileRateLimits 解析限额，须在 normalize 之后调用
+func compileRateLimits(limits []RateLimit) ([]compiledLimit, error) {
+	compiled := make([]compiledLimit, 0, len(limits))
+	for _, limit := range limits {
+		c := compiledLimit{prefix: limit.Prefix}
+		if len(limit.Methods) > 0 {
+			c.methods = make(map[string]bool, len(limit.Methods))
+			for _, method := range limit.Methods {
+				c.methods[method] = true
+			}
+		}
+		for _, spec := range []struct {
+			scope string
+			spec  *LimitSpec
+		}{{scopeUser, limit.User}, {scopeTenant, limit.Tenant}, {scopeIP, limit.IP}} {
+			if spec.spec == nil {
+				continue
+			}
+			rate, err := ratelimit.ParseRate(spec.spec.Rate, spec.spec.Burst)
+			if err != nil {
+				return nil, fmt.Errorf("rate limit %s %s: %w", limit.Prefix, spec.scope, err)
+			}
+			c.scopes = append(c.scopes, scopeLimit{scope: spec.scope, rate: rate})
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// allowRequest 按匹配的规则依次检查各维度的限额，超出时返回 429 和 Retry-After；
+// 限流器出错时放行，避免 Redis 故障导致网关不可用
+func (d *Dispatcher) allowRequest(c *gin.Context, limits []compiledLimit) bool {
+	if d.limiter == nil {
+		return true
+	}
+
+	var limit *compiledLimit
+	for i := range limits {
+		candidate := &limits[i]
+		if matchPrefix(candidate.prefix, c.Request.URL.Path) &&
+			(candidate.methods == nil || candidate.methods[c.Request.Method]) {
+			limit = candidate
+			break
+		}
+	}
+	if limit == nil {
+		return true
+	}
+
+	for _, scope := range limit.scopes {
+		var subject string
+		switch scope.scope {
+		case scopeUser:
+			if userID := c.GetUint("user_id"); userID > 0 {
+				subject = strconv.FormatUint(uint64(userID), 10)
+			}
+		case scopeTenant:
+			if tenantID := c.GetUint("tenant_id"); tenantID > 0 {
+				subject = strconv.FormatUint(uint64(tenantID), 10)
+			}
+		case scopeIP:
+			subject = c.ClientIP()
+		}
+		if subject == "" {
+			continue
+		}
+
+		key := limit.prefix + ":" + scope.scope + ":" + subject
+		allowed, wait, err := d.limiter.Allow(c.Request.Context(), key, scope.rate)
+		if err != nil {
+			log.Printf("限流检查失败，放行请求: %v", err)
+			continue
+		}
+		if !allowed {
+			retryAfter := max(1, int(math.Ceil(wait.Seconds())))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "rate limit exceeded",
+				"scope":       scope.scope,
+				"retry_after": retryAfter,
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// rateLimitView 限流规则的展示形式
+func rateLimitView(limit compiledLimit) gin.H {
+	scopes := gin.H{}
+	for _, scope := range limit.scopes {
+		scopes[scope.scope] = gin.H{
+			"per_second": scope.rate.PerSecond,
+			"burst":      scope.rate.Burst,
+		}
+	}
+	methods := make([]string, 0, len(limit.methods))
+	for method := range limit.methods {
+		methods = append(methods, method)
+	}
+	if len(methods) == 0 {
+		methods = []string{"*"}
+	}
+	sort.Strings(methods)
+	return gin.H{"prefix": limit.prefix, "methods": methods, "limits": scopes}
+}
@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/addp/gateway/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func compileTestLimits(t *testing.T, limits ...RateLimit) []compiledLimit {
+	t.Helper()
+	table := &Table{RateLimits: limits}
+	if err := table.normalizeRateLimits(); err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := compileRateLimits(table.RateLimits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+// limitRequest 以指定用户、租户和客户端地址检查限额，返回状态码和 Retry-After
+func limitRequest(d *Dispatcher, limits []compiledLimit, method, path string, userID, tenantID uint, remoteAddr string) (int, string) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, path, nil)
+	c.Request.RemoteAddr = remoteAddr
+	if userID > 0 {
+		c.Set("user_id", userID)
+		c.Set("tenant_id", tenantID)
+	}
+	if !d.allowRequest(c, limits) {
+		return w.Code, w.Header().Get("Retry-After")
+	}
+	return http.StatusOK, ""
+}
+
+func TestAllowRequestScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	d := &Dispatcher{limiter: ratelimit.NewMemoryLimiter()}
+	limits := compileTestLimits(t,
+		RateLimit{Prefix: "/api", User: &LimitSpec{Rate: "2/m"}, Tenant: &LimitSpec{Rate: "3/m"}},
+		RateLimit{Prefix: "/api/auth/login", IP: &LimitSpec{Rate: "1/m"}},
+		RateLimit{Prefix: "/api/export", Methods: []string{"POST"}, User: &LimitSpec{Rate: "1/h"}},
+	)
+
+	// 每个用户 2 次
+	for i := 0; i < 2; i++ {
+		if code, _ := limitRequest(d, limits, http.MethodGet, "/api/items", 1, 10, "192.0.2.1:1000"); code != http.StatusOK {
+			t.Fatalf("user 1 request %d = %d", i+1, code)
+		}
+	}
+	code, retryAfter := limitRequest(d, limits, http.MethodGet, "/api/items", 1, 10, "192.0.2.1:1000")
+	if code != http.StatusTooManyRequests || retryAfter != "30" {
+		t.Fatalf("user 1 over limit = %d (Retry-After %q), want 429 after 30s", code, retryAfter)
+	}
+
+	// 同租户的其他用户共用租户额度（超出用户限额被拒绝的请求不消耗租户令牌）
+	if code, _ := limitRequest(d, limits, http.MethodGet, "/api/items", 2, 10, "192.0.2.2:1000"); code != http.StatusOK {
+		t.Fatalf("user 2 first request = %d", code)
+	}
+	if code, _ := limitRequest(d, limits, http.MethodGet, "/api/items", 2, 10, "192.0.2.2:1000"); code != http.StatusTooManyRequests {
+		t.Fatalf("tenant 10 over limit = %d, want 429", code)
+	}
+	// 其他租户和没有租户的用户不受影响
+	if code, _ := limitRequest(d, limits, http.MethodGet, "/api/items", 3, 20, "192.0.2.3:1000"); code != http.StatusOK {
+		t.Fatalf("tenant 20 = %d", code)
+	}
+	if code, _ := limitRequest(d, limits, http.MethodGet, "/api/items", 4, 0, "192.0.2.4:1000"); code != http.StatusOK {
+		t.Fatalf("user without tenant = %d", code)
+	}
+
+	// 登录按客户端 IP 限制，使用更具体的规则而不是 /api 的用户限额
+	if code, _ := limitRequest(d, limits, http.MethodPost, "/api/auth/login", 0, 0, "198.51.100.1:1000"); code != http.StatusOK {
+		t.Fatalf("first login = %d", code)
+	}
+	if code, _ := limitRequest(d, limits, http.MethodPost, "/api/auth/login", 0, 0, "198.51.100.1:2000"); code != http.StatusTooManyRequests {
+		t.Fatalf("second login from same IP = %d, want 429", code)
+	}
+	if code, _ := limitRequest(d, limits, http.MethodPost, "/api/auth/login", 0, 0, "198.51.100.2:1000"); code != http.StatusOK {
+		t.Fatalf("login from other IP = %d", code)
+	}
+
+	// 有方法限定的规则只限制对应方法，其余方法按 /api 规则
+	if code, _ := limitRequest(d, limits, http.MethodPost, "/api/export", 5, 0, "192.0.2.5:1000"); code != http.StatusOK {
+		t.Fatalf("first export = %d", code)
+	}
+	if code, _ := limitRequest(d, limits, http.MethodPost, "/api/export", 5, 0, "192.0.2.5:1000"); code != http.StatusTooManyRequests {
+		t.Fatalf("second export = %d, want 429", code)
+	}
+	if code, _ := limitRequest(d, limits, http.MethodGet, "/api/export", 5, 0, "192.0.2.5:1000"); code != http.StatusOK {
+		t.Fatalf("GET export = %d", code)
+	}
+}
+
+func TestRateLimitRulesValidation(t *testing.T) {
+	cases := map[string]RateLimit{
+		"no scope":   {Prefix: "/api"},
+		"bad prefix": {Prefix: "api", User: &LimitSpec{Rate: "1/s"}},
+		"bad method": {Prefix: "/api", Methods: []string{"FETCH"}, User: &LimitSpec{Rate: "1/s"}},
+	}
+	for name, limit := range cases {
+		table := &Table{RateLimits: []RateLimit{limit}}
+		if err := table.normalizeRateLimits(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	table := &Table{RateLimits: []RateLimit{{Prefix: "/api", User: &LimitSpec{Rate: "1/w"}}}}
+	if err := table.normalizeRateLimits(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compileRateLimits(table.RateLimits); err == nil {
+		t.Error("invalid rate accepted")
+	}
+}
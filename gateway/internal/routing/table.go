@@ -0,0 +1,271 @@
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/addp/gateway/internal/config"
+	"github.com/addp/gateway/internal/proxy"
+	"gopkg.in/yaml.v3"
+)
+
+// Table 路由表文件（YAML 或 JSON）
+type Table struct {
+	Services   map[string]Service `json:"services" yaml:"services"` // 覆盖环境变量中的服务地址
+	Routes     []Route            `json:"routes" yaml:"routes"`
+	RateLimits []RateLimit        `json:"rate_limits,omitempty" yaml:"rate_limits"`
+}
+
+// Service 服务的实例地址和负载均衡策略，也可以简写为地址字符串（多个实例用逗号分隔）
+type Service struct {
+	URLs    []string `json:"urls" yaml:"urls"`
+	Balance string   `json:"balance,omitempty" yaml:"balance"` // round_robin（默认）、least_conn
+}
+
+// serviceFields 避免 Unmarshal 递归调用自身
+type serviceFields Service
+
+func (s *Service) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = Service{URLs: config.SplitURLs(text)}
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode((*serviceFields)(s))
+}
+
+func (s *Service) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = Service{URLs: config.SplitURLs(node.Value)}
+		return nil
+	}
+	return node.Decode((*serviceFields)(s))
+}
+
+// key 实例和策略都相同的服务复用同一个代理
+func (s Service) key() string {
+	return s.Balance + "|" + strings.Join(s.URLs, ",")
+}
+
+// Route 一条路由规则，按最长前缀匹配
+type Route struct {
+	Prefix      string   `json:"prefix" yaml:"prefix"`
+	Service     string   `json:"service" yaml:"service"`
+	StripPrefix bool     `json:"strip_prefix,omitempty" yaml:"strip_prefix"` // 转发前去掉前缀
+	Methods     []string `json:"methods,omitempty" yaml:"methods"`           // 为空时允许所有方法
+	Auth        *bool    `json:"auth,omitempty" yaml:"auth"`                 // 是否需要认证，默认需要
+	Timeout     Duration `json:"timeout,omitempty" yaml:"timeout"`           // 等待响应头的超时，为空时使用 PROXY_TIMEOUT
+}
+
+// RequiresAuth 未配置时默认需要认证
+func (r Route) RequiresAuth() bool {
+	return r.Auth == nil || *r.Auth
+}
+
+// Duration 以 "30s"、"5m" 形式配置的时长
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// defaultServices 环境变量中配置的服务地址和负载均衡策略
+func defaultServices(cfg *config.Config) map[string]Service {
+	services := make(map[string]Service, 4)
+	for name, value := range map[string]string{
+		"system":   cfg.SystemServiceURL,
+		"manager":  cfg.ManagerServiceURL,
+		"meta":     cfg.MetaServiceURL,
+		"transfer": cfg.TransferServiceURL,
+	} {
+		services[name] = Service{URLs: config.SplitURLs(value), Balance: cfg.LoadBalance}
+	}
+	return services
+}
+
+// DefaultTable 没有路由表文件时使用的内置路由
+func DefaultTable(cfg *config.Config) *Table {
+	public := false
+	fileTimeout := Duration(cfg.FileProxyTimeout)
+	return &Table{
+		Routes: []Route{
+			// System 模块路由（认证、用户、日志、资源、租户、角色）
+			{Prefix: "/api/auth", Service: "system", Auth: &public},
+			{Prefix: "/api/users", Service: "system"},
+			{Prefix: "/api/logs", Service: "system"},
+			{Prefix: "/api/resources", Service: "system"},
+			{Prefix: "/api/tenants", Service: "system"},
+			{Prefix: "/api/roles", Service: "system"},
+			{Prefix: "/api/permissions", Service: "system"},
+
+			// Manager 模块路由（配置、数据浏览、表管理、数据源、目录、预览）
+			{Prefix: "/api/config", Service: "manager"},
+			{Prefix: "/api/data-explorer", Service: "manager", Timeout: fileTimeout},
+			{Prefix: "/api/tables", Service: "manager"},
+			{Prefix: "/api/datasources", Service: "manager"},
+			{Prefix: "/api/directories", Service: "manager"},
+			{Prefix: "/api/preview", Service: "manager", Timeout: fileTimeout},
+			{Prefix: "/api/upload", Service: "manager", Timeout: fileTimeout},
+
+			// Meta 模块路由（元数据、血缘）
+			{Prefix: "/api/meta", Service: "meta"},
+			{Prefix: "/api/metadata", Service: "meta"},
+			{Prefix: "/api/datasets", Service: "meta"},
+			{Prefix: "/api/lineage", Service: "meta"},
+
+			// Transfer 模块路由（任务、执行）
+			{Prefix: "/api/tasks", Service: "transfer"},
+			{Prefix: "/api/executions", Service: "transfer"},
+		},
+		RateLimits: []RateLimit{
+			// 元数据扫描会连接数据源读取全部表结构，开销大
+			{Prefix: "/api/meta/scan", User: &LimitSpec{Rate: "10/m", Burst: 3}, Tenant: &LimitSpec{Rate: "30/m", Burst: 10}},
+			// 表和对象预览
+			{Prefix: "/api/data-explorer/preview", User: &LimitSpec{Rate: "60/m", Burst: 10}, Tenant: &LimitSpec{Rate: "300/m", Burst: 50}},
+			{Prefix: "/api/preview", User: &LimitSpec{Rate: "60/m", Burst: 10}, Tenant: &LimitSpec{Rate: "300/m", Burst: 50}},
+			// 登录按客户端 IP 限制，防止暴力破解
+			{Prefix: "/api/auth/login", IP: &LimitSpec{Rate: "10/m", Burst: 5}},
+			// 其他接口
+			{Prefix: "/api", User: &LimitSpec{Rate: "50/s", Burst: 100}},
+		},
+	}
+}
+
+// LoadTable 读取路由表文件，.json 按 JSON 解析，其余按 YAML 解析
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var table Table
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&table)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &table, nil
+}
+
+// normalize 合并服务地址、校验路由并按前缀从长到短排序
+func (t *Table) normalize(cfg *config.Config) error {
+	services := defaultServices(cfg)
+	for name, service := range t.Services {
+		if len(service.URLs) == 0 {
+			// 只修改负载均衡策略时沿用环境变量中的地址
+			service.URLs = services[name].URLs
+		}
+		if service.Balance == "" {
+			service.Balance = cfg.LoadBalance
+		}
+		services[name] = service
+	}
+	for name, service := range services {
+		if len(service.URLs) == 0 {
+			return fmt.Errorf("service %s: no urls configured", name)
+		}
+		for _, target := range service.URLs {
+			u, err := url.Parse(target)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("service %s: invalid url %q", name, target)
+			}
+		}
+		if !proxy.ValidBalance(service.Balance) {
+			return fmt.Errorf("service %s: unsupported balance %q", name, service.Balance)
+		}
+	}
+	t.Services = services
+
+	if len(t.Routes) == 0 {
+		return fmt.Errorf("no routes configured")
+	}
+	seen := make(map[string]bool, len(t.Routes))
+	for i := range t.Routes {
+		route := &t.Routes[i]
+		prefix, err := normalizePrefix(route.Prefix)
+		if err != nil {
+			return fmt.Errorf("route %d: %w", i+1, err)
+		}
+		route.Prefix = prefix
+		if seen[route.Prefix] {
+			return fmt.Errorf("route %s: duplicate prefix", route.Prefix)
+		}
+		seen[route.Prefix] = true
+
+		if _, ok := services[route.Service]; !ok {
+			return fmt.Errorf("route %s: unknown service %q", route.Prefix, route.Service)
+		}
+		if err := normalizeMethods(route.Methods); err != nil {
+			return fmt.Errorf("route %s: %w", route.Prefix, err)
+		}
+		if route.Timeout < 0 {
+			return fmt.Errorf("route %s: timeout must not be negative", route.Prefix)
+		}
+	}
+
+	sort.SliceStable(t.Routes, func(i, j int) bool {
+		return len(t.Routes[i].Prefix) > len(t.Routes[j].Prefix)
+	})
+	return t.normalizeRateLimits()
+}
+
+// normalizePrefix 去掉前缀末尾的 /
+func normalizePrefix(prefix string) (string, error) {
+	if len(prefix) > 1 {
+		prefix = strings.TrimRight(prefix, "/")
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("prefix must start with /")
+	}
+	return prefix, nil
+}
+
+// normalizeMethods 方法名转为大写并校验
+func normalizeMethods(methods []string) error {
+	for i, method := range methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("unsupported method %q", method)
+		}
+		methods[i] = method
+	}
+	return nil
+}
+
+// matchPrefix 前缀按路径段匹配：/api/meta 匹配 /api/meta 和 /api/meta/...，不匹配 /api/metadata
+func matchPrefix(prefix, path string) bool {
+	return prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func (r Route) matches(path string) bool {
+	return matchPrefix(r.Prefix, path)
+}
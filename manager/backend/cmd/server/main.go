@@ -3,7 +3,10 @@ package main
 import (
 	"log"
 
+	"github.com/addp/common/authz"
 	commonClient "github.com/addp/common/client"
+	"github.com/addp/common/metrics"
+	"github.com/addp/common/tracing"
 	"github.com/addp/manager/internal/api"
 	"github.com/addp/manager/internal/config"
 	"github.com/addp/manager/internal/repository"
@@ -14,11 +17,17 @@ func main() {
 	// 加载配置
 	cfg := config.Load()
 
+	// 配置 OTEL_EXPORTER_OTLP_ENDPOINT 时导出 trace
+	defer tracing.Init("manager")()
+
 	// 初始化数据库
 	db, err := repository.InitDatabase(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB("manager", sqlDB)
+	}
 
 	// 初始化 repositories
 	resourceRepo := repository.NewResourceRepository(db)
@@ -26,18 +35,24 @@ func main() {
 
 	log.Printf("Manager config: EnableIntegration=%v, InternalAPIKey set=%v", cfg.EnableIntegration, cfg.InternalAPIKey != "")
 
-	// 初始化 System 客户端（用于拉取解密的资源连接信息）
-	var systemClient *commonClient.SystemClient
-	if cfg.EnableIntegration && cfg.InternalAPIKey != "" {
-		systemClient = commonClient.NewSystemClientWithInternalKey(cfg.SystemServiceURL, cfg.InternalAPIKey)
+	// 未配置权限检查时拒绝启动，不以无权限检查的状态对外服务
+	checker, err := authz.NewSystemChecker(cfg.SystemServiceURL, cfg.InternalAPIKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize permission checker: %v", err)
 	}
 
+	// 初始化 System 客户端（用于拉取解密的资源连接信息并检查资源授权）
+	systemClient := commonClient.NewSystemClientWithInternalKey(cfg.SystemServiceURL, cfg.InternalAPIKey)
+
 	// 初始化 services
 	resourceService := service.NewResourceService(resourceRepo)
 	metadataService := service.NewMetadataService(metadataRepo, resourceRepo, systemClient)
 
+	// Prometheus 指标只在内部端口上提供
+	metrics.Serve("manager", cfg.MetricsPort)
+
 	// 设置路由
-	router := api.SetupRouter(cfg, resourceService, metadataService)
+	router := api.SetupRouter(cfg, db, checker, resourceService, metadataService)
 
 	// 启动服务
 	log.Printf("Manager service starting on port %s", cfg.Port)
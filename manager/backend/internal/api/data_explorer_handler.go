@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/addp/manager/internal/middleware"
 	"github.com/addp/manager/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -20,7 +21,7 @@ func NewDataExplorerHandler(metadataService *service.MetadataService) *DataExplo
 
 // GetTree 返回资源- schema-表树
 func (h *DataExplorerHandler) GetTree(c *gin.Context) {
-	tree, err := h.metadataService.GetResourceTree()
+	tree, err := h.metadataService.GetResourceTree(c.Request.Context(), middleware.GetTenantID(c), middleware.GetUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -53,7 +54,7 @@ func (h *DataExplorerHandler) PreviewTable(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
-	preview, err := h.metadataService.PreviewTable(uint(resourceIDUint), schemaName, tableName, page, pageSize)
+	preview, err := h.metadataService.PreviewTable(uint(resourceIDUint), middleware.GetUserID(c), schemaName, tableName, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/addp/common/health"
+	"github.com/addp/manager/internal/config"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// healthHandler 检查数据库、MinIO 和 System 服务，数据库不可用时返回 503
+func healthHandler(cfg *config.Config, db *gorm.DB) gin.HandlerFunc {
+	checker := health.NewChecker("manager", 2*time.Second).
+		Add("database", true, func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		})
+	if cfg.MinIOEndpoint != "" {
+		endpoint := cfg.MinIOEndpoint
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+		checker.Add("minio", false, health.HTTPCheck(strings.TrimRight(endpoint, "/")+"/minio/health/live"))
+	}
+	if cfg.EnableIntegration {
+		checker.Add("system", false, health.HTTPCheck(strings.TrimRight(cfg.SystemServiceURL, "/")+"/health"))
+	}
+
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		c.JSON(report.HTTPStatus(), report)
+	}
+}
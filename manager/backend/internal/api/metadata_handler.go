@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/addp/manager/internal/middleware"
 	"github.com/addp/manager/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -28,7 +29,7 @@ func (h *MetadataHandler) ScanResource(c *gin.Context) {
 		return
 	}
 
-	result, err := h.metadataService.ScanResource(uint(id))
+	result, err := h.metadataService.ScanResource(uint(id), middleware.GetUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -75,7 +76,7 @@ func (h *MetadataHandler) ManageTable(c *gin.Context) {
 		return
 	}
 
-	if err := h.metadataService.ManageTable(uint(id)); err != nil {
+	if err := h.metadataService.ManageTable(uint(id), middleware.GetUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,13 +1,19 @@
 package api
 
 import (
+	"github.com/addp/common/authz"
 	"github.com/addp/manager/internal/config"
+	"github.com/addp/manager/internal/middleware"
 	"github.com/addp/manager/internal/service"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func SetupRouter(cfg *config.Config, resourceService *service.ResourceService, metadataService *service.MetadataService) *gin.Engine {
-	router := gin.Default()
+func SetupRouter(cfg *config.Config, db *gorm.DB, checker *authz.Checker, resourceService *service.ResourceService, metadataService *service.MetadataService) *gin.Engine {
+	router := gin.New()
+
+	// 访问日志附带请求 ID，请求 ID 和 trace 随调用传递给其他服务
+	router.Use(middleware.Logger(), gin.Recovery(), middleware.RequestID(), middleware.Metrics())
 
 	// CORS
 	router.Use(func(c *gin.Context) {
@@ -22,12 +28,7 @@ func SetupRouter(cfg *config.Config, resourceService *service.ResourceService, m
 	})
 
 	// 健康检查
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "ok",
-			"service": "manager",
-		})
-	})
+	router.GET("/health", healthHandler(cfg, db))
 
 	// 根路由
 	router.GET("/", func(c *gin.Context) {
@@ -37,6 +38,12 @@ func SetupRouter(cfg *config.Config, resourceService *service.ResourceService, m
 		})
 	})
 
+	// 按 System 中分配的角色检查权限
+	read := middleware.RequirePermission(checker, authz.ManagerRead)
+	preview := middleware.RequirePermission(checker, authz.ManagerPreview)
+	manage := middleware.RequirePermission(checker, authz.ManagerManage)
+	scan := middleware.RequirePermission(checker, authz.MetaScan)
+
 	// API 路由组
 	api := router.Group("/api")
 	{
@@ -46,33 +53,37 @@ func SetupRouter(cfg *config.Config, resourceService *service.ResourceService, m
 			configGroup.GET("/map", configHandler.GetMapConfig)
 		}
 
+		// 以下接口需要认证：优先信任 Gateway 签名的身份头，未经过 Gateway 时通过 System 验证令牌
+		authenticated := api.Group("")
+		authenticated.Use(middleware.AuthMiddleware(cfg.SystemServiceURL, cfg.IdentitySecret))
+
 		// 数据探查
-		explorer := api.Group("/data-explorer")
+		explorer := authenticated.Group("/data-explorer")
 		{
 			handler := NewDataExplorerHandler(metadataService)
-			explorer.GET("/tree", handler.GetTree)
-			explorer.GET("/preview", handler.PreviewTable)
+			explorer.GET("/tree", read, handler.GetTree)
+			explorer.GET("/preview", preview, handler.PreviewTable)
 		}
 
 		// 资源管理
-		resources := api.Group("/resources")
+		resources := authenticated.Group("/resources")
 		{
 			resourceHandler := NewResourceHandler(resourceService)
-			resources.GET("", resourceHandler.List)
-			resources.GET("/:id", resourceHandler.GetByID)
+			resources.GET("", read, resourceHandler.List)
+			resources.GET("/:id", read, resourceHandler.GetByID)
 
 			// 元数据扫描和管理
 			metadataHandler := NewMetadataHandler(metadataService)
-			resources.POST("/:id/scan", metadataHandler.ScanResource)
-			resources.GET("/:id/tables", metadataHandler.GetTables)
+			resources.POST("/:id/scan", scan, metadataHandler.ScanResource)
+			resources.GET("/:id/tables", read, metadataHandler.GetTables)
 		}
 
 		// 表管理
-		tables := api.Group("/tables")
+		tables := authenticated.Group("/tables")
 		{
 			metadataHandler := NewMetadataHandler(metadataService)
-			tables.POST("/:id/manage", metadataHandler.ManageTable)
-			tables.POST("/:id/unmanage", metadataHandler.UnmanageTable)
+			tables.POST("/:id/manage", manage, metadataHandler.ManageTable)
+			tables.POST("/:id/unmanage", manage, metadataHandler.UnmanageTable)
 		}
 	}
 
@@ -10,16 +10,20 @@ type Config struct {
 	commonConfig.BaseConfig
 
 	// Manager 模块特有配置
-	Port     string
-	DBSchema string
+	Port          string
+	DBSchema      string
+	MinIOEndpoint string // 平台对象存储地址，配置后健康检查会检查其可用性
+	MetricsPort   string // Prometheus 指标的内部端口，不经过服务端口暴露，为空时不监听
 }
 
 func Load() *Config {
 	systemURL := commonConfig.GetEnv("SYSTEM_SERVICE_URL", "http://localhost:8080")
 
 	cfg := &Config{
-		Port:     commonConfig.GetEnv("PORT", "8081"),
-		DBSchema: commonConfig.GetEnv("DB_SCHEMA", "manager"),
+		Port:          commonConfig.GetEnv("PORT", "8081"),
+		DBSchema:      commonConfig.GetEnv("DB_SCHEMA", "manager"),
+		MinIOEndpoint: commonConfig.GetEnv("MINIO_ENDPOINT", ""),
+		MetricsPort:   commonConfig.GetEnv("METRICS_PORT", "9091"),
 	}
 
 	// 设置 BaseConfig 字段
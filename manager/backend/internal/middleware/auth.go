@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/addp/common/auth"
+	"github.com/addp/common/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// UserInfo 从System服务返回的用户信息
+type UserInfo struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	TenantID *uint  `json:"tenant_id"` // 可能为null
+	UserType string `json:"user_type"`
+}
+
+// AuthMiddleware 认证中间件 - 优先信任Gateway签名的身份头（identitySecret 为 IDENTITY_SECRET），
+// 未经过Gateway时通过System服务验证token
+func AuthMiddleware(systemServiceURL, identitySecret string) gin.HandlerFunc {
+	// 已使用的身份头 nonce，拒绝重放
+	nonces := auth.NewNonceCache()
+	return func(c *gin.Context) {
+		// Gateway 已校验过令牌
+		identity, err := auth.VerifyIdentity(c.Request, identitySecret, nonces)
+		if err == nil {
+			c.Set("user_id", identity.UserID)
+			c.Set("username", identity.Username)
+			c.Set("tenant_id", identity.TenantID)
+			c.Set("user_type", identity.UserType)
+			c.Next()
+			return
+		}
+		if !errors.Is(err, auth.ErrNoIdentity) {
+			log.Printf("忽略无效的身份头: %v", err)
+		}
+
+		// 获取Authorization头
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			c.Abort()
+			return
+		}
+
+		// 检查Bearer格式
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+			c.Abort()
+			return
+		}
+
+		// 调用System服务验证token
+		req, err := http.NewRequestWithContext(c.Request.Context(), "GET", systemServiceURL+"/api/users/me", nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
+			c.Abort()
+			return
+		}
+		req.Header.Set("Authorization", authHeader)
+		tracing.Inject(c.Request.Context(), req.Header)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token with system service", "details": err.Error()})
+			c.Abort()
+			return
+		}
+		defer resp.Body.Close()
+
+		// 如果System返回非200，说明token无效
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "details": string(body)})
+			c.Abort()
+			return
+		}
+
+		// 解析用户信息
+		var userInfo UserInfo
+		if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse user info"})
+			c.Abort()
+			return
+		}
+
+		// 设置用户信息到上下文
+		c.Set("user_id", userInfo.ID)
+		c.Set("username", userInfo.Username)
+		c.Set("user_type", userInfo.UserType)
+
+		// tenant_id 可能为null，设置为0
+		if userInfo.TenantID != nil {
+			c.Set("tenant_id", *userInfo.TenantID)
+		} else {
+			c.Set("tenant_id", uint(0))
+		}
+
+		c.Next()
+	}
+}
+
+// GetUserID 从上下文获取用户ID
+func GetUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		return userID.(uint)
+	}
+	return 0
+}
+
+// GetTenantID 从上下文获取租户ID
+func GetTenantID(c *gin.Context) uint {
+	if tenantID, exists := c.Get("tenant_id"); exists {
+		return tenantID.(uint)
+	}
+	return 0
+}
+
+// GetUserType 从上下文获取用户类型
+func GetUserType(c *gin.Context) string {
+	if userType, exists := c.Get("user_type"); exists {
+		return userType.(string)
+	}
+	return ""
+}
+
+// GetUsername 从上下文获取用户名
+func GetUsername(c *gin.Context) string {
+	if username, exists := c.Get("username"); exists {
+		return username.(string)
+	}
+	return ""
+}
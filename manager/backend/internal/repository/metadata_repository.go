@@ -455,6 +455,13 @@ func (r *MetadataRepository) GetObjectMetadataNode(resourceID uint, bucketName,
 
 // QueryTablePreview 查询表数据预览
 func (r *MetadataRepository) QueryTablePreview(resource *models.Resource, schemaName, tableName string, page, pageSize, maxRows int) ([]string, []map[string]interface{}, int, []string, error) {
+	start := time.Now()
+	columns, rows, total, geometryColumns, err := r.queryTablePreview(resource, schemaName, tableName, page, pageSize, maxRows)
+	observePreview(resource.ResourceType, start, err)
+	return columns, rows, total, geometryColumns, err
+}
+
+func (r *MetadataRepository) queryTablePreview(resource *models.Resource, schemaName, tableName string, page, pageSize, maxRows int) ([]string, []map[string]interface{}, int, []string, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/addp/common/metrics"
+)
+
+var previewDuration = metrics.NewHistogramVec("manager_preview_query_duration_seconds",
+	"表数据预览查询耗时（秒）", nil, "resource_type", "status")
+
+// observePreview 记录一次预览查询
+func observePreview(resourceType string, start time.Time, err error) {
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	previewDuration.WithLabelValues(strings.ToLower(resourceType), status).Observe(time.Since(start).Seconds())
+}
@@ -1,16 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/addp/common/authz"
 	commonClient "github.com/addp/common/client"
 	commonModels "github.com/addp/common/models"
 	"github.com/addp/manager/internal/models"
 	"github.com/addp/manager/internal/repository"
 )
 
+// ErrAccessCheckUnavailable 无法通过 System 检查资源授权时拒绝访问
+var ErrAccessCheckUnavailable = errors.New("resource access checks are unavailable")
+
 type MetadataService struct {
 	metadataRepo *repository.MetadataRepository
 	resourceRepo *repository.ResourceRepository
@@ -25,10 +31,10 @@ func NewMetadataService(metadataRepo *repository.MetadataRepository, resourceRep
 	}
 }
 
-// ScanResource 扫描资源的元数据（轻量级）
-func (s *MetadataService) ScanResource(resourceID uint) (*models.MetadataScanResult, error) {
-	// 获取资源信息（优先从 System 服务获取解密后的连接信息）
-	resource, err := s.getResource(resourceID)
+// ScanResource 扫描资源的元数据（轻量级），要求用户有权查看该资源
+func (s *MetadataService) ScanResource(resourceID, userID uint) (*models.MetadataScanResult, error) {
+	// 获取资源信息（从 System 服务获取解密后的连接信息并检查访问级别）
+	resource, err := s.getResource(resourceID, userID, authz.AccessView)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get resource: %w", err)
 	}
@@ -85,8 +91,8 @@ func (s *MetadataService) GetTables(resourceID uint, isManaged *bool) ([]models.
 	return s.metadataRepo.GetManagedTables(resourceID, isManaged)
 }
 
-// ManageTable 纳管表（提取详细元数据）
-func (s *MetadataService) ManageTable(tableID uint) error {
+// ManageTable 纳管表（提取详细元数据），要求用户有权查看表所在资源
+func (s *MetadataService) ManageTable(tableID, userID uint) error {
 	// 获取表信息  - 直接通过GetByID获取
 	table, err := s.metadataRepo.GetManagedTableByID(tableID)
 	if err != nil {
@@ -94,7 +100,7 @@ func (s *MetadataService) ManageTable(tableID uint) error {
 	}
 
 	// 获取资源连接信息
-	resource, err := s.getResource(table.ResourceID)
+	resource, err := s.getResource(table.ResourceID, userID, authz.AccessView)
 	if err != nil {
 		return fmt.Errorf("failed to get resource: %w", err)
 	}
@@ -108,13 +114,18 @@ func (s *MetadataService) UnmanageTable(tableID uint) error {
 	return s.metadataRepo.UnmarkTableAsManaged(tableID)
 }
 
-// GetResourceTree 获取资源- Schema-表树
-func (s *MetadataService) GetResourceTree() ([]models.DataExplorerResource, error) {
+// GetResourceTree 获取资源- Schema-表树，只包含用户有权查看的资源
+func (s *MetadataService) GetResourceTree(ctx context.Context, tenantID, userID uint) ([]models.DataExplorerResource, error) {
 	resources, err := s.resourceRepo.ListAllActive()
 	if err != nil {
 		return nil, err
 	}
 
+	visible, err := s.visibleResourceIDs(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	topNodes, childNodes, items, err := s.metadataRepo.ListScannedNodesAndItems()
 	if err != nil {
 		return nil, err
@@ -143,6 +154,9 @@ func (s *MetadataService) GetResourceTree() ([]models.DataExplorerResource, erro
 
 	var result []models.DataExplorerResource
 	for _, res := range resources {
+		if !visible[res.ID] {
+			continue
+		}
 		rootNodes := topNodesByResource[res.ID]
 		if len(rootNodes) == 0 {
 			continue
@@ -273,8 +287,8 @@ func buildObjectStorageTree(node *models.MetaNodeLite, childNodes map[uint][]*mo
 
 // PreviewTable 获取表数据预览
 // 当 tableName 为空时，返回 schema/bucket 的统计信息和子节点列表
-func (s *MetadataService) PreviewTable(resourceID uint, schemaName, tableName string, page, pageSize int) (*models.TablePreview, error) {
-	resource, err := s.getResource(resourceID)
+func (s *MetadataService) PreviewTable(resourceID, userID uint, schemaName, tableName string, page, pageSize int) (*models.TablePreview, error) {
+	resource, err := s.getResource(resourceID, userID, authz.AccessPreview)
 	if err != nil {
 		return nil, err
 	}
@@ -305,14 +319,33 @@ func (s *MetadataService) PreviewTable(resourceID uint, schemaName, tableName st
 	}, nil
 }
 
-// getResource 优先通过 System 服务获取解密后的资源信息，失败时回退到本地数据库
-func (s *MetadataService) getResource(resourceID uint) (*models.Resource, error) {
-	if s.systemClient != nil {
-		if sysResource, err := s.systemClient.GetResource(resourceID); err == nil {
-			return convertResource(sysResource), nil
-		}
+// getResource 通过 System 服务获取解密后的资源信息并检查用户的访问级别；
+// 无法检查访问级别（未集成 System、缺少用户身份或 System 不可用）时拒绝访问，不回退到本地数据库
+func (s *MetadataService) getResource(resourceID, userID uint, level string) (*models.Resource, error) {
+	if s.systemClient == nil || userID == 0 {
+		return nil, ErrAccessCheckUnavailable
+	}
+	sysResource, err := s.systemClient.GetResourceForUser(resourceID, userID, level)
+	if err != nil {
+		return nil, err
+	}
+	return convertResource(sysResource), nil
+}
+
+// visibleResourceIDs 通过 System 查询用户有权查看的资源；无法查询时返回错误，不返回未过滤的结果
+func (s *MetadataService) visibleResourceIDs(ctx context.Context, tenantID, userID uint) (map[uint]bool, error) {
+	if s.systemClient == nil || userID == 0 {
+		return nil, ErrAccessCheckUnavailable
+	}
+	resources, err := s.systemClient.ListResourcesForUserWithContext(ctx, "", tenantID, userID, authz.AccessView)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accessible resources: %w", err)
+	}
+	visible := make(map[uint]bool, len(resources))
+	for _, resource := range resources {
+		visible[resource.ID] = true
 	}
-	return s.resourceRepo.GetByID(resourceID)
+	return visible, nil
 }
 
 func convertResource(src *commonModels.Resource) *models.Resource {
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/addp/common/authz"
+	"github.com/addp/common/metrics"
+	"github.com/addp/common/tracing"
 	"github.com/addp/meta/internal/api"
 	"github.com/addp/meta/internal/config"
 	"github.com/addp/meta/internal/repository"
@@ -13,19 +16,34 @@ func main() {
 	// 加载配置
 	cfg := config.LoadConfig()
 
+	// 配置 OTEL_EXPORTER_OTLP_ENDPOINT 时导出 trace
+	defer tracing.Init("meta")()
+
 	// 初始化数据库
 	db, err := repository.InitDatabase(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB("meta", sqlDB)
+	}
 
 	log.Println("Database initialized successfully")
 
 	// TODO: 实现定时任务调度（Phase 4）
 	// 可以使用 robfig/cron 库实现定时扫描
 
+	// 未配置权限检查时拒绝启动，不以无权限检查的状态对外服务
+	checker, err := authz.NewSystemChecker(cfg.SystemServiceURL, cfg.InternalAPIKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize permission checker: %v", err)
+	}
+
+	// Prometheus 指标只在内部端口上提供
+	metrics.Serve("meta", cfg.MetricsPort)
+
 	// 设置路由（使用新的简化路由）
-	router := api.SetupRouterNew(cfg, db)
+	router := api.SetupRouterNew(cfg, db, checker)
 
 	// 启动服务器
 	addr := fmt.Sprintf(":%s", cfg.ServerPort)
@@ -27,7 +27,7 @@ func NewHandler(resourceService *service.ResourceService, scanService *service.S
 func (h *Handler) GetResources(c *gin.Context) {
 	tenantID := middleware.GetTenantID(c)
 
-	resources, err := h.resourceService.GetResourcesWithStats(tenantID)
+	resources, err := h.resourceService.GetResourcesWithStats(c.Request.Context(), tenantID, middleware.GetUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -48,6 +48,11 @@ func (h *Handler) GetSchemas(c *gin.Context) {
 		return
 	}
 
+	if err := h.resourceService.CheckAccess(c.Request.Context(), uint(resourceID), tenantID, middleware.GetUserID(c)); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
 	schemas, err := h.scanService.GetSchemasByResource(uint(resourceID), tenantID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -80,7 +85,7 @@ func (h *Handler) ListAvailableSchemas(c *gin.Context) {
 		token = token[7:]
 	}
 
-	schemas, err := h.scanService.ListAvailableSchemas(uint(resourceID), tenantID, token)
+	schemas, err := h.scanService.ListAvailableSchemas(c.Request.Context(), uint(resourceID), tenantID, middleware.GetUserID(c), token)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -111,7 +116,7 @@ func (h *Handler) ListObjectStorageNodes(c *gin.Context) {
 		token = token[7:]
 	}
 
-	nodes, err := h.scanService.ListObjectStorageNodes(uint(resourceID), tenantID, path, token)
+	nodes, err := h.scanService.ListObjectStorageNodes(c.Request.Context(), uint(resourceID), tenantID, middleware.GetUserID(c), path, token)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -125,7 +130,7 @@ func (h *Handler) ListObjectStorageNodes(c *gin.Context) {
 func (h *Handler) AutoScan(c *gin.Context) {
 	tenantID := middleware.GetTenantID(c)
 
-	result, err := h.scanService.AutoScanUnscanned(tenantID)
+	result, err := h.scanService.AutoScanUnscanned(c.Request.Context(), tenantID, middleware.GetUserID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -155,7 +160,7 @@ func (h *Handler) ScanResource(c *gin.Context) {
 		token = token[7:]
 	}
 
-	result, err := h.scanService.ScanResource(req.ResourceID, tenantID, req.SchemaNames, req.ObjectPaths, token)
+	result, err := h.scanService.ScanResource(c.Request.Context(), req.ResourceID, tenantID, middleware.GetUserID(c), req.SchemaNames, req.ObjectPaths, token)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"github.com/addp/common/authz"
 	"github.com/addp/common/client"
 	"github.com/addp/meta/internal/config"
 	"github.com/addp/meta/internal/middleware"
@@ -10,8 +11,11 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRouterNew(cfg *config.Config, db *gorm.DB) *gin.Engine {
-	router := gin.Default()
+func SetupRouterNew(cfg *config.Config, db *gorm.DB, checker *authz.Checker) *gin.Engine {
+	router := gin.New()
+
+	// 访问日志附带请求 ID，请求 ID 和 trace 随调用传递给其他服务
+	router.Use(middleware.Logger(), gin.Recovery(), middleware.RequestID(), middleware.Metrics())
 
 	// CORS配置
 	corsConfig := cors.DefaultConfig()
@@ -31,25 +35,27 @@ func SetupRouterNew(cfg *config.Config, db *gorm.DB) *gin.Engine {
 	handler := NewHandler(resourceService, scanService)
 
 	// 健康检查
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "healthy"})
-	})
+	router.GET("/health", healthHandler(cfg, db))
+
+	// 按 System 中分配的角色检查权限
+	read := middleware.RequirePermission(checker, authz.MetaRead)
+	scan := middleware.RequirePermission(checker, authz.MetaScan)
 
 	// API路由组（需要认证）
 	api := router.Group("/api/meta")
-	api.Use(middleware.AuthMiddleware(cfg.SystemServiceURL))
+	api.Use(middleware.AuthMiddleware(cfg.SystemServiceURL, cfg.IdentitySecret))
 	{
 		// 资源相关
-		api.GET("/resources", handler.GetResources)
+		api.GET("/resources", read, handler.GetResources)
 
 		// Schema相关
-		api.GET("/schemas/:resource_id", handler.GetSchemas)
-		api.GET("/schemas/:resource_id/available", handler.ListAvailableSchemas)
-		api.GET("/object-storage/:resource_id/nodes", handler.ListObjectStorageNodes)
+		api.GET("/schemas/:resource_id", read, handler.GetSchemas)
+		api.GET("/schemas/:resource_id/available", read, handler.ListAvailableSchemas)
+		api.GET("/object-storage/:resource_id/nodes", read, handler.ListObjectStorageNodes)
 
 		// 扫描相关
-		api.POST("/scan/auto", handler.AutoScan)
-		api.POST("/scan/resource", handler.ScanResource)
+		api.POST("/scan/auto", scan, handler.AutoScan)
+		api.POST("/scan/resource", scan, handler.ScanResource)
 	}
 
 	return router
@@ -18,6 +18,7 @@ type Config struct {
 	AutoSyncLevel     string // database | table | field
 	DeepScanTimeout   string
 	DeepScanBatchSize int
+	MetricsPort       string // Prometheus 指标的内部端口，不经过服务端口暴露，为空时不监听
 }
 
 func LoadConfig() *Config {
@@ -32,6 +33,7 @@ func LoadConfig() *Config {
 		AutoSyncLevel:     commonConfig.GetEnv("AUTO_SYNC_LEVEL", "database"),
 		DeepScanTimeout:   commonConfig.GetEnv("DEEP_SCAN_TIMEOUT", "30m"),
 		DeepScanBatchSize: commonConfig.GetEnvInt("DEEP_SCAN_BATCH_SIZE", 10),
+		MetricsPort:       commonConfig.GetEnv("METRICS_PORT", "9092"),
 	}
 
 	// 设置 BaseConfig 字段
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/addp/common/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 要求当前用户拥有指定权限，需在 AuthMiddleware 之后使用
+func RequirePermission(checker *authz.Checker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if status, body := checker.Authorize(c.Request.Context(), GetUserID(c), permission); status != http.StatusOK {
+			c.AbortWithStatusJSON(status, body)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/addp/common/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDKey gin 上下文中保存请求 ID 的键
+const RequestIDKey = "request_id"
+
+// RequestID 使用上游传入的请求 ID（没有时生成）并开始本次请求的 span，请求 ID 写入响应头
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var finish func(status int)
+		c.Request, finish = tracing.StartServerRequest(c.Writer, c.Request, c.FullPath())
+		defer func() { finish(c.Writer.Status()) }()
+		c.Set(RequestIDKey, tracing.RequestID(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// Logger gin 访问日志，每行附带请求 ID
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		requestID, _ := p.Keys[RequestIDKey].(string)
+		return tracing.AccessLog(p.TimeStamp, p.StatusCode, p.Latency, p.ClientIP, p.Method, p.Path, requestID, p.ErrorMessage)
+	})
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/addp/common/metrics"
+)
+
+var (
+	scanDuration = metrics.NewHistogramVec("meta_scan_duration_seconds",
+		"单个资源的扫描耗时（秒）", []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 1800}, "resource_type", "status")
+	scanItems = metrics.NewCounterVec("meta_scan_items_total",
+		"扫描到的 schema（bucket）、表（对象）和字段数", "resource_type", "kind")
+)
+
+// observeScan 记录一次资源扫描
+func observeScan(resourceType string, start time.Time, schemas, tables, fields int, err error) {
+	resourceType = strings.ToLower(resourceType)
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	scanDuration.WithLabelValues(resourceType, status).Observe(time.Since(start).Seconds())
+	scanItems.WithLabelValues(resourceType, "schema").Add(float64(schemas))
+	scanItems.WithLabelValues(resourceType, "table").Add(float64(tables))
+	scanItems.WithLabelValues(resourceType, "field").Add(float64(fields))
+}
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/addp/common/authz"
 	commonClient "github.com/addp/common/client"
 	commonModels "github.com/addp/common/models"
 	"github.com/addp/meta/internal/models"
@@ -43,10 +45,10 @@ func NewResourceService(db *gorm.DB, systemURL, internalKey string) *ResourceSer
 	}
 }
 
-// GetResourcesByTenant 获取租户的所有数据库类型资源
-func (s *ResourceService) GetResourcesByTenant(tenantID uint) ([]*commonModels.Resource, error) {
+// GetResourcesByTenant 获取租户的所有数据库类型资源；userID 不为 0 时只返回该用户有权查看（view 级别）的资源
+func (s *ResourceService) GetResourcesByTenant(ctx context.Context, tenantID, userID uint) ([]*commonModels.Resource, error) {
 	if s.internalClient != nil {
-		systemResources, err := s.internalClient.ListResources("", tenantID)
+		systemResources, err := s.internalClient.ListResourcesForUserWithContext(ctx, "", tenantID, userID, authz.AccessView)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch resources from system: %w", err)
 		}
@@ -85,11 +87,11 @@ func (s *ResourceService) GetResourcesByTenant(tenantID uint) ([]*commonModels.R
 	return resources, nil
 }
 
-// GetResourceByID 根据ID获取资源（从System API获取，密码已解密）
+// GetResourceByID 根据ID获取资源（从System API获取，密码已解密），要求用户有权查看该资源
 // token: 用户的JWT token，用于认证System API调用
-func (s *ResourceService) GetResourceByID(resourceID, tenantID uint, token string) (*commonModels.Resource, error) {
+func (s *ResourceService) GetResourceByID(ctx context.Context, resourceID, tenantID, userID uint, token string) (*commonModels.Resource, error) {
 	if s.internalClient != nil {
-		resource, err := s.internalClient.GetResource(resourceID)
+		resource, err := s.internalClient.GetResourceForUserWithContext(ctx, resourceID, userID, authz.AccessView)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get resource from System API: %w", err)
 		}
@@ -102,7 +104,7 @@ func (s *ResourceService) GetResourceByID(resourceID, tenantID uint, token strin
 	// 使用用户token创建SystemClient（无内部密钥时降级使用用户接口，敏感字段将被脱敏）
 	systemClient := commonClient.NewSystemClient(s.systemURL, token)
 
-	resource, err := systemClient.GetResource(resourceID)
+	resource, err := systemClient.GetResourceWithContext(ctx, resourceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get resource from System API: %w", err)
 	}
@@ -114,9 +116,18 @@ func (s *ResourceService) GetResourceByID(resourceID, tenantID uint, token strin
 	return resource, nil
 }
 
+// CheckAccess 检查用户是否有权查看资源的元数据；未配置内部 API Key 时不检查
+func (s *ResourceService) CheckAccess(ctx context.Context, resourceID, tenantID, userID uint) error {
+	if s.internalClient == nil {
+		return nil
+	}
+	_, err := s.GetResourceByID(ctx, resourceID, tenantID, userID, "")
+	return err
+}
+
 // GetResourcesWithStats 获取资源及其扫描统计
-func (s *ResourceService) GetResourcesWithStats(tenantID uint) ([]*models.ResourceWithStats, error) {
-	resources, err := s.GetResourcesByTenant(tenantID)
+func (s *ResourceService) GetResourcesWithStats(ctx context.Context, tenantID, userID uint) ([]*models.ResourceWithStats, error) {
+	resources, err := s.GetResourcesByTenant(ctx, tenantID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/addp/common/client"
 	commonModels "github.com/addp/common/models"
+	"github.com/addp/common/tracing"
 	"github.com/addp/meta/internal/models"
 	"github.com/addp/meta/internal/scanner"
 	"gorm.io/gorm"
@@ -373,7 +375,7 @@ func ensureNodeAggregate(stats map[uint]*nodeAggregate, node *models.MetaNode) *
 }
 
 // AutoScanUnscanned 自动扫描所有未扫描的资源
-func (s *ScanServiceNew) AutoScanUnscanned(tenantID uint) (*models.ScanResponse, error) {
+func (s *ScanServiceNew) AutoScanUnscanned(ctx context.Context, tenantID, userID uint) (*models.ScanResponse, error) {
 	startTime := time.Now()
 
 	// 创建扫描日志
@@ -388,8 +390,8 @@ func (s *ScanServiceNew) AutoScanUnscanned(tenantID uint) (*models.ScanResponse,
 		return nil, fmt.Errorf("failed to create scan log: %w", err)
 	}
 
-	// 获取所有数据库资源
-	resources, err := s.resourceService.GetResourcesByTenant(tenantID)
+	// 获取用户有权查看的所有数据库资源
+	resources, err := s.resourceService.GetResourcesByTenant(ctx, tenantID, userID)
 	if err != nil {
 		s.updateScanLogFailed(scanLog, err.Error())
 		return nil, err
@@ -402,9 +404,11 @@ func (s *ScanServiceNew) AutoScanUnscanned(tenantID uint) (*models.ScanResponse,
 
 	// 对每个资源进行扫描
 	for _, resource := range resources {
+		scanStart := time.Now()
 		schemas, tables, fields, err := s.scanResource(resource, tenantID, scanLog.ID)
+		observeScan(resource.ResourceType, scanStart, schemas, tables, fields, err)
 		if err != nil {
-			log.Printf("Failed to scan resource %s: %v", resource.Name, err)
+			log.Printf("Failed to scan resource %s: %v (request_id: %s)", resource.Name, err, tracing.RequestID(ctx))
 			continue
 		}
 
@@ -437,11 +441,11 @@ func (s *ScanServiceNew) AutoScanUnscanned(tenantID uint) (*models.ScanResponse,
 }
 
 // ScanResource 扫描指定资源
-func (s *ScanServiceNew) ScanResource(resourceID, tenantID uint, schemaNames, objectPaths []string, token string) (*models.ScanResponse, error) {
+func (s *ScanServiceNew) ScanResource(ctx context.Context, resourceID, tenantID, userID uint, schemaNames, objectPaths []string, token string) (*models.ScanResponse, error) {
 	startTime := time.Now()
 
 	// 获取资源
-	resource, err := s.resourceService.GetResourceByID(resourceID, tenantID, token)
+	resource, err := s.resourceService.GetResourceByID(ctx, resourceID, tenantID, userID, token)
 	if err != nil {
 		return nil, err
 	}
@@ -470,6 +474,7 @@ func (s *ScanServiceNew) ScanResource(resourceID, tenantID uint, schemaNames, ob
 	} else {
 		schemas, tables, fields, err = s.scanResourceSchemas(resource, tenantID, schemaNames, scanLog.ID)
 	}
+	observeScan(resourceType, startTime, schemas, tables, fields, err)
 
 	if err != nil {
 		s.updateScanLogFailed(scanLog, err.Error())
@@ -947,8 +952,8 @@ func (s *ScanServiceNew) GetSchemasByResource(resourceID, tenantID uint) ([]*mod
 }
 
 // ListAvailableSchemas 列出资源中可用的Schema（从数据库实时查询）
-func (s *ScanServiceNew) ListAvailableSchemas(resourceID, tenantID uint, token string) ([]*models.SchemaInfo, error) {
-	resource, err := s.resourceService.GetResourceByID(resourceID, tenantID, token)
+func (s *ScanServiceNew) ListAvailableSchemas(ctx context.Context, resourceID, tenantID, userID uint, token string) ([]*models.SchemaInfo, error) {
+	resource, err := s.resourceService.GetResourceByID(ctx, resourceID, tenantID, userID, token)
 	if err != nil {
 		return nil, err
 	}
@@ -979,8 +984,8 @@ func (s *ScanServiceNew) ListAvailableSchemas(resourceID, tenantID uint, token s
 	return result, nil
 }
 
-func (s *ScanServiceNew) ListObjectStorageNodes(resourceID, tenantID uint, path, token string) ([]*models.ObjectNode, error) {
-	resource, err := s.resourceService.GetResourceByID(resourceID, tenantID, token)
+func (s *ScanServiceNew) ListObjectStorageNodes(ctx context.Context, resourceID, tenantID, userID uint, path, token string) ([]*models.ObjectNode, error) {
+	resource, err := s.resourceService.GetResourceByID(ctx, resourceID, tenantID, userID, token)
 	if err != nil {
 		return nil, err
 	}
@@ -209,39 +209,78 @@ CREATE SCHEMA IF NOT EXISTS transfer;
 
 CREATE TABLE IF NOT EXISTS transfer.tasks (
     id SERIAL PRIMARY KEY,
+    tenant_id INTEGER,
     name VARCHAR(255) NOT NULL,
     type VARCHAR(50) NOT NULL, -- 'import', 'export', 'sync'
     source_id INTEGER,
     target_id INTEGER,
     config JSONB NOT NULL,
     schedule VARCHAR(100), -- Cron expression
-    status VARCHAR(20) DEFAULT 'pending', -- 'pending', 'running', 'success', 'failed', 'paused'
+    status VARCHAR(20) DEFAULT 'pending', -- 'pending', 'running', 'success', 'failed', 'paused', 'cancelled'
     progress NUMERIC(5,2) DEFAULT 0,
     created_by INTEGER,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    overlap_policy VARCHAR(20) DEFAULT 'skip', -- 'skip', 'queue', 'cancel_previous'
+    catch_up VARCHAR(20) DEFAULT 'latest', -- 'none', 'latest', 'all'
+    last_scheduled_at TIMESTAMP,
+    next_run_at TIMESTAMP,
+    watermark TEXT, -- sync 任务的高水位
+    watermark_updated_at TIMESTAMP
 );
 
 CREATE INDEX IF NOT EXISTS idx_tasks_status ON transfer.tasks(status);
 CREATE INDEX IF NOT EXISTS idx_tasks_type ON transfer.tasks(type);
+CREATE INDEX IF NOT EXISTS idx_tasks_tenant ON transfer.tasks(tenant_id);
 
 CREATE TABLE IF NOT EXISTS transfer.task_executions (
     id SERIAL PRIMARY KEY,
     task_id INTEGER REFERENCES transfer.tasks(id) ON DELETE CASCADE,
-    status VARCHAR(20) NOT NULL,
-    start_time TIMESTAMP NOT NULL,
+    tenant_id INTEGER,
+    status VARCHAR(20) NOT NULL, -- 'queued', 'running', 'success', 'failed', 'paused', 'cancelled', 'skipped'
+    trigger_type VARCHAR(20) DEFAULT 'manual', -- 'manual', 'retry', 'resume', 'schedule'
+    attempt INTEGER DEFAULT 1,
+    scheduled_at TIMESTAMP,
+    stop_request VARCHAR(20), -- 'pause', 'cancel'
+    worker_id VARCHAR(128),
+    heartbeat_at TIMESTAMP,
+    start_time TIMESTAMP,
     end_time TIMESTAMP,
+    phase VARCHAR(20), -- 'queued', 'preparing', 'transferring', 'committing', 'finished'
+    total_records BIGINT DEFAULT 0,
     records_read BIGINT DEFAULT 0,
     records_written BIGINT DEFAULT 0,
+    records_rejected BIGINT DEFAULT 0,
     bytes_read BIGINT DEFAULT 0,
     bytes_written BIGINT DEFAULT 0,
     error_msg TEXT,
-    logs TEXT
+    logs TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX IF NOT EXISTS idx_executions_task ON transfer.task_executions(task_id);
 CREATE INDEX IF NOT EXISTS idx_executions_status ON transfer.task_executions(status);
 CREATE INDEX IF NOT EXISTS idx_executions_start_time ON transfer.task_executions(start_time);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_executions_task_scheduled ON transfer.task_executions(task_id, scheduled_at);
+
+CREATE TABLE IF NOT EXISTS transfer.task_checkpoints (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES transfer.tasks(id) ON DELETE CASCADE,
+    partition VARCHAR(255) NOT NULL DEFAULT 'default',
+    execution_id INTEGER NOT NULL,
+    position JSONB, -- 源端读取位置：主键水位或行偏移
+    writer_state JSONB, -- 目标端状态：分片上传 ID 与已上传分片
+    records_read BIGINT DEFAULT 0,
+    records_written BIGINT DEFAULT 0,
+    records_rejected BIGINT DEFAULT 0,
+    bytes_read BIGINT DEFAULT 0,
+    bytes_written BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_checkpoints_task_partition ON transfer.task_checkpoints(task_id, partition);
 
 CREATE TABLE IF NOT EXISTS transfer.data_mappings (
     id SERIAL PRIMARY KEY,
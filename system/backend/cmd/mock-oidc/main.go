@@ -0,0 +1,275 @@
+// mock-oidc 本地开发和测试用的 OIDC 提供方：登录页直接填写用户名、邮箱、租户和组，
+// 支持授权码 + PKCE（S256）、JWKS、UserInfo。不做任何认证，切勿用于生产环境。
+//
+//	go run ./cmd/mock-oidc -addr :9000 -issuer http://localhost:9000 -client-id addp
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock-oidc"
+
+type authorization struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+	expiresAt     time.Time
+}
+
+type server struct {
+	issuer   string
+	clientID string
+	key      *rsa.PrivateKey
+
+	mu       sync.Mutex
+	codes    map[string]*authorization
+	userinfo map[string]map[string]interface{} // access_token → 声明
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<html><head><meta charset="utf-8"><title>Mock OIDC</title></head>
+<body style="font-family:sans-serif;max-width:420px;margin:40px auto">
+<h2>Mock OIDC 登录</h2>
+<form method="post">
+{{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">{{end}}
+<p>用户名 <input name="username" value="alice" required></p>
+<p>邮箱 <input name="email" value="alice@example.com"></p>
+<p>姓名 <input name="name" value="Alice"></p>
+<p>租户 <input name="tenant" value=""></p>
+<p>组（逗号分隔） <input name="groups" value=""></p>
+<p><label><input type="checkbox" name="email_verified" value="true" checked> 邮箱已验证</label></p>
+<p><button type="submit">登录</button></p>
+</form></body></html>`))
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL (must match OIDC_ISSUER)")
+	clientID := flag.String("client-id", "addp", "accepted client_id")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("generate key: %v", err)
+	}
+	s := &server{
+		issuer:   strings.TrimRight(*issuer, "/"),
+		clientID: *clientID,
+		key:      key,
+		codes:    make(map[string]*authorization),
+		userinfo: make(map[string]map[string]interface{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userInfo)
+
+	log.Printf("Mock OIDC provider listening on %s (issuer %s, client_id %s)", *addr, s.issuer, s.clientID)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (s *server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.issuer,
+		"authorization_endpoint":                s.issuer + "/authorize",
+		"token_endpoint":                        s.issuer + "/token",
+		"userinfo_endpoint":                     s.issuer + "/userinfo",
+		"jwks_uri":                              s.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *server) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize GET 显示登录表单，POST 签发授权码并跳回客户端
+func (s *server) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params := map[string]string{}
+	for _, name := range []string{"client_id", "redirect_uri", "state", "nonce", "code_challenge", "code_challenge_method"} {
+		params[name] = r.Form.Get(name)
+	}
+	if params["client_id"] != s.clientID || params["redirect_uri"] == "" {
+		http.Error(w, "invalid client_id or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if params["code_challenge"] == "" || params["code_challenge_method"] != "S256" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		loginPage.Execute(w, map[string]interface{}{"Params": params})
+		return
+	}
+
+	username := strings.TrimSpace(r.Form.Get("username"))
+	claims := map[string]interface{}{
+		"sub":                "mock|" + username,
+		"preferred_username": username,
+		"name":               r.Form.Get("name"),
+		"email":              r.Form.Get("email"),
+		"email_verified":     r.Form.Get("email_verified") == "true",
+		"groups":             splitList(r.Form.Get("groups")),
+	}
+	if tenant := strings.TrimSpace(r.Form.Get("tenant")); tenant != "" {
+		claims["tenant"] = tenant
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	s.codes[code] = &authorization{
+		clientID:      params["client_id"],
+		redirectURI:   params["redirect_uri"],
+		nonce:         params["nonce"],
+		codeChallenge: params["code_challenge"],
+		claims:        claims,
+		expiresAt:     time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(params["redirect_uri"])
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	q := redirect.Query()
+	q.Set("code", code)
+	q.Set("state", params["state"])
+	redirect.RawQuery = q.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token 用授权码换取 ID Token，校验 redirect_uri 和 PKCE code_verifier
+func (s *server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	code := r.Form.Get("code")
+	s.mu.Lock()
+	auth := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+
+	clientID := r.Form.Get("client_id")
+	if id, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(id)
+	}
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	switch {
+	case auth == nil || time.Now().After(auth.expiresAt):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case clientID != auth.clientID || r.Form.Get("redirect_uri") != auth.redirectURI:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	idClaims := jwt.MapClaims{
+		"iss":   s.issuer,
+		"aud":   auth.clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		idClaims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, idClaims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	accessToken := randomString()
+	s.mu.Lock()
+	s.userinfo[accessToken] = auth.claims
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *server) userInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	claims, ok := s.userinfo[accessToken]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+	writeJSON(w, http.StatusOK, claims)
+}
+
+func splitList(s string) []string {
+	values := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"log"
 
+	"github.com/addp/common/metrics"
+	"github.com/addp/common/tracing"
 	"github.com/addp/system/internal/api"
 	"github.com/addp/system/internal/config"
 	"github.com/addp/system/internal/repository"
@@ -13,17 +15,28 @@ func main() {
 	// 加载配置
 	cfg := config.Load()
 
+	// 配置 OTEL_EXPORTER_OTLP_ENDPOINT 时导出 trace
+	defer tracing.Init("system")()
+
 	// 初始化数据库
 	db, err := repository.InitDB(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB("system", sqlDB)
+	}
 
 	// 自动迁移
 	if err := repository.AutoMigrate(db); err != nil {
 		log.Fatalf("数据库迁移失败: %v", err)
 	}
 
+	// 初始化内置角色
+	if err := repository.InitBuiltinRoles(db); err != nil {
+		log.Fatalf("内置角色初始化失败: %v", err)
+	}
+
 	// 初始化超级管理员用户
 	if err := repository.InitSuperAdmin(db); err != nil {
 		log.Fatalf("超级管理员用户初始化失败: %v", err)
@@ -34,6 +47,9 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Prometheus 指标只在内部端口上提供
+	metrics.Serve("system", cfg.MetricsPort)
+
 	// 创建路由
 	router := api.SetupRouter(db, cfg)
 
@@ -1,24 +1,34 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/addp/system/internal/config"
 	"github.com/addp/system/internal/models"
 	"github.com/addp/system/internal/service"
-	"github.com/addp/system/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	userService *service.UserService
-	cfg         *config.Config
+	userService    *service.UserService
+	sessionService *service.SessionService
+	tokenService   *service.APITokenService
+	mfaService     *service.MFAService
+	logService     *service.LogService
+	cfg            *config.Config
 }
 
-func NewAuthHandler(userService *service.UserService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(userService *service.UserService, sessionService *service.SessionService, tokenService *service.APITokenService, mfaService *service.MFAService, logService *service.LogService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		cfg:         cfg,
+		userService:    userService,
+		sessionService: sessionService,
+		tokenService:   tokenService,
+		mfaService:     mfaService,
+		logService:     logService,
+		cfg:            cfg,
 	}
 }
 
@@ -31,19 +41,214 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	user, err := h.userService.Authenticate(req.Username, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		h.recordLogin(c, req.Username, nil, models.LoginMethodPassword, false, err.Error())
+		err = publicAuthError(err)
+		status := http.StatusUnauthorized
+		body := gin.H{"error": err.Error()}
+		switch {
+		case errors.Is(err, service.ErrLocalLoginDisabled):
+			status = http.StatusForbidden
+		case errors.Is(err, service.ErrAuthUnavailable):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, service.ErrPasswordExpired):
+			// 前端据此引导用户修改密码（POST /api/auth/password）
+			status = http.StatusForbidden
+			body["code"] = "password_expired"
+		}
+		c.JSON(status, body)
+		return
+	}
+
+	// 启用多因素认证时先返回验证码登录令牌，验证码通过后（POST /api/auth/mfa）再创建会话
+	if user.MFAEnabled {
+		challenge, err := h.mfaService.Challenge(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建验证失败"})
+			return
+		}
+		h.recordLogin(c, req.Username, user, models.LoginMethodPassword, true, "mfa_required")
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	h.recordLogin(c, req.Username, user, models.LoginMethodPassword, true, "")
+	h.createSession(c, user)
+}
+
+// VerifyMFA 使用登录返回的 mfa_token 和身份验证器的验证码完成登录
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Username, h.cfg.JWTSecret, h.cfg.TokenExpireMinutes)
+	user, err := h.mfaService.Verify(req.MFAToken, req.Code)
+	if err != nil {
+		if user != nil {
+			h.recordLogin(c, user.Username, user, models.LoginMethodMFA, false, err.Error())
+		}
+		status := http.StatusUnauthorized
+		body := gin.H{"error": err.Error()}
+		switch {
+		case errors.Is(err, service.ErrAccountLocked):
+			status = http.StatusLocked
+		case errors.Is(err, service.ErrInvalidMFACode):
+			// 验证码错误时可以重新输入，其他错误需要重新输入密码
+			body["code"] = "invalid_mfa_code"
+		}
+		c.JSON(status, body)
+		return
+	}
+
+	h.recordLogin(c, user.Username, user, models.LoginMethodMFA, true, "")
+	h.createSession(c, user)
+}
+
+// ChangePassword 使用原密码（启用多因素认证时还有验证码）修改已过期的密码，在登录页使用；修改后需要重新登录
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req models.PasswordChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.ChangePassword(&req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrUserDisabled),
+			errors.Is(err, service.ErrAccountLocked), errors.Is(err, service.ErrInvalidMFACode),
+			errors.Is(err, service.ErrMFARequired):
+			status = http.StatusUnauthorized
+		case errors.Is(err, service.ErrPasswordNotExpired):
+			status = http.StatusForbidden
+		}
+		if status != http.StatusBadRequest {
+			h.recordLogin(c, req.Username, user, models.LoginMethodPasswordChange, false, err.Error())
+		}
+		body := gin.H{"error": publicAuthError(err).Error()}
+		if errors.Is(err, service.ErrMFARequired) {
+			// 前端据此显示验证码输入框
+			body["code"] = "mfa_required"
+		}
+		c.JSON(status, body)
+		return
+	}
+
+	h.recordLogin(c, req.Username, user, models.LoginMethodPasswordChange, true, "")
+	c.JSON(http.StatusOK, gin.H{"message": "密码已修改，请重新登录"})
+}
+
+// publicAuthError 未登录的接口对用户不存在、密码错误、账号锁定和禁用返回同一个错误，
+// 避免调用方据此枚举用户名；实际原因只记录在登录日志中
+func publicAuthError(err error) error {
+	if errors.Is(err, service.ErrAccountLocked) || errors.Is(err, service.ErrUserDisabled) {
+		return service.ErrInvalidCredentials
+	}
+	return err
+}
+
+func (h *AuthHandler) createSession(c *gin.Context, user *models.User) {
+	resp, err := h.sessionService.Create(user, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		AccessToken: token,
-		TokenType:   "Bearer",
+	c.JSON(http.StatusOK, resp)
+}
+
+// recordLogin 记录登录尝试，user 为空时按用户名查找
+func (h *AuthHandler) recordLogin(c *gin.Context, username string, user *models.User, method string, success bool, reason string) {
+	attempt := &models.LoginAttempt{
+		Username:  username,
+		Method:    method,
+		Success:   success,
+		Reason:    reason,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if user != nil {
+		attempt.Username = user.Username
+		attempt.UserID = &user.ID
+		attempt.TenantID = user.TenantID
+	}
+	h.logService.RecordLogin(attempt)
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.sessionService.Refresh(req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout 注销当前会话，访问令牌和刷新令牌都失效
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if sessionID != "" {
+		if err := h.sessionService.Revoke(sessionID, models.RevokeReasonLogout); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "注销失败"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
+
+// RevokedTokens 返回 since（Unix 秒）之后吊销且尚未过期的访问令牌，供网关同步黑名单
+func (h *AuthHandler) RevokedTokens(c *gin.Context) {
+	since, _ := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+
+	tokens, err := h.sessionService.ListRevokedTokens(time.Unix(since, 0))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "server_time": time.Now().Unix()})
+}
+
+// VerifyAPIToken 校验访问令牌并返回所属用户和权限范围，供网关认证使用访问令牌的请求
+func (h *AuthHandler) VerifyAPIToken(c *gin.Context) {
+	var req struct {
+		Token     string `json:"token" binding:"required"`
+		IPAddress string `json:"ip_address"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, user, err := h.tokenService.Verify(req.Token, req.IPAddress)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIToken) || errors.Is(err, service.ErrUserDisabled) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token_id":   token.ID,
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"tenant_id":  user.TenantID,
+		"user_type":  user.UserType,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
 	})
 }
 
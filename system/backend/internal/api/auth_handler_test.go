@@ -0,0 +1,25 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/addp/system/internal/service"
+)
+
+func TestPublicAuthError(t *testing.T) {
+	// 锁定、禁用与密码错误对调用方不可区分
+	for _, err := range []error{service.ErrInvalidCredentials, service.ErrAccountLocked, service.ErrUserDisabled,
+		fmt.Errorf("wrapped: %w", service.ErrAccountLocked)} {
+		if got := publicAuthError(err); got.Error() != service.ErrInvalidCredentials.Error() {
+			t.Fatalf("publicAuthError(%v) = %q", err, got)
+		}
+	}
+	// 其他错误（密码正确后才会返回或与账号无关）保持不变
+	for _, err := range []error{service.ErrPasswordExpired, service.ErrLocalLoginDisabled, service.ErrAuthUnavailable} {
+		if got := publicAuthError(err); !errors.Is(got, err) {
+			t.Fatalf("publicAuthError(%v) = %v", err, got)
+		}
+	}
+}
@@ -29,6 +29,7 @@ func (h *ConfigHandler) GetSharedConfig(c *gin.Context) {
 			"name": h.cfg.ProjectName,
 		},
 		"jwt_secret":       h.cfg.JWTSecret,
+		"identity_secret":  h.cfg.IdentitySecret,
 		"encryption_key":   encryptionKey,
 		"internal_api_key": h.cfg.InternalAPIKey,
 		"map": gin.H{
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/addp/common/health"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// healthHandler 检查系统数据库，不可用时返回 503
+func healthHandler(db *gorm.DB) gin.HandlerFunc {
+	checker := health.NewChecker("system", 2*time.Second).
+		Add("database", true, func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		})
+
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		c.JSON(report.HTTPStatus(), report)
+	}
+}
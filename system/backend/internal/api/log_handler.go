@@ -59,4 +59,27 @@ func (h *LogHandler) GetByID(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, log)
+}
+
+// ListLoginAttempts 登录记录（包括失败的登录），可按 user_id 过滤
+func (h *LogHandler) ListLoginAttempts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	var userID *uint
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		id, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err == nil {
+			uid := uint(id)
+			userID = &uid
+		}
+	}
+
+	attempts, err := h.logService.ListLoginAttempts(page, pageSize, userID, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAHandler 当前用户绑定和停用身份验证器
+type MFAHandler struct {
+	mfaService *service.MFAService
+}
+
+func NewMFAHandler(mfaService *service.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+// Setup 生成新的 TOTP 密钥，返回密钥和 otpauth 地址
+func (h *MFAHandler) Setup(c *gin.Context) {
+	if !h.requireLogin(c) {
+		return
+	}
+
+	resp, err := h.mfaService.Setup(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Enable 输入身份验证器的验证码确认启用
+func (h *MFAHandler) Enable(c *gin.Context) {
+	if !h.requireLogin(c) {
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.mfaService.Enable(c.GetUint("user_id"), req.Code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已启用多因素认证"})
+}
+
+// Disable 输入当前验证码停用
+func (h *MFAHandler) Disable(c *gin.Context) {
+	if !h.requireLogin(c) {
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.mfaService.Disable(c.GetUint("user_id"), req.Code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已停用多因素认证"})
+}
+
+// requireLogin 访问令牌不能修改多因素认证设置
+func (h *MFAHandler) requireLogin(c *gin.Context) bool {
+	if c.GetString("auth_method") == "token" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "请登录后修改多因素认证设置"})
+		return false
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/addp/common/authz"
 	"github.com/addp/system/internal/models"
 	"github.com/addp/system/internal/service"
 	"github.com/gin-gonic/gin"
@@ -131,6 +132,67 @@ func (h *ResourceHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
+// ListGrants 查询资源授权
+func (h *ResourceHandler) ListGrants(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的资源ID"})
+		return
+	}
+
+	grants, err := h.resourceService.ListGrants(uint(id), c.GetUint("user_id"))
+	if err != nil {
+		h.respondWithResourceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// Grant 授权资源给用户或角色
+func (h *ResourceHandler) Grant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的资源ID"})
+		return
+	}
+
+	var req models.ResourceGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grant, err := h.resourceService.Grant(uint(id), &req, c.GetUint("user_id"))
+	if err != nil {
+		h.respondWithResourceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grant)
+}
+
+// RevokeGrant 删除资源授权
+func (h *ResourceHandler) RevokeGrant(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的资源ID"})
+		return
+	}
+	grantID, err := strconv.ParseUint(c.Param("grant_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的授权ID"})
+		return
+	}
+
+	if err := h.resourceService.RevokeGrant(uint(id), uint(grantID), c.GetUint("user_id")); err != nil {
+		h.respondWithResourceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消授权"})
+}
+
 func (h *ResourceHandler) respondWithResourceError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, service.ErrResourceNotFound):
@@ -224,8 +286,14 @@ func (h *ResourceHandler) ListInternal(c *gin.Context) {
 		}
 	}
 
+	// 指定 user_id 时只返回该用户有权访问的资源（默认 view 级别）
+	userID, level, ok := parseAccessQuery(c, authz.AccessView)
+	if !ok {
+		return
+	}
+
 	// 调用服务层的内部列表方法（不做租户隔离检查）
-	resources, err := h.resourceService.ListInternal(resourceType, tenantIDUint)
+	resources, err := h.resourceService.ListInternal(resourceType, tenantIDUint, userID, level)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -242,11 +310,36 @@ func (h *ResourceHandler) GetByIDInternal(c *gin.Context) {
 		return
 	}
 
-	resource, err := h.resourceService.GetByIDInternal(uint(id))
+	// 指定 user_id 时检查该用户的访问级别（默认 connect，返回的是解密后的连接信息）
+	userID, level, ok := parseAccessQuery(c, authz.AccessConnect)
+	if !ok {
+		return
+	}
+
+	resource, err := h.resourceService.GetByIDInternal(uint(id), userID, level)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "资源不存在"})
+		h.respondWithResourceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, resource)
 }
+
+// parseAccessQuery 解析内部接口的 user_id 和 level 参数
+func parseAccessQuery(c *gin.Context, defaultLevel string) (uint, string, bool) {
+	userIDStr := c.Query("user_id")
+	if userIDStr == "" {
+		return 0, "", true
+	}
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, "", false
+	}
+	level := c.DefaultQuery("level", defaultLevel)
+	if authz.AccessRank(level) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的访问级别"})
+		return 0, "", false
+	}
+	return uint(userID), level, true
+}
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"log"
+	"time"
+
+	"github.com/addp/common/authz"
 	"github.com/addp/system/internal/config"
 	"github.com/addp/system/internal/middleware"
 	"github.com/addp/system/internal/repository"
@@ -10,7 +14,10 @@ import (
 )
 
 func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
+
+	// 访问日志附带请求 ID，请求 ID 和 trace 随调用传递给其他服务
+	router.Use(middleware.Logger(), gin.Recovery(), middleware.RequestID(), middleware.Metrics())
 
 	// CORS
 	router.Use(func(c *gin.Context) {
@@ -29,12 +36,37 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	logRepo := repository.NewLogRepository(db)
 	resourceRepo := repository.NewResourceRepository(db)
 	tenantRepo := repository.NewTenantRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	grantRepo := repository.NewResourceGrantRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	tokenRepo := repository.NewAPITokenRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
 
 	// 初始化 services
-	userService := service.NewUserService(userRepo)
-	logService := service.NewLogService(logRepo, userRepo)
-	resourceService := service.NewResourceService(resourceRepo, userRepo, cfg.EncryptionKey)
-	tenantService := service.NewTenantService(tenantRepo, userRepo, db)
+	roleService := service.NewRoleService(roleRepo, userRepo)
+	sessionService := service.NewSessionService(sessionRepo, userRepo, cfg)
+	sessionService.StartCleanup(time.Hour)
+	passwordPolicy := service.NewPasswordPolicy(cfg, userRepo)
+	loginGuard := service.NewLoginGuard(cfg, userRepo)
+	mfaService := service.NewMFAService(mfaRepo, userRepo, loginGuard, cfg)
+	mfaService.StartCleanup(time.Hour)
+	authenticators := []service.Authenticator{service.NewLocalAuthenticator(userRepo, passwordPolicy, cfg.LocalLoginEnabled)}
+	if cfg.LDAPURL != "" {
+		ldapAuthenticator, err := service.NewLDAPAuthenticator(cfg, identityRepo, userRepo, tenantRepo, roleRepo)
+		if err != nil {
+			log.Fatalf("Failed to initialize LDAP authenticator: %v", err)
+		}
+		ldapAuthenticator.StartGroupSync(cfg.LDAPSyncInterval)
+		authenticators = append(authenticators, ldapAuthenticator)
+	}
+	tokenService := service.NewAPITokenService(tokenRepo, userRepo)
+	userService := service.NewUserService(userRepo, sessionService, roleService, tokenService, passwordPolicy, loginGuard, mfaService, service.OrderAuthenticators(cfg.AuthBackends, authenticators...))
+	logService := service.NewLogService(logRepo, userRepo, roleService)
+	resourceService := service.NewResourceService(resourceRepo, grantRepo, userRepo, roleService, cfg.EncryptionKey)
+	tenantService := service.NewTenantService(tenantRepo, userRepo, roleService, passwordPolicy, db)
+	ssoService := service.NewSSOService(cfg, identityRepo, userRepo, tenantRepo, roleRepo, sessionService, loginGuard, mfaService)
+	ssoService.StartCleanup(time.Hour)
 
 	// 日志中间件
 	router.Use(middleware.LoggerMiddleware(logService, userRepo))
@@ -46,24 +78,40 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			"name_en": "All Domain Data Platform",
 		})
 	})
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	router.GET("/health", healthHandler(db))
+
+	authMiddleware := middleware.AuthMiddleware(cfg, sessionService, tokenService)
+	authHandler := NewAuthHandler(userService, sessionService, tokenService, mfaService, logService, cfg)
+	roleHandler := NewRoleHandler(roleService)
+	ssoHandler := NewSSOHandler(ssoService, logService, cfg)
+	mfaHandler := NewMFAHandler(mfaService)
+	requirePermission := func(permission string) gin.HandlerFunc {
+		return middleware.RequirePermission(roleService, permission)
+	}
 
 	// API 路由组
 	api := router.Group("/api")
 	{
-		// 认证路由（不需要认证）
+		// 认证路由（除注销外不需要认证）
 		auth := api.Group("/auth")
 		{
-			authHandler := NewAuthHandler(userService, cfg)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/mfa", authHandler.VerifyMFA)           // 启用多因素认证时的第二步
+			auth.POST("/password", authHandler.ChangePassword) // 使用原密码修改已过期的密码
 			auth.POST("/register", authHandler.Register)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authMiddleware, authHandler.Logout)
+
+			// 单点登录（OIDC）
+			auth.GET("/oidc", ssoHandler.Config)
+			auth.GET("/oidc/login", ssoHandler.Login)
+			auth.GET("/oidc/callback", ssoHandler.Callback)
+			auth.POST("/oidc/link", authMiddleware, ssoHandler.Link) // 已登录用户关联单点登录账号
 		}
 
 		// 需要认证的路由
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(cfg))
+		protected.Use(authMiddleware)
 		{
 			// 用户管理
 			users := protected.Group("/users")
@@ -72,9 +120,36 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				users.POST("", userHandler.Create)
 				users.GET("", userHandler.List)
 				users.GET("/me", userHandler.Me)
+				users.GET("/me/permissions", roleHandler.MyPermissions)
+				users.GET("/me/identities", ssoHandler.Identities)
+				users.POST("/me/mfa/setup", mfaHandler.Setup)
+				users.POST("/me/mfa/enable", mfaHandler.Enable)
+				users.POST("/me/mfa/disable", mfaHandler.Disable)
+				users.GET("/service-accounts", userHandler.ListServiceAccounts)
+				users.POST("/service-accounts", userHandler.CreateServiceAccount)
 				users.GET("/:id", userHandler.GetByID)
 				users.PUT("/:id", userHandler.Update)
 				users.DELETE("/:id", userHandler.Delete)
+				users.GET("/:id/sessions", userHandler.ListSessions)
+				users.DELETE("/:id/sessions", userHandler.RevokeSessions) // 强制下线
+				users.POST("/:id/unlock", userHandler.Unlock)             // 解除登录失败锁定
+				users.DELETE("/:id/mfa", userHandler.ResetMFA)            // 管理员停用多因素认证
+				users.GET("/:id/tokens", userHandler.ListTokens)
+				users.POST("/:id/tokens", userHandler.CreateToken)
+				users.DELETE("/:id/tokens/:token_id", userHandler.RevokeToken)
+				users.GET("/:id/roles", requirePermission(authz.RoleManage), roleHandler.GetUserRoles)
+				users.PUT("/:id/roles", requirePermission(authz.RoleManage), roleHandler.SetUserRoles)
+			}
+
+			// 角色和权限管理
+			protected.GET("/permissions", requirePermission(authz.RoleManage), roleHandler.ListPermissions)
+			roles := protected.Group("/roles")
+			roles.Use(requirePermission(authz.RoleManage))
+			{
+				roles.GET("", roleHandler.List)
+				roles.POST("", roleHandler.Create)
+				roles.PUT("/:id", roleHandler.Update)
+				roles.DELETE("/:id", roleHandler.Delete)
 			}
 
 			// 日志管理
@@ -82,7 +157,8 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			{
 				logHandler := NewLogHandler(logService)
 				logs.GET("", logHandler.List)
-				logs.GET("/:id", logHandler.GetByID)
+				logs.GET("/login-attempts", logHandler.ListLoginAttempts)
+				logs.GET("/:id", requirePermission(authz.LogRead), logHandler.GetByID)
 			}
 
 			// 资源管理
@@ -96,6 +172,9 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 				resources.DELETE("/:id", resourceHandler.Delete)
 				resources.POST("/:id/test", resourceHandler.TestConnection)                    // 测试已有资源连接
 				resources.POST("/test-connection", resourceHandler.TestConnectionBeforeCreate) // 创建前测试连接
+				resources.GET("/:id/grants", resourceHandler.ListGrants)
+				resources.POST("/:id/grants", resourceHandler.Grant) // 授权给用户或角色，已有授权时更新级别
+				resources.DELETE("/:id/grants/:grant_id", resourceHandler.RevokeGrant)
 			}
 
 			// 租户管理
@@ -118,6 +197,13 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		configHandler := NewConfigHandler(cfg)
 		internal.GET("/config", configHandler.GetSharedConfig)
 
+		// 访问令牌黑名单（网关定期同步）
+		internal.GET("/auth/revoked-tokens", authHandler.RevokedTokens)
+		internal.POST("/auth/tokens/verify", authHandler.VerifyAPIToken)
+
+		// 用户有效权限（其他服务的权限检查）
+		internal.GET("/users/:id/permissions", roleHandler.UserPermissionsInternal)
+
 		// 服务间调用的资源API
 		resourceHandler := NewResourceHandler(resourceService)
 		internal.GET("/resources", resourceHandler.ListInternal)
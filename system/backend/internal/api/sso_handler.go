@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ssoStateCookie 保存发起单点登录的浏览器的 state，回调时校验
+const ssoStateCookie = "addp_oidc_state"
+
+type SSOHandler struct {
+	ssoService *service.SSOService
+	logService *service.LogService
+	cfg        *config.Config
+}
+
+func NewSSOHandler(ssoService *service.SSOService, logService *service.LogService, cfg *config.Config) *SSOHandler {
+	return &SSOHandler{
+		ssoService: ssoService,
+		logService: logService,
+		cfg:        cfg,
+	}
+}
+
+// Config 登录页查询是否启用单点登录；启用 LDAP 时即使禁用本地账号也保留用户名密码登录
+func (h *SSOHandler) Config(c *gin.Context) {
+	ldapEnabled := h.cfg.LDAPURL != "" && slices.Contains(h.cfg.AuthBackends, "ldap")
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":             h.ssoService.Enabled(),
+		"display_name":        h.cfg.OIDCDisplayName,
+		"local_login_enabled": h.cfg.LocalLoginEnabled || ldapEnabled,
+	})
+}
+
+// Login 跳转到提供方登录页，redirect 为登录完成后前端跳转的路径
+func (h *SSOHandler) Login(c *gin.Context) {
+	authURL, state, err := h.ssoService.Begin(c.Request.Context(), c.DefaultQuery("redirect", "/"), 0)
+	if err != nil {
+		h.respondWithError(c, err)
+		return
+	}
+	h.setStateCookie(c, state, int(service.SSOStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link 已登录用户关联单点登录账号，返回提供方登录地址由前端跳转
+func (h *SSOHandler) Link(c *gin.Context) {
+	authURL, state, err := h.ssoService.Begin(c.Request.Context(), c.DefaultQuery("redirect", "/"), c.GetUint("user_id"))
+	if err != nil {
+		h.respondWithError(c, err)
+		return
+	}
+	h.setStateCookie(c, state, int(service.SSOStateTTL.Seconds()))
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// Callback 提供方回调，完成后带着令牌（启用多因素认证时为 mfa_token）放在 URL fragment 中跳转到前端
+func (h *SSOHandler) Callback(c *gin.Context) {
+	browserState, _ := c.Cookie(ssoStateCookie)
+	h.setStateCookie(c, "", -1)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.redirectToFrontend(c, url.Values{"error": {firstNonEmpty(c.Query("error_description"), providerErr)}})
+		return
+	}
+
+	result, err := h.ssoService.Complete(c.Request.Context(), c.Query("code"), c.Query("state"), browserState, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		log.Printf("单点登录失败: %v", err)
+		h.recordLogin(c, nil, err.Error())
+		h.redirectToFrontend(c, url.Values{"error": {err.Error()}})
+		return
+	}
+
+	values := url.Values{"redirect": {result.Redirect}}
+	switch {
+	case result.Linked:
+		values.Set("linked", "1")
+	case result.MFA != nil:
+		// 前端提交验证码（POST /api/auth/mfa）后创建会话
+		h.recordLogin(c, result.User, "mfa_required")
+		values.Set("mfa_token", result.MFA.MFAToken)
+		values.Set("expires_in", strconv.Itoa(result.MFA.ExpiresIn))
+	default:
+		h.recordLogin(c, result.User, "")
+		values.Set("access_token", result.Login.AccessToken)
+		values.Set("token_type", result.Login.TokenType)
+		values.Set("expires_in", strconv.Itoa(result.Login.ExpiresIn))
+		values.Set("refresh_token", result.Login.RefreshToken)
+		values.Set("refresh_expires_in", strconv.Itoa(result.Login.RefreshExpiresIn))
+	}
+	h.redirectToFrontend(c, values)
+}
+
+// Identities 当前用户关联的单点登录账号
+func (h *SSOHandler) Identities(c *gin.Context) {
+	identities, err := h.ssoService.ListIdentities(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, identities)
+}
+
+// recordLogin 记录单点登录尝试，user 为空表示失败（失败时通常无法确定用户）；
+// 成功时 reason 非空表示还需要完成后续步骤
+func (h *SSOHandler) recordLogin(c *gin.Context, user *models.User, reason string) {
+	attempt := &models.LoginAttempt{
+		Method:    models.LoginMethodOIDC,
+		Success:   user != nil,
+		Reason:    reason,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if user != nil {
+		attempt.Username = user.Username
+		attempt.UserID = &user.ID
+		attempt.TenantID = user.TenantID
+	}
+	h.logService.RecordLogin(attempt)
+}
+
+// setStateCookie 写入（maxAge 为负时删除）state Cookie：只在回调地址下发送，
+// SameSite=Lax 保证从提供方跳转回来的顶层 GET 请求会携带，跨站的子请求不会携带
+func (h *SSOHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	path := "/"
+	secure := false
+	if u, err := url.Parse(h.cfg.OIDCRedirectURL); err == nil {
+		if u.Path != "" {
+			path = u.Path
+		}
+		secure = u.Scheme == "https"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// redirectToFrontend 结果放在 fragment 中，不会出现在服务端访问日志和 Referer 里
+func (h *SSOHandler) redirectToFrontend(c *gin.Context, values url.Values) {
+	target := strings.SplitN(h.cfg.OIDCFrontendURL, "#", 2)[0]
+	c.Redirect(http.StatusFound, target+"#"+values.Encode())
+}
+
+func (h *SSOHandler) respondWithError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrSSODisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/addp/system/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSetStateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name        string
+		redirectURL string
+		wantPath    string
+		wantSecure  bool
+	}{
+		{"https callback", "https://addp.example.com/api/auth/oidc/callback", "/api/auth/oidc/callback", true},
+		{"http callback", "http://localhost:8000/api/auth/oidc/callback", "/api/auth/oidc/callback", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &SSOHandler{cfg: &config.Config{OIDCRedirectURL: tc.redirectURL}}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			h.setStateCookie(c, "state-1", 600)
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("cookies = %v", cookies)
+			}
+			cookie := cookies[0]
+			if cookie.Name != ssoStateCookie || cookie.Value != "state-1" || cookie.MaxAge != 600 {
+				t.Fatalf("cookie = %+v", cookie)
+			}
+			if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+				t.Fatalf("cookie must be HttpOnly and SameSite=Lax: %+v", cookie)
+			}
+			if cookie.Path != tc.wantPath || cookie.Secure != tc.wantSecure {
+				t.Fatalf("path = %q, secure = %v, want %q, %v", cookie.Path, cookie.Secure, tc.wantPath, tc.wantSecure)
+			}
+		})
+	}
+
+	// 回调后删除
+	h := &SSOHandler{cfg: &config.Config{OIDCRedirectURL: "https://addp.example.com/api/auth/oidc/callback"}}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	h.setStateCookie(c, "", -1)
+	if cookie := w.Result().Cookies()[0]; cookie.MaxAge >= 0 || cookie.Value != "" {
+		t.Fatalf("cleared cookie = %+v", cookie)
+	}
+}
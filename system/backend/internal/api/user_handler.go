@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -79,9 +80,9 @@ func (h *UserHandler) Update(c *gin.Context) {
 	}
 
 	currentUserID := c.GetUint("user_id")
-	user, err := h.userService.Update(uint(id), &req, currentUserID)
+	user, err := h.userService.Update(uint(id), &req, currentUserID, interactive(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -104,6 +105,162 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
+// ListSessions 查询用户当前有效的登录会话
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+	sessions, err := h.userService.ListSessions(uint(id), currentUserID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSessions 强制用户下线
+func (h *UserHandler) RevokeSessions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+	if err := h.userService.RevokeSessions(uint(id), currentUserID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已强制下线"})
+}
+
+// Unlock 解除因登录失败被锁定的账号
+func (h *UserHandler) Unlock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.userService.Unlock(uint(id), c.GetUint("user_id")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除锁定"})
+}
+
+// ResetMFA 停用用户的多因素认证（用户丢失身份验证器时）
+func (h *UserHandler) ResetMFA(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.userService.ResetMFA(uint(id), c.GetUint("user_id")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已停用多因素认证"})
+}
+
+// CreateServiceAccount 在当前租户创建服务账号
+func (h *UserHandler) CreateServiceAccount(c *gin.Context) {
+	var req models.ServiceAccountCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.CreateServiceAccount(&req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// ListServiceAccounts 查询当前租户的服务账号
+func (h *UserHandler) ListServiceAccounts(c *gin.Context) {
+	users, err := h.userService.ListServiceAccounts(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// ListTokens 查询用户的访问令牌（不含令牌明文）
+func (h *UserHandler) ListTokens(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	tokens, err := h.userService.ListTokens(uint(id), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken 创建访问令牌，令牌明文只在响应中返回一次
+func (h *UserHandler) CreateToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req models.APITokenCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.userService.CreateToken(uint(id), &req, c.GetUint("user_id"), interactive(c))
+	if err != nil {
+		c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeToken 吊销访问令牌
+func (h *UserHandler) RevokeToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+	tokenID, err := strconv.ParseUint(c.Param("token_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的令牌ID"})
+		return
+	}
+
+	if err := h.userService.RevokeToken(uint(id), uint(tokenID), c.GetUint("user_id")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "访问令牌已吊销"})
+}
+
 func (h *UserHandler) Me(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	user, err := h.userService.GetByID(userID, userID)
@@ -113,4 +270,17 @@ func (h *UserHandler) Me(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, user)
+}
+
+// interactive 请求是否来自登录会话（JWT），使用访问令牌时为 false
+func interactive(c *gin.Context) bool {
+	return c.GetString("auth_method") == "jwt"
+}
+
+// userErrorStatus 需要登录会话的操作返回 403，其他错误返回 400
+func userErrorStatus(err error) int {
+	if errors.Is(err, service.ErrSessionRequired) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
 }
\ No newline at end of file
@@ -4,16 +4,21 @@ import (
 	"encoding/base64"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	Env                     string
 	ServerAddr              string
+	MetricsPort             string // Prometheus 指标的内部端口，不经过服务端口暴露，为空时不监听
 	DatabaseURL             string
 	JWTSecret               string
+	IdentitySecret          string // Gateway 签名身份头的密钥，与 JWT 密钥分开，通过共享配置下发
 	EncryptionKey           []byte
 	TokenExpireMinutes      int
+	RefreshTokenExpireHours int
 	ProjectName             string
 	AllowPublicRegistration bool
 
@@ -27,6 +32,62 @@ type Config struct {
 	// 内部 API Key（用于服务间调用）
 	InternalAPIKey string
 
+	// 单点登录（OIDC 授权码 + PKCE），OIDCIssuer 为空时不启用
+	LocalLoginEnabled bool // 为 false 时只有超级管理员可以用用户名密码登录
+	OIDCIssuer        string
+	OIDCClientID      string
+	OIDCClientSecret  string // 公共客户端可以为空，仅依赖 PKCE
+	OIDCRedirectURL   string // System 回调地址，需在提供方登记
+	OIDCScopes        []string
+	OIDCDisplayName   string // 登录页按钮名称
+	OIDCFrontendURL   string // 登录完成后跳转的前端地址，令牌放在 URL fragment 中
+	OIDCUsernameClaim string
+	OIDCTenantClaim   string              // 声明值为租户名，可经 OIDCTenantMap 映射
+	OIDCTenantMap     map[string][]string // 声明值 → 租户名
+	OIDCDefaultTenant string              // 声明中没有租户时使用的租户名
+	OIDCGroupsClaim   string
+	OIDCGroupRoleMap  map[string][]string // 组 → 角色名（内置角色或租户角色），配置后每次登录按组同步角色
+	OIDCAutoProvision bool                // 首次登录时自动创建用户
+	OIDCLinkByEmail   bool                // 按已验证的邮箱自动关联已有本地账号
+
+	// 用户名密码登录依次尝试的认证后端（ldap、local）
+	AuthBackends []string
+
+	// LDAP / Active Directory，LDAPURL 为空时不启用
+	LDAPURL               string // ldap://host:389 或 ldaps://host:636
+	LDAPStartTLS          bool
+	LDAPTLSSkipVerify     bool
+	LDAPCACertFile        string // 自签名 CA 证书（PEM）
+	LDAPBindDN            string // 查询用户和组的服务账号，为空时匿名查询
+	LDAPBindPassword      string
+	LDAPUserBaseDN        string
+	LDAPUserFilter        string // {username} 替换为转义后的登录名
+	LDAPUsernameAttribute string
+	LDAPEmailAttribute    string
+	LDAPNameAttribute     string
+	LDAPTenantAttribute   string              // 属性值为租户名（如部门），可经 LDAPTenantMap 映射
+	LDAPTenantMap         map[string][]string // 属性值 → 租户名
+	LDAPDefaultTenant     string
+	LDAPGroupBaseDN       string              // 为空时使用 LDAPUserBaseDN
+	LDAPGroupFilter       string              // {dn} 替换为转义后的用户 DN，{username} 替换为登录名
+	LDAPGroupAttribute    string              // 组名属性
+	LDAPGroupRoleMap      map[string][]string // 组名 → 角色名，配置后登录时和定期按组同步角色
+	LDAPSyncInterval      time.Duration
+	LDAPAutoProvision     bool
+
+	// 密码策略，0 表示不限制
+	PasswordMinLength      int
+	PasswordMinCharClasses int           // 至少包含的字符类别数（大写、小写、数字、符号）
+	PasswordHistory        int           // 不能与最近 N 次使用过的密码相同
+	PasswordMaxAge         time.Duration // 密码有效期，过期后必须修改密码才能登录
+
+	// 登录失败锁定，LoginMaxFailedAttempts 为 0 时不锁定
+	LoginMaxFailedAttempts int
+	LoginLockoutDuration   time.Duration // 锁定时长，0 表示需要管理员解锁
+
+	// 多因素认证（TOTP）
+	MFAIssuer string // 身份验证器应用中显示的名称
+
 	// 地图服务配置
 	AMapKey         string
 	AMapSecurityKey string
@@ -40,10 +101,13 @@ func Load() *Config {
 	return &Config{
 		Env:                     getEnv("ENV", "development"),
 		ServerAddr:              getEnv("SERVER_ADDR", ":8080"),
+		MetricsPort:             getEnv("METRICS_PORT", "9090"),
 		DatabaseURL:             "", // PostgreSQL 不使用此字段
 		JWTSecret:               getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		IdentitySecret:          getEnv("IDENTITY_SECRET", "your-identity-secret-change-in-production"),
 		EncryptionKey:           encryptionKey,
 		TokenExpireMinutes:      30,
+		RefreshTokenExpireHours: getEnvAsInt("REFRESH_TOKEN_EXPIRE_HOURS", 168),
 		ProjectName:             getEnv("PROJECT_NAME", "全域数据平台"),
 		AllowPublicRegistration: getEnvAsBool("ALLOW_PUBLIC_REGISTRATION", false),
 
@@ -57,6 +121,56 @@ func Load() *Config {
 		// 内部 API Key（可选，用于服务间调用安全）
 		InternalAPIKey: getEnv("INTERNAL_API_KEY", ""),
 
+		// 单点登录
+		LocalLoginEnabled: getEnvAsBool("LOCAL_LOGIN_ENABLED", true),
+		OIDCIssuer:        getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:      getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:   getEnv("OIDC_REDIRECT_URL", "http://localhost:8000/api/auth/oidc/callback"),
+		OIDCScopes:        strings.Fields(getEnv("OIDC_SCOPES", "openid profile email groups")),
+		OIDCDisplayName:   getEnv("OIDC_DISPLAY_NAME", "企业单点登录"),
+		OIDCFrontendURL:   getEnv("OIDC_FRONTEND_URL", "/login"),
+		OIDCUsernameClaim: getEnv("OIDC_USERNAME_CLAIM", "preferred_username"),
+		OIDCTenantClaim:   getEnv("OIDC_TENANT_CLAIM", "tenant"),
+		OIDCTenantMap:     getEnvAsMap("OIDC_TENANT_MAP"),
+		OIDCDefaultTenant: getEnv("OIDC_DEFAULT_TENANT", ""),
+		OIDCGroupsClaim:   getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCGroupRoleMap:  getEnvAsMap("OIDC_GROUP_ROLE_MAP"),
+		OIDCAutoProvision: getEnvAsBool("OIDC_AUTO_PROVISION", true),
+		OIDCLinkByEmail:   getEnvAsBool("OIDC_LINK_BY_EMAIL", false),
+
+		// LDAP / Active Directory
+		AuthBackends:          loadAuthBackends(),
+		LDAPURL:               getEnv("LDAP_URL", ""),
+		LDAPStartTLS:          getEnvAsBool("LDAP_START_TLS", false),
+		LDAPTLSSkipVerify:     getEnvAsBool("LDAP_TLS_SKIP_VERIFY", false),
+		LDAPCACertFile:        getEnv("LDAP_CA_CERT_FILE", ""),
+		LDAPBindDN:            getEnv("LDAP_BIND_DN", ""),
+		LDAPBindPassword:      getEnv("LDAP_BIND_PASSWORD", ""),
+		LDAPUserBaseDN:        getEnv("LDAP_USER_BASE_DN", ""),
+		LDAPUserFilter:        getEnv("LDAP_USER_FILTER", "(&(objectClass=user)(sAMAccountName={username}))"),
+		LDAPUsernameAttribute: getEnv("LDAP_USERNAME_ATTRIBUTE", "sAMAccountName"),
+		LDAPEmailAttribute:    getEnv("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		LDAPNameAttribute:     getEnv("LDAP_NAME_ATTRIBUTE", "displayName"),
+		LDAPTenantAttribute:   getEnv("LDAP_TENANT_ATTRIBUTE", "department"),
+		LDAPTenantMap:         getEnvAsMap("LDAP_TENANT_MAP"),
+		LDAPDefaultTenant:     getEnv("LDAP_DEFAULT_TENANT", ""),
+		LDAPGroupBaseDN:       getEnv("LDAP_GROUP_BASE_DN", ""),
+		LDAPGroupFilter:       getEnv("LDAP_GROUP_FILTER", "(&(objectClass=group)(member={dn}))"),
+		LDAPGroupAttribute:    getEnv("LDAP_GROUP_ATTRIBUTE", "cn"),
+		LDAPGroupRoleMap:      getEnvAsMap("LDAP_GROUP_ROLE_MAP"),
+		LDAPSyncInterval:      time.Duration(getEnvAsInt("LDAP_SYNC_INTERVAL_MINUTES", 60)) * time.Minute,
+		LDAPAutoProvision:     getEnvAsBool("LDAP_AUTO_PROVISION", true),
+
+		// 密码策略和登录锁定
+		PasswordMinLength:      getEnvAsCount("PASSWORD_MIN_LENGTH", 8),
+		PasswordMinCharClasses: getEnvAsCount("PASSWORD_MIN_CHAR_CLASSES", 3),
+		PasswordHistory:        getEnvAsCount("PASSWORD_HISTORY", 5),
+		PasswordMaxAge:         time.Duration(getEnvAsCount("PASSWORD_MAX_AGE_DAYS", 0)) * 24 * time.Hour,
+		LoginMaxFailedAttempts: getEnvAsCount("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+		LoginLockoutDuration:   time.Duration(getEnvAsCount("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		MFAIssuer:              getEnv("MFA_ISSUER", "ADDP"),
+
 		// 地图服务配置（默认使用提供的高德开放平台 Key）
 		AMapKey:         getEnv("AMAP_KEY", "7babce80a669a0fac7a8c4c951f7c952"),
 		AMapSecurityKey: getEnv("AMAP_SECURITY_KEY", "5784bbf4bbcffc8815cb44db32439b7d"),
@@ -83,6 +197,56 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsCount 与 getEnvAsInt 相同，但允许设置为 0（表示不限制）
+func getEnvAsCount(key string, defaultValue int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsMap 解析 "key1=value1,key2=value2" 格式的映射，同一个 key 可以出现多次
+func getEnvAsMap(key string) map[string][]string {
+	result := make(map[string][]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			continue
+		}
+		result[k] = append(result[k], v)
+	}
+	return result
+}
+
+// loadAuthBackends 未配置 AUTH_BACKENDS 时，启用 LDAP 则先 LDAP 后本地，否则只用本地账号
+func loadAuthBackends() []string {
+	var backends []string
+	for _, name := range strings.Split(os.Getenv("AUTH_BACKENDS"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			backends = append(backends, name)
+		}
+	}
+	if len(backends) > 0 {
+		return backends
+	}
+	if os.Getenv("LDAP_URL") != "" {
+		return []string{"ldap", "local"}
+	}
+	return []string{"local"}
+}
+
 // loadEncryptionKey 加载加密密钥 (32字节 AES-256)
 func loadEncryptionKey() []byte {
 	keyStr := os.Getenv("ENCRYPTION_KEY")
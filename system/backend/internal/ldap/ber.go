@@ -0,0 +1,200 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BER 标识字节的类别和构造位，LDAP 只用到单字节标签
+const (
+	classUniversal   = 0x00
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+)
+
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x10 | constructed
+	tagSet         = 0x11 | constructed
+)
+
+// maxPacketSize 单个响应的上限，防止异常的长度字段耗尽内存
+const maxPacketSize = 16 << 20
+
+// packet BER 编码的一个元素：原始类型保存 value，构造类型保存 children
+type packet struct {
+	tag      byte
+	value    []byte
+	children []*packet
+}
+
+func newSequence(tag byte, children ...*packet) *packet {
+	return &packet{tag: tag, children: children}
+}
+
+func newString(tag byte, s string) *packet {
+	return &packet{tag: tag, value: []byte(s)}
+}
+
+func newInteger(tag byte, n int64) *packet {
+	// 二进制补码，最短编码
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+		if (n == 0 && b[0]&0x80 == 0) || (n == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+	return &packet{tag: tag, value: b}
+}
+
+func newBoolean(v bool) *packet {
+	if v {
+		return &packet{tag: tagBoolean, value: []byte{0xff}}
+	}
+	return &packet{tag: tagBoolean, value: []byte{0x00}}
+}
+
+func (p *packet) isConstructed() bool {
+	return p.tag&constructed != 0
+}
+
+func (p *packet) encode() []byte {
+	content := p.value
+	if p.isConstructed() {
+		content = nil
+		for _, child := range p.children {
+			content = append(content, child.encode()...)
+		}
+	}
+	out := append([]byte{p.tag}, encodeLength(len(content))...)
+	return append(out, content...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// readPacket 从连接读取一个完整的 BER 元素
+func readPacket(r *bufio.Reader) (*packet, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if tag&0x1f == 0x1f {
+		return nil, errors.New("ldap: multi-byte BER tags are not supported")
+	}
+	length, err := readLength(r)
+	if err != nil {
+		return nil, err
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return parsePacket(tag, content)
+}
+
+func readLength(r *bufio.Reader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b < 0x80 {
+		return int(b), nil
+	}
+	count := int(b & 0x7f)
+	if count == 0 || count > 4 {
+		return 0, errors.New("ldap: unsupported BER length")
+	}
+	length := 0
+	for i := 0; i < count; i++ {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		length = length<<8 | int(b)
+	}
+	if length > maxPacketSize {
+		return 0, fmt.Errorf("ldap: packet too large (%d bytes)", length)
+	}
+	return length, nil
+}
+
+func parsePacket(tag byte, content []byte) (*packet, error) {
+	p := &packet{tag: tag}
+	if !p.isConstructed() {
+		p.value = content
+		return p, nil
+	}
+	for len(content) > 0 {
+		if len(content) < 2 {
+			return nil, errors.New("ldap: truncated BER element")
+		}
+		childTag := content[0]
+		length, n, err := parseLength(content[1:])
+		if err != nil {
+			return nil, err
+		}
+		start := 1 + n
+		if start+length > len(content) {
+			return nil, errors.New("ldap: truncated BER element")
+		}
+		child, err := parsePacket(childTag, content[start:start+length])
+		if err != nil {
+			return nil, err
+		}
+		p.children = append(p.children, child)
+		content = content[start+length:]
+	}
+	return p, nil
+}
+
+func parseLength(b []byte) (length, n int, err error) {
+	if b[0] < 0x80 {
+		return int(b[0]), 1, nil
+	}
+	count := int(b[0] & 0x7f)
+	if count == 0 || count > 4 || len(b) < 1+count {
+		return 0, 0, errors.New("ldap: unsupported BER length")
+	}
+	for _, c := range b[1 : 1+count] {
+		length = length<<8 | int(c)
+	}
+	return length, 1 + count, nil
+}
+
+func (p *packet) int() int64 {
+	var n int64
+	for i, b := range p.value {
+		if i == 0 && b&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int64(b)
+	}
+	return n
+}
+
+func (p *packet) string() string {
+	return string(p.value)
+}
+
+func (p *packet) child(i int) *packet {
+	if i < len(p.children) {
+		return p.children[i]
+	}
+	return &packet{}
+}
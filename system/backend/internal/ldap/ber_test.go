@@ -0,0 +1,163 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, data []byte) (*packet, error) {
+	t.Helper()
+	return readPacket(bufio.NewReader(bytes.NewReader(data)))
+}
+
+func assertPacketEqual(t *testing.T, got, want *packet) {
+	t.Helper()
+	if got.tag != want.tag {
+		t.Fatalf("tag = 0x%02x, want 0x%02x", got.tag, want.tag)
+	}
+	if !want.isConstructed() {
+		if !bytes.Equal(got.value, want.value) {
+			t.Fatalf("value of tag 0x%02x = %x, want %x", want.tag, got.value, want.value)
+		}
+		return
+	}
+	if len(got.children) != len(want.children) {
+		t.Fatalf("tag 0x%02x has %d children, want %d", want.tag, len(got.children), len(want.children))
+	}
+	for i := range want.children {
+		assertPacketEqual(t, got.children[i], want.children[i])
+	}
+}
+
+func TestEncodeLength(t *testing.T) {
+	cases := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7f, []byte{0x7f}},
+		{0x80, []byte{0x81, 0x80}},
+		{0xff, []byte{0x81, 0xff}},
+		{0x100, []byte{0x82, 0x01, 0x00}},
+		{70000, []byte{0x83, 0x01, 0x11, 0x70}},
+	}
+	for _, tc := range cases {
+		if got := encodeLength(tc.n); !bytes.Equal(got, tc.want) {
+			t.Errorf("encodeLength(%d) = %x, want %x", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		p    *packet
+	}{
+		{"short string", newString(tagOctetString, "cn=admin,dc=example,dc=com")},
+		{"empty string", newString(tagOctetString, "")},
+		{"one byte long form", newString(tagOctetString, strings.Repeat("a", 200))},
+		{"three byte long form", newString(tagOctetString, strings.Repeat("b", 70000))},
+		{"boolean", newBoolean(true)},
+		{"nested sequence", newSequence(tagSequence,
+			newInteger(tagInteger, 7),
+			newSequence(opSearchEntry,
+				newString(tagOctetString, "uid=john,ou=people"),
+				newSequence(tagSequence,
+					newSequence(tagSequence,
+						newString(tagOctetString, "memberOf"),
+						newSequence(tagSet,
+							newString(tagOctetString, strings.Repeat("cn=group,", 30)),
+							newString(tagOctetString, "cn=admins"),
+						),
+					),
+				),
+			),
+		)},
+		{"empty sequence", newSequence(tagSequence)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decode(t, tc.p.encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertPacketEqual(t, got, tc.p)
+		})
+	}
+}
+
+func TestIntegerRoundTrip(t *testing.T) {
+	cases := []struct {
+		n       int64
+		encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x00, 0x80}},
+		{256, []byte{0x01, 0x00}},
+		{-1, []byte{0xff}},
+		{-128, []byte{0x80}},
+		{-129, []byte{0xff, 0x7f}},
+		{1 << 40, []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, tc := range cases {
+		p := newInteger(tagInteger, tc.n)
+		if !bytes.Equal(p.value, tc.encoded) {
+			t.Errorf("newInteger(%d) = %x, want %x", tc.n, p.value, tc.encoded)
+		}
+		got, err := decode(t, p.encode())
+		if err != nil {
+			t.Fatalf("decode %d: %v", tc.n, err)
+		}
+		if got.int() != tc.n {
+			t.Errorf("round trip of %d = %d", tc.n, got.int())
+		}
+	}
+}
+
+func TestReadPacketTruncated(t *testing.T) {
+	full := newSequence(tagSequence,
+		newInteger(tagInteger, 1),
+		newString(tagOctetString, strings.Repeat("x", 300)),
+	).encode()
+	for n := 0; n < len(full); n++ {
+		if _, err := decode(t, full[:n]); err == nil {
+			t.Fatalf("decoding first %d of %d bytes succeeded", n, len(full))
+		}
+	}
+}
+
+func TestReadPacketRejectsMalformed(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"multi-byte tag", []byte{0x1f, 0x01, 0x00}},
+		{"indefinite length", []byte{tagOctetString, 0x80, 0x00, 0x00}},
+		{"length of five bytes", []byte{tagOctetString, 0x85, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}},
+		{"length over limit", []byte{tagOctetString, 0x84, 0x7f, 0xff, 0xff, 0xff}},
+		// 外层长度完整，但子元素声明的长度超过外层内容
+		{"child longer than parent", []byte{tagSequence, 0x03, tagOctetString, 0x05, 'a'}},
+		{"child missing length", []byte{tagSequence, 0x01, tagOctetString}},
+		{"child long form truncated", []byte{tagSequence, 0x03, tagOctetString, 0x82, 0x01}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := decode(t, tc.data); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestPacketChildOutOfRange(t *testing.T) {
+	p := newSequence(tagSequence, newString(tagOctetString, "a"))
+	if got := p.child(5).string(); got != "" {
+		t.Fatalf("missing child = %q, want empty", got)
+	}
+	if got := p.child(5).int(); got != 0 {
+		t.Fatalf("missing child int = %d, want 0", got)
+	}
+}
@@ -0,0 +1,307 @@
+// Package ldap 最小的 LDAPv3 客户端：简单绑定、搜索和 StartTLS，满足 System 的目录认证和组同步
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 协议操作的应用标签（RFC 4511 4.2 起）
+const (
+	opBindRequest       = classApplication | constructed | 0
+	opBindResponse      = classApplication | constructed | 1
+	opUnbindRequest     = classApplication | 2
+	opSearchRequest     = classApplication | constructed | 3
+	opSearchEntry       = classApplication | constructed | 4
+	opSearchDone        = classApplication | constructed | 5
+	opSearchReference   = classApplication | constructed | 19
+	opExtendedRequest   = classApplication | constructed | 23
+	opExtendedResponse  = classApplication | constructed | 24
+	authSimple          = classContext | 0
+	extendedRequestName = classContext | 0
+)
+
+const oidStartTLS = "1.3.6.1.4.1.1466.20037"
+
+// 常用的结果码
+const (
+	ResultSuccess            = 0
+	ResultSizeLimitExceeded  = 4
+	ResultNoSuchObject       = 32
+	ResultInvalidCredentials = 49
+)
+
+// Scope 搜索范围
+type Scope int
+
+const (
+	ScopeBase Scope = iota
+	ScopeOneLevel
+	ScopeSubtree
+)
+
+// Error 服务端返回的非成功结果
+type Error struct {
+	ResultCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.ResultCode)
+	}
+	return fmt.Sprintf("ldap: result code %d: %s", e.ResultCode, e.Message)
+}
+
+// IsResultCode 判断错误是否为指定结果码
+func IsResultCode(err error, code int) bool {
+	var ldapErr *Error
+	return errors.As(err, &ldapErr) && ldapErr.ResultCode == code
+}
+
+// SearchRequest 搜索参数，Filter 为 RFC 4515 字符串形式
+type SearchRequest struct {
+	BaseDN     string
+	Scope      Scope
+	Filter     string
+	Attributes []string
+	SizeLimit  int
+}
+
+// Entry 搜索结果，属性名统一为小写
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Get 返回属性的第一个值
+func (e *Entry) Get(name string) string {
+	if values := e.Attributes[strings.ToLower(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values 返回属性的全部值
+func (e *Entry) Values(name string) []string {
+	return e.Attributes[strings.ToLower(name)]
+}
+
+// Conn 一个 LDAP 连接，操作按顺序同步执行，不能并发使用
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	host    string
+	msgID   int64
+	timeout time.Duration
+}
+
+// Dial 连接 ldap:// 或 ldaps:// 地址，tlsConfig 为空时使用系统证书
+func Dial(rawURL string, tlsConfig *tls.Config, timeout time.Duration) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid url %q: %w", rawURL, err)
+	}
+
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	host := u.Hostname()
+	port := u.Port()
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+		conn, err = dialer.Dial("tcp", net.JoinHostPort(host, port))
+	case "ldaps":
+		if port == "" {
+			port = "636"
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), withServerName(tlsConfig, host))
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conn{conn: conn, reader: bufio.NewReader(conn), host: host, timeout: timeout}, nil
+}
+
+// StartTLS 在明文连接上升级为 TLS
+func (c *Conn) StartTLS(tlsConfig *tls.Config) error {
+	request := newSequence(opExtendedRequest, newString(extendedRequestName, oidStartTLS))
+	response, err := c.roundTrip(request, opExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if err := resultError(response); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(c.conn, withServerName(tlsConfig, c.host))
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// Bind 简单绑定；密码为空时拒绝，避免被服务端当作匿名绑定而"认证成功"
+func (c *Conn) Bind(dn, password string) error {
+	if dn != "" && password == "" {
+		return &Error{ResultCode: ResultInvalidCredentials, Message: "empty password"}
+	}
+	request := newSequence(opBindRequest,
+		newInteger(tagInteger, 3),
+		newString(tagOctetString, dn),
+		newString(authSimple, password),
+	)
+	response, err := c.roundTrip(request, opBindResponse)
+	if err != nil {
+		return err
+	}
+	return resultError(response)
+}
+
+// Search 执行搜索，超过 SizeLimit 时返回已收到的结果
+func (c *Conn) Search(req *SearchRequest) ([]*Entry, error) {
+	filter, err := compileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	attributes := newSequence(tagSequence)
+	for _, attr := range req.Attributes {
+		attributes.children = append(attributes.children, newString(tagOctetString, attr))
+	}
+	request := newSequence(opSearchRequest,
+		newString(tagOctetString, req.BaseDN),
+		newInteger(tagEnumerated, int64(req.Scope)),
+		newInteger(tagEnumerated, 0), // neverDerefAliases
+		newInteger(tagInteger, int64(req.SizeLimit)),
+		newInteger(tagInteger, int64(c.timeout/time.Second)),
+		newBoolean(false),
+		filter,
+		attributes,
+	)
+
+	id, err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case opSearchEntry:
+			entries = append(entries, parseEntry(op))
+		case opSearchReference:
+			// 不跟随引用
+		case opSearchDone:
+			if err := resultError(op); err != nil && !IsResultCode(err, ResultSizeLimitExceeded) {
+				return nil, err
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("ldap: unexpected response tag 0x%02x", op.tag)
+		}
+	}
+}
+
+// Close 发送 Unbind 并关闭连接
+func (c *Conn) Close() error {
+	c.send(&packet{tag: opUnbindRequest})
+	return c.conn.Close()
+}
+
+func (c *Conn) roundTrip(request *packet, responseTag byte) (*packet, error) {
+	id, err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.receive(id)
+	if err != nil {
+		return nil, err
+	}
+	if response.tag != responseTag {
+		return nil, fmt.Errorf("ldap: unexpected response tag 0x%02x", response.tag)
+	}
+	return response, nil
+}
+
+func (c *Conn) send(op *packet) (int64, error) {
+	c.msgID++
+	message := newSequence(tagSequence, newInteger(tagInteger, c.msgID), op)
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(message.encode())
+	return c.msgID, err
+}
+
+// receive 读取指定消息 ID 的下一个响应；消息 ID 为 0 的是服务端断开通知
+func (c *Conn) receive(id int64) (*packet, error) {
+	for {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+		message, err := readPacket(c.reader)
+		if err != nil {
+			return nil, err
+		}
+		if message.tag != tagSequence || len(message.children) < 2 {
+			return nil, errors.New("ldap: malformed message")
+		}
+		switch message.child(0).int() {
+		case id:
+			return message.child(1), nil
+		case 0:
+			if err := resultError(message.child(1)); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("ldap: server closed the connection")
+		}
+	}
+}
+
+// resultError 解析 LDAPResult（resultCode, matchedDN, diagnosticMessage）
+func resultError(result *packet) error {
+	code := int(result.child(0).int())
+	if code == ResultSuccess {
+		return nil
+	}
+	return &Error{ResultCode: code, Message: result.child(2).string()}
+}
+
+func parseEntry(op *packet) *Entry {
+	entry := &Entry{DN: op.child(0).string(), Attributes: make(map[string][]string)}
+	for _, attr := range op.child(1).children {
+		name := strings.ToLower(attr.child(0).string())
+		for _, value := range attr.child(1).children {
+			entry.Attributes[name] = append(entry.Attributes[name], value.string())
+		}
+	}
+	return entry
+}
+
+func withServerName(tlsConfig *tls.Config, host string) *tls.Config {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+	return tlsConfig
+}
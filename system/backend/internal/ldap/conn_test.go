@@ -0,0 +1,213 @@
+package ldap
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// serveFake 在内存连接上模拟 LDAP 服务端：handle 返回对请求的响应操作，按请求的消息 ID 依次发回
+func serveFake(t *testing.T, handle func(op *packet) []*packet) *Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go func() {
+		reader := bufio.NewReader(server)
+		for {
+			message, err := readPacket(reader)
+			if err != nil {
+				return
+			}
+			id := message.child(0).int()
+			for _, response := range handle(message.child(1)) {
+				out := newSequence(tagSequence, newInteger(tagInteger, id), response)
+				if _, err := server.Write(out.encode()); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return &Conn{conn: client, reader: bufio.NewReader(client), host: "fake", timeout: 2 * time.Second}
+}
+
+func ldapResult(tag byte, code int, message string) *packet {
+	return newSequence(tag,
+		newInteger(tagEnumerated, int64(code)),
+		newString(tagOctetString, ""),
+		newString(tagOctetString, message),
+	)
+}
+
+func searchEntry(dn string, attr string, values ...string) *packet {
+	set := newSequence(tagSet)
+	for _, v := range values {
+		set.children = append(set.children, newString(tagOctetString, v))
+	}
+	return newSequence(opSearchEntry,
+		newString(tagOctetString, dn),
+		newSequence(tagSequence, newSequence(tagSequence, newString(tagOctetString, attr), set)),
+	)
+}
+
+func TestBindResultCodes(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     int
+		wantCode int
+	}{
+		{"success", ResultSuccess, ResultSuccess},
+		{"invalid credentials", ResultInvalidCredentials, ResultInvalidCredentials},
+		{"other error", 53, 53}, // unwillingToPerform
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotDN, gotPassword string
+			var gotVersion int64
+			conn := serveFake(t, func(op *packet) []*packet {
+				if op.tag != opBindRequest {
+					t.Errorf("request tag = 0x%02x, want bind", op.tag)
+				}
+				gotVersion, gotDN, gotPassword = op.child(0).int(), op.child(1).string(), op.child(2).string()
+				return []*packet{ldapResult(opBindResponse, tc.code, "diagnostic")}
+			})
+
+			err := conn.Bind("uid=john,dc=example,dc=com", "secret")
+			if gotVersion != 3 || gotDN != "uid=john,dc=example,dc=com" || gotPassword != "secret" {
+				t.Fatalf("bind request = (%d, %q, %q)", gotVersion, gotDN, gotPassword)
+			}
+			if tc.wantCode == ResultSuccess {
+				if err != nil {
+					t.Fatalf("Bind: %v", err)
+				}
+				return
+			}
+			if !IsResultCode(err, tc.wantCode) {
+				t.Fatalf("Bind error = %v, want result code %d", err, tc.wantCode)
+			}
+			if ldapErr := err.(*Error); ldapErr.Message != "diagnostic" {
+				t.Fatalf("message = %q, want diagnostic", ldapErr.Message)
+			}
+		})
+	}
+}
+
+func TestBindRejectsEmptyPassword(t *testing.T) {
+	// 不应发送到服务端：很多目录把空密码的简单绑定当作匿名绑定成功
+	conn := serveFake(t, func(op *packet) []*packet {
+		t.Error("empty password bind reached the server")
+		return []*packet{ldapResult(opBindResponse, ResultSuccess, "")}
+	})
+	if err := conn.Bind("uid=john,dc=example,dc=com", ""); !IsResultCode(err, ResultInvalidCredentials) {
+		t.Fatalf("Bind error = %v, want invalid credentials", err)
+	}
+}
+
+func TestBindUnexpectedResponse(t *testing.T) {
+	conn := serveFake(t, func(op *packet) []*packet {
+		return []*packet{ldapResult(opSearchDone, ResultSuccess, "")}
+	})
+	if err := conn.Bind("cn=admin", "secret"); err == nil {
+		t.Fatal("expected an error for a mismatched response")
+	}
+}
+
+func TestSearchResultCodes(t *testing.T) {
+	entries := []*packet{
+		searchEntry("uid=a,dc=example", "memberOf", "cn=dev", "cn=ops"),
+		newSequence(opSearchReference, newString(tagOctetString, "ldap://other/dc=example")),
+		searchEntry("uid=b,dc=example", "MAIL", "b@example.com"),
+	}
+	cases := []struct {
+		name     string
+		code     int
+		wantErr  int
+		wantDNs  []string
+		wantMail string
+	}{
+		{"success", ResultSuccess, ResultSuccess, []string{"uid=a,dc=example", "uid=b,dc=example"}, "b@example.com"},
+		{"size limit exceeded returns partial results", ResultSizeLimitExceeded, ResultSuccess, []string{"uid=a,dc=example", "uid=b,dc=example"}, "b@example.com"},
+		{"no such object", ResultNoSuchObject, ResultNoSuchObject, nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var request *packet
+			conn := serveFake(t, func(op *packet) []*packet {
+				request = op
+				return append(append([]*packet{}, entries...), ldapResult(opSearchDone, tc.code, ""))
+			})
+
+			got, err := conn.Search(&SearchRequest{
+				BaseDN:     "dc=example",
+				Scope:      ScopeSubtree,
+				Filter:     "(uid=" + EscapeFilter("a*") + ")",
+				Attributes: []string{"memberOf", "mail"},
+				SizeLimit:  2,
+			})
+			if request.tag != opSearchRequest || request.child(0).string() != "dc=example" ||
+				request.child(1).int() != int64(ScopeSubtree) || request.child(3).int() != 2 {
+				t.Fatalf("unexpected search request %+v", request)
+			}
+
+			if tc.wantErr != ResultSuccess {
+				if !IsResultCode(err, tc.wantErr) {
+					t.Fatalf("Search error = %v, want result code %d", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			var dns []string
+			for _, e := range got {
+				dns = append(dns, e.DN)
+			}
+			if !reflect.DeepEqual(dns, tc.wantDNs) {
+				t.Fatalf("DNs = %v, want %v", dns, tc.wantDNs)
+			}
+			if values := got[0].Values("memberof"); !reflect.DeepEqual(values, []string{"cn=dev", "cn=ops"}) {
+				t.Fatalf("memberOf = %v", values)
+			}
+			if mail := got[1].Get("mail"); mail != tc.wantMail {
+				t.Fatalf("mail = %q, want %q", mail, tc.wantMail)
+			}
+		})
+	}
+}
+
+func TestSearchInvalidFilter(t *testing.T) {
+	conn := serveFake(t, func(op *packet) []*packet {
+		t.Error("invalid filter reached the server")
+		return nil
+	})
+	if _, err := conn.Search(&SearchRequest{BaseDN: "dc=example", Filter: "(uid=a"}); err == nil {
+		t.Fatal("expected a filter error")
+	}
+}
+
+func TestReceiveNoticeOfDisconnection(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		reader := bufio.NewReader(server)
+		if _, err := readPacket(reader); err != nil {
+			return
+		}
+		// 消息 ID 为 0 的 Notice of Disconnection（RFC 4511 4.4.1）
+		notice := newSequence(tagSequence, newInteger(tagInteger, 0), ldapResult(opExtendedResponse, 52, "server shutting down"))
+		server.Write(notice.encode())
+	}()
+
+	conn := &Conn{conn: client, reader: bufio.NewReader(client), timeout: 2 * time.Second}
+	if err := conn.Bind("cn=admin", "secret"); !IsResultCode(err, 52) {
+		t.Fatalf("Bind error = %v, want result code 52", err)
+	}
+}
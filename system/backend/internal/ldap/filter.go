@@ -0,0 +1,189 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// 过滤器的上下文标签（RFC 4511 4.5.1）
+const (
+	filterAnd            = classContext | constructed | 0
+	filterOr             = classContext | constructed | 1
+	filterNot            = classContext | constructed | 2
+	filterEquality       = classContext | constructed | 3
+	filterSubstrings     = classContext | constructed | 4
+	filterGreaterOrEqual = classContext | constructed | 5
+	filterLessOrEqual    = classContext | constructed | 6
+	filterPresent        = classContext | 7
+	filterApprox         = classContext | constructed | 8
+
+	substringInitial = classContext | 0
+	substringAny     = classContext | 1
+	substringFinal   = classContext | 2
+)
+
+// EscapeFilter 转义过滤器中的值（RFC 4515），拼接用户输入时必须使用
+func EscapeFilter(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter 把字符串形式的过滤器编译为 BER，不支持 extensibleMatch
+func compileFilter(filter string) (*packet, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		filter = "(objectClass=*)"
+	}
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+	p, rest, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("ldap: unexpected %q after filter", rest)
+	}
+	return p, nil
+}
+
+// parseFilter 解析一个带括号的过滤器，返回剩余的字符串
+func parseFilter(s string) (*packet, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", fmt.Errorf("ldap: filter must start with '(': %q", s)
+	}
+	s = s[1:]
+	if s == "" {
+		return nil, "", fmt.Errorf("ldap: unterminated filter")
+	}
+
+	switch s[0] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if s[0] == '|' {
+			tag = filterOr
+		}
+		p := newSequence(tag)
+		s = s[1:]
+		for strings.HasPrefix(s, "(") {
+			child, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			p.children = append(p.children, child)
+			s = rest
+		}
+		if !strings.HasPrefix(s, ")") {
+			return nil, "", fmt.Errorf("ldap: unterminated filter")
+		}
+		return p, s[1:], nil
+	case '!':
+		child, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("ldap: unterminated filter")
+		}
+		return newSequence(filterNot, child), rest[1:], nil
+	}
+
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", fmt.Errorf("ldap: unterminated filter")
+	}
+	p, err := parseItem(s[:end])
+	if err != nil {
+		return nil, "", err
+	}
+	return p, s[end+1:], nil
+}
+
+// parseItem 解析 attr=value、attr>=value、attr<=value、attr~=value、attr=* 和子串匹配
+func parseItem(item string) (*packet, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, fmt.Errorf("ldap: invalid filter item %q", item)
+	}
+	attr, value := item[:eq], item[eq+1:]
+
+	tag := byte(filterEquality)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreaterOrEqual, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLessOrEqual, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApprox, attr[:len(attr)-1]
+	case ':':
+		return nil, fmt.Errorf("ldap: extensible match is not supported: %q", item)
+	}
+	if attr == "" {
+		return nil, fmt.Errorf("ldap: invalid filter item %q", item)
+	}
+
+	if tag == filterEquality && value == "*" {
+		return newString(filterPresent, attr), nil
+	}
+	if tag == filterEquality && strings.Contains(value, "*") {
+		parts := strings.Split(value, "*")
+		substrings := newSequence(tagSequence)
+		for i, part := range parts {
+			if part == "" {
+				continue
+			}
+			decoded, err := unescapeValue(part)
+			if err != nil {
+				return nil, err
+			}
+			partTag := byte(substringAny)
+			switch i {
+			case 0:
+				partTag = substringInitial
+			case len(parts) - 1:
+				partTag = substringFinal
+			}
+			substrings.children = append(substrings.children, newString(partTag, decoded))
+		}
+		return newSequence(filterSubstrings, newString(tagOctetString, attr), substrings), nil
+	}
+
+	decoded, err := unescapeValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return newSequence(tag, newString(tagOctetString, attr), newString(tagOctetString, decoded)), nil
+}
+
+// unescapeValue 还原 \XX 形式的转义
+func unescapeValue(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("ldap: invalid escape in %q", value)
+		}
+		c, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("ldap: invalid escape in %q", value)
+		}
+		b.Write(c)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,105 @@
+package ldap
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeFilter(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"john", "john"},
+		{"*", `\2a`},
+		{"(admin)", `\28admin\29`},
+		{`domain\user`, `domain\5cuser`},
+		{"a\x00b", `a\00b`},
+		{"*)(uid=*))(|(uid=*", `\2a\29\28uid=\2a\29\29\28|\28uid=\2a`},
+		{"张三", "张三"},
+	}
+	for _, tc := range cases {
+		if got := EscapeFilter(tc.in); got != tc.want {
+			t.Errorf("EscapeFilter(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestEscapeFilterRoundTrip 转义后的值编译为单个等值匹配，取回的值与原值一致
+func TestEscapeFilterRoundTrip(t *testing.T) {
+	for _, value := range []string{"john", "a*b", "(x)", `back\slash`, "nul\x00byte", "*)(uid=*"} {
+		p, err := compileFilter("(uid=" + EscapeFilter(value) + ")")
+		if err != nil {
+			t.Fatalf("compile %q: %v", value, err)
+		}
+		want := newSequence(filterEquality, newString(tagOctetString, "uid"), newString(tagOctetString, value))
+		if !bytes.Equal(p.encode(), want.encode()) {
+			t.Fatalf("value %q did not compile to a single equality match", value)
+		}
+	}
+}
+
+func equality(attr, value string) *packet {
+	return newSequence(filterEquality, newString(tagOctetString, attr), newString(tagOctetString, value))
+}
+
+func TestCompileFilter(t *testing.T) {
+	cases := []struct {
+		filter string
+		want   *packet
+	}{
+		{"", newString(filterPresent, "objectClass")},
+		{"uid=john", equality("uid", "john")},
+		{"(uid=john)", equality("uid", "john")},
+		{"  (cn=*)  ", newString(filterPresent, "cn")},
+		{"(&(objectClass=person)(|(uid=a)(mail=b)))", newSequence(filterAnd,
+			equality("objectClass", "person"),
+			newSequence(filterOr, equality("uid", "a"), equality("mail", "b")),
+		)},
+		{"(!(cn=x))", newSequence(filterNot, equality("cn", "x"))},
+		{"(age>=18)", newSequence(filterGreaterOrEqual, newString(tagOctetString, "age"), newString(tagOctetString, "18"))},
+		{"(age<=65)", newSequence(filterLessOrEqual, newString(tagOctetString, "age"), newString(tagOctetString, "65"))},
+		{"(cn~=jon)", newSequence(filterApprox, newString(tagOctetString, "cn"), newString(tagOctetString, "jon"))},
+		{"(cn=a*b*c)", newSequence(filterSubstrings, newString(tagOctetString, "cn"), newSequence(tagSequence,
+			newString(substringInitial, "a"),
+			newString(substringAny, "b"),
+			newString(substringFinal, "c"),
+		))},
+		{"(cn=*son)", newSequence(filterSubstrings, newString(tagOctetString, "cn"), newSequence(tagSequence,
+			newString(substringFinal, "son"),
+		))},
+		{`(cn=\28a\29*)`, newSequence(filterSubstrings, newString(tagOctetString, "cn"), newSequence(tagSequence,
+			newString(substringInitial, "(a)"),
+		))},
+	}
+	for _, tc := range cases {
+		t.Run(tc.filter, func(t *testing.T) {
+			got, err := compileFilter(tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.encode(), tc.want.encode()) {
+				t.Fatalf("compileFilter(%q) = %x, want %x", tc.filter, got.encode(), tc.want.encode())
+			}
+		})
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		"(cn=x",
+		"(cn=x))",
+		"(&(cn=x)",
+		"(!(cn=x)",
+		"(=x)",
+		"(>=x)",
+		"(cn)",
+		"(cn:dn:=x)",
+		`(cn=\zz)`,
+		`(cn=abc\2)`,
+		"(",
+	} {
+		if _, err := compileFilter(filter); err == nil {
+			t.Errorf("compileFilter(%q) succeeded, want error", filter)
+		}
+	}
+}
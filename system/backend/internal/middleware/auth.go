@@ -1,16 +1,19 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/addp/common/auth"
 	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/service"
 	"github.com/addp/system/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware JWT 认证中间件（用于用户请求）
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// AuthMiddleware 用户请求认证中间件，接受 JWT 和访问令牌；已注销或被吊销的 JWT（jti 在黑名单中）不能再使用
+func AuthMiddleware(cfg *config.Config, sessionService *service.SessionService, tokenService *service.APITokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,6 +30,11 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		if auth.IsAPIToken(parts[1]) {
+			authenticateAPIToken(c, tokenService, parts[1])
+			return
+		}
+
 		claims, err := utils.ParseToken(parts[1], cfg.JWTSecret)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
@@ -34,12 +42,57 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// 没有 jti 的令牌无法吊销，需要重新登录
+		if claims.ID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+		revoked, err := sessionService.IsTokenRevoked(claims.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "校验认证令牌失败"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_method", "jwt")
 		c.Next()
 	}
 }
 
+// authenticateAPIToken 校验访问令牌及其对 System 的权限范围
+func authenticateAPIToken(c *gin.Context, tokenService *service.APITokenService, raw string) {
+	token, user, err := tokenService.Verify(raw, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAPIToken) || errors.Is(err, service.ErrUserDisabled) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "校验访问令牌失败"})
+		}
+		c.Abort()
+		return
+	}
+	if !auth.ScopeAllows(token.Scopes, "system", c.Request.Method) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "访问令牌的权限范围不允许该操作"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("auth_method", "token")
+	c.Set("token_id", token.ID)
+	c.Next()
+}
+
 // InternalAPIMiddleware 内部 API 认证中间件（用于服务间调用）
 func InternalAPIMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -19,6 +19,7 @@ func LoggerMiddleware(logService *service.LogService, userRepo *repository.UserR
 			log := &models.AuditLog{
 				Action:    c.Request.Method + " " + c.Request.URL.Path,
 				IPAddress: c.ClientIP(),
+				RequestID: c.GetString(RequestIDKey),
 			}
 
 			if exists {
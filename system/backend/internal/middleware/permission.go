@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/addp/system/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 要求当前用户拥有指定权限，需在 AuthMiddleware 之后使用
+func RequirePermission(roleService *service.RoleService, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, err := roleService.UserPermissions(c.GetUint("user_id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		for _, p := range permissions {
+			if p == permission {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "没有权限执行该操作", "permission": permission})
+	}
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// APIToken 个人访问令牌，供脚本和 CI 代替登录使用；只保存令牌的 SHA-256 哈希，明文只在创建时返回一次
+type APIToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	TokenPrefix string     `gorm:"size:32" json:"token_prefix"` // 令牌开头几位，用于识别
+	TokenHash   string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	Scopes      StringList `gorm:"type:json;not null" json:"scopes"`
+	ExpiresAt   time.Time  `gorm:"index" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `gorm:"size:64" json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedBy   *uint      `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type APITokenCreateRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes"`          // 默认 ["read"]
+	ExpiresInDays int      `json:"expires_in_days"` // 默认 90 天
+}
+
+// APITokenCreateResponse 创建结果，Token 为令牌明文
+type APITokenCreateResponse struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+// ServiceAccountCreateRequest 服务账号属于创建者的租户，不能用密码登录，只能使用访问令牌
+type ServiceAccountCreateRequest struct {
+	Username string `json:"username" binding:"required"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// UserIdentity 外部身份（OIDC issuer + sub，或 LDAP 地址 + 用户 DN）与本地用户的关联，一个用户可以关联多个外部身份
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Issuer      string     `gorm:"size:255;not null;uniqueIndex:idx_identity_subject" json:"issuer"`
+	Subject     string     `gorm:"size:255;not null;uniqueIndex:idx_identity_subject" json:"subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OIDCState 进行中的单点登录，保存 PKCE code_verifier 和 nonce，回调时一次性消费
+type OIDCState struct {
+	State        string    `gorm:"primaryKey;size:64"`
+	CodeVerifier string    `gorm:"size:128;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	LinkUserID   *uint     // 已登录用户发起的关联，回调后把外部身份关联到该用户
+	Redirect     string    // 登录完成后前端跳转的路径
+	ExpiresAt    time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
+
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
@@ -14,6 +14,7 @@ type AuditLog struct {
 	ResourceID   string    `json:"resource_id"`
 	Details      string    `gorm:"type:text" json:"details"`
 	IPAddress    string    `json:"ip_address"`
+	RequestID    string    `gorm:"index;size:128" json:"request_id"` // 与网关和各服务日志中的请求 ID 对应
 	CreatedAt    time.Time `gorm:"index" json:"created_at"`
 }
 
@@ -22,4 +23,27 @@ type AuditLogCreateRequest struct {
 	ResourceType string `json:"resource_type"`
 	ResourceID   string `json:"resource_id"`
 	Details      string `json:"details"`
+}
+
+// 登录方式
+const (
+	LoginMethodPassword = "password" // 用户名密码（本地账号或 LDAP）
+	LoginMethodMFA      = "mfa"      // 多因素认证验证码
+	LoginMethodOIDC     = "oidc"     // 单点登录
+	// LoginMethodPasswordChange 登录页使用原密码修改密码，原密码错误同样计入登录失败
+	LoginMethodPasswordChange = "password_change"
+)
+
+// LoginAttempt 登录尝试记录，包括失败的尝试；用户不存在时 UserID 为空
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Username  string    `gorm:"index;size:255" json:"username"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	TenantID  *uint     `gorm:"index" json:"tenant_id"`
+	Method    string    `gorm:"size:20" json:"method"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason"` // 失败原因，或 mfa_required（密码正确，等待验证码）
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
 }
\ No newline at end of file
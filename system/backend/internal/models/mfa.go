@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// MFAChallenge 密码验证通过、等待输入验证码的登录，令牌只保存哈希
+type MFAChallenge struct {
+	TokenHash string    `gorm:"primaryKey;size:64"`
+	UserID    uint      `gorm:"index;not null"`
+	Attempts  int       `gorm:"default:0"` // 验证码错误次数
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// MFAChallengeResponse 需要多因素认证时登录接口的返回值，使用 mfa_token 和验证码调用 /api/auth/mfa 完成登录
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"` // 秒
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFASetupResponse 开始绑定身份验证器，secret 可手动输入，otpauth_url 可生成二维码
+type MFASetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -31,6 +31,7 @@ type Resource struct {
 	IsActive       bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
+	AccessLevel    string         `gorm:"-" json:"access_level,omitempty"` // 当前用户的访问级别，仅用户接口返回
 }
 
 type ResourceCreateRequest struct {
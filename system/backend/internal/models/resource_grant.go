@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// 授权对象类型
+const (
+	GrantSubjectUser   = "user"
+	GrantSubjectRole   = "role"
+	GrantSubjectTenant = "tenant" // 授予资源所在租户的所有用户，subject_id 为租户 ID
+)
+
+// ResourceGrant 资源授权：把资源按访问级别（authz.AccessView 等）授予用户或角色。
+// 默认只有管理员和创建者可以访问，其余用户需要授予本人、其角色或整个租户
+type ResourceGrant struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ResourceID  uint      `gorm:"not null;uniqueIndex:idx_resource_grant_subject" json:"resource_id"`
+	SubjectType string    `gorm:"size:16;not null;uniqueIndex:idx_resource_grant_subject;index:idx_resource_grant_by_subject" json:"subject_type"`
+	SubjectID   uint      `gorm:"not null;uniqueIndex:idx_resource_grant_subject;index:idx_resource_grant_by_subject" json:"subject_id"`
+	Level       string    `gorm:"size:16;not null" json:"level"`
+	CreatedBy   *uint     `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ResourceGrantRequest 授权请求，同一对象已有授权时更新级别
+type ResourceGrantRequest struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=user role tenant"`
+	SubjectID   uint   `json:"subject_id" binding:"required"`
+	Level       string `json:"level" binding:"required"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/addp/common/authz"
+)
+
+// StringList 以 JSON 数组保存的字符串列表
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	}
+	return nil
+}
+
+// 内置角色，所有租户共用，不能修改或删除
+const (
+	RoleTenantAdmin = "tenant_admin" // 租户管理员拥有全部租户权限，无需分配
+	RoleUser        = "user"         // 未分配角色的普通用户使用该角色的权限
+	RoleViewer      = "viewer"
+)
+
+// BuiltinRoles 内置角色的定义，启动时同步到数据库
+var BuiltinRoles = []Role{
+	{
+		Name:        RoleTenantAdmin,
+		Description: "租户管理员：拥有本租户全部权限",
+		Permissions: authz.TenantScoped(),
+	},
+	{
+		Name:        RoleUser,
+		Description: "普通用户：查看资源和日志，使用元数据、数据管理和数据传输",
+		Permissions: StringList{
+			authz.ResourceRead, authz.LogRead,
+			authz.MetaRead, authz.MetaScan,
+			authz.ManagerRead, authz.ManagerPreview, authz.ManagerManage,
+			authz.TransferRead, authz.TransferManage, authz.TransferRun,
+		},
+	},
+	{
+		Name:        RoleViewer,
+		Description: "只读用户：查看资源、元数据、数据目录和传输任务",
+		Permissions: StringList{
+			authz.ResourceRead, authz.MetaRead,
+			authz.ManagerRead, authz.ManagerPreview,
+			authz.TransferRead,
+		},
+	},
+}
+
+// Role 由权限组成的角色；TenantID 为 null 的是内置角色
+type Role struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TenantID    *uint      `gorm:"index" json:"tenant_id"`
+	Name        string     `gorm:"not null;size:64" json:"name"`
+	Description string     `json:"description"`
+	Permissions StringList `gorm:"type:json;not null" json:"permissions"`
+	IsBuiltin   bool       `gorm:"default:false" json:"is_builtin"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// UserRole 用户和角色的分配关系
+type UserRole struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	RoleID    uint      `gorm:"primaryKey;index" json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RoleCreateRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type RoleUpdateRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+}
+
+type UserRolesRequest struct {
+	RoleIDs []uint `json:"role_ids"`
+}
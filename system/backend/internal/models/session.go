@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// 会话吊销原因
+const (
+	RevokeReasonLogout          = "logout"
+	RevokeReasonKicked          = "kicked"
+	RevokeReasonUserDisabled    = "user_disabled"
+	RevokeReasonPasswordChanged = "password_changed"
+	RevokeReasonUserDeleted     = "user_deleted"
+	RevokeReasonRefreshReuse    = "refresh_reuse"
+)
+
+// Session 登录会话，刷新令牌只保存哈希；每次刷新轮换刷新令牌并签发新的访问令牌
+type Session struct {
+	ID               string     `gorm:"primaryKey;size:64" json:"id"`
+	UserID           uint       `gorm:"index;not null" json:"user_id"`
+	RefreshTokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	AccessTokenID    string     `gorm:"size:64" json:"-"` // 当前访问令牌的 jti，历次签发的令牌见 SessionToken
+	AccessExpiresAt  time.Time  `json:"-"`
+	IPAddress        string     `json:"ip_address"`
+	UserAgent        string     `json:"user_agent"`
+	ExpiresAt        time.Time  `gorm:"index" json:"expires_at"` // 刷新令牌过期时间
+	LastRefreshedAt  *time.Time `json:"last_refreshed_at"`
+	RevokedAt        *time.Time `gorm:"index" json:"revoked_at"`
+	RevokeReason     string     `gorm:"size:32" json:"revoke_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RevokedToken 访问令牌黑名单，令牌过期后删除
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64" json:"jti"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// SessionToken 会话签发过的访问令牌，会话吊销时全部加入黑名单；令牌过期后删除
+type SessionToken struct {
+	JTI       string    `gorm:"primaryKey;size:64" json:"jti"`
+	SessionID string    `gorm:"index;size:64;not null" json:"session_id"`
+	UserID    uint      `json:"user_id"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RotatedRefreshToken 已被轮换掉的刷新令牌哈希，再次出现说明刷新令牌被重放，整个会话随之吊销
+type RotatedRefreshToken struct {
+	Hash      string    `gorm:"primaryKey;size:64" json:"-"`
+	SessionID string    `gorm:"index;size:64;not null" json:"session_id"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"` // 与会话的刷新令牌过期时间一致
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
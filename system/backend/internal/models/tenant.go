@@ -17,7 +17,7 @@ type TenantCreateRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	AdminUsername string `json:"admin_username" binding:"required"`
-	AdminPassword string `json:"admin_password" binding:"required,min=6"`
+	AdminPassword string `json:"admin_password" binding:"required"`
 	AdminEmail    string `json:"admin_email"`
 	AdminFullName string `json:"admin_full_name"`
 }
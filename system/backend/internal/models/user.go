@@ -14,23 +14,38 @@ const (
 )
 
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Username     string    `gorm:"uniqueIndex;not null" json:"username"`
-	Email        string    `gorm:"uniqueIndex" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	FullName     string    `json:"full_name"`
-	IsActive     bool      `gorm:"default:true" json:"is_active"`
-	UserType     UserType  `gorm:"type:varchar(20);default:'user';not null" json:"user_type"` // 用户类型
-	TenantID     *uint     `gorm:"index" json:"tenant_id"`                                     // 租户ID (SuperAdmin没有租户)
-	IsSuperuser  bool      `gorm:"default:false" json:"is_superuser"`                          // 保留以兼容旧代码
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	Username          string     `gorm:"uniqueIndex;not null" json:"username"`
+	Email             string     `gorm:"uniqueIndex" json:"email"`
+	PasswordHash      string     `gorm:"not null" json:"-"`
+	FullName          string     `json:"full_name"`
+	IsActive          bool       `gorm:"default:true" json:"is_active"`
+	UserType          UserType   `gorm:"type:varchar(20);default:'user';not null" json:"user_type"` // 用户类型
+	TenantID          *uint      `gorm:"index" json:"tenant_id"`                                    // 租户ID (SuperAdmin没有租户)
+	IsSuperuser       bool       `gorm:"default:false" json:"is_superuser"`                         // 保留以兼容旧代码
+	IsServiceAccount  bool       `gorm:"default:false" json:"is_service_account"`                   // 服务账号只能使用访问令牌
+	PasswordChangedAt *time.Time `json:"password_changed_at"`                                       // 为空时按创建时间计算密码有效期
+	FailedLoginCount  int        `gorm:"default:0" json:"failed_login_count"`
+	LockedAt          *time.Time `json:"locked_at"` // 连续登录失败被锁定的时间
+	MFAEnabled        bool       `gorm:"default:false" json:"mfa_enabled"`
+	MFASecret         string     `json:"-"` // 加密保存的 TOTP 密钥，启用前为待验证的密钥
+	MFALastStep       int64      `json:"-"` // 最近一次使用的 TOTP 时间步，防止验证码重放
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// PasswordHistory 用过的密码哈希，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"index;not null"`
+	PasswordHash string    `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"index"`
 }
 
 type UserCreateRequest struct {
 	Username string   `json:"username" binding:"required"`
 	Email    string   `json:"email"`
-	Password string   `json:"password" binding:"required,min=6"`
+	Password string   `json:"password" binding:"required"` // 复杂度由密码策略校验
 	FullName string   `json:"full_name"`
 	UserType UserType `json:"user_type"` // 用户类型
 }
@@ -48,7 +63,18 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// PasswordChangeRequest 使用原密码修改已过期的密码（不需要登录），启用多因素认证时需要验证码
+type PasswordChangeRequest struct {
+	Username    string `json:"username" binding:"required"`
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+	MFACode     string `json:"mfa_code"`
+}
+
 type LoginResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"` // 访问令牌有效期（秒）
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // 刷新令牌有效期（秒）
 }
\ No newline at end of file
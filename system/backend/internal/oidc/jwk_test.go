@@ -0,0 +1,44 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestPublicKeys(t *testing.T) {
+	set := jwkSet{Keys: []jwk{
+		{Kty: "RSA", Kid: "rsa", N: "sXch", E: "AQAB"},
+		{Kty: "EC", Kid: "ec", Use: "sig", Crv: "P-256", X: "AQ", Y: "Ag"},
+		{Kty: "RSA", Kid: "enc", Use: "enc", N: "sXch", E: "AQAB"},
+		{Kty: "EC", Kid: "curve", Crv: "secp256k1", X: "AQ", Y: "Ag"},
+		{Kty: "oct", Kid: "hmac"},
+		{Kty: "RSA", Kid: "bad", N: "!!", E: "AQAB"},
+	}}
+
+	keys := set.publicKeys()
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys, want 2: %v", len(keys), keys)
+	}
+	rsaKey, ok := keys["rsa"].(*rsa.PublicKey)
+	if !ok || rsaKey.E != 65537 {
+		t.Fatalf("rsa key = %#v", keys["rsa"])
+	}
+	if _, ok := keys["ec"].(*ecdsa.PublicKey); !ok {
+		t.Fatalf("ec key = %#v", keys["ec"])
+	}
+}
+
+func TestLookupKey(t *testing.T) {
+	single := map[string]interface{}{"only": "key"}
+	if _, ok := lookupKey(single, ""); !ok {
+		t.Fatal("a token without kid should use the only key")
+	}
+	if _, ok := lookupKey(single, "other"); ok {
+		t.Fatal("an unknown kid must not fall back to the only key")
+	}
+	multiple := map[string]interface{}{"a": 1, "b": 2}
+	if _, ok := lookupKey(multiple, ""); ok {
+		t.Fatal("a token without kid must not pick one of several keys")
+	}
+}
@@ -0,0 +1,319 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "addp-client"
+
+// testIssuer 内存中的 OIDC 提供方：发现文档和 JWKS，公钥 kid 分别为 rsa 和 ec
+type testIssuer struct {
+	server    *httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	jwksCalls atomic.Int32
+	userInfo  Claims // UserInfo 端点返回的声明，nil 时发现文档不包含该端点
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		d := Discovery{
+			Issuer:                ti.server.URL,
+			AuthorizationEndpoint: ti.server.URL + "/authorize",
+			TokenEndpoint:         ti.server.URL + "/token",
+			JWKSURI:               ti.server.URL + "/jwks",
+		}
+		if ti.userInfo != nil {
+			d.UserinfoEndpoint = ti.server.URL + "/userinfo"
+		}
+		json.NewEncoder(w).Encode(d)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(ti.userInfo)
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		ti.jwksCalls.Add(1)
+		b64 := base64.RawURLEncoding.EncodeToString
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{
+			{Kty: "RSA", Kid: "rsa", Use: "sig", N: b64(rsaKey.N.Bytes()), E: b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{Kty: "EC", Kid: "ec", Crv: "P-256", X: b64(ecKey.X.Bytes()), Y: b64(ecKey.Y.Bytes())},
+		}})
+	})
+	ti.server = httptest.NewServer(mux)
+	t.Cleanup(ti.server.Close)
+	return ti
+}
+
+func (ti *testIssuer) provider() *Provider {
+	return NewProvider(ti.server.URL, testClientID, "", "https://addp.example.com/callback", []string{"openid"})
+}
+
+func (ti *testIssuer) claims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   ti.server.URL,
+		"aud":   testClientID,
+		"sub":   "user-1",
+		"nonce": "nonce-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ti := newTestIssuer(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(name string, value interface{}) jwt.MapClaims {
+		c := ti.claims()
+		if value == nil {
+			delete(c, name)
+		} else {
+			c[name] = value
+		}
+		return c
+	}
+
+	cases := []struct {
+		name  string
+		token func() string
+		nonce string
+		ok    bool
+	}{
+		{"valid RS256", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", ti.claims(), ti.rsaKey)
+		}, "nonce-1", true},
+		{"valid ES256", func() string {
+			return sign(t, jwt.SigningMethodES256, "ec", ti.claims(), ti.ecKey)
+		}, "nonce-1", true},
+		{"audience list containing client", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("aud", []string{"other", testClientID}), ti.rsaKey)
+		}, "nonce-1", true},
+		{"expired within leeway", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("exp", time.Now().Add(-30*time.Second).Unix()), ti.rsaKey)
+		}, "nonce-1", true},
+		{"signed by another key", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", ti.claims(), otherKey)
+		}, "nonce-1", false},
+		{"tampered payload", func() string {
+			parts := strings.Split(sign(t, jwt.SigningMethodRS256, "rsa", ti.claims(), ti.rsaKey), ".")
+			payload, _ := json.Marshal(with("sub", "admin"))
+			parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+			return strings.Join(parts, ".")
+		}, "nonce-1", false},
+		{"alg none", func() string {
+			return sign(t, jwt.SigningMethodNone, "rsa", ti.claims(), jwt.UnsafeAllowNoneSignatureType)
+		}, "nonce-1", false},
+		{"HS256 keyed with the RSA public key", func() string {
+			der, _ := x509.MarshalPKIXPublicKey(&ti.rsaKey.PublicKey)
+			return sign(t, jwt.SigningMethodHS256, "rsa", ti.claims(), der)
+		}, "nonce-1", false},
+		{"alg does not match key type", func() string {
+			return sign(t, jwt.SigningMethodES256, "rsa", ti.claims(), ti.ecKey)
+		}, "nonce-1", false},
+		{"unknown kid", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rotated", ti.claims(), ti.rsaKey)
+		}, "nonce-1", false},
+		{"wrong issuer", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("iss", "https://evil.example.com"), ti.rsaKey)
+		}, "nonce-1", false},
+		{"wrong audience", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("aud", "another-client"), ti.rsaKey)
+		}, "nonce-1", false},
+		{"expired", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("exp", time.Now().Add(-2*time.Minute).Unix()), ti.rsaKey)
+		}, "nonce-1", false},
+		{"missing exp", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("exp", nil), ti.rsaKey)
+		}, "nonce-1", false},
+		{"nonce mismatch", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", ti.claims(), ti.rsaKey)
+		}, "nonce-2", false},
+		{"missing nonce", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("nonce", nil), ti.rsaKey)
+		}, "nonce-1", false},
+		{"missing sub", func() string {
+			return sign(t, jwt.SigningMethodRS256, "rsa", with("sub", nil), ti.rsaKey)
+		}, "nonce-1", false},
+	}
+
+	provider := ti.provider()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims, err := provider.VerifyIDToken(context.Background(), tc.token(), tc.nonce)
+			if tc.ok {
+				if err != nil {
+					t.Fatalf("VerifyIDToken: %v", err)
+				}
+				if claims.String("sub") != "user-1" {
+					t.Fatalf("sub = %q, want user-1", claims.String("sub"))
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("VerifyIDToken accepted an invalid token")
+			}
+		})
+	}
+}
+
+// TestVerifyIDTokenCachesKeys 已知 kid 使用缓存的公钥，未知 kid 重新拉取 JWKS
+func TestVerifyIDTokenCachesKeys(t *testing.T) {
+	ti := newTestIssuer(t)
+	provider := ti.provider()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := provider.VerifyIDToken(ctx, sign(t, jwt.SigningMethodRS256, "rsa", ti.claims(), ti.rsaKey), "nonce-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ti.jwksCalls.Load() != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", ti.jwksCalls.Load())
+	}
+
+	provider.VerifyIDToken(ctx, sign(t, jwt.SigningMethodRS256, "rotated", ti.claims(), ti.rsaKey), "nonce-1")
+	if ti.jwksCalls.Load() != 2 {
+		t.Fatalf("jwks fetched %d times after unknown kid, want 2", ti.jwksCalls.Load())
+	}
+}
+
+// TestCodeChallenge RFC 7636 附录 B 的 S256 示例
+func TestCodeChallenge(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if got := CodeChallenge(verifier); got != want {
+		t.Fatalf("CodeChallenge = %s, want %s", got, want)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	ti := newTestIssuer(t)
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	raw, err := ti.provider().AuthCodeURL(context.Background(), "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if q.Get(name) != value {
+			t.Errorf("%s = %q, want %q", name, q.Get(name), value)
+		}
+	}
+	if q.Has("code_verifier") {
+		t.Error("code_verifier must not be sent to the authorization endpoint")
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Discovery{
+			Issuer:                "https://other.example.com",
+			AuthorizationEndpoint: "https://other.example.com/authorize",
+			TokenEndpoint:         "https://other.example.com/token",
+			JWKSURI:               "https://other.example.com/jwks",
+		})
+	}))
+	defer server.Close()
+
+	provider := NewProvider(server.URL, testClientID, "", "", nil)
+	if _, err := provider.Discover(context.Background()); err == nil {
+		t.Fatal("expected issuer mismatch error")
+	}
+}
+
+func TestUserInfoSubject(t *testing.T) {
+	cases := []struct {
+		name     string
+		userInfo Claims
+		wantErr  error
+		want     Claims
+	}{
+		{"matching sub", Claims{"sub": "user-1", "groups": []interface{}{"analysts"}}, nil, Claims{"sub": "user-1", "groups": []interface{}{"analysts"}}},
+		{"missing sub", Claims{"groups": []interface{}{"admins"}}, ErrUserInfoSubject, nil},
+		{"empty sub", Claims{"sub": "", "email": "a@example.com"}, ErrUserInfoSubject, nil},
+		{"different sub", Claims{"sub": "user-2", "groups": []interface{}{"admins"}}, ErrUserInfoSubject, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ti := newTestIssuer(t)
+			ti.userInfo = tc.userInfo
+			got, err := ti.provider().UserInfo(context.Background(), "access-1", "user-1")
+			if err != tc.wantErr {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("claims = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// 提供方没有 UserInfo 端点时只使用 ID Token 声明
+	ti := newTestIssuer(t)
+	got, err := ti.provider().UserInfo(context.Background(), "access-1", "user-1")
+	if err != nil || got != nil {
+		t.Fatalf("without endpoint: claims = %v, err = %v", got, err)
+	}
+}
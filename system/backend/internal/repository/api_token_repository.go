@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+)
+
+type APITokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository(db *gorm.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func (r *APITokenRepository) Create(token *models.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *APITokenRepository) GetByID(id uint) (*models.APIToken, error) {
+	var token models.APIToken
+	err := r.db.First(&token, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *APITokenRepository) GetByHash(hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListByUser 查询用户未吊销的令牌（包括已过期的，便于查看和清理）
+func (r *APITokenRepository) ListByUser(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Revoke 吊销令牌，已吊销时返回 false
+func (r *APITokenRepository) Revoke(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.APIToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Touch 记录最近一次使用
+func (r *APITokenRepository) Touch(id uint, ip string, at time.Time) error {
+	return r.db.Model(&models.APIToken{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
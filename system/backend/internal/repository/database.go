@@ -55,9 +55,49 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.User{},
 		&models.AuditLog{},
 		&models.Resource{},
+		&models.Session{},
+		&models.RevokedToken{},
+		&models.SessionToken{},
+		&models.RotatedRefreshToken{},
+		&models.Role{},
+		&models.UserRole{},
+		&models.ResourceGrant{},
+		&models.UserIdentity{},
+		&models.OIDCState{},
+		&models.APIToken{},
+		&models.PasswordHistory{},
+		&models.LoginAttempt{},
+		&models.MFAChallenge{},
 	)
 }
 
+// InitBuiltinRoles 创建内置角色，已存在时按代码中的定义更新权限
+func InitBuiltinRoles(db *gorm.DB) error {
+	for _, builtin := range models.BuiltinRoles {
+		var role models.Role
+		result := db.Where("name = ? AND tenant_id IS NULL", builtin.Name).First(&role)
+		if result.Error == gorm.ErrRecordNotFound {
+			role = builtin
+			role.IsBuiltin = true
+			if err := db.Create(&role).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		role.Description = builtin.Description
+		role.Permissions = builtin.Permissions
+		role.IsBuiltin = true
+		if err := db.Save(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // InitSuperAdmin 初始化超级管理员用户
 func InitSuperAdmin(db *gorm.DB) error {
 	// 检查SuperAdmin用户是否存在
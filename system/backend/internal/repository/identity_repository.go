@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository 外部身份（OIDC、LDAP）关联和进行中的单点登录状态
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+func (r *IdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// GetBySubject 按提供方和外部用户 ID 查询关联
+func (r *IdentityRepository) GetBySubject(issuer, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) ListByUser(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// ListByIssuer 查询同一身份源的全部关联，用于目录组同步
+func (r *IdentityRepository) ListByIssuer(issuer string) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("issuer = ?", issuer).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// Touch 记录最近一次外部登录
+func (r *IdentityRepository) Touch(id uint, email string, at time.Time) error {
+	updates := map[string]interface{}{"last_login_at": at}
+	if email != "" {
+		updates["email"] = email
+	}
+	return r.db.Model(&models.UserIdentity{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *IdentityRepository) CreateState(state *models.OIDCState) error {
+	return r.db.Create(state).Error
+}
+
+// ConsumeState 取出并删除登录状态，同一个 state 只能使用一次
+func (r *IdentityRepository) ConsumeState(state string) (*models.OIDCState, error) {
+	var record models.OIDCState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ?", state).First(&record).Error; err != nil {
+			return err
+		}
+		result := tx.Where("state = ?", state).Delete(&models.OIDCState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, errors.New("login state expired")
+	}
+	return &record, nil
+}
+
+// DeleteExpiredStates 清理未完成的过期登录状态
+func (r *IdentityRepository) DeleteExpiredStates(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.OIDCState{}).Error
+}
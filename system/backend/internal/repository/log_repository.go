@@ -49,4 +49,24 @@ func (r *LogRepository) GetByID(id uint) (*models.AuditLog, error) {
 		return nil, err
 	}
 	return &log, nil
+}
+
+func (r *LogRepository) CreateLoginAttempt(attempt *models.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// ListLoginAttempts 查询登录记录，tenantID 为空时查询全部
+func (r *LogRepository) ListLoginAttempts(tenantID *uint, offset, limit int, userID *uint) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	query := r.db.Order("created_at DESC")
+
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	err := query.Offset(offset).Limit(limit).Find(&attempts).Error
+	return attempts, err
 }
\ No newline at end of file
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+)
+
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+func (r *MFARepository) CreateChallenge(challenge *models.MFAChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+// GetChallenge 查询未过期的验证码登录
+func (r *MFARepository) GetChallenge(tokenHash string) (*models.MFAChallenge, error) {
+	var challenge models.MFAChallenge
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&challenge).Error; err != nil {
+		return nil, err
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, errors.New("mfa challenge expired")
+	}
+	return &challenge, nil
+}
+
+// IncrementAttempts 验证码错误次数加一，返回累计次数
+func (r *MFARepository) IncrementAttempts(tokenHash string) (int, error) {
+	var attempts int
+	err := r.db.Raw("UPDATE mfa_challenges SET attempts = attempts + 1 WHERE token_hash = ? RETURNING attempts", tokenHash).Scan(&attempts).Error
+	return attempts, err
+}
+
+// DeleteChallenge 删除验证码登录，返回是否删除成功（同一个登录只能完成一次）
+func (r *MFARepository) DeleteChallenge(tokenHash string) (bool, error) {
+	result := r.db.Where("token_hash = ?", tokenHash).Delete(&models.MFAChallenge{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteExpiredChallenges 清理未完成的过期验证码登录
+func (r *MFARepository) DeleteExpiredChallenges(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.MFAChallenge{}).Error
+}
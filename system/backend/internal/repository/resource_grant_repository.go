@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ResourceGrantRepository struct {
+	db *gorm.DB
+}
+
+func NewResourceGrantRepository(db *gorm.DB) *ResourceGrantRepository {
+	return &ResourceGrantRepository{db: db}
+}
+
+// Upsert 创建授权，同一资源和对象已有授权时更新级别
+func (r *ResourceGrantRepository) Upsert(grant *models.ResourceGrant) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource_id"}, {Name: "subject_type"}, {Name: "subject_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "updated_at"}),
+	}).Create(grant).Error
+}
+
+func (r *ResourceGrantRepository) GetByID(id uint) (*models.ResourceGrant, error) {
+	var grant models.ResourceGrant
+	err := r.db.First(&grant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// GetBySubject 查询资源对某个对象的授权
+func (r *ResourceGrantRepository) GetBySubject(resourceID uint, subjectType string, subjectID uint) (*models.ResourceGrant, error) {
+	var grant models.ResourceGrant
+	err := r.db.Where("resource_id = ? AND subject_type = ? AND subject_id = ?", resourceID, subjectType, subjectID).
+		First(&grant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// ListByResource 查询资源的所有授权
+func (r *ResourceGrantRepository) ListByResource(resourceID uint) ([]models.ResourceGrant, error) {
+	var grants []models.ResourceGrant
+	err := r.db.Where("resource_id = ?", resourceID).Order("id").Find(&grants).Error
+	return grants, err
+}
+
+// ListByResources 批量查询资源的授权，按资源 ID 分组
+func (r *ResourceGrantRepository) ListByResources(resourceIDs []uint) (map[uint][]models.ResourceGrant, error) {
+	result := make(map[uint][]models.ResourceGrant)
+	if len(resourceIDs) == 0 {
+		return result, nil
+	}
+	var grants []models.ResourceGrant
+	if err := r.db.Where("resource_id IN ?", resourceIDs).Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		result[grant.ResourceID] = append(result[grant.ResourceID], grant)
+	}
+	return result, nil
+}
+
+func (r *ResourceGrantRepository) Delete(id uint) error {
+	return r.db.Delete(&models.ResourceGrant{}, id).Error
+}
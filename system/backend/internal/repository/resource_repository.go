@@ -51,10 +51,38 @@ func (r *ResourceRepository) ListByTenant(tenantID uint, offset, limit int, reso
 	return resources, err
 }
 
+// ListVisibleByTenant 查询用户在租户内可见的资源：用户创建的资源，以及授予用户、其角色或整个租户的资源
+func (r *ResourceRepository) ListVisibleByTenant(tenantID, userID uint, roleIDs []uint, offset, limit int, resourceType string) ([]models.Resource, error) {
+	var resources []models.Resource
+	granted := r.db.Where("created_by = ?", userID).
+		Or("EXISTS (SELECT 1 FROM resource_grants g WHERE g.resource_id = resources.id AND g.subject_type = ? AND g.subject_id = ?)",
+			models.GrantSubjectTenant, tenantID).
+		Or("EXISTS (SELECT 1 FROM resource_grants g WHERE g.resource_id = resources.id AND g.subject_type = ? AND g.subject_id = ?)",
+			models.GrantSubjectUser, userID)
+	if len(roleIDs) > 0 {
+		granted = granted.Or("EXISTS (SELECT 1 FROM resource_grants g WHERE g.resource_id = resources.id AND g.subject_type = ? AND g.subject_id IN ?)",
+			models.GrantSubjectRole, roleIDs)
+	}
+	query := r.db.Where("tenant_id = ?", tenantID).Where(granted)
+
+	if resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+
+	err := query.Offset(offset).Limit(limit).Find(&resources).Error
+	return resources, err
+}
+
 func (r *ResourceRepository) Update(resource *models.Resource) error {
 	return r.db.Save(resource).Error
 }
 
+// Delete 删除资源及其授权
 func (r *ResourceRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Resource{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("resource_id = ?", id).Delete(&models.ResourceGrant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Resource{}, id).Error
+	})
 }
\ No newline at end of file
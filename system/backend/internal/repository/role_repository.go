@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) Create(role *models.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *RoleRepository) GetByID(id uint) (*models.Role, error) {
+	var role models.Role
+	err := r.db.First(&role, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetByName 查询租户内的角色，tenantID 为 nil 时查询内置角色
+func (r *RoleRepository) GetByName(tenantID *uint, name string) (*models.Role, error) {
+	var role models.Role
+	query := r.db.Where("name = ?", name)
+	if tenantID == nil {
+		query = query.Where("tenant_id IS NULL")
+	} else {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	err := query.First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListVisible 返回内置角色和租户自定义角色，tenantID 为 nil 时只返回内置角色
+func (r *RoleRepository) ListVisible(tenantID *uint) ([]models.Role, error) {
+	var roles []models.Role
+	query := r.db.Order("is_builtin DESC, id")
+	if tenantID == nil {
+		query = query.Where("tenant_id IS NULL")
+	} else {
+		query = query.Where("tenant_id IS NULL OR tenant_id = ?", *tenantID)
+	}
+	err := query.Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) Update(role *models.Role) error {
+	return r.db.Save(role).Error
+}
+
+// Delete 删除角色及其分配关系和资源授权
+func (r *RoleRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", id).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject_type = ? AND subject_id = ?", models.GrantSubjectRole, id).Delete(&models.ResourceGrant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Role{}, id).Error
+	})
+}
+
+// ListByUser 查询分配给用户的角色
+func (r *RoleRepository) ListByUser(userID uint) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).Order("roles.id").Find(&roles).Error
+	return roles, err
+}
+
+// SetUserRoles 替换用户的角色分配
+func (r *RoleRepository) SetUserRoles(userID uint, roleIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if len(roleIDs) == 0 {
+			return nil
+		}
+		assignments := make([]models.UserRole, 0, len(roleIDs))
+		for _, roleID := range roleIDs {
+			assignments = append(assignments, models.UserRole{UserID: userID, RoleID: roleID})
+		}
+		return tx.Create(&assignments).Error
+	})
+}
+
+// DeleteUserRoles 删除用户的所有角色分配
+func (r *RoleRepository) DeleteUserRoles(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create 保存新会话，并记录会话的第一个访问令牌
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Create(sessionToken(session)).Error
+	})
+}
+
+func (r *SessionRepository) GetByID(id string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) GetByRefreshTokenHash(hash string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveByUser 查询用户未吊销且未过期的会话
+func (r *SessionRepository) ListActiveByUser(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// GetSessionIDByRotatedRefreshToken 查询已被轮换掉的刷新令牌所属的会话
+func (r *SessionRepository) GetSessionIDByRotatedRefreshToken(hash string) (string, error) {
+	var rotated models.RotatedRefreshToken
+	if err := r.db.Where("hash = ?", hash).First(&rotated).Error; err != nil {
+		return "", err
+	}
+	return rotated.SessionID, nil
+}
+
+// Rotate 轮换刷新令牌和访问令牌；按旧的刷新令牌哈希更新，同一个刷新令牌并发使用时只有一次成功。
+// 旧的刷新令牌哈希留作重放检测，新的访问令牌记录到会话的令牌列表
+func (r *SessionRepository) Rotate(session *models.Session, oldHash string) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, oldHash).
+			Updates(map[string]interface{}{
+				"refresh_token_hash": session.RefreshTokenHash,
+				"access_token_id":    session.AccessTokenID,
+				"access_expires_at":  session.AccessExpiresAt,
+				"ip_address":         session.IPAddress,
+				"user_agent":         session.UserAgent,
+				"last_refreshed_at":  session.LastRefreshedAt,
+			})
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		rotated = true
+		if err := tx.Create(&models.RotatedRefreshToken{
+			Hash:      oldHash,
+			SessionID: session.ID,
+			ExpiresAt: session.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(sessionToken(session)).Error
+	})
+	return rotated && err == nil, err
+}
+
+// RevokeSession 吊销单个会话
+func (r *SessionRepository) RevokeSession(id string, reason string) ([]models.Session, error) {
+	return r.Revoke(func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ?", id)
+	}, reason)
+}
+
+// RevokeUserSessions 吊销用户的所有会话
+func (r *SessionRepository) RevokeUserSessions(userID uint, reason string) ([]models.Session, error) {
+	return r.Revoke(func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
+	}, reason)
+}
+
+// Revoke 吊销会话，并把会话签发过的所有未过期访问令牌加入黑名单；scope 限定要吊销的会话
+func (r *SessionRepository) Revoke(scope func(*gorm.DB) *gorm.DB, reason string) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx.Where("revoked_at IS NULL")).Find(&sessions).Error; err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		ids := make([]string, 0, len(sessions))
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+		var tokens []models.SessionToken
+		if err := tx.Where("session_id IN ? AND expires_at > ?", ids, now).Find(&tokens).Error; err != nil {
+			return err
+		}
+		// 会话当前的访问令牌也加入黑名单，兼容令牌列表出现之前创建的会话
+		for _, session := range sessions {
+			tokens = append(tokens, *sessionToken(&session))
+		}
+
+		seen := make(map[string]bool, len(tokens))
+		var revoked []models.RevokedToken
+		for _, token := range tokens {
+			if token.JTI == "" || seen[token.JTI] || !token.ExpiresAt.After(now) {
+				continue
+			}
+			seen[token.JTI] = true
+			revoked = append(revoked, models.RevokedToken{
+				JTI:       token.JTI,
+				UserID:    token.UserID,
+				ExpiresAt: token.ExpiresAt,
+			})
+		}
+
+		if err := tx.Model(&models.Session{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"revoked_at": now, "revoke_reason": reason}).Error; err != nil {
+			return err
+		}
+		if len(revoked) > 0 {
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error
+		}
+		return nil
+	})
+	return sessions, err
+}
+
+// IsTokenRevoked 访问令牌是否在黑名单中
+func (r *SessionRepository) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// ListRevokedTokens 查询 since 之后加入黑名单且尚未过期的访问令牌
+func (r *SessionRepository) ListRevokedTokens(since time.Time) ([]models.RevokedToken, error) {
+	var tokens []models.RevokedToken
+	err := r.db.Where("created_at > ? AND expires_at > ?", since, time.Now()).
+		Order("created_at").Find(&tokens).Error
+	return tokens, err
+}
+
+// DeleteExpired 删除已过期的黑名单记录、令牌记录和过期的会话
+func (r *SessionRepository) DeleteExpired(before time.Time) error {
+	for _, model := range []interface{}{&models.RevokedToken{}, &models.SessionToken{}, &models.RotatedRefreshToken{}} {
+		if err := r.db.Where("expires_at <= ?", before).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return r.db.Where("expires_at <= ?", before).Delete(&models.Session{}).Error
+}
+
+// sessionToken 会话当前访问令牌的记录
+func sessionToken(session *models.Session) *models.SessionToken {
+	return &models.SessionToken{
+		JTI:       session.AccessTokenID,
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		ExpiresAt: session.AccessExpiresAt,
+	}
+}
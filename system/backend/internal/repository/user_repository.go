@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/addp/system/internal/models"
 	"gorm.io/gorm"
 )
@@ -35,6 +37,16 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetByEmail 按邮箱查询用户
+func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) List(offset, limit int) ([]models.User, error) {
 	var users []models.User
 	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
@@ -47,10 +59,93 @@ func (r *UserRepository) ListByTenant(tenantID uint, offset, limit int) ([]model
 	return users, err
 }
 
+// ListServiceAccounts 查询租户的服务账号
+func (r *UserRepository) ListServiceAccounts(tenantID uint) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("tenant_id = ? AND is_service_account = ?", tenantID, true).Order("id").Find(&users).Error
+	return users, err
+}
+
 func (r *UserRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// IncrementFailedLogins 登录失败次数加一，返回累计次数；锁定时间早于 lockExpiredBefore（锁定已到期）时
+// 先解除锁定并从 1 重新计数，两步在同一条语句中完成
+func (r *UserRepository) IncrementFailedLogins(id uint, lockExpiredBefore time.Time) (int, error) {
+	var count int
+	err := r.db.Raw(`UPDATE users SET
+		failed_login_count = CASE WHEN locked_at IS NOT NULL AND locked_at <= ? THEN 1 ELSE failed_login_count + 1 END,
+		locked_at = CASE WHEN locked_at IS NOT NULL AND locked_at <= ? THEN NULL ELSE locked_at END
+		WHERE id = ? RETURNING failed_login_count`, lockExpiredBefore, lockExpiredBefore, id).Scan(&count).Error
+	return count, err
+}
+
+// Lock 锁定账号
+func (r *UserRepository) Lock(id uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("locked_at", at).Error
+}
+
+// ResetFailedLogins 清零登录失败次数并解除锁定
+func (r *UserRepository) ResetFailedLogins(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_count": 0,
+		"locked_at":          nil,
+	}).Error
+}
+
+// AdvanceMFAStep 记录最近使用的 TOTP 时间步，返回 false 表示该验证码已被使用过
+func (r *UserRepository) AdvanceMFAStep(id uint, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).Where("id = ? AND mfa_last_step < ?", id, step).Update("mfa_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ResetMFA 停用多因素认证并删除密钥
+func (r *UserRepository) ResetMFA(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"mfa_enabled":   false,
+		"mfa_secret":    "",
+		"mfa_last_step": 0,
+	}).Error
+}
+
+// AddPasswordHistory 记录密码哈希，只保留最近 keep 条
+func (r *UserRepository) AddPasswordHistory(userID uint, passwordHash string, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: passwordHash}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND id NOT IN (?)", userID,
+			tx.Model(&models.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("id DESC").Limit(keep),
+		).Delete(&models.PasswordHistory{}).Error
+	})
+}
+
+// ListPasswordHistory 查询最近 limit 次使用过的密码哈希
+func (r *UserRepository) ListPasswordHistory(userID uint, limit int) ([]string, error) {
+	var hashes []string
+	err := r.db.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).Order("id DESC").Limit(limit).Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
+
+// Delete 删除用户及其资源授权和外部身份关联
 func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subject_type = ? AND subject_id = ?", models.GrantSubjectUser, id).Delete(&models.ResourceGrant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.MFAChallenge{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
 }
\ No newline at end of file
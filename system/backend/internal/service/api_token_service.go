@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/addp/common/auth"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	apiTokenDefaultDays = 90
+	apiTokenMaxDays     = 365
+	// apiTokenTouchInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+	apiTokenTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidAPIToken = errors.New("访问令牌无效、已过期或已吊销")
+	// ErrSessionRequired 创建访问令牌和修改密码只能使用登录会话，避免令牌泄露后被续期或用于接管账号
+	ErrSessionRequired = errors.New("访问令牌不能执行该操作，请登录后重试")
+)
+
+// apiTokenStore APITokenService 使用的令牌存储（repository.APITokenRepository）
+type apiTokenStore interface {
+	Create(token *models.APIToken) error
+	GetByID(id uint) (*models.APIToken, error)
+	GetByHash(hash string) (*models.APIToken, error)
+	ListByUser(userID uint) ([]models.APIToken, error)
+	Revoke(id uint, at time.Time) (bool, error)
+	Touch(id uint, ip string, at time.Time) error
+}
+
+// APITokenService 访问令牌的签发、校验和吊销，权限检查由 UserService 负责
+type APITokenService struct {
+	repo     apiTokenStore
+	userRepo userStore
+}
+
+func NewAPITokenService(repo *repository.APITokenRepository, userRepo *repository.UserRepository) *APITokenService {
+	return &APITokenService{repo: repo, userRepo: userRepo}
+}
+
+// Create 为用户签发令牌，明文只在返回值中出现一次
+func (s *APITokenService) Create(userID uint, req *models.APITokenCreateRequest, creatorID uint) (*models.APITokenCreateResponse, error) {
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{auth.ScopeRead}
+	}
+	for _, scope := range scopes {
+		if !auth.ValidScope(scope) {
+			return nil, fmt.Errorf("无效的权限范围: %s", scope)
+		}
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = apiTokenDefaultDays
+	}
+	if days < 0 || days > apiTokenMaxDays {
+		return nil, fmt.Errorf("有效期必须在 1 到 %d 天之间", apiTokenMaxDays)
+	}
+
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	raw := auth.APITokenPrefix + secret
+
+	token := &models.APIToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenPrefix: raw[:len(auth.APITokenPrefix)+8],
+		TokenHash:   utils.HashToken(raw),
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().AddDate(0, 0, days),
+		CreatedBy:   &creatorID,
+	}
+	if err := s.repo.Create(token); err != nil {
+		return nil, err
+	}
+	return &models.APITokenCreateResponse{APIToken: *token, Token: raw}, nil
+}
+
+// Verify 校验令牌并返回令牌和所属用户，同时记录最近使用时间和地址
+func (s *APITokenService) Verify(raw, ipAddress string) (*models.APIToken, *models.User, error) {
+	token, err := s.repo.GetByHash(utils.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIToken
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive {
+		return nil, nil, ErrUserDisabled
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval || token.LastUsedIP != ipAddress {
+		if err := s.repo.Touch(token.ID, ipAddress, now); err != nil {
+			log.Printf("更新访问令牌 %d 的使用时间失败: %v", token.ID, err)
+		}
+	}
+	return token, user, nil
+}
+
+func (s *APITokenService) List(userID uint) ([]models.APIToken, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// Revoke 吊销用户的令牌
+func (s *APITokenService) Revoke(userID, tokenID uint) error {
+	token, err := s.repo.GetByID(tokenID)
+	if err != nil || token.UserID != userID {
+		return errors.New("访问令牌不存在")
+	}
+	if _, err := s.repo.Revoke(tokenID, time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
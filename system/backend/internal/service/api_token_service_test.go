@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/addp/common/auth"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// memTokens 内存中的访问令牌存储，touches 记录更新最近使用时间的次数
+type memTokens struct {
+	tokens  map[uint]*models.APIToken
+	touches int
+}
+
+func newMemTokens() *memTokens {
+	return &memTokens{tokens: map[uint]*models.APIToken{}}
+}
+
+func (m *memTokens) Create(token *models.APIToken) error {
+	token.ID = uint(len(m.tokens) + 1)
+	stored := *token
+	m.tokens[token.ID] = &stored
+	return nil
+}
+
+func (m *memTokens) GetByID(id uint) (*models.APIToken, error) {
+	token, ok := m.tokens[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *token
+	return &found, nil
+}
+
+func (m *memTokens) GetByHash(hash string) (*models.APIToken, error) {
+	for id, token := range m.tokens {
+		if token.TokenHash == hash {
+			return m.GetByID(id)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memTokens) ListByUser(userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *memTokens) Revoke(id uint, at time.Time) (bool, error) {
+	token, ok := m.tokens[id]
+	if !ok || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.RevokedAt = &at
+	return true, nil
+}
+
+func (m *memTokens) Touch(id uint, ip string, at time.Time) error {
+	m.touches++
+	m.tokens[id].LastUsedAt = &at
+	m.tokens[id].LastUsedIP = ip
+	return nil
+}
+
+func newTestAPITokenService() (*APITokenService, *memTokens, *memUserStore, *models.User) {
+	users := newMemUserStore()
+	user := &models.User{Username: "alice", UserType: models.UserTypeUser, IsActive: true}
+	users.Create(user)
+	store := newMemTokens()
+	return &APITokenService{repo: store, userRepo: users}, store, users, user
+}
+
+func TestAPITokenCreate(t *testing.T) {
+	s, store, _, user := newTestAPITokenService()
+
+	created, err := s.Create(user.ID, &models.APITokenCreateRequest{Name: "ci"}, user.ID)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !auth.IsAPIToken(created.Token) || !strings.HasPrefix(created.Token, created.TokenPrefix) {
+		t.Fatalf("token = %q, prefix = %q", created.Token, created.TokenPrefix)
+	}
+	// 只保存哈希，默认只读、有效期 90 天
+	stored := store.tokens[created.ID]
+	if stored.TokenHash != utils.HashToken(created.Token) || strings.Contains(stored.TokenHash, created.Token) {
+		t.Fatal("token hash not stored")
+	}
+	if len(stored.Scopes) != 1 || stored.Scopes[0] != auth.ScopeRead {
+		t.Fatalf("default scopes = %v", stored.Scopes)
+	}
+	if days := time.Until(stored.ExpiresAt).Hours() / 24; days < apiTokenDefaultDays-1 || days > apiTokenDefaultDays {
+		t.Fatalf("default expiry in %.1f days", days)
+	}
+
+	invalid := map[string]*models.APITokenCreateRequest{
+		"unknown scope":      {Name: "x", Scopes: []string{"admin"}},
+		"unknown service":    {Name: "x", Scopes: []string{"billing:read"}},
+		"empty service":      {Name: "x", Scopes: []string{":write"}},
+		"negative expiry":    {Name: "x", ExpiresInDays: -1},
+		"expiry beyond max":  {Name: "x", ExpiresInDays: apiTokenMaxDays + 1},
+		"one invalid scope":  {Name: "x", Scopes: []string{"meta:read", "meta:admin"}},
+		"scope without name": {Name: "x", Scopes: []string{""}},
+	}
+	for name, req := range invalid {
+		if _, err := s.Create(user.ID, req, user.ID); err == nil {
+			t.Errorf("%s: token created", name)
+		}
+	}
+	if len(store.tokens) != 1 {
+		t.Fatalf("%d tokens stored, want 1", len(store.tokens))
+	}
+}
+
+func TestAPITokenVerify(t *testing.T) {
+	s, store, users, user := newTestAPITokenService()
+	create := func(name string) *models.APITokenCreateResponse {
+		t.Helper()
+		created, err := s.Create(user.ID, &models.APITokenCreateRequest{Name: name, Scopes: []string{"meta:write"}}, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return created
+	}
+	valid := create("valid")
+
+	token, owner, err := s.Verify(valid.Token, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if token.ID != valid.ID || owner.ID != user.ID || len(token.Scopes) != 1 || token.Scopes[0] != "meta:write" {
+		t.Fatalf("token = %+v, user = %+v", token, owner)
+	}
+	// 最近使用时间按间隔更新，地址变化时立即更新
+	s.Verify(valid.Token, "10.0.0.1")
+	if store.touches != 1 {
+		t.Fatalf("touches = %d, want 1", store.touches)
+	}
+	s.Verify(valid.Token, "10.0.0.2")
+	if store.touches != 2 || store.tokens[valid.ID].LastUsedIP != "10.0.0.2" {
+		t.Fatalf("touches = %d, last ip = %q", store.touches, store.tokens[valid.ID].LastUsedIP)
+	}
+
+	revoked := create("revoked")
+	if err := s.Revoke(user.ID, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	expired := create("expired")
+	store.tokens[expired.ID].ExpiresAt = time.Now().Add(-time.Second)
+	for name, raw := range map[string]string{
+		"unknown": auth.APITokenPrefix + "unknown",
+		"revoked": revoked.Token,
+		"expired": expired.Token,
+	} {
+		if _, _, err := s.Verify(raw, "10.0.0.1"); !errors.Is(err, ErrInvalidAPIToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidAPIToken", name, err)
+		}
+	}
+
+	// 用户禁用或删除后令牌失效
+	users.users[user.ID].IsActive = false
+	if _, _, err := s.Verify(valid.Token, "10.0.0.1"); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("disabled user: err = %v, want ErrUserDisabled", err)
+	}
+	users.Delete(user.ID)
+	if _, _, err := s.Verify(valid.Token, "10.0.0.1"); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("deleted user: err = %v, want ErrInvalidAPIToken", err)
+	}
+}
+
+func TestAPITokenRevokeOnlyOwnTokens(t *testing.T) {
+	s, store, _, user := newTestAPITokenService()
+	created, err := s.Create(user.ID, &models.APITokenCreateRequest{Name: "ci"}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Revoke(user.ID+1, created.ID); err == nil {
+		t.Fatal("revoked another user's token")
+	}
+	if store.tokens[created.ID].RevokedAt != nil {
+		t.Fatal("token revoked")
+	}
+	if err := s.Revoke(user.ID, created.ID); err != nil || store.tokens[created.ID].RevokedAt == nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCredentials 用户不存在或密码错误，继续尝试下一个认证后端
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	// ErrAuthUnavailable 认证后端暂时不可用（如目录服务器连接失败），继续尝试下一个认证后端
+	ErrAuthUnavailable    = errors.New("认证服务暂时不可用，请稍后重试")
+	ErrUserDisabled       = errors.New("用户已被禁用")
+	ErrLocalLoginDisabled = errors.New("已禁用用户名密码登录，请使用单点登录")
+)
+
+// Authenticator 用户名密码认证后端。返回 ErrInvalidCredentials 或 ErrAuthUnavailable 时
+// UserService 继续尝试下一个后端，其他错误直接返回给用户
+type Authenticator interface {
+	Name() string
+	Authenticate(username, password string) (*models.User, error)
+}
+
+// OrderAuthenticators 按配置的名称顺序（如 ldap,local）排列认证后端，未启用的名称忽略
+func OrderAuthenticators(order []string, available ...Authenticator) []Authenticator {
+	byName := make(map[string]Authenticator, len(available))
+	for _, a := range available {
+		byName[a.Name()] = a
+	}
+	ordered := make([]Authenticator, 0, len(order))
+	for _, name := range order {
+		if a, ok := byName[name]; ok {
+			ordered = append(ordered, a)
+			delete(byName, name)
+		} else {
+			log.Printf("认证后端 %s 未启用，已忽略", name)
+		}
+	}
+	return ordered
+}
+
+// LocalAuthenticator 本地账号（bcrypt 密码）认证
+type LocalAuthenticator struct {
+	repo    userStore
+	policy  *PasswordPolicy
+	enabled bool
+}
+
+// NewLocalAuthenticator enabled 为 false 时只允许超级管理员登录，用于强制单点登录时的应急处理
+func NewLocalAuthenticator(repo *repository.UserRepository, policy *PasswordPolicy, enabled bool) *LocalAuthenticator {
+	return &LocalAuthenticator{repo: repo, policy: policy, enabled: enabled}
+}
+
+func (a *LocalAuthenticator) Name() string {
+	return "local"
+}
+
+func (a *LocalAuthenticator) Authenticate(username, password string) (*models.User, error) {
+	user, err := a.repo.GetByUsername(username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// 服务账号只能使用访问令牌
+	if user.IsServiceAccount || !utils.CheckPassword(password, user.PasswordHash) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		return nil, ErrUserDisabled
+	}
+
+	if !a.enabled && user.UserType != models.UserTypeSuperAdmin {
+		return nil, ErrLocalLoginDisabled
+	}
+
+	if a.policy.Expired(user) {
+		return nil, ErrPasswordExpired
+	}
+
+	return user, nil
+}
+
+// mapTenant 外部身份源的租户值经 mapping 映射后作为租户名，没有值时使用默认租户
+func mapTenant(tenantRepo *repository.TenantRepository, value string, mapping map[string][]string, defaultTenant string) (*models.Tenant, error) {
+	name := value
+	if mapped := mapping[value]; value != "" && len(mapped) > 0 {
+		name = mapped[0]
+	}
+	if name == "" {
+		name = defaultTenant
+	}
+	if name == "" {
+		return nil, errors.New("无法确定用户所属租户，请联系管理员")
+	}
+
+	tenant, err := tenantRepo.GetByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("租户 %s 不存在", name)
+	}
+	if !tenant.IsActive {
+		return nil, fmt.Errorf("租户 %s 已停用", name)
+	}
+	return tenant, nil
+}
+
+// provisionUser 为外部身份创建普通用户，本地密码为随机值（只能通过外部身份源登录）
+func provisionUser(userRepo *repository.UserRepository, tenant *models.Tenant, username, email, fullName string) (*models.User, error) {
+	if _, err := userRepo.GetByUsername(username); err == nil {
+		return nil, fmt.Errorf("用户名 %s 已存在，请使用本地账号登录后关联外部账号或联系管理员", username)
+	}
+	if email != "" {
+		if _, err := userRepo.GetByEmail(email); err == nil {
+			return nil, fmt.Errorf("邮箱 %s 已被其他用户使用，请联系管理员", email)
+		}
+	}
+
+	password, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: hash,
+		FullName:     fullName,
+		IsActive:     true,
+		UserType:     models.UserTypeUser,
+		TenantID:     &tenant.ID,
+	}
+	if err := userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// syncGroupRoles 按外部组替换普通用户的角色，mapping 为组名 → 角色名（内置角色或本租户角色）；
+// 没有匹配的组时清空分配，使用内置 user 角色
+func syncGroupRoles(roleRepo *repository.RoleRepository, user *models.User, groups []string, mapping map[string][]string) error {
+	if len(mapping) == 0 || user.UserType != models.UserTypeUser {
+		return nil
+	}
+
+	seen := make(map[uint]bool)
+	roleIDs := make([]uint, 0)
+	for _, group := range groups {
+		for _, name := range mapping[group] {
+			role, err := roleRepo.GetByName(nil, name)
+			if err != nil && user.TenantID != nil {
+				role, err = roleRepo.GetByName(user.TenantID, name)
+			}
+			if err != nil {
+				log.Printf("外部组 %s 映射的角色 %s 不存在，已忽略", group, name)
+				continue
+			}
+			if !seen[role.ID] {
+				seen[role.ID] = true
+				roleIDs = append(roleIDs, role.ID)
+			}
+		}
+	}
+	return roleRepo.SetUserRoles(user.ID, roleIDs)
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/ldap"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ldapTimeout 单次连接和操作的超时时间
+const ldapTimeout = 10 * time.Second
+
+// LDAPAuthenticator LDAP / Active Directory 认证：服务账号查找用户 DN，再用用户密码绑定验证。
+// 首次登录按目录属性确定租户并创建用户，外部身份记录为 issuer=LDAPURL、subject=用户 DN
+type LDAPAuthenticator struct {
+	cfg        *config.Config
+	tlsConfig  *tls.Config
+	repo       *repository.IdentityRepository
+	userRepo   *repository.UserRepository
+	tenantRepo *repository.TenantRepository
+	roleRepo   *repository.RoleRepository
+}
+
+func NewLDAPAuthenticator(cfg *config.Config, repo *repository.IdentityRepository, userRepo *repository.UserRepository, tenantRepo *repository.TenantRepository, roleRepo *repository.RoleRepository) (*LDAPAuthenticator, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.LDAPTLSSkipVerify}
+	if cfg.LDAPCACertFile != "" {
+		pem, err := os.ReadFile(cfg.LDAPCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 LDAP CA 证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("LDAP CA 证书 %s 中没有有效的 PEM 证书", cfg.LDAPCACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &LDAPAuthenticator{
+		cfg:        cfg,
+		tlsConfig:  tlsConfig,
+		repo:       repo,
+		userRepo:   userRepo,
+		tenantRepo: tenantRepo,
+		roleRepo:   roleRepo,
+	}, nil
+}
+
+func (a *LDAPAuthenticator) Name() string {
+	return "ldap"
+}
+
+func (a *LDAPAuthenticator) Authenticate(username, password string) (*models.User, error) {
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := a.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsResultCode(err, ldap.ResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+
+	var groups []string
+	if len(a.cfg.LDAPGroupRoleMap) > 0 {
+		// 有服务账号时用服务账号查询组，否则以用户身份查询
+		if err := a.bindService(conn); err != nil {
+			return nil, err
+		}
+		if groups, err = a.groups(conn, entry.DN, username); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := a.resolveUser(entry, username)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrUserDisabled
+	}
+	if err := syncGroupRoles(a.roleRepo, user, groups, a.cfg.LDAPGroupRoleMap); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SyncGroups 按目录中的组重新同步所有 LDAP 用户的角色，未配置组→角色映射时不执行
+func (a *LDAPAuthenticator) SyncGroups() error {
+	if len(a.cfg.LDAPGroupRoleMap) == 0 {
+		return nil
+	}
+
+	identities, err := a.repo.ListByIssuer(a.cfg.LDAPURL)
+	if err != nil {
+		return err
+	}
+	if len(identities) == 0 {
+		return nil
+	}
+
+	conn, err := a.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	synced := 0
+	for _, identity := range identities {
+		user, err := a.userRepo.GetByID(identity.UserID)
+		if err != nil {
+			continue
+		}
+
+		entries, err := conn.Search(&ldap.SearchRequest{
+			BaseDN:     identity.Subject,
+			Scope:      ldap.ScopeBase,
+			Filter:     "(objectClass=*)",
+			Attributes: []string{a.cfg.LDAPUsernameAttribute},
+		})
+		if ldap.IsResultCode(err, ldap.ResultNoSuchObject) || (err == nil && len(entries) == 0) {
+			log.Printf("LDAP 用户 %s 在目录中已不存在，跳过角色同步", identity.Subject)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		username := entries[0].Get(a.cfg.LDAPUsernameAttribute)
+		if username == "" {
+			username = user.Username
+		}
+		groups, err := a.groups(conn, identity.Subject, username)
+		if err != nil {
+			return err
+		}
+		if err := syncGroupRoles(a.roleRepo, user, groups, a.cfg.LDAPGroupRoleMap); err != nil {
+			log.Printf("同步用户 %s 的 LDAP 组角色失败: %v", user.Username, err)
+			continue
+		}
+		synced++
+	}
+	log.Printf("LDAP 组角色同步完成，共 %d 个用户", synced)
+	return nil
+}
+
+// StartGroupSync 定期同步 LDAP 组角色
+func (a *LDAPAuthenticator) StartGroupSync(interval time.Duration) {
+	if len(a.cfg.LDAPGroupRoleMap) == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := a.SyncGroups(); err != nil {
+				log.Printf("LDAP 组角色同步失败: %v", err)
+			}
+		}
+	}()
+}
+
+// connect 建立连接（按配置 StartTLS）并以服务账号绑定，连接失败视为认证后端不可用
+func (a *LDAPAuthenticator) connect() (*ldap.Conn, error) {
+	conn, err := ldap.Dial(a.cfg.LDAPURL, a.tlsConfig, ldapTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	if a.cfg.LDAPStartTLS {
+		if err := conn.StartTLS(a.tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+		}
+	}
+	if err := a.bindService(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) bindService(conn *ldap.Conn) error {
+	if a.cfg.LDAPBindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(a.cfg.LDAPBindDN, a.cfg.LDAPBindPassword); err != nil {
+		return fmt.Errorf("%w: LDAP 服务账号绑定失败: %v", ErrAuthUnavailable, err)
+	}
+	return nil
+}
+
+// findUser 按登录名查找唯一的用户条目，找不到或匹配到多个时视为用户名或密码错误
+func (a *LDAPAuthenticator) findUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	entries, err := conn.Search(&ldap.SearchRequest{
+		BaseDN: a.cfg.LDAPUserBaseDN,
+		Scope:  ldap.ScopeSubtree,
+		Filter: strings.ReplaceAll(a.cfg.LDAPUserFilter, "{username}", ldap.EscapeFilter(username)),
+		Attributes: []string{
+			a.cfg.LDAPUsernameAttribute,
+			a.cfg.LDAPEmailAttribute,
+			a.cfg.LDAPNameAttribute,
+			a.cfg.LDAPTenantAttribute,
+		},
+		SizeLimit: 2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthUnavailable, err)
+	}
+	switch len(entries) {
+	case 0:
+		return nil, ErrInvalidCredentials
+	case 1:
+		return entries[0], nil
+	default:
+		log.Printf("LDAP 中匹配到多个用户 %s，请检查 LDAP_USER_FILTER", username)
+		return nil, ErrInvalidCredentials
+	}
+}
+
+// groups 查询用户所属的组名
+func (a *LDAPAuthenticator) groups(conn *ldap.Conn, dn, username string) ([]string, error) {
+	baseDN := a.cfg.LDAPGroupBaseDN
+	if baseDN == "" {
+		baseDN = a.cfg.LDAPUserBaseDN
+	}
+	filter := strings.NewReplacer("{dn}", ldap.EscapeFilter(dn), "{username}", ldap.EscapeFilter(username)).Replace(a.cfg.LDAPGroupFilter)
+
+	entries, err := conn.Search(&ldap.SearchRequest{
+		BaseDN:     baseDN,
+		Scope:      ldap.ScopeSubtree,
+		Filter:     filter,
+		Attributes: []string{a.cfg.LDAPGroupAttribute},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: 查询 LDAP 组失败: %v", ErrAuthUnavailable, err)
+	}
+
+	groups := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if name := entry.Get(a.cfg.LDAPGroupAttribute); name != "" {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
+// resolveUser 按外部身份查找已关联的用户，首次登录时创建用户
+func (a *LDAPAuthenticator) resolveUser(entry *ldap.Entry, loginName string) (*models.User, error) {
+	email := entry.Get(a.cfg.LDAPEmailAttribute)
+
+	identity, err := a.repo.GetBySubject(a.cfg.LDAPURL, entry.DN)
+	if err == nil {
+		if err := a.repo.Touch(identity.ID, email, time.Now()); err != nil {
+			log.Printf("更新外部身份 %d 的登录时间失败: %v", identity.ID, err)
+		}
+		return a.userRepo.GetByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !a.cfg.LDAPAutoProvision {
+		return nil, errors.New("没有与该目录账号关联的用户，请联系管理员")
+	}
+	tenant, err := mapTenant(a.tenantRepo, entry.Get(a.cfg.LDAPTenantAttribute), a.cfg.LDAPTenantMap, a.cfg.LDAPDefaultTenant)
+	if err != nil {
+		return nil, err
+	}
+	username := entry.Get(a.cfg.LDAPUsernameAttribute)
+	if username == "" {
+		username = loginName
+	}
+	user, err := provisionUser(a.userRepo, tenant, username, email, entry.Get(a.cfg.LDAPNameAttribute))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := a.repo.Create(&models.UserIdentity{
+		UserID:      user.ID,
+		Issuer:      a.cfg.LDAPURL,
+		Subject:     entry.DN,
+		Email:       email,
+		LastLoginAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+	log.Printf("LDAP 登录自动创建用户 %s（租户 %s）", user.Username, tenant.Name)
+	return user, nil
+}
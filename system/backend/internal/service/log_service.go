@@ -2,7 +2,9 @@ package service
 
 import (
 	"errors"
+	"log"
 
+	"github.com/addp/common/authz"
 	"github.com/addp/system/internal/models"
 	"github.com/addp/system/internal/repository"
 )
@@ -10,12 +12,14 @@ import (
 type LogService struct {
 	repo     *repository.LogRepository
 	userRepo *repository.UserRepository
+	roles    *RoleService
 }
 
-func NewLogService(repo *repository.LogRepository, userRepo *repository.UserRepository) *LogService {
+func NewLogService(repo *repository.LogRepository, userRepo *repository.UserRepository, roles *RoleService) *LogService {
 	return &LogService{
 		repo:     repo,
 		userRepo: userRepo,
+		roles:    roles,
 	}
 }
 
@@ -31,13 +35,16 @@ func (s *LogService) List(page, pageSize int, userID *uint, currentUserID uint)
 	if err != nil {
 		return nil, errors.New("当前用户不存在")
 	}
+	if !s.roles.HasPermission(currentUser, authz.LogRead) {
+		return nil, errors.New("没有权限查看日志")
+	}
 
 	// SuperAdmin可以查看所有日志
 	if currentUser.UserType == models.UserTypeSuperAdmin {
 		return s.repo.List(offset, pageSize, userID)
 	}
 
-	// 租户管理员和普通用户只能查看本租户的日志
+	// 其他用户只能查看本租户的日志
 	if currentUser.TenantID == nil {
 		return []models.AuditLog{}, nil
 	}
@@ -46,4 +53,38 @@ func (s *LogService) List(page, pageSize int, userID *uint, currentUserID uint)
 
 func (s *LogService) GetByID(id uint) (*models.AuditLog, error) {
 	return s.repo.GetByID(id)
+}
+
+// RecordLogin 记录登录尝试，没有用户信息时按用户名查找（用户不存在时只记录用户名）
+func (s *LogService) RecordLogin(attempt *models.LoginAttempt) {
+	if attempt.UserID == nil && attempt.Username != "" {
+		if user, err := s.userRepo.GetByUsername(attempt.Username); err == nil {
+			attempt.UserID = &user.ID
+			attempt.TenantID = user.TenantID
+		}
+	}
+	if err := s.repo.CreateLoginAttempt(attempt); err != nil {
+		log.Printf("记录登录尝试失败: %v", err)
+	}
+}
+
+// ListLoginAttempts 查询登录记录，权限与查看审计日志相同；没有租户的登录记录（如不存在的用户名）只有超级管理员可以查看
+func (s *LogService) ListLoginAttempts(page, pageSize int, userID *uint, currentUserID uint) ([]models.LoginAttempt, error) {
+	offset := (page - 1) * pageSize
+
+	currentUser, err := s.userRepo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	if !s.roles.HasPermission(currentUser, authz.LogRead) {
+		return nil, errors.New("没有权限查看日志")
+	}
+
+	if currentUser.UserType == models.UserTypeSuperAdmin {
+		return s.repo.ListLoginAttempts(nil, offset, pageSize, userID)
+	}
+	if currentUser.TenantID == nil {
+		return []models.LoginAttempt{}, nil
+	}
+	return s.repo.ListLoginAttempts(currentUser.TenantID, offset, pageSize, userID)
 }
\ No newline at end of file
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+)
+
+var ErrAccountLocked = errors.New("登录失败次数过多，账号已被锁定，请稍后重试或联系管理员解锁")
+
+// LoginGuard 连续登录失败（密码或验证码错误）达到上限后锁定账号，登录成功后清零
+type LoginGuard struct {
+	repo        userStore
+	maxAttempts int
+	lockout     time.Duration
+}
+
+func NewLoginGuard(cfg *config.Config, repo *repository.UserRepository) *LoginGuard {
+	return &LoginGuard{repo: repo, maxAttempts: cfg.LoginMaxFailedAttempts, lockout: cfg.LoginLockoutDuration}
+}
+
+// Locked 账号是否处于锁定期；锁定时长为 0 时一直锁定到管理员解锁
+func (g *LoginGuard) Locked(user *models.User) bool {
+	if g.maxAttempts == 0 || user.LockedAt == nil {
+		return false
+	}
+	return g.lockout == 0 || time.Since(*user.LockedAt) < g.lockout
+}
+
+// Fail 记录一次登录失败，达到上限时锁定账号并返回 ErrAccountLocked；
+// 上一次锁定已到期时重新计数，到期后仍有完整的 maxAttempts 次尝试
+func (g *LoginGuard) Fail(user *models.User) error {
+	if g.maxAttempts == 0 {
+		return nil
+	}
+	// 锁定时长为 0 时锁定不会到期，零值时间不会匹配任何锁定记录
+	var lockExpiredBefore time.Time
+	if g.lockout > 0 {
+		lockExpiredBefore = time.Now().Add(-g.lockout)
+	}
+	count, err := g.repo.IncrementFailedLogins(user.ID, lockExpiredBefore)
+	if err != nil {
+		log.Printf("记录用户 %s 登录失败次数失败: %v", user.Username, err)
+		return nil
+	}
+	if count < g.maxAttempts {
+		return nil
+	}
+	if err := g.repo.Lock(user.ID, time.Now()); err != nil {
+		log.Printf("锁定用户 %s 失败: %v", user.Username, err)
+		return nil
+	}
+	log.Printf("用户 %s 连续 %d 次登录失败，已锁定", user.Username, count)
+	return ErrAccountLocked
+}
+
+// Succeed 登录成功后清零失败次数
+func (g *LoginGuard) Succeed(user *models.User) {
+	if user.FailedLoginCount == 0 && user.LockedAt == nil {
+		return
+	}
+	if err := g.repo.ResetFailedLogins(user.ID); err != nil {
+		log.Printf("清零用户 %s 登录失败次数失败: %v", user.Username, err)
+	}
+}
+
+// Unlock 管理员解除锁定
+func (g *LoginGuard) Unlock(userID uint) error {
+	return g.repo.ResetFailedLogins(userID)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	commonutils "github.com/addp/common/utils"
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"github.com/addp/system/pkg/utils"
+)
+
+const (
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaMaxAttempts 同一次登录允许输错验证码的次数，超过后需要重新输入密码
+	mfaMaxAttempts = 5
+)
+
+var (
+	ErrInvalidMFACode   = errors.New("验证码错误")
+	ErrMFAChallengeGone = errors.New("验证已失效，请重新登录")
+	ErrMFARequired      = errors.New("请输入身份验证器中的验证码")
+)
+
+// mfaChallengeStore 验证码登录的存储，由 repository.MFARepository 实现
+type mfaChallengeStore interface {
+	CreateChallenge(challenge *models.MFAChallenge) error
+	GetChallenge(tokenHash string) (*models.MFAChallenge, error)
+	IncrementAttempts(tokenHash string) (int, error)
+	DeleteChallenge(tokenHash string) (bool, error)
+	DeleteExpiredChallenges(now time.Time) error
+}
+
+// MFAService 基于 TOTP 的多因素认证：绑定身份验证器，以及密码验证通过后的验证码登录
+type MFAService struct {
+	repo          mfaChallengeStore
+	userRepo      userStore
+	guard         *LoginGuard
+	issuer        string
+	encryptionKey []byte
+}
+
+func NewMFAService(repo *repository.MFARepository, userRepo *repository.UserRepository, guard *LoginGuard, cfg *config.Config) *MFAService {
+	return &MFAService{
+		repo:          repo,
+		userRepo:      userRepo,
+		guard:         guard,
+		issuer:        cfg.MFAIssuer,
+		encryptionKey: cfg.EncryptionKey,
+	}
+}
+
+// Setup 生成新的 TOTP 密钥，用验证码确认（Enable）后才启用
+func (s *MFAService) Setup(userID uint) (*models.MFASetupResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.IsServiceAccount {
+		return nil, errors.New("服务账号不支持多因素认证")
+	}
+	if user.MFAEnabled {
+		return nil, errors.New("已启用多因素认证，请先停用")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := commonutils.Encrypt(secret, s.encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	user.MFASecret = encrypted
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &models.MFASetupResponse{
+		Secret:     secret,
+		OTPAuthURL: utils.TOTPURL(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 使用身份验证器生成的验证码确认绑定
+func (s *MFAService) Enable(userID uint, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.MFAEnabled {
+		return errors.New("已启用多因素认证")
+	}
+	if user.MFASecret == "" {
+		return errors.New("请先获取多因素认证密钥")
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		return err
+	}
+
+	user.MFAEnabled = true
+	return s.userRepo.Update(user)
+}
+
+// Disable 用户停用自己的多因素认证，需要输入当前验证码
+func (s *MFAService) Disable(userID uint, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if !user.MFAEnabled {
+		return errors.New("未启用多因素认证")
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		return err
+	}
+	return s.userRepo.ResetMFA(user.ID)
+}
+
+// Challenge 密码验证通过后创建验证码登录，令牌只在返回值中出现
+func (s *MFAService) Challenge(user *models.User) (*models.MFAChallengeResponse, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateChallenge(&models.MFAChallenge{
+		TokenHash: utils.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	}); err != nil {
+		return nil, err
+	}
+	return &models.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+// Verify 校验验证码完成登录。验证码错误计入账号的登录失败次数，同一次登录错误过多后失效；
+// 失败时只要能确定用户就同时返回用户，用于记录登录尝试
+func (s *MFAService) Verify(token, code string) (*models.User, error) {
+	hash := utils.HashToken(token)
+	challenge, err := s.repo.GetChallenge(hash)
+	if err != nil {
+		return nil, ErrMFAChallengeGone
+	}
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		return nil, ErrMFAChallengeGone
+	}
+	if !user.IsActive {
+		return user, ErrUserDisabled
+	}
+	if s.guard.Locked(user) {
+		s.repo.DeleteChallenge(hash)
+		return user, ErrAccountLocked
+	}
+
+	if err := s.verifyCode(user, code); err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			return user, err
+		}
+		if lockErr := s.guard.Fail(user); lockErr != nil {
+			s.repo.DeleteChallenge(hash)
+			return user, lockErr
+		}
+		if attempts, _ := s.repo.IncrementAttempts(hash); attempts >= mfaMaxAttempts {
+			s.repo.DeleteChallenge(hash)
+		}
+		return user, err
+	}
+
+	// 同一次登录只能完成一次
+	if deleted, err := s.repo.DeleteChallenge(hash); err != nil || !deleted {
+		return user, ErrMFAChallengeGone
+	}
+	s.guard.Succeed(user)
+	return user, nil
+}
+
+// VerifyCode 校验启用了多因素认证的用户在登录之外（如登录页修改过期密码）提交的验证码，
+// 验证码错误与登录时一样计入登录失败次数
+func (s *MFAService) VerifyCode(user *models.User, code string) error {
+	if code == "" {
+		return ErrMFARequired
+	}
+	if err := s.verifyCode(user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if lockErr := s.guard.Fail(user); lockErr != nil {
+				return lockErr
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// StartCleanup 定期清理未完成的验证码登录
+func (s *MFAService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.repo.DeleteExpiredChallenges(time.Now()); err != nil {
+				log.Printf("清理过期验证码登录失败: %v", err)
+			}
+		}
+	}()
+}
+
+// verifyCode 校验验证码，同一个验证码只能使用一次
+func (s *MFAService) verifyCode(user *models.User, code string) error {
+	secret, err := commonutils.Decrypt(user.MFASecret, s.encryptionKey)
+	if err != nil {
+		return fmt.Errorf("读取多因素认证密钥失败: %w", err)
+	}
+	step, ok := utils.VerifyTOTP(secret, code, time.Now())
+	if !ok || step <= user.MFALastStep {
+		return ErrInvalidMFACode
+	}
+	advanced, err := s.userRepo.AdvanceMFAStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidMFACode
+	}
+	user.MFALastStep = step
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// wrongCode 返回与当前验证码不同的六位数字
+func wrongCode(t *testing.T, secret string) string {
+	t.Helper()
+	if totpAt(t, secret, time.Now()) == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func TestMFAVerifyRejectsReplay(t *testing.T) {
+	f := newAuthFixture(t)
+	f.guard.maxAttempts = 0 // 重放同样计入登录失败次数，这里只检查重放本身
+	user, secret := f.addUser(t, "alice", testOldPassword, 0, true)
+	code := totpAt(t, secret, time.Now())
+
+	first, err := f.mfa.Challenge(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.mfa.Verify(first.MFAToken, code); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	// 同一次登录只能完成一次
+	if _, err := f.mfa.Verify(first.MFAToken, code); !errors.Is(err, ErrMFAChallengeGone) {
+		t.Fatalf("reused challenge: err = %v, want ErrMFAChallengeGone", err)
+	}
+
+	// 截获的验证码在新的一次登录中也不能再用，包括时钟偏差窗口内更早的验证码
+	for _, at := range []time.Time{time.Now(), time.Now().Add(-30 * time.Second)} {
+		second, err := f.mfa.Challenge(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.mfa.Verify(second.MFAToken, totpAt(t, secret, at)); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("replayed code at %v: err = %v, want ErrInvalidMFACode", at, err)
+		}
+	}
+	// 修改过期密码时提交的验证码与登录共用同一个重放检查
+	if err := f.mfa.VerifyCode(user, code); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("VerifyCode with used code: err = %v, want ErrInvalidMFACode", err)
+	}
+}
+
+func TestMFAVerifyWrongCodes(t *testing.T) {
+	f := newAuthFixture(t)
+	f.guard.maxAttempts = 0 // 只检查同一次登录的尝试次数
+	user, secret := f.addUser(t, "alice", testOldPassword, 0, true)
+
+	challenge, err := f.mfa.Challenge(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= mfaMaxAttempts; i++ {
+		if _, err := f.mfa.Verify(challenge.MFAToken, wrongCode(t, secret)); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidMFACode", i, err)
+		}
+	}
+	// 输错次数用完后正确的验证码也需要重新输入密码
+	if _, err := f.mfa.Verify(challenge.MFAToken, totpAt(t, secret, time.Now())); !errors.Is(err, ErrMFAChallengeGone) {
+		t.Fatalf("after %d failures: err = %v, want ErrMFAChallengeGone", mfaMaxAttempts, err)
+	}
+}
+
+func TestMFAVerifyLocksAccount(t *testing.T) {
+	f := newAuthFixture(t)
+	user, secret := f.addUser(t, "alice", testOldPassword, 0, true)
+
+	challenge, err := f.mfa.Challenge(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < f.cfg.LoginMaxFailedAttempts; i++ {
+		if _, err := f.mfa.Verify(challenge.MFAToken, wrongCode(t, secret)); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidMFACode", i, err)
+		}
+	}
+	if _, err := f.mfa.Verify(challenge.MFAToken, wrongCode(t, secret)); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("last attempt: err = %v, want ErrAccountLocked", err)
+	}
+
+	// 锁定期间即使重新输入正确的密码拿到验证码登录，也不能完成
+	next, err := f.mfa.Challenge(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.mfa.Verify(next.MFAToken, totpAt(t, secret, time.Now())); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("locked: err = %v, want ErrAccountLocked", err)
+	}
+	if _, err := f.service.Authenticate("alice", testOldPassword); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("Authenticate while locked: err = %v, want ErrAccountLocked", err)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"github.com/addp/system/pkg/utils"
+)
+
+var (
+	ErrPasswordExpired = errors.New("密码已过期，请修改密码后登录")
+	// ErrPasswordNotExpired 登录页的修改密码只用于已过期的密码，未过期时登录后修改
+	ErrPasswordNotExpired = errors.New("密码未过期，请登录后修改密码")
+)
+
+// PasswordPolicy 本地账号的密码复杂度、历史密码和有效期
+type PasswordPolicy struct {
+	cfg  *config.Config
+	repo userStore
+}
+
+func NewPasswordPolicy(cfg *config.Config, repo *repository.UserRepository) *PasswordPolicy {
+	return &PasswordPolicy{cfg: cfg, repo: repo}
+}
+
+// Validate 校验密码长度和包含的字符类别
+func (p *PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.cfg.PasswordMinLength {
+		return fmt.Errorf("密码长度不能少于 %d 位", p.cfg.PasswordMinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{upper, lower, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.cfg.PasswordMinCharClasses {
+		return fmt.Errorf("密码至少需要包含大写字母、小写字母、数字、符号中的 %d 类", p.cfg.PasswordMinCharClasses)
+	}
+	return nil
+}
+
+// SetPassword 校验新密码并写入用户，调用方保存用户后调用 Remember；
+// 已有用户的新密码不能与当前密码及最近 PasswordHistory 次用过的密码相同
+func (p *PasswordPolicy) SetPassword(user *models.User, password string) error {
+	if err := p.Validate(password); err != nil {
+		return err
+	}
+
+	if user.ID != 0 && p.cfg.PasswordHistory > 0 {
+		hashes, err := p.repo.ListPasswordHistory(user.ID, p.cfg.PasswordHistory)
+		if err != nil {
+			return err
+		}
+		// 升级前设置的密码没有历史记录
+		hashes = append(hashes, user.PasswordHash)
+		for _, hash := range hashes {
+			if utils.CheckPassword(password, hash) {
+				return fmt.Errorf("不能使用最近 %d 次用过的密码", p.cfg.PasswordHistory)
+			}
+		}
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.PasswordHash = hash
+	user.PasswordChangedAt = &now
+	return nil
+}
+
+// Remember 记录用户当前的密码，用于检查历史密码
+func (p *PasswordPolicy) Remember(user *models.User) {
+	if p.cfg.PasswordHistory == 0 {
+		return
+	}
+	if err := p.repo.AddPasswordHistory(user.ID, user.PasswordHash, p.cfg.PasswordHistory); err != nil {
+		log.Printf("记录用户 %s 的历史密码失败: %v", user.Username, err)
+	}
+}
+
+// Expired 本地密码是否已超过有效期，没有修改记录时按创建时间计算
+func (p *PasswordPolicy) Expired(user *models.User) bool {
+	if p.cfg.PasswordMaxAge == 0 || user.IsServiceAccount {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > p.cfg.PasswordMaxAge
+}
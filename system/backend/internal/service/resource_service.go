@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/addp/common/authz"
 	commonutils "github.com/addp/common/utils"
 	"github.com/addp/system/internal/models"
 	"github.com/addp/system/internal/repository"
@@ -15,16 +16,22 @@ var (
 	ErrResourceForbidden = errors.New("没有权限访问该资源")
 )
 
+// ResourceService 管理资源配置。访问资源需要同时满足：角色拥有对应权限（authz.ResourceRead 等），
+// 且对该资源的访问级别（authz.AccessView 等）足够，访问级别由资源授权决定
 type ResourceService struct {
 	repo          *repository.ResourceRepository
+	grantRepo     *repository.ResourceGrantRepository
 	userRepo      *repository.UserRepository
+	roles         *RoleService
 	encryptionKey []byte
 }
 
-func NewResourceService(repo *repository.ResourceRepository, userRepo *repository.UserRepository, encryptionKey []byte) *ResourceService {
+func NewResourceService(repo *repository.ResourceRepository, grantRepo *repository.ResourceGrantRepository, userRepo *repository.UserRepository, roles *RoleService, encryptionKey []byte) *ResourceService {
 	return &ResourceService{
 		repo:          repo,
+		grantRepo:     grantRepo,
 		userRepo:      userRepo,
+		roles:         roles,
 		encryptionKey: encryptionKey,
 	}
 }
@@ -36,7 +43,7 @@ func (s *ResourceService) Create(req *models.ResourceCreateRequest, createdBy ui
 		return nil, errors.New("用户不存在")
 	}
 
-	if err := s.ensureResourceManagementPermission(user); err != nil {
+	if err := s.ensurePermission(user, authz.ResourceManage); err != nil {
 		return nil, err
 	}
 
@@ -68,6 +75,9 @@ func (s *ResourceService) GetByID(id uint, currentUserID uint) (*models.Resource
 	if err != nil {
 		return nil, err
 	}
+	if err := s.ensurePermission(currentUser, authz.ResourceRead); err != nil {
+		return nil, err
+	}
 
 	resource, err := s.repo.GetByID(id)
 	if err != nil {
@@ -77,11 +87,17 @@ func (s *ResourceService) GetByID(id uint, currentUserID uint) (*models.Resource
 		return nil, err
 	}
 
-	if err := s.authorizeResourceAccess(resource, currentUser); err != nil {
+	level, err := s.accessLevel(resource, currentUser)
+	if err != nil {
 		return nil, err
 	}
+	if !authz.AccessAllows(level, authz.AccessView) {
+		return nil, ErrResourceForbidden
+	}
 
-	return s.sanitizeResource(resource), nil
+	sanitized := s.sanitizeResource(resource)
+	sanitized.AccessLevel = level
+	return sanitized, nil
 }
 
 func (s *ResourceService) List(page, pageSize int, resourceType string, currentUserID uint) ([]models.Resource, error) {
@@ -92,27 +108,44 @@ func (s *ResourceService) List(page, pageSize int, resourceType string, currentU
 	if err != nil {
 		return nil, err
 	}
+	if err := s.ensurePermission(currentUser, authz.ResourceRead); err != nil {
+		return nil, err
+	}
 
 	var resources []models.Resource
 
-	// SuperAdmin可以查看所有资源
-	if currentUser.UserType == models.UserTypeSuperAdmin {
+	// SuperAdmin可以查看所有资源，租户管理员可以查看本租户所有资源，普通用户只能查看授权给自己的资源
+	var roleIDs []uint
+	switch {
+	case currentUser.UserType == models.UserTypeSuperAdmin:
 		resources, err = s.repo.List(offset, pageSize, resourceType)
-	} else {
-		if currentUser.TenantID == nil {
-			return nil, errors.New("当前用户未关联租户，无法访问资源")
-		}
+	case currentUser.TenantID == nil:
+		return nil, errors.New("当前用户未关联租户，无法访问资源")
+	case currentUser.UserType == models.UserTypeTenantAdmin:
 		resources, err = s.repo.ListByTenant(*currentUser.TenantID, offset, pageSize, resourceType)
+	default:
+		roleIDs, err = s.roles.RoleIDs(currentUser)
+		if err != nil {
+			return nil, err
+		}
+		resources, err = s.repo.ListVisibleByTenant(*currentUser.TenantID, currentUser.ID, roleIDs, offset, pageSize, resourceType)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
+	grants, err := s.grantRepo.ListByResources(resourceIDs(resources))
 	if err != nil {
 		return nil, err
 	}
 
-	// 脱敏敏感字段
+	// 脱敏敏感字段，附带当前用户的访问级别
 	sanitized := make([]models.Resource, 0, len(resources))
 	for i := range resources {
-		sanitized = append(sanitized, *s.sanitizeResource(&resources[i]))
+		resource := s.sanitizeResource(&resources[i])
+		resource.AccessLevel = levelFor(&resources[i], currentUser, grants[resources[i].ID], roleIDs)
+		sanitized = append(sanitized, *resource)
 	}
 
 	return sanitized, nil
@@ -132,11 +165,11 @@ func (s *ResourceService) Update(id uint, req *models.ResourceUpdateRequest, cur
 		return nil, err
 	}
 
-	if err := s.authorizeResourceAccess(resource, currentUser); err != nil {
+	if err := s.ensureAccess(resource, currentUser, authz.AccessManage); err != nil {
 		return nil, err
 	}
 
-	if err := s.ensureResourceManagementPermission(currentUser); err != nil {
+	if err := s.ensurePermission(currentUser, authz.ResourceManage); err != nil {
 		return nil, err
 	}
 
@@ -179,19 +212,19 @@ func (s *ResourceService) Delete(id uint, currentUserID uint) error {
 		return err
 	}
 
-	if err := s.authorizeResourceAccess(resource, currentUser); err != nil {
+	if err := s.ensureAccess(resource, currentUser, authz.AccessManage); err != nil {
 		return err
 	}
 
-	if err := s.ensureResourceManagementPermission(currentUser); err != nil {
+	if err := s.ensurePermission(currentUser, authz.ResourceManage); err != nil {
 		return err
 	}
 
 	return s.repo.Delete(id)
 }
 
-// ListInternal 内部服务调用的资源列表查询（不做租户权限检查）
-func (s *ResourceService) ListInternal(resourceType string, tenantID uint) ([]models.Resource, error) {
+// ListInternal 内部服务调用的资源列表查询；userID 不为 0 时只返回该用户访问级别不低于 level 的资源
+func (s *ResourceService) ListInternal(resourceType string, tenantID, userID uint, level string) ([]models.Resource, error) {
 	var resources []models.Resource
 	var err error
 
@@ -207,6 +240,13 @@ func (s *ResourceService) ListInternal(resourceType string, tenantID uint) ([]mo
 		return nil, err
 	}
 
+	if userID > 0 {
+		resources, err = s.filterAccessible(resources, userID, level)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 解密所有资源的敏感字段
 	for i := range resources {
 		decryptedConnInfo, err := s.decryptSensitiveFields(resources[i].ConnectionInfo)
@@ -219,8 +259,8 @@ func (s *ResourceService) ListInternal(resourceType string, tenantID uint) ([]mo
 	return resources, nil
 }
 
-// GetByIDInternal 内部服务直接访问资源详情（返回解密信息）
-func (s *ResourceService) GetByIDInternal(id uint) (*models.Resource, error) {
+// GetByIDInternal 内部服务直接访问资源详情（返回解密信息）；userID 不为 0 时要求该用户的访问级别不低于 level
+func (s *ResourceService) GetByIDInternal(id, userID uint, level string) (*models.Resource, error) {
 	resource, err := s.repo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -229,6 +269,16 @@ func (s *ResourceService) GetByIDInternal(id uint) (*models.Resource, error) {
 		return nil, err
 	}
 
+	if userID > 0 {
+		user, err := s.getCurrentUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.ensureAccess(resource, user, level); err != nil {
+			return nil, err
+		}
+	}
+
 	decryptedConnInfo, err := s.decryptSensitiveFields(resource.ConnectionInfo)
 	if err != nil {
 		return nil, fmt.Errorf("解密连接信息失败: %w", err)
@@ -254,11 +304,11 @@ func (s *ResourceService) GetForConnection(id uint, currentUserID uint) (*models
 		return nil, err
 	}
 
-	if err := s.authorizeResourceAccess(resource, currentUser); err != nil {
+	if err := s.ensureAccess(resource, currentUser, authz.AccessConnect); err != nil {
 		return nil, err
 	}
 
-	if err := s.ensureResourceManagementPermission(currentUser); err != nil {
+	if err := s.ensurePermission(currentUser, authz.ResourceConn); err != nil {
 		return nil, err
 	}
 
@@ -359,22 +409,196 @@ func (s *ResourceService) getCurrentUser(userID uint) (*models.User, error) {
 	return user, nil
 }
 
-func (s *ResourceService) authorizeResourceAccess(resource *models.Resource, user *models.User) error {
-	if user.UserType == models.UserTypeSuperAdmin {
-		return nil
+// ListGrants 查询资源的授权，需要资源管理权限和 manage 访问级别
+func (s *ResourceService) ListGrants(resourceID uint, currentUserID uint) ([]models.ResourceGrant, error) {
+	if _, _, err := s.getManaged(resourceID, currentUserID); err != nil {
+		return nil, err
 	}
+	return s.grantRepo.ListByResource(resourceID)
+}
 
-	if user.TenantID == nil || resource.TenantID == nil {
-		return ErrResourceForbidden
+// Grant 把资源授予同租户的用户或角色，已有授权时更新级别
+func (s *ResourceService) Grant(resourceID uint, req *models.ResourceGrantRequest, currentUserID uint) (*models.ResourceGrant, error) {
+	resource, currentUser, err := s.getManaged(resourceID, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if authz.AccessRank(req.Level) == 0 {
+		return nil, fmt.Errorf("未知的访问级别: %s", req.Level)
 	}
 
-	if *user.TenantID != *resource.TenantID {
-		return ErrResourceForbidden
+	switch req.SubjectType {
+	case models.GrantSubjectUser:
+		user, err := s.userRepo.GetByID(req.SubjectID)
+		if err != nil {
+			return nil, errors.New("被授权的用户不存在")
+		}
+		if user.TenantID == nil || resource.TenantID == nil || *user.TenantID != *resource.TenantID {
+			return nil, errors.New("只能授权给资源所在租户的用户")
+		}
+	case models.GrantSubjectRole:
+		if _, err := s.roles.GetForTenant(req.SubjectID, resource.TenantID); err != nil {
+			return nil, err
+		}
+	case models.GrantSubjectTenant:
+		if resource.TenantID == nil || req.SubjectID != *resource.TenantID {
+			return nil, errors.New("只能授权给资源所在的租户")
+		}
+	default:
+		return nil, fmt.Errorf("未知的授权对象类型: %s", req.SubjectType)
 	}
 
+	grant := &models.ResourceGrant{
+		ResourceID:  resource.ID,
+		SubjectType: req.SubjectType,
+		SubjectID:   req.SubjectID,
+		Level:       req.Level,
+		CreatedBy:   &currentUser.ID,
+	}
+	if err := s.grantRepo.Upsert(grant); err != nil {
+		return nil, err
+	}
+	return s.grantRepo.GetBySubject(resource.ID, req.SubjectType, req.SubjectID)
+}
+
+// RevokeGrant 删除资源授权
+func (s *ResourceService) RevokeGrant(resourceID, grantID uint, currentUserID uint) error {
+	if _, _, err := s.getManaged(resourceID, currentUserID); err != nil {
+		return err
+	}
+	grant, err := s.grantRepo.GetByID(grantID)
+	if err != nil || grant.ResourceID != resourceID {
+		return errors.New("授权不存在")
+	}
+	return s.grantRepo.Delete(grant.ID)
+}
+
+// getManaged 返回当前用户可以管理授权的资源
+func (s *ResourceService) getManaged(resourceID uint, currentUserID uint) (*models.Resource, *models.User, error) {
+	currentUser, err := s.getCurrentUser(currentUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensurePermission(currentUser, authz.ResourceManage); err != nil {
+		return nil, nil, err
+	}
+
+	resource, err := s.repo.GetByID(resourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrResourceNotFound
+		}
+		return nil, nil, err
+	}
+	if err := s.ensureAccess(resource, currentUser, authz.AccessManage); err != nil {
+		return nil, nil, err
+	}
+	return resource, currentUser, nil
+}
+
+// ensureAccess 检查用户对资源的访问级别是否满足 level
+func (s *ResourceService) ensureAccess(resource *models.Resource, user *models.User, level string) error {
+	granted, err := s.accessLevel(resource, user)
+	if err != nil {
+		return err
+	}
+	if !authz.AccessAllows(granted, level) {
+		return ErrResourceForbidden
+	}
 	return nil
 }
 
+// accessLevel 返回用户对资源的访问级别，空字符串表示不能访问
+func (s *ResourceService) accessLevel(resource *models.Resource, user *models.User) (string, error) {
+	grants, err := s.grantRepo.ListByResource(resource.ID)
+	if err != nil {
+		return "", err
+	}
+	roleIDs, err := s.userRoleIDs(user)
+	if err != nil {
+		return "", err
+	}
+	return levelFor(resource, user, grants, roleIDs), nil
+}
+
+// filterAccessible 过滤出用户访问级别不低于 level 的资源
+func (s *ResourceService) filterAccessible(resources []models.Resource, userID uint, level string) ([]models.Resource, error) {
+	user, err := s.getCurrentUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs, err := s.userRoleIDs(user)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.grantRepo.ListByResources(resourceIDs(resources))
+	if err != nil {
+		return nil, err
+	}
+
+	accessible := make([]models.Resource, 0, len(resources))
+	for i := range resources {
+		if authz.AccessAllows(levelFor(&resources[i], user, grants[resources[i].ID], roleIDs), level) {
+			accessible = append(accessible, resources[i])
+		}
+	}
+	return accessible, nil
+}
+
+// userRoleIDs 普通用户生效的角色 ID，管理员不需要按角色匹配授权
+func (s *ResourceService) userRoleIDs(user *models.User) ([]uint, error) {
+	if user.UserType != models.UserTypeUser {
+		return nil, nil
+	}
+	return s.roles.RoleIDs(user)
+}
+
+// levelFor 计算访问级别：超级管理员、本租户管理员和创建者为 manage；
+// 其余用户取授予本人、其角色或整个租户的最高级别，没有匹配的授权时不能访问
+func levelFor(resource *models.Resource, user *models.User, grants []models.ResourceGrant, roleIDs []uint) string {
+	if !user.IsActive {
+		return ""
+	}
+	if user.UserType == models.UserTypeSuperAdmin {
+		return authz.AccessManage
+	}
+	if user.TenantID == nil || resource.TenantID == nil || *user.TenantID != *resource.TenantID {
+		return ""
+	}
+	if user.UserType == models.UserTypeTenantAdmin {
+		return authz.AccessManage
+	}
+	if resource.CreatedBy != nil && *resource.CreatedBy == user.ID {
+		return authz.AccessManage
+	}
+
+	level := ""
+	for _, grant := range grants {
+		matched := grant.SubjectType == models.GrantSubjectUser && grant.SubjectID == user.ID ||
+			grant.SubjectType == models.GrantSubjectTenant && grant.SubjectID == *user.TenantID
+		if grant.SubjectType == models.GrantSubjectRole {
+			for _, roleID := range roleIDs {
+				if grant.SubjectID == roleID {
+					matched = true
+					break
+				}
+			}
+		}
+		if matched && authz.AccessRank(grant.Level) > authz.AccessRank(level) {
+			level = grant.Level
+		}
+	}
+	return level
+}
+
+func resourceIDs(resources []models.Resource) []uint {
+	ids := make([]uint, 0, len(resources))
+	for _, resource := range resources {
+		ids = append(ids, resource.ID)
+	}
+	return ids
+}
+
 func (s *ResourceService) isSensitiveField(field string) bool {
 	switch field {
 	case "password", "access_key", "secret_key", "token", "api_key":
@@ -384,8 +608,9 @@ func (s *ResourceService) isSensitiveField(field string) bool {
 	}
 }
 
-func (s *ResourceService) ensureResourceManagementPermission(user *models.User) error {
-	if user.UserType == models.UserTypeSuperAdmin || user.UserType == models.UserTypeTenantAdmin {
+// ensurePermission 检查用户是否拥有资源相关权限
+func (s *ResourceService) ensurePermission(user *models.User, permission string) error {
+	if s.roles.HasPermission(user, permission) {
 		return nil
 	}
 	return ErrResourceForbidden
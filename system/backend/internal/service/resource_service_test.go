@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/addp/common/authz"
+	"github.com/addp/system/internal/models"
+)
+
+func TestLevelFor(t *testing.T) {
+	tenantID, otherTenantID := uint(1), uint(2)
+	creatorID := uint(10)
+	resource := &models.Resource{ID: 100, TenantID: &tenantID, CreatedBy: &creatorID}
+
+	user := func(id uint, userType models.UserType, tenant *uint) *models.User {
+		return &models.User{ID: id, UserType: userType, TenantID: tenant, IsActive: true}
+	}
+	superAdmin := user(1, models.UserTypeSuperAdmin, nil)
+	tenantAdmin := user(2, models.UserTypeTenantAdmin, &tenantID)
+	creator := user(creatorID, models.UserTypeUser, &tenantID)
+	alice := user(11, models.UserTypeUser, &tenantID)
+	bob := user(12, models.UserTypeUser, &tenantID)
+	outsider := user(13, models.UserTypeUser, &otherTenantID)
+	inactive := user(14, models.UserTypeUser, &tenantID)
+	inactive.IsActive = false
+
+	userGrant := func(id uint, level string) models.ResourceGrant {
+		return models.ResourceGrant{ResourceID: resource.ID, SubjectType: models.GrantSubjectUser, SubjectID: id, Level: level}
+	}
+	roleGrant := func(id uint, level string) models.ResourceGrant {
+		return models.ResourceGrant{ResourceID: resource.ID, SubjectType: models.GrantSubjectRole, SubjectID: id, Level: level}
+	}
+	tenantGrant := func(id uint, level string) models.ResourceGrant {
+		return models.ResourceGrant{ResourceID: resource.ID, SubjectType: models.GrantSubjectTenant, SubjectID: id, Level: level}
+	}
+
+	cases := []struct {
+		name    string
+		user    *models.User
+		grants  []models.ResourceGrant
+		roleIDs []uint
+		want    string
+	}{
+		// 没有授权时只有管理员和创建者可以访问
+		{"no grants: super admin", superAdmin, nil, nil, authz.AccessManage},
+		{"no grants: tenant admin", tenantAdmin, nil, nil, authz.AccessManage},
+		{"no grants: creator", creator, nil, nil, authz.AccessManage},
+		{"no grants: tenant user denied", alice, nil, nil, ""},
+		{"no grants: other tenant denied", outsider, nil, nil, ""},
+
+		// 第一条授权只给被授权的用户增加访问，不影响其他用户
+		{"first grant: grantee", bob, []models.ResourceGrant{userGrant(bob.ID, authz.AccessPreview)}, nil, authz.AccessPreview},
+		{"first grant: other user still denied", alice, []models.ResourceGrant{userGrant(bob.ID, authz.AccessPreview)}, nil, ""},
+		{"first grant: creator keeps manage", creator, []models.ResourceGrant{userGrant(bob.ID, authz.AccessView)}, nil, authz.AccessManage},
+
+		// 租户授权对本租户所有用户生效，之后的授权只能提升级别
+		{"tenant grant", alice, []models.ResourceGrant{tenantGrant(tenantID, authz.AccessView)}, nil, authz.AccessView},
+		{"tenant grant kept after user grant", alice,
+			[]models.ResourceGrant{tenantGrant(tenantID, authz.AccessView), userGrant(bob.ID, authz.AccessConnect)}, nil, authz.AccessView},
+		{"highest of tenant and user grants", bob,
+			[]models.ResourceGrant{tenantGrant(tenantID, authz.AccessView), userGrant(bob.ID, authz.AccessConnect)}, nil, authz.AccessConnect},
+		{"tenant grant for another tenant", alice, []models.ResourceGrant{tenantGrant(otherTenantID, authz.AccessManage)}, nil, ""},
+		{"outsider ignores tenant grant", outsider, []models.ResourceGrant{tenantGrant(tenantID, authz.AccessManage)}, nil, ""},
+
+		{"role grant", alice, []models.ResourceGrant{roleGrant(5, authz.AccessConnect)}, []uint{4, 5}, authz.AccessConnect},
+		{"role grant for another role", alice, []models.ResourceGrant{roleGrant(6, authz.AccessConnect)}, []uint{4, 5}, ""},
+		{"inactive user", inactive, []models.ResourceGrant{tenantGrant(tenantID, authz.AccessManage)}, nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := levelFor(resource, tc.user, tc.grants, tc.roleIDs); got != tc.want {
+				t.Fatalf("levelFor = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/addp/common/authz"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"gorm.io/gorm"
+)
+
+// roleStore RoleService 使用的角色存储（repository.RoleRepository）
+type roleStore interface {
+	Create(role *models.Role) error
+	GetByID(id uint) (*models.Role, error)
+	GetByName(tenantID *uint, name string) (*models.Role, error)
+	ListVisible(tenantID *uint) ([]models.Role, error)
+	Update(role *models.Role) error
+	Delete(id uint) error
+	ListByUser(userID uint) ([]models.Role, error)
+	SetUserRoles(userID uint, roleIDs []uint) error
+	DeleteUserRoles(userID uint) error
+}
+
+// roleUsers RoleService 查询用户使用的存储
+type roleUsers interface {
+	GetByID(id uint) (*models.User, error)
+}
+
+// RoleService 管理租户角色并计算用户的有效权限：
+// 超级管理员拥有全部权限，租户管理员拥有全部租户权限，普通用户拥有所分配角色的权限之和，未分配角色时使用内置 user 角色
+type RoleService struct {
+	repo     roleStore
+	userRepo roleUsers
+}
+
+func NewRoleService(repo *repository.RoleRepository, userRepo *repository.UserRepository) *RoleService {
+	return &RoleService{repo: repo, userRepo: userRepo}
+}
+
+// Permissions 返回用户的有效权限（已排序）
+func (s *RoleService) Permissions(user *models.User) ([]string, error) {
+	if !user.IsActive {
+		return []string{}, nil
+	}
+	switch user.UserType {
+	case models.UserTypeSuperAdmin:
+		return authz.All(), nil
+	case models.UserTypeTenantAdmin:
+		return authz.TenantScoped(), nil
+	}
+
+	roles, err := s.effectiveRoles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			if authz.Valid(permission) && !authz.IsPlatform(permission) {
+				set[permission] = true
+			}
+		}
+	}
+	permissions := make([]string, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// RoleIDs 返回普通用户生效的角色 ID，用于匹配授予角色的资源
+func (s *RoleService) RoleIDs(user *models.User) ([]uint, error) {
+	roles, err := s.effectiveRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(roles))
+	for _, role := range roles {
+		ids = append(ids, role.ID)
+	}
+	return ids, nil
+}
+
+// effectiveRoles 返回用户生效的角色：已分配的内置角色和本租户角色，未分配角色时使用内置 user 角色
+func (s *RoleService) effectiveRoles(user *models.User) ([]models.Role, error) {
+	assigned, err := s.repo.ListByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assigned) == 0 {
+		role, err := s.repo.GetByName(nil, models.RoleUser)
+		if err != nil {
+			return nil, fmt.Errorf("内置角色 %s 不存在: %w", models.RoleUser, err)
+		}
+		return []models.Role{*role}, nil
+	}
+
+	roles := make([]models.Role, 0, len(assigned))
+	for _, role := range assigned {
+		if role.TenantID != nil && (user.TenantID == nil || *role.TenantID != *user.TenantID) {
+			continue
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// HasPermission 用户是否拥有权限，查询失败时视为没有权限
+func (s *RoleService) HasPermission(user *models.User, permission string) bool {
+	permissions, err := s.Permissions(user)
+	if err != nil {
+		log.Printf("查询用户 %d 的权限失败: %v", user.ID, err)
+		return false
+	}
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UserPermissions 按用户 ID 查询有效权限
+func (s *RoleService) UserPermissions(userID uint) ([]string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	return s.Permissions(user)
+}
+
+// ListPermissions 返回当前用户可以分配的权限
+func (s *RoleService) ListPermissions(currentUserID uint) ([]authz.Permission, error) {
+	currentUser, err := s.userRepo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	permissions := make([]authz.Permission, 0, len(authz.Catalog))
+	for _, p := range authz.Catalog {
+		if p.Platform && currentUser.UserType != models.UserTypeSuperAdmin {
+			continue
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, nil
+}
+
+// List 返回内置角色和当前用户所在租户的角色
+func (s *RoleService) List(currentUserID uint) ([]models.Role, error) {
+	currentUser, err := s.userRepo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	return s.repo.ListVisible(currentUser.TenantID)
+}
+
+func (s *RoleService) Create(req *models.RoleCreateRequest, currentUserID uint) (*models.Role, error) {
+	currentUser, err := s.userRepo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	if currentUser.TenantID == nil {
+		return nil, errors.New("角色属于租户，当前用户未关联租户")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.validateName(currentUser.TenantID, name, 0); err != nil {
+		return nil, err
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		TenantID:    currentUser.TenantID,
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := s.repo.Create(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *RoleService) Update(id uint, req *models.RoleUpdateRequest, currentUserID uint) (*models.Role, error) {
+	role, err := s.getEditable(id, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if err := s.validateName(role.TenantID, name, role.ID); err != nil {
+			return nil, err
+		}
+		role.Name = name
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		permissions, err := normalizePermissions(*req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+
+	if err := s.repo.Update(role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *RoleService) Delete(id uint, currentUserID uint) error {
+	role, err := s.getEditable(id, currentUserID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(role.ID)
+}
+
+// GetUserRoles 查询用户已分配的角色
+func (s *RoleService) GetUserRoles(userID uint, currentUserID uint) ([]models.Role, error) {
+	if _, err := s.getManagedUser(userID, currentUserID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(userID)
+}
+
+// SetUserRoles 替换用户的角色，角色必须是内置角色或用户所在租户的角色
+func (s *RoleService) SetUserRoles(userID uint, roleIDs []uint, currentUserID uint) ([]models.Role, error) {
+	user, err := s.getManagedUser(userID, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.UserType != models.UserTypeUser {
+		return nil, errors.New("只能为普通用户分配角色，管理员拥有全部权限")
+	}
+
+	seen := make(map[uint]bool, len(roleIDs))
+	ids := make([]uint, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		if seen[roleID] {
+			continue
+		}
+		seen[roleID] = true
+
+		if _, err := s.GetForTenant(roleID, user.TenantID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, roleID)
+	}
+
+	if err := s.repo.SetUserRoles(userID, ids); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(userID)
+}
+
+// GetForTenant 查询可在租户内使用的角色（内置角色或该租户的角色）
+func (s *RoleService) GetForTenant(roleID uint, tenantID *uint) (*models.Role, error) {
+	role, err := s.repo.GetByID(roleID)
+	if err != nil {
+		return nil, fmt.Errorf("角色 %d 不存在", roleID)
+	}
+	if role.TenantID != nil && (tenantID == nil || *role.TenantID != *tenantID) {
+		return nil, fmt.Errorf("角色 %d 不属于该租户", roleID)
+	}
+	return role, nil
+}
+
+// RemoveUser 删除用户的角色分配（删除用户时调用）
+func (s *RoleService) RemoveUser(userID uint) error {
+	return s.repo.DeleteUserRoles(userID)
+}
+
+// getEditable 返回当前用户可以修改的角色（本租户的自定义角色）
+func (s *RoleService) getEditable(id uint, currentUserID uint) (*models.Role, error) {
+	currentUser, err := s.userRepo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	role, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("角色不存在")
+	}
+	if role.IsBuiltin || role.TenantID == nil {
+		return nil, errors.New("内置角色不能修改或删除")
+	}
+	if currentUser.TenantID == nil || *role.TenantID != *currentUser.TenantID {
+		return nil, errors.New("只能管理本租户的角色")
+	}
+	return role, nil
+}
+
+// getManagedUser 返回当前用户可以分配角色的用户（同租户）
+func (s *RoleService) getManagedUser(userID uint, currentUserID uint) (*models.User, error) {
+	currentUser, err := s.userRepo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if currentUser.UserType == models.UserTypeSuperAdmin {
+		return user, nil
+	}
+	if user.TenantID == nil || currentUser.TenantID == nil || *user.TenantID != *currentUser.TenantID {
+		return nil, errors.New("只能管理同租户用户的角色")
+	}
+	return user, nil
+}
+
+// validateName 角色名在租户内唯一，且不能与内置角色重名
+func (s *RoleService) validateName(tenantID *uint, name string, currentID uint) error {
+	if name == "" {
+		return errors.New("角色名不能为空")
+	}
+	if _, err := s.repo.GetByName(nil, name); err == nil {
+		return errors.New("不能与内置角色重名")
+	}
+	existing, err := s.repo.GetByName(tenantID, name)
+	if err == nil && existing.ID != currentID {
+		return errors.New("角色名已存在")
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// normalizePermissions 校验权限名并去重排序，租户角色不能包含平台级权限
+func normalizePermissions(permissions []string) (models.StringList, error) {
+	set := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		permission = strings.TrimSpace(permission)
+		if !authz.Valid(permission) {
+			return nil, fmt.Errorf("未知的权限: %s", permission)
+		}
+		if authz.IsPlatform(permission) {
+			return nil, fmt.Errorf("权限 %s 不能分配给租户角色", permission)
+		}
+		set[permission] = true
+	}
+	result := make(models.StringList, 0, len(set))
+	for permission := range set {
+		result = append(result, permission)
+	}
+	sort.Strings(result)
+	return result, nil
+}
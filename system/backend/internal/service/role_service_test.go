@@ -0,0 +1,317 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/addp/common/authz"
+	"github.com/addp/system/internal/models"
+	"gorm.io/gorm"
+)
+
+// memRoles 内存中的角色和角色分配
+type memRoles struct {
+	roles       map[uint]*models.Role
+	assignments map[uint][]uint // 用户 ID → 角色 ID
+	nextID      uint
+}
+
+func newMemRoles() *memRoles {
+	m := &memRoles{roles: map[uint]*models.Role{}, assignments: map[uint][]uint{}}
+	for _, role := range models.BuiltinRoles {
+		role.IsBuiltin = true
+		m.Create(&role)
+	}
+	return m
+}
+
+func (m *memRoles) Create(role *models.Role) error {
+	m.nextID++
+	role.ID = m.nextID
+	stored := *role
+	m.roles[role.ID] = &stored
+	return nil
+}
+
+func (m *memRoles) GetByID(id uint) (*models.Role, error) {
+	role, ok := m.roles[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *role
+	return &found, nil
+}
+
+func (m *memRoles) GetByName(tenantID *uint, name string) (*models.Role, error) {
+	for id, role := range m.roles {
+		if role.Name == name && sameTenant(role.TenantID, tenantID) {
+			return m.GetByID(id)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memRoles) ListVisible(tenantID *uint) ([]models.Role, error) {
+	var roles []models.Role
+	for id := uint(1); id <= m.nextID; id++ {
+		if role, ok := m.roles[id]; ok && (role.TenantID == nil || sameTenant(role.TenantID, tenantID)) {
+			roles = append(roles, *role)
+		}
+	}
+	return roles, nil
+}
+
+func (m *memRoles) Update(role *models.Role) error {
+	stored := *role
+	m.roles[role.ID] = &stored
+	return nil
+}
+
+func (m *memRoles) Delete(id uint) error {
+	delete(m.roles, id)
+	for userID, roleIDs := range m.assignments {
+		kept := roleIDs[:0]
+		for _, roleID := range roleIDs {
+			if roleID != id {
+				kept = append(kept, roleID)
+			}
+		}
+		m.assignments[userID] = kept
+	}
+	return nil
+}
+
+func (m *memRoles) ListByUser(userID uint) ([]models.Role, error) {
+	roles := []models.Role{}
+	for _, roleID := range m.assignments[userID] {
+		if role, ok := m.roles[roleID]; ok {
+			roles = append(roles, *role)
+		}
+	}
+	return roles, nil
+}
+
+func (m *memRoles) SetUserRoles(userID uint, roleIDs []uint) error {
+	m.assignments[userID] = append([]uint(nil), roleIDs...)
+	return nil
+}
+
+func (m *memRoles) DeleteUserRoles(userID uint) error {
+	delete(m.assignments, userID)
+	return nil
+}
+
+func sameTenant(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// rbacFixture 两个租户、各自的管理员和普通用户
+type rbacFixture struct {
+	roles   *memRoles
+	users   *memUserStore
+	service *RoleService
+
+	tenantA, tenantB     uint
+	superAdmin           *models.User
+	adminA, alice, bob   *models.User
+	adminB, carol        *models.User
+	userRoleID, viewerID uint
+}
+
+func newRBACFixture(t *testing.T) *rbacFixture {
+	t.Helper()
+	f := &rbacFixture{roles: newMemRoles(), users: newMemUserStore(), tenantA: 1, tenantB: 2}
+	f.service = &RoleService{repo: f.roles, userRepo: f.users}
+
+	add := func(name string, userType models.UserType, tenantID *uint) *models.User {
+		user := &models.User{Username: name, UserType: userType, TenantID: tenantID, IsActive: true}
+		if err := f.users.Create(user); err != nil {
+			t.Fatal(err)
+		}
+		return user
+	}
+	f.superAdmin = add("root", models.UserTypeSuperAdmin, nil)
+	f.adminA = add("admin-a", models.UserTypeTenantAdmin, &f.tenantA)
+	f.alice = add("alice", models.UserTypeUser, &f.tenantA)
+	f.bob = add("bob", models.UserTypeUser, &f.tenantA)
+	f.adminB = add("admin-b", models.UserTypeTenantAdmin, &f.tenantB)
+	f.carol = add("carol", models.UserTypeUser, &f.tenantB)
+
+	user, _ := f.roles.GetByName(nil, models.RoleUser)
+	viewer, _ := f.roles.GetByName(nil, models.RoleViewer)
+	f.userRoleID, f.viewerID = user.ID, viewer.ID
+	return f
+}
+
+func (f *rbacFixture) createRole(t *testing.T, admin *models.User, name string, permissions ...string) *models.Role {
+	t.Helper()
+	role, err := f.service.Create(&models.RoleCreateRequest{Name: name, Permissions: permissions}, admin.ID)
+	if err != nil {
+		t.Fatalf("Create role %s: %v", name, err)
+	}
+	return role
+}
+
+func TestRolePermissions(t *testing.T) {
+	f := newRBACFixture(t)
+	auditor := f.createRole(t, f.adminA, "auditor", authz.LogRead, authz.UserRead)
+	scanner := f.createRole(t, f.adminA, "scanner", authz.MetaRead, authz.MetaScan)
+	foreign := f.createRole(t, f.adminB, "operator", authz.TransferRun)
+
+	permissions := func(user *models.User) []string {
+		t.Helper()
+		got, err := f.service.Permissions(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	sorted := func(names ...string) []string {
+		sort.Strings(names)
+		return names
+	}
+
+	// 超级管理员拥有全部权限，租户管理员拥有全部租户权限（不含平台级权限）
+	if got := permissions(f.superAdmin); !reflect.DeepEqual(got, authz.All()) {
+		t.Fatalf("super admin = %v", got)
+	}
+	if got := permissions(f.adminA); !reflect.DeepEqual(got, authz.TenantScoped()) {
+		t.Fatalf("tenant admin = %v", got)
+	}
+	if f.service.HasPermission(f.adminA, authz.TenantManage) {
+		t.Fatal("tenant admin has platform permission")
+	}
+
+	// 未分配角色时使用内置 user 角色
+	builtin, _ := f.roles.GetByID(f.userRoleID)
+	if got := permissions(f.alice); !reflect.DeepEqual(got, sorted(builtin.Permissions...)) {
+		t.Fatalf("unassigned user = %v, want %v", got, builtin.Permissions)
+	}
+	if f.service.HasPermission(f.alice, authz.UserManage) {
+		t.Fatal("unassigned user can manage users")
+	}
+
+	// 分配角色后为各角色权限之和，不再包含内置 user 角色的权限
+	if _, err := f.service.SetUserRoles(f.alice.ID, []uint{auditor.ID, scanner.ID, auditor.ID}, f.adminA.ID); err != nil {
+		t.Fatalf("SetUserRoles: %v", err)
+	}
+	want := sorted(authz.LogRead, authz.UserRead, authz.MetaRead, authz.MetaScan)
+	if got := permissions(f.alice); !reflect.DeepEqual(got, want) {
+		t.Fatalf("assigned user = %v, want %v", got, want)
+	}
+	if f.service.HasPermission(f.alice, authz.TransferRun) {
+		t.Fatal("permission from unassigned role")
+	}
+
+	// 其他租户的角色（如用户转移租户后遗留的分配）和角色中的平台级权限不生效
+	f.roles.assignments[f.alice.ID] = append(f.roles.assignments[f.alice.ID], foreign.ID)
+	auditorRole := f.roles.roles[auditor.ID]
+	auditorRole.Permissions = append(auditorRole.Permissions, authz.TenantManage, "unknown:perm")
+	if got := permissions(f.alice); !reflect.DeepEqual(got, want) {
+		t.Fatalf("with foreign role and platform permission = %v, want %v", got, want)
+	}
+
+	// 禁用的用户没有权限
+	f.alice.IsActive = false
+	if got := permissions(f.alice); len(got) != 0 {
+		t.Fatalf("inactive user = %v", got)
+	}
+
+	// 内置 viewer 角色可以分配给任何租户的用户
+	if _, err := f.service.SetUserRoles(f.carol.ID, []uint{f.viewerID}, f.adminB.ID); err != nil {
+		t.Fatalf("assign builtin role: %v", err)
+	}
+	if !f.service.HasPermission(f.carol, authz.MetaRead) || f.service.HasPermission(f.carol, authz.MetaScan) {
+		t.Fatalf("viewer permissions = %v", permissions(f.carol))
+	}
+}
+
+func TestRoleAssignmentIsTenantScoped(t *testing.T) {
+	f := newRBACFixture(t)
+	roleA := f.createRole(t, f.adminA, "auditor", authz.LogRead)
+	roleB := f.createRole(t, f.adminB, "auditor", authz.LogRead)
+
+	cases := []struct {
+		name    string
+		user    *models.User
+		roleIDs []uint
+		current *models.User
+	}{
+		{"role from another tenant", f.alice, []uint{roleB.ID}, f.adminA},
+		{"user from another tenant", f.carol, []uint{roleA.ID}, f.adminA},
+		{"unknown role", f.alice, []uint{999}, f.adminA},
+		{"admins have all permissions", f.adminA, []uint{roleA.ID}, f.superAdmin},
+	}
+	for _, tc := range cases {
+		if _, err := f.service.SetUserRoles(tc.user.ID, tc.roleIDs, tc.current.ID); err == nil {
+			t.Errorf("%s: assignment accepted", tc.name)
+		}
+	}
+	if _, err := f.service.GetUserRoles(f.carol.ID, f.adminA.ID); err == nil {
+		t.Error("tenant admin read roles of another tenant's user")
+	}
+
+	// 超级管理员可以为任何租户的用户分配该租户的角色
+	if _, err := f.service.SetUserRoles(f.carol.ID, []uint{roleB.ID}, f.superAdmin.ID); err != nil {
+		t.Fatalf("super admin assignment: %v", err)
+	}
+}
+
+func TestRoleManagement(t *testing.T) {
+	f := newRBACFixture(t)
+
+	// 角色名在租户内唯一，不能与内置角色重名，不能包含平台级或未知权限
+	f.createRole(t, f.adminA, "auditor", authz.LogRead)
+	f.createRole(t, f.adminB, "auditor", authz.LogRead)
+	invalid := map[string]*models.RoleCreateRequest{
+		"duplicate name":      {Name: "auditor", Permissions: []string{authz.LogRead}},
+		"builtin name":        {Name: models.RoleViewer, Permissions: []string{authz.LogRead}},
+		"empty name":          {Name: "  ", Permissions: []string{authz.LogRead}},
+		"platform permission": {Name: "ops", Permissions: []string{authz.TenantManage}},
+		"unknown permission":  {Name: "ops", Permissions: []string{"meta:delete"}},
+	}
+	for name, req := range invalid {
+		if _, err := f.service.Create(req, f.adminA.ID); err == nil {
+			t.Errorf("%s: role created", name)
+		}
+	}
+	if _, err := f.service.Create(&models.RoleCreateRequest{Name: "ops", Permissions: []string{authz.LogRead}}, f.superAdmin.ID); err == nil {
+		t.Error("role created without a tenant")
+	}
+
+	// 内置角色和其他租户的角色不能修改或删除
+	roleB, _ := f.roles.GetByName(&f.tenantB, "auditor")
+	name := "renamed"
+	if _, err := f.service.Update(f.viewerID, &models.RoleUpdateRequest{Name: &name}, f.adminA.ID); err == nil {
+		t.Error("builtin role updated")
+	}
+	if _, err := f.service.Update(roleB.ID, &models.RoleUpdateRequest{Name: &name}, f.adminA.ID); err == nil {
+		t.Error("role of another tenant updated")
+	}
+	if err := f.service.Delete(roleB.ID, f.adminA.ID); err == nil {
+		t.Error("role of another tenant deleted")
+	}
+
+	// 本租户的角色可以修改，权限去重排序
+	roleA, _ := f.roles.GetByName(&f.tenantA, "auditor")
+	permissions := []string{authz.UserRead, authz.LogRead, authz.UserRead}
+	updated, err := f.service.Update(roleA.ID, &models.RoleUpdateRequest{Permissions: &permissions}, f.adminA.ID)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if want := (models.StringList{authz.LogRead, authz.UserRead}); !reflect.DeepEqual(updated.Permissions, want) {
+		t.Fatalf("permissions = %v, want %v", updated.Permissions, want)
+	}
+
+	// 删除角色后分配给用户的角色随之失效，用户回到内置 user 角色
+	if _, err := f.service.SetUserRoles(f.alice.ID, []uint{roleA.ID}, f.adminA.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.service.Delete(roleA.ID, f.adminA.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if !f.service.HasPermission(f.alice, authz.TransferRun) {
+		t.Fatal("user without roles did not fall back to the builtin user role")
+	}
+}
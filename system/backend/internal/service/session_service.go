@@ -0,0 +1,219 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/repository"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// sessionStore 会话的存储，由 repository.SessionRepository 实现
+type sessionStore interface {
+	Create(session *models.Session) error
+	GetByRefreshTokenHash(hash string) (*models.Session, error)
+	GetSessionIDByRotatedRefreshToken(hash string) (string, error)
+	Rotate(session *models.Session, oldHash string) (bool, error)
+	RevokeSession(id string, reason string) ([]models.Session, error)
+	RevokeUserSessions(userID uint, reason string) ([]models.Session, error)
+	ListActiveByUser(userID uint) ([]models.Session, error)
+	IsTokenRevoked(jti string) (bool, error)
+	ListRevokedTokens(since time.Time) ([]models.RevokedToken, error)
+	DeleteExpired(before time.Time) error
+}
+
+// sessionUsers 刷新令牌时查询会话所属的用户，由 repository.UserRepository 实现
+type sessionUsers interface {
+	GetByID(id uint) (*models.User, error)
+}
+
+// SessionService 管理登录会话：签发和刷新令牌、注销、强制下线以及访问令牌黑名单
+type SessionService struct {
+	repo     sessionStore
+	userRepo sessionUsers
+	cfg      *config.Config
+}
+
+func NewSessionService(repo *repository.SessionRepository, userRepo *repository.UserRepository, cfg *config.Config) *SessionService {
+	return &SessionService{repo: repo, userRepo: userRepo, cfg: cfg}
+}
+
+// Create 登录成功后创建会话，返回访问令牌和刷新令牌
+func (s *SessionService) Create(user *models.User, ipAddress, userAgent string) (*models.LoginResponse, error) {
+	sessionID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		IPAddress: ipAddress,
+		UserAgent: truncate(userAgent, 255),
+		ExpiresAt: time.Now().Add(s.refreshTTL()),
+	}
+
+	resp, err := s.issue(user, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌，同时轮换刷新令牌（旧的刷新令牌随即失效）。
+// 已轮换掉的刷新令牌再次使用说明令牌已泄露，吊销整个会话
+func (s *SessionService) Refresh(refreshToken, ipAddress, userAgent string) (*models.LoginResponse, error) {
+	oldHash := utils.HashToken(refreshToken)
+	session, err := s.repo.GetByRefreshTokenHash(oldHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.revokeReused(oldHash)
+			return nil, errors.New("刷新令牌无效")
+		}
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, errors.New("会话已失效，请重新登录")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, errors.New("刷新令牌已过期，请重新登录")
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if !user.IsActive {
+		s.Revoke(session.ID, models.RevokeReasonUserDisabled)
+		return nil, errors.New("用户已被禁用")
+	}
+
+	now := time.Now()
+	session.IPAddress = ipAddress
+	session.UserAgent = truncate(userAgent, 255)
+	session.LastRefreshedAt = &now
+	resp, err := s.issue(user, session)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Rotate(session, oldHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 同一个刷新令牌被并发使用，另一次请求已完成轮换
+		s.revokeReused(oldHash)
+		return nil, errors.New("刷新令牌无效")
+	}
+	return resp, nil
+}
+
+// revokeReused 刷新令牌已被轮换过时吊销其所属的会话
+func (s *SessionService) revokeReused(hash string) {
+	sessionID, err := s.repo.GetSessionIDByRotatedRefreshToken(hash)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("查询已轮换的刷新令牌失败: %v", err)
+		}
+		return
+	}
+	if err := s.Revoke(sessionID, models.RevokeReasonRefreshReuse); err != nil {
+		log.Printf("吊销会话 %s 失败: %v", sessionID, err)
+		return
+	}
+	log.Printf("刷新令牌被重复使用，已吊销会话 %s", sessionID)
+}
+
+// issue 为会话签发新的访问令牌和刷新令牌，并记录到会话中
+func (s *SessionService) issue(user *models.User, session *models.Session) (*models.LoginResponse, error) {
+	tokenID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTTL := time.Duration(s.cfg.TokenExpireMinutes) * time.Minute
+	accessExpiresAt := time.Now().Add(accessTTL)
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, user.TenantID, string(user.UserType), session.ID, tokenID, s.cfg.JWTSecret, accessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshTokenHash = utils.HashToken(refreshToken)
+	session.AccessTokenID = tokenID
+	session.AccessExpiresAt = accessExpiresAt
+
+	return &models.LoginResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(accessTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(time.Until(session.ExpiresAt).Seconds()),
+	}, nil
+}
+
+// Revoke 吊销单个会话（注销）
+func (s *SessionService) Revoke(sessionID string, reason string) error {
+	_, err := s.repo.RevokeSession(sessionID, reason)
+	return err
+}
+
+// RevokeUser 吊销用户的所有会话（强制下线、禁用用户、修改密码）
+func (s *SessionService) RevokeUser(userID uint, reason string) error {
+	sessions, err := s.repo.RevokeUserSessions(userID, reason)
+	if err != nil {
+		return err
+	}
+	if len(sessions) > 0 {
+		log.Printf("已吊销用户 %d 的 %d 个会话 (%s)", userID, len(sessions), reason)
+	}
+	return nil
+}
+
+// ListActive 查询用户当前有效的会话
+func (s *SessionService) ListActive(userID uint) ([]models.Session, error) {
+	return s.repo.ListActiveByUser(userID)
+}
+
+// IsTokenRevoked 访问令牌是否已被吊销
+func (s *SessionService) IsTokenRevoked(jti string) (bool, error) {
+	return s.repo.IsTokenRevoked(jti)
+}
+
+// ListRevokedTokens 返回 since 之后吊销且尚未过期的访问令牌，供网关同步黑名单
+func (s *SessionService) ListRevokedTokens(since time.Time) ([]models.RevokedToken, error) {
+	return s.repo.ListRevokedTokens(since)
+}
+
+// StartCleanup 定期清理过期的黑名单记录和会话
+func (s *SessionService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.repo.DeleteExpired(time.Now()); err != nil {
+				log.Printf("清理过期会话失败: %v", err)
+			}
+		}
+	}()
+}
+
+func (s *SessionService) refreshTTL() time.Duration {
+	return time.Duration(s.cfg.RefreshTokenExpireHours) * time.Hour
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// memSessions 内存中的会话存储：按会话记录签发过的访问令牌，吊销会话时把这些令牌加入黑名单
+type memSessions struct {
+	sessions map[string]*models.Session
+	tokens   map[string]string // jti -> 会话 ID
+	rotated  map[string]string // 已轮换的刷新令牌哈希 -> 会话 ID
+	revoked  map[string]bool
+}
+
+func newMemSessions() *memSessions {
+	return &memSessions{
+		sessions: map[string]*models.Session{},
+		tokens:   map[string]string{},
+		rotated:  map[string]string{},
+		revoked:  map[string]bool{},
+	}
+}
+
+func (m *memSessions) Create(session *models.Session) error {
+	stored := *session
+	m.sessions[session.ID] = &stored
+	m.tokens[session.AccessTokenID] = session.ID
+	return nil
+}
+
+func (m *memSessions) GetByRefreshTokenHash(hash string) (*models.Session, error) {
+	for _, session := range m.sessions {
+		if session.RefreshTokenHash == hash {
+			found := *session
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memSessions) GetSessionIDByRotatedRefreshToken(hash string) (string, error) {
+	if id, ok := m.rotated[hash]; ok {
+		return id, nil
+	}
+	return "", gorm.ErrRecordNotFound
+}
+
+func (m *memSessions) Rotate(session *models.Session, oldHash string) (bool, error) {
+	stored := m.sessions[session.ID]
+	if stored == nil || stored.RefreshTokenHash != oldHash || stored.RevokedAt != nil {
+		return false, nil
+	}
+	*stored = *session
+	m.rotated[oldHash] = session.ID
+	m.tokens[session.AccessTokenID] = session.ID
+	return true, nil
+}
+
+func (m *memSessions) revoke(match func(*models.Session) bool, reason string) ([]models.Session, error) {
+	var revoked []models.Session
+	now := time.Now()
+	for _, session := range m.sessions {
+		if session.RevokedAt != nil || !match(session) {
+			continue
+		}
+		session.RevokedAt, session.RevokeReason = &now, reason
+		for jti, sessionID := range m.tokens {
+			if sessionID == session.ID {
+				m.revoked[jti] = true
+			}
+		}
+		revoked = append(revoked, *session)
+	}
+	return revoked, nil
+}
+
+func (m *memSessions) RevokeSession(id string, reason string) ([]models.Session, error) {
+	return m.revoke(func(s *models.Session) bool { return s.ID == id }, reason)
+}
+
+func (m *memSessions) RevokeUserSessions(userID uint, reason string) ([]models.Session, error) {
+	return m.revoke(func(s *models.Session) bool { return s.UserID == userID }, reason)
+}
+
+func (m *memSessions) ListActiveByUser(userID uint) ([]models.Session, error) { return nil, nil }
+func (m *memSessions) IsTokenRevoked(jti string) (bool, error)                { return m.revoked[jti], nil }
+func (m *memSessions) ListRevokedTokens(time.Time) ([]models.RevokedToken, error) {
+	return nil, nil
+}
+func (m *memSessions) DeleteExpired(time.Time) error { return nil }
+
+const testJWTSecret = "session-test-secret"
+
+func newTestSessionService() (*SessionService, *memSessions, *models.User) {
+	users := newMemUserStore()
+	user := &models.User{ID: 1, Username: "alice", UserType: models.UserTypeUser, IsActive: true}
+	users.Create(user)
+	store := newMemSessions()
+	cfg := &config.Config{JWTSecret: testJWTSecret, TokenExpireMinutes: 30, RefreshTokenExpireHours: 1}
+	return &SessionService{repo: store, userRepo: users, cfg: cfg}, store, users.users[user.ID]
+}
+
+// accessJTI 解析访问令牌的 jti 和会话 ID
+func accessJTI(t *testing.T, resp *models.LoginResponse) (string, string) {
+	t.Helper()
+	claims, err := utils.ParseToken(resp.AccessToken, testJWTSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims.ID, claims.SessionID
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	s, store, user := newTestSessionService()
+	first, err := s.Create(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Refresh(first.RefreshToken, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("refresh did not rotate tokens")
+	}
+	firstJTI, sessionID := accessJTI(t, first)
+	secondJTI, secondSession := accessJTI(t, second)
+	if firstJTI == secondJTI || sessionID != secondSession {
+		t.Fatalf("jti %s -> %s, session %s -> %s", firstJTI, secondJTI, sessionID, secondSession)
+	}
+
+	// 新的刷新令牌继续可用
+	if _, err := s.Refresh(second.RefreshToken, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("Refresh with rotated token: %v", err)
+	}
+	if store.sessions[sessionID].RevokedAt != nil {
+		t.Fatal("session revoked by normal rotation")
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	s, store, user := newTestSessionService()
+	first, err := s.Create(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.Refresh(first.RefreshToken, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 攻击者重放已轮换掉的刷新令牌：请求失败，整个会话被吊销
+	if _, err := s.Refresh(first.RefreshToken, "10.0.0.1", "attacker"); err == nil {
+		t.Fatal("reused refresh token accepted")
+	}
+	firstJTI, sessionID := accessJTI(t, first)
+	secondJTI, _ := accessJTI(t, second)
+	session := store.sessions[sessionID]
+	if session.RevokedAt == nil || session.RevokeReason != models.RevokeReasonRefreshReuse {
+		t.Fatalf("session revoked_at = %v, reason = %q", session.RevokedAt, session.RevokeReason)
+	}
+	for _, jti := range []string{firstJTI, secondJTI} {
+		if revoked, _ := s.IsTokenRevoked(jti); !revoked {
+			t.Fatalf("access token %s still valid after reuse", jti)
+		}
+	}
+	// 合法用户手中最新的刷新令牌也随之失效
+	if _, err := s.Refresh(second.RefreshToken, "127.0.0.1", "test"); err == nil {
+		t.Fatal("refresh token of revoked session accepted")
+	}
+
+	// 从未签发过的刷新令牌只是无效，不影响其他会话
+	other, err := s.Create(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh("unknown-token", "127.0.0.1", "test"); err == nil {
+		t.Fatal("unknown refresh token accepted")
+	}
+	if _, err := s.Refresh(other.RefreshToken, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("unrelated session affected: %v", err)
+	}
+}
+
+func TestRevokeBlocksEveryIssuedToken(t *testing.T) {
+	s, store, user := newTestSessionService()
+	resp, err := s.Create(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jti, sessionID := accessJTI(t, resp)
+	jtis := []string{jti}
+	for i := 0; i < 2; i++ {
+		if resp, err = s.Refresh(resp.RefreshToken, "127.0.0.1", "test"); err != nil {
+			t.Fatal(err)
+		}
+		jti, _ = accessJTI(t, resp)
+		jtis = append(jtis, jti)
+	}
+
+	if err := s.Revoke(sessionID, models.RevokeReasonLogout); err != nil {
+		t.Fatal(err)
+	}
+	// 注销后此前签发的访问令牌都不能再用，而不只是最后一个
+	for _, jti := range jtis {
+		if revoked, _ := s.IsTokenRevoked(jti); !revoked {
+			t.Fatalf("access token %s still valid after logout", jti)
+		}
+	}
+	if store.sessions[sessionID].RevokeReason != models.RevokeReasonLogout {
+		t.Fatalf("reason = %q", store.sessions[sessionID].RevokeReason)
+	}
+	if _, err := s.Refresh(resp.RefreshToken, "127.0.0.1", "test"); err == nil {
+		t.Fatal("refresh after logout accepted")
+	}
+}
+
+func TestRefreshDisabledUser(t *testing.T) {
+	s, store, user := newTestSessionService()
+	resp, err := s.Create(user, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.IsActive = false
+	if _, err := s.Refresh(resp.RefreshToken, "127.0.0.1", "test"); err == nil {
+		t.Fatal("refresh for disabled user accepted")
+	}
+	jti, sessionID := accessJTI(t, resp)
+	if store.sessions[sessionID].RevokeReason != models.RevokeReasonUserDisabled {
+		t.Fatalf("reason = %q", store.sessions[sessionID].RevokeReason)
+	}
+	if revoked, _ := s.IsTokenRevoked(jti); !revoked {
+		t.Fatal("access token of disabled user still valid")
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/internal/oidc"
+	"github.com/addp/system/internal/repository"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// SSOStateTTL 从跳转到提供方到回调的最长时间
+const SSOStateTTL = 10 * time.Minute
+
+var (
+	ErrSSODisabled = errors.New("未启用单点登录")
+	ErrSSOState    = errors.New("登录请求无效或已过期，请重新登录")
+)
+
+// SSOResult 单点登录回调的结果：登录时返回令牌，启用多因素认证时返回验证码登录，关联外部身份时只标记已关联
+type SSOResult struct {
+	Login    *models.LoginResponse
+	MFA      *models.MFAChallengeResponse // 用户启用了多因素认证，验证码通过后（POST /api/auth/mfa）才创建会话
+	User     *models.User                 // 登录的用户，用于记录登录尝试
+	Linked   bool
+	Redirect string
+}
+
+// SSOService OIDC 单点登录：授权码 + PKCE，首次登录按声明确定租户并创建用户（或关联已有账号），按组同步角色
+type SSOService struct {
+	cfg        *config.Config
+	provider   *oidc.Provider
+	repo       *repository.IdentityRepository
+	userRepo   *repository.UserRepository
+	tenantRepo *repository.TenantRepository
+	roleRepo   *repository.RoleRepository
+	sessions   *SessionService
+	guard      *LoginGuard
+	mfa        *MFAService
+}
+
+func NewSSOService(cfg *config.Config, repo *repository.IdentityRepository, userRepo *repository.UserRepository, tenantRepo *repository.TenantRepository, roleRepo *repository.RoleRepository, sessions *SessionService, guard *LoginGuard, mfa *MFAService) *SSOService {
+	s := &SSOService{
+		cfg:        cfg,
+		repo:       repo,
+		userRepo:   userRepo,
+		tenantRepo: tenantRepo,
+		roleRepo:   roleRepo,
+		sessions:   sessions,
+		guard:      guard,
+		mfa:        mfa,
+	}
+	if cfg.OIDCIssuer != "" {
+		s.provider = oidc.NewProvider(cfg.OIDCIssuer, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURL, cfg.OIDCScopes)
+	}
+	return s
+}
+
+func (s *SSOService) Enabled() bool {
+	return s.provider != nil
+}
+
+// Begin 保存登录状态并返回提供方登录地址和 state；linkUserID 不为 0 时回调后把外部身份关联到该用户。
+// 调用方需把 state 写入发起登录的浏览器的 Cookie，回调时传给 Complete 校验
+func (s *SSOService) Begin(ctx context.Context, redirect string, linkUserID uint) (string, string, error) {
+	if !s.Enabled() {
+		return "", "", ErrSSODisabled
+	}
+
+	state, err := utils.RandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.RandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := utils.RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	record := &models.OIDCState{
+		State:        state,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		Redirect:     safeRedirect(redirect),
+		ExpiresAt:    time.Now().Add(SSOStateTTL),
+	}
+	if linkUserID > 0 {
+		record.LinkUserID = &linkUserID
+	}
+	if err := s.repo.CreateState(record); err != nil {
+		return "", "", err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// Complete 处理提供方回调：校验 state 和 ID Token，找到或创建本地用户后创建登录会话。
+// browserState 为发起登录的浏览器 Cookie 中的 state，与回调的 state 不一致时拒绝，
+// 防止受害者的浏览器完成攻击者发起的登录或账号关联（登录 CSRF）
+func (s *SSOService) Complete(ctx context.Context, code, state, browserState, ipAddress, userAgent string) (*SSOResult, error) {
+	if !s.Enabled() {
+		return nil, ErrSSODisabled
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrSSOState
+	}
+
+	record, err := s.repo.ConsumeState(state)
+	if err != nil {
+		return nil, ErrSSOState
+	}
+
+	token, err := s.provider.Exchange(ctx, code, record.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, token.IDToken, record.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	// UserInfo 中的声明（如 groups）补充到 ID Token 声明中；sub 缺失或不一致时拒绝登录
+	userInfo, err := s.provider.UserInfo(ctx, token.AccessToken, claims.String("sub"))
+	if errors.Is(err, oidc.ErrUserInfoSubject) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("查询 OIDC UserInfo 失败，仅使用 ID Token 声明: %v", err)
+	}
+	for k, v := range userInfo {
+		if _, ok := claims[k]; !ok {
+			claims[k] = v
+		}
+	}
+
+	result := &SSOResult{Redirect: record.Redirect}
+	if record.LinkUserID != nil {
+		if err := s.link(*record.LinkUserID, claims); err != nil {
+			return nil, err
+		}
+		result.Linked = true
+		return result, nil
+	}
+
+	user, err := s.resolveUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.login(result, user, claims.Strings(s.cfg.OIDCGroupsClaim), ipAddress, userAgent); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// login 为提供方确认的用户完成登录。与用户名密码登录一样：禁用或锁定的用户不能登录，
+// 启用多因素认证时只返回验证码登录，验证码通过后才创建会话
+func (s *SSOService) login(result *SSOResult, user *models.User, groups []string, ipAddress, userAgent string) error {
+	if !user.IsActive {
+		return ErrUserDisabled
+	}
+	if s.guard.Locked(user) {
+		return ErrAccountLocked
+	}
+	// 配置了组→角色映射时，每次登录按组同步角色
+	if err := syncGroupRoles(s.roleRepo, user, groups, s.cfg.OIDCGroupRoleMap); err != nil {
+		return err
+	}
+
+	result.User = user
+	var err error
+	if user.MFAEnabled {
+		result.MFA, err = s.mfa.Challenge(user)
+	} else {
+		result.Login, err = s.sessions.Create(user, ipAddress, userAgent)
+	}
+	return err
+}
+
+// ListIdentities 查询用户关联的外部身份
+func (s *SSOService) ListIdentities(userID uint) ([]models.UserIdentity, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// StartCleanup 定期清理未完成的登录状态
+func (s *SSOService) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.repo.DeleteExpiredStates(time.Now()); err != nil {
+				log.Printf("清理过期单点登录状态失败: %v", err)
+			}
+		}
+	}()
+}
+
+// link 把外部身份关联到已登录的用户
+func (s *SSOService) link(userID uint, claims oidc.Claims) error {
+	identity, err := s.repo.GetBySubject(s.cfg.OIDCIssuer, claims.String("sub"))
+	if err == nil {
+		if identity.UserID == userID {
+			return nil
+		}
+		return errors.New("该单点登录账号已关联其他用户")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return s.repo.Create(&models.UserIdentity{
+		UserID:  userID,
+		Issuer:  s.cfg.OIDCIssuer,
+		Subject: claims.String("sub"),
+		Email:   claims.String("email"),
+	})
+}
+
+// resolveUser 按已关联的外部身份查找用户；没有关联时按已验证邮箱关联已有账号，或自动创建用户
+func (s *SSOService) resolveUser(claims oidc.Claims) (*models.User, error) {
+	subject := claims.String("sub")
+	email := claims.String("email")
+
+	identity, err := s.repo.GetBySubject(s.cfg.OIDCIssuer, subject)
+	if err == nil {
+		if err := s.repo.Touch(identity.ID, email, time.Now()); err != nil {
+			log.Printf("更新外部身份 %d 的登录时间失败: %v", identity.ID, err)
+		}
+		return s.userRepo.GetByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var user *models.User
+	// 超级管理员不自动关联，需登录后手动关联；服务账号不能关联
+	if s.cfg.OIDCLinkByEmail && email != "" && claims.Bool("email_verified") {
+		existing, err := s.userRepo.GetByEmail(email)
+		if err == nil && existing.UserType != models.UserTypeSuperAdmin && !existing.IsServiceAccount {
+			user = existing
+		}
+	}
+	if user == nil {
+		if !s.cfg.OIDCAutoProvision {
+			return nil, errors.New("没有与该单点登录账号关联的用户，请联系管理员")
+		}
+		if user, err = s.provision(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	if err := s.repo.Create(&models.UserIdentity{
+		UserID:      user.ID,
+		Issuer:      s.cfg.OIDCIssuer,
+		Subject:     subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provision 按声明确定租户并创建普通用户
+func (s *SSOService) provision(claims oidc.Claims) (*models.User, error) {
+	tenant, err := mapTenant(s.tenantRepo, claims.String(s.cfg.OIDCTenantClaim), s.cfg.OIDCTenantMap, s.cfg.OIDCDefaultTenant)
+	if err != nil {
+		return nil, err
+	}
+
+	username := claims.String(s.cfg.OIDCUsernameClaim)
+	if username == "" {
+		username = claims.String("email")
+	}
+	if username == "" {
+		username = claims.String("sub")
+	}
+
+	user, err := provisionUser(s.userRepo, tenant, username, claims.String("email"), claims.String("name"))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("单点登录自动创建用户 %s（租户 %s）", user.Username, tenant.Name)
+	return user, nil
+}
+
+// safeRedirect 只允许站内相对路径，避免登录后跳转到外部网站
+func safeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
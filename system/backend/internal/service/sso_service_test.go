@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/addp/system/internal/config"
+)
+
+// TestCompleteRequiresBrowserState 回调的 state 必须与发起登录的浏览器 Cookie 一致，校验先于消费 state
+func TestCompleteRequiresBrowserState(t *testing.T) {
+	// repo 为 nil：校验失败时不应访问数据库，否则会 panic
+	s := NewSSOService(&config.Config{OIDCIssuer: "https://idp.example.com"}, nil, nil, nil, nil, nil, nil, nil)
+
+	cases := []struct {
+		name         string
+		state        string
+		browserState string
+	}{
+		{"no cookie", "state-1", ""},
+		{"cookie from another flow", "state-1", "state-2"},
+		{"empty state", "", ""},
+		{"prefix of state", "state-1", "state-"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Complete(context.Background(), "code", tc.state, tc.browserState, "127.0.0.1", "test")
+			if !errors.Is(err, ErrSSOState) {
+				t.Fatalf("err = %v, want ErrSSOState", err)
+			}
+		})
+	}
+}
+
+// TestSSOLoginAppliesGuardAndMFA 单点登录与用户名密码登录一样检查锁定和多因素认证
+func TestSSOLoginAppliesGuardAndMFA(t *testing.T) {
+	f := newAuthFixture(t)
+	s := &SSOService{cfg: f.cfg, sessions: f.service.sessions, guard: f.guard, mfa: f.mfa}
+	plain, _ := f.addUser(t, "alice", testOldPassword, 0, false)
+	enrolled, _ := f.addUser(t, "bob", testOldPassword, 0, true)
+
+	result := &SSOResult{}
+	if err := s.login(result, plain, nil, "127.0.0.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Login == nil || result.MFA != nil {
+		t.Fatalf("plain user: %+v, want session", result)
+	}
+
+	// 启用多因素认证：不创建会话，只返回验证码登录
+	result = &SSOResult{}
+	if err := s.login(result, enrolled, nil, "127.0.0.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Login != nil || result.MFA == nil || result.MFA.MFAToken == "" {
+		t.Fatalf("enrolled user: %+v, want MFA challenge only", result)
+	}
+	if len(f.sessions.sessions) != 1 {
+		t.Fatalf("sessions = %d, want only the plain user's", len(f.sessions.sessions))
+	}
+
+	// 密码试错被锁定的账号也不能通过单点登录绕过
+	for i := 0; i < f.cfg.LoginMaxFailedAttempts; i++ {
+		f.guard.Fail(plain)
+	}
+	locked, _ := f.users.GetByID(plain.ID)
+	if err := s.login(&SSOResult{}, locked, nil, "127.0.0.1", "test"); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("locked user: err = %v, want ErrAccountLocked", err)
+	}
+
+	disabled, _ := f.users.GetByID(enrolled.ID)
+	disabled.IsActive = false
+	if err := s.login(&SSOResult{}, disabled, nil, "127.0.0.1", "test"); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("disabled user: err = %v, want ErrUserDisabled", err)
+	}
+}
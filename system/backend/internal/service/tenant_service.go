@@ -3,22 +3,26 @@ package service
 import (
 	"errors"
 
+	"github.com/addp/common/authz"
 	"github.com/addp/system/internal/models"
 	"github.com/addp/system/internal/repository"
-	"github.com/addp/system/pkg/utils"
 	"gorm.io/gorm"
 )
 
 type TenantService struct {
 	tenantRepo *repository.TenantRepository
 	userRepo   *repository.UserRepository
+	roles      *RoleService
+	policy     *PasswordPolicy
 	db         *gorm.DB
 }
 
-func NewTenantService(tenantRepo *repository.TenantRepository, userRepo *repository.UserRepository, db *gorm.DB) *TenantService {
+func NewTenantService(tenantRepo *repository.TenantRepository, userRepo *repository.UserRepository, roles *RoleService, policy *PasswordPolicy, db *gorm.DB) *TenantService {
 	return &TenantService{
 		tenantRepo: tenantRepo,
 		userRepo:   userRepo,
+		roles:      roles,
+		policy:     policy,
 		db:         db,
 	}
 }
@@ -30,7 +34,7 @@ func (s *TenantService) Create(req *models.TenantCreateRequest, currentUserID ui
 		return nil, errors.New("当前用户不存在")
 	}
 
-	if currentUser.UserType != models.UserTypeSuperAdmin {
+	if !s.roles.HasPermission(currentUser, authz.TenantManage) {
 		return nil, errors.New("只有超级管理员可以创建租户")
 	}
 
@@ -46,6 +50,19 @@ func (s *TenantService) Create(req *models.TenantCreateRequest, currentUserID ui
 		return nil, errors.New("管理员用户名已存在")
 	}
 
+	// 租户管理员的密码同样需要符合密码策略
+	admin := &models.User{
+		Username:    req.AdminUsername,
+		Email:       req.AdminEmail,
+		FullName:    req.AdminFullName,
+		IsActive:    true,
+		UserType:    models.UserTypeTenantAdmin,
+		IsSuperuser: false,
+	}
+	if err := s.policy.SetPassword(admin, req.AdminPassword); err != nil {
+		return nil, err
+	}
+
 	// 使用事务创建租户和租户管理员
 	var tenant *models.Tenant
 	err = s.db.Transaction(func(tx *gorm.DB) error {
@@ -61,22 +78,7 @@ func (s *TenantService) Create(req *models.TenantCreateRequest, currentUserID ui
 		}
 
 		// 创建租户管理员
-		passwordHash, err := utils.HashPassword(req.AdminPassword)
-		if err != nil {
-			return err
-		}
-
-		admin := &models.User{
-			Username:     req.AdminUsername,
-			Email:        req.AdminEmail,
-			PasswordHash: passwordHash,
-			FullName:     req.AdminFullName,
-			IsActive:     true,
-			UserType:     models.UserTypeTenantAdmin,
-			TenantID:     &tenant.ID,
-			IsSuperuser:  false,
-		}
-
+		admin.TenantID = &tenant.ID
 		if err := tx.Create(admin).Error; err != nil {
 			return err
 		}
@@ -87,6 +89,7 @@ func (s *TenantService) Create(req *models.TenantCreateRequest, currentUserID ui
 	if err != nil {
 		return nil, err
 	}
+	s.policy.Remember(admin)
 
 	return tenant, nil
 }
@@ -98,7 +101,7 @@ func (s *TenantService) GetByID(id uint, currentUserID uint) (*models.Tenant, er
 		return nil, errors.New("当前用户不存在")
 	}
 
-	if currentUser.UserType != models.UserTypeSuperAdmin {
+	if !s.roles.HasPermission(currentUser, authz.TenantManage) {
 		return nil, errors.New("只有超级管理员可以查看租户")
 	}
 
@@ -112,7 +115,7 @@ func (s *TenantService) List(page, pageSize int, currentUserID uint) ([]models.T
 		return nil, errors.New("当前用户不存在")
 	}
 
-	if currentUser.UserType != models.UserTypeSuperAdmin {
+	if !s.roles.HasPermission(currentUser, authz.TenantManage) {
 		return nil, errors.New("只有超级管理员可以查看租户列表")
 	}
 
@@ -127,7 +130,7 @@ func (s *TenantService) Update(id uint, req *models.TenantUpdateRequest, current
 		return nil, errors.New("当前用户不存在")
 	}
 
-	if currentUser.UserType != models.UserTypeSuperAdmin {
+	if !s.roles.HasPermission(currentUser, authz.TenantManage) {
 		return nil, errors.New("只有超级管理员可以修改租户")
 	}
 
@@ -167,7 +170,7 @@ func (s *TenantService) Delete(id uint, currentUserID uint) error {
 		return errors.New("当前用户不存在")
 	}
 
-	if currentUser.UserType != models.UserTypeSuperAdmin {
+	if !s.roles.HasPermission(currentUser, authz.TenantManage) {
 		return errors.New("只有超级管理员可以删除租户")
 	}
 
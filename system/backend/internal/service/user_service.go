@@ -2,19 +2,47 @@ package service
 
 import (
 	"errors"
+	"log"
+	"time"
 
+	"github.com/addp/common/authz"
 	"github.com/addp/system/internal/models"
 	"github.com/addp/system/internal/repository"
 	"github.com/addp/system/pkg/utils"
-	"gorm.io/gorm"
 )
 
+// userStore 用户的存储，由 repository.UserRepository 实现；UserService、LoginGuard、
+// PasswordPolicy、MFAService 和 APITokenService 共用
+type userStore interface {
+	Create(user *models.User) error
+	GetByID(id uint) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	ListByTenant(tenantID uint, offset, limit int) ([]models.User, error)
+	ListServiceAccounts(tenantID uint) ([]models.User, error)
+	Update(user *models.User) error
+	Delete(id uint) error
+	IncrementFailedLogins(id uint, lockExpiredBefore time.Time) (int, error)
+	Lock(id uint, at time.Time) error
+	ResetFailedLogins(id uint) error
+	AdvanceMFAStep(id uint, step int64) (bool, error)
+	ResetMFA(id uint) error
+	AddPasswordHistory(userID uint, passwordHash string, keep int) error
+	ListPasswordHistory(userID uint, limit int) ([]string, error)
+}
+
 type UserService struct {
-	repo *repository.UserRepository
+	repo           userStore
+	sessions       *SessionService
+	roles          *RoleService
+	tokens         *APITokenService
+	policy         *PasswordPolicy
+	guard          *LoginGuard
+	mfa            *MFAService
+	authenticators []Authenticator
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repository.UserRepository, sessions *SessionService, roles *RoleService, tokens *APITokenService, policy *PasswordPolicy, guard *LoginGuard, mfa *MFAService, authenticators []Authenticator) *UserService {
+	return &UserService{repo: repo, sessions: sessions, roles: roles, tokens: tokens, policy: policy, guard: guard, mfa: mfa, authenticators: authenticators}
 }
 
 func (s *UserService) Create(req *models.UserCreateRequest, creatorID uint) (*models.User, error) {
@@ -35,12 +63,6 @@ func (s *UserService) Create(req *models.UserCreateRequest, creatorID uint) (*mo
 		return nil, err
 	}
 
-	// Hash 密码
-	passwordHash, err := utils.HashPassword(req.Password)
-	if err != nil {
-		return nil, err
-	}
-
 	// 设置默认用户类型
 	userType := req.UserType
 	if userType == "" {
@@ -48,19 +70,24 @@ func (s *UserService) Create(req *models.UserCreateRequest, creatorID uint) (*mo
 	}
 
 	user := &models.User{
-		Username:     req.Username,
-		Email:        req.Email,
-		PasswordHash: passwordHash,
-		FullName:     req.FullName,
-		IsActive:     true,
-		UserType:     userType,
-		TenantID:     creator.TenantID, // 继承创建者的租户ID
-		IsSuperuser:  userType == models.UserTypeSuperAdmin,
+		Username:    req.Username,
+		Email:       req.Email,
+		FullName:    req.FullName,
+		IsActive:    true,
+		UserType:    userType,
+		TenantID:    creator.TenantID, // 继承创建者的租户ID
+		IsSuperuser: userType == models.UserTypeSuperAdmin,
+	}
+
+	// 按密码策略校验并 Hash 密码
+	if err := s.policy.SetPassword(user, req.Password); err != nil {
+		return nil, err
 	}
 
 	if err := s.repo.Create(user); err != nil {
 		return nil, err
 	}
+	s.policy.Remember(user)
 
 	return user, nil
 }
@@ -72,15 +99,14 @@ func (s *UserService) validateCreatePermission(creator *models.User, targetUserT
 		return errors.New("超级管理员不能直接创建用户，请通过创建租户来添加用户")
 	}
 
-	// 租户管理员只能创建普通用户
-	if creator.UserType == models.UserTypeTenantAdmin {
+	// 有用户管理权限（租户管理员或被分配了 user:manage）时只能创建普通用户
+	if s.roles.HasPermission(creator, authz.UserManage) {
 		if targetUserType != models.UserTypeUser && targetUserType != "" {
-			return errors.New("租户管理员只能创建普通用户")
+			return errors.New("只能创建普通用户")
 		}
 		return nil
 	}
 
-	// 普通用户不能创建用户
 	return errors.New("没有权限创建用户")
 }
 
@@ -101,15 +127,15 @@ func (s *UserService) GetByID(id uint, currentUserID uint) (*models.User, error)
 		return user, nil
 	}
 
-	// 租户管理员只能查看同租户的用户
-	if currentUser.UserType == models.UserTypeTenantAdmin {
+	// 有用户查看权限时只能查看同租户的用户
+	if s.canReadUsers(currentUser) {
 		if user.TenantID == nil || currentUser.TenantID == nil || *user.TenantID != *currentUser.TenantID {
 			return nil, errors.New("没有权限查看该用户")
 		}
 		return user, nil
 	}
 
-	// 普通用户只能查看自己
+	// 否则只能查看自己
 	if user.ID != currentUserID {
 		return nil, errors.New("没有权限查看该用户")
 	}
@@ -131,16 +157,21 @@ func (s *UserService) List(page, pageSize int, currentUserID uint) ([]models.Use
 		return []models.User{}, nil
 	}
 
-	// 租户管理员只能查看同租户的用户
-	if currentUser.UserType == models.UserTypeTenantAdmin {
+	// 有用户查看权限时查看同租户的用户
+	if currentUser.TenantID != nil && s.canReadUsers(currentUser) {
 		return s.repo.ListByTenant(*currentUser.TenantID, offset, pageSize)
 	}
 
-	// 普通用户只能查看自己
+	// 否则只能查看自己
 	return []models.User{*currentUser}, nil
 }
 
-func (s *UserService) Update(id uint, req *models.UserUpdateRequest, currentUserID uint) (*models.User, error) {
+// Update 修改用户信息；interactive 为 false（使用访问令牌）时不能修改密码
+func (s *UserService) Update(id uint, req *models.UserUpdateRequest, currentUserID uint, interactive bool) (*models.User, error) {
+	if req.Password != nil && !interactive {
+		return nil, ErrSessionRequired
+	}
+
 	user, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -157,6 +188,9 @@ func (s *UserService) Update(id uint, req *models.UserUpdateRequest, currentUser
 		return nil, err
 	}
 
+	// 修改密码或禁用用户后吊销其所有会话，已签发的令牌立即失效
+	revokeReason := ""
+
 	// 更新字段
 	if req.Email != nil {
 		user.Email = *req.Email
@@ -165,16 +199,18 @@ func (s *UserService) Update(id uint, req *models.UserUpdateRequest, currentUser
 		user.FullName = *req.FullName
 	}
 	if req.Password != nil {
-		passwordHash, err := utils.HashPassword(*req.Password)
-		if err != nil {
+		if err := s.policy.SetPassword(user, *req.Password); err != nil {
 			return nil, err
 		}
-		user.PasswordHash = passwordHash
+		revokeReason = models.RevokeReasonPasswordChanged
 	}
 
-	// 只有超级管理员和租户管理员可以修改激活状态和用户类型
-	if currentUser.UserType == models.UserTypeSuperAdmin || currentUser.UserType == models.UserTypeTenantAdmin {
+	// 只有拥有用户管理权限的用户可以修改激活状态和用户类型
+	if s.roles.HasPermission(currentUser, authz.UserManage) {
 		if req.IsActive != nil {
+			if user.IsActive && !*req.IsActive {
+				revokeReason = models.RevokeReasonUserDisabled
+			}
 			user.IsActive = *req.IsActive
 		}
 		if req.UserType != nil {
@@ -190,6 +226,15 @@ func (s *UserService) Update(id uint, req *models.UserUpdateRequest, currentUser
 	if err := s.repo.Update(user); err != nil {
 		return nil, err
 	}
+	if req.Password != nil {
+		s.policy.Remember(user)
+	}
+
+	if revokeReason != "" {
+		if err := s.sessions.RevokeUser(user.ID, revokeReason); err != nil {
+			return nil, err
+		}
+	}
 
 	return user, nil
 }
@@ -201,8 +246,8 @@ func (s *UserService) validateUpdatePermission(currentUser, targetUser *models.U
 		return nil
 	}
 
-	// 租户管理员只能修改同租户的用户
-	if currentUser.UserType == models.UserTypeTenantAdmin {
+	// 有用户管理权限时只能修改同租户的用户
+	if s.roles.HasPermission(currentUser, authz.UserManage) {
 		if targetUser.TenantID == nil || currentUser.TenantID == nil || *targetUser.TenantID != *currentUser.TenantID {
 			return errors.New("只能修改同租户的用户")
 		}
@@ -213,12 +258,12 @@ func (s *UserService) validateUpdatePermission(currentUser, targetUser *models.U
 		return nil
 	}
 
-	// 普通用户只能修改自己的信息
+	// 没有用户管理权限时只能修改自己的信息
 	if currentUser.ID != targetUser.ID {
 		return errors.New("只能修改自己的信息")
 	}
 
-	// 普通用户不能修改激活状态和用户类型
+	// 不能修改自己的激活状态和用户类型
 	if req.IsActive != nil || req.UserType != nil {
 		return errors.New("没有权限修改用户状态和类型")
 	}
@@ -246,11 +291,11 @@ func (s *UserService) Delete(id uint, currentUserID uint) error {
 
 	// 超级管理员可以删除所有用户（除了SuperAdmin）
 	if currentUser.UserType == models.UserTypeSuperAdmin {
-		return s.repo.Delete(id)
+		return s.deleteUser(id)
 	}
 
-	// 租户管理员只能删除同租户的普通用户
-	if currentUser.UserType == models.UserTypeTenantAdmin {
+	// 有用户管理权限时只能删除同租户的普通用户
+	if s.roles.HasPermission(currentUser, authz.UserManage) {
 		if targetUser.TenantID == nil || currentUser.TenantID == nil || *targetUser.TenantID != *currentUser.TenantID {
 			return errors.New("只能删除同租户的用户")
 		}
@@ -258,13 +303,144 @@ func (s *UserService) Delete(id uint, currentUserID uint) error {
 		if targetUser.UserType == models.UserTypeTenantAdmin {
 			return errors.New("不能删除租户管理员")
 		}
-		return s.repo.Delete(id)
+		return s.deleteUser(id)
 	}
 
-	// 普通用户不能删除用户
 	return errors.New("没有权限删除用户")
 }
 
+// deleteUser 删除用户并吊销其会话、删除其角色分配
+func (s *UserService) deleteUser(id uint) error {
+	if err := s.sessions.RevokeUser(id, models.RevokeReasonUserDeleted); err != nil {
+		return err
+	}
+	if err := s.roles.RemoveUser(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// canReadUsers 是否可以查看同租户的其他用户
+func (s *UserService) canReadUsers(user *models.User) bool {
+	return s.roles.HasPermission(user, authz.UserRead) || s.roles.HasPermission(user, authz.UserManage)
+}
+
+// ListSessions 查询用户当前有效的会话，权限与修改用户信息相同
+func (s *UserService) ListSessions(id uint, currentUserID uint) ([]models.Session, error) {
+	if err := s.validateSessionPermission(id, currentUserID); err != nil {
+		return nil, err
+	}
+	return s.sessions.ListActive(id)
+}
+
+// RevokeSessions 强制用户下线，吊销其所有会话
+func (s *UserService) RevokeSessions(id uint, currentUserID uint) error {
+	if err := s.validateSessionPermission(id, currentUserID); err != nil {
+		return err
+	}
+	return s.sessions.RevokeUser(id, models.RevokeReasonKicked)
+}
+
+// validateSessionPermission 管理员可以管理可修改用户的会话，普通用户只能管理自己的会话
+func (s *UserService) validateSessionPermission(id uint, currentUserID uint) error {
+	targetUser, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	currentUser, err := s.repo.GetByID(currentUserID)
+	if err != nil {
+		return errors.New("当前用户不存在")
+	}
+	return s.validateUpdatePermission(currentUser, targetUser, &models.UserUpdateRequest{})
+}
+
+// CreateServiceAccount 在创建者的租户中创建服务账号，需要用户管理权限；服务账号的本地密码为随机值
+func (s *UserService) CreateServiceAccount(req *models.ServiceAccountCreateRequest, creatorID uint) (*models.User, error) {
+	creator, err := s.repo.GetByID(creatorID)
+	if err != nil {
+		return nil, errors.New("创建者不存在")
+	}
+	if creator.TenantID == nil || !s.roles.HasPermission(creator, authz.UserManage) {
+		return nil, errors.New("没有权限创建服务账号")
+	}
+	if _, err := s.repo.GetByUsername(req.Username); err == nil {
+		return nil, errors.New("用户名已存在")
+	}
+
+	password, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:         req.Username,
+		Email:            req.Email,
+		PasswordHash:     passwordHash,
+		FullName:         req.FullName,
+		IsActive:         true,
+		UserType:         models.UserTypeUser,
+		TenantID:         creator.TenantID,
+		IsServiceAccount: true,
+	}
+	if err := s.repo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListServiceAccounts 查询当前用户租户的服务账号
+func (s *UserService) ListServiceAccounts(currentUserID uint) ([]models.User, error) {
+	currentUser, err := s.repo.GetByID(currentUserID)
+	if err != nil {
+		return nil, errors.New("当前用户不存在")
+	}
+	if currentUser.TenantID == nil || !s.canReadUsers(currentUser) {
+		return nil, errors.New("没有权限查看服务账号")
+	}
+	return s.repo.ListServiceAccounts(*currentUser.TenantID)
+}
+
+// ListTokens 查询用户的访问令牌，权限与管理会话相同
+func (s *UserService) ListTokens(id uint, currentUserID uint) ([]models.APIToken, error) {
+	if err := s.validateSessionPermission(id, currentUserID); err != nil {
+		return nil, err
+	}
+	return s.tokens.List(id)
+}
+
+// CreateToken 用户为自己创建令牌；管理员可以为可修改的服务账号创建令牌，但不能代替其他用户创建。
+// 只能使用登录会话创建（interactive），访问令牌不能再签发访问令牌
+func (s *UserService) CreateToken(id uint, req *models.APITokenCreateRequest, currentUserID uint, interactive bool) (*models.APITokenCreateResponse, error) {
+	if !interactive {
+		return nil, ErrSessionRequired
+	}
+	if id != currentUserID {
+		targetUser, err := s.repo.GetByID(id)
+		if err != nil {
+			return nil, errors.New("用户不存在")
+		}
+		if !targetUser.IsServiceAccount {
+			return nil, errors.New("只能为自己或服务账号创建访问令牌")
+		}
+		if err := s.validateSessionPermission(id, currentUserID); err != nil {
+			return nil, err
+		}
+	}
+	return s.tokens.Create(id, req, currentUserID)
+}
+
+// RevokeToken 吊销用户的访问令牌，权限与管理会话相同
+func (s *UserService) RevokeToken(id, tokenID uint, currentUserID uint) error {
+	if err := s.validateSessionPermission(id, currentUserID); err != nil {
+		return err
+	}
+	return s.tokens.Revoke(id, tokenID)
+}
+
 func (s *UserService) Register(req *models.UserCreateRequest) (*models.User, error) {
 	// 检查用户名是否已存在
 	_, err := s.repo.GetByUsername(req.Username)
@@ -272,46 +448,141 @@ func (s *UserService) Register(req *models.UserCreateRequest) (*models.User, err
 		return nil, errors.New("用户名已存在")
 	}
 
-	// Hash 密码
-	passwordHash, err := utils.HashPassword(req.Password)
-	if err != nil {
-		return nil, err
+	user := &models.User{
+		Username:    req.Username,
+		Email:       req.Email,
+		FullName:    req.FullName,
+		IsActive:    true,
+		UserType:    models.UserTypeUser,
+		TenantID:    nil, // 注册用户没有租户
+		IsSuperuser: false,
 	}
 
-	user := &models.User{
-		Username:     req.Username,
-		Email:        req.Email,
-		PasswordHash: passwordHash,
-		FullName:     req.FullName,
-		IsActive:     true,
-		UserType:     models.UserTypeUser,
-		TenantID:     nil, // 注册用户没有租户
-		IsSuperuser:  false,
+	// 按密码策略校验并 Hash 密码
+	if err := s.policy.SetPassword(user, req.Password); err != nil {
+		return nil, err
 	}
 
 	if err := s.repo.Create(user); err != nil {
 		return nil, err
 	}
+	s.policy.Remember(user)
 
 	return user, nil
 }
 
+// Authenticate 用户名密码登录。本地已有的用户连续登录失败达到上限后锁定，锁定期间不再尝试任何认证后端
 func (s *UserService) Authenticate(username, password string) (*models.User, error) {
-	user, err := s.repo.GetByUsername(username)
+	existing, _ := s.repo.GetByUsername(username)
+	if existing != nil && s.guard.Locked(existing) {
+		return nil, ErrAccountLocked
+	}
+
+	user, err := s.authenticate(username, password)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户名或密码错误")
+		if errors.Is(err, ErrInvalidCredentials) && existing != nil {
+			if lockErr := s.guard.Fail(existing); lockErr != nil {
+				return nil, lockErr
+			}
 		}
 		return nil, err
 	}
+	s.guard.Succeed(user)
+	return user, nil
+}
 
-	if !utils.CheckPassword(password, user.PasswordHash) {
-		return nil, errors.New("用户名或密码错误")
+// ChangePassword 使用原密码修改本地账号已过期的密码，不需要登录（在登录页修改），
+// 启用多因素认证时还需要验证码；未过期的密码只能登录后修改。原密码和验证码错误同样计入登录失败次数
+func (s *UserService) ChangePassword(req *models.PasswordChangeRequest) (*models.User, error) {
+	user, err := s.repo.GetByUsername(req.Username)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if s.guard.Locked(user) {
+		return nil, ErrAccountLocked
+	}
+	if user.IsServiceAccount || !utils.CheckPassword(req.OldPassword, user.PasswordHash) {
+		if err := s.guard.Fail(user); err != nil {
+			return user, err
+		}
+		return user, ErrInvalidCredentials
 	}
-
 	if !user.IsActive {
-		return nil, errors.New("用户已被禁用")
+		return user, ErrUserDisabled
+	}
+	if !s.policy.Expired(user) {
+		return user, ErrPasswordNotExpired
+	}
+	if user.MFAEnabled {
+		if err := s.mfa.VerifyCode(user, req.MFACode); err != nil {
+			return user, err
+		}
 	}
 
+	if err := s.policy.SetPassword(user, req.NewPassword); err != nil {
+		return user, err
+	}
+	user.FailedLoginCount = 0
+	user.LockedAt = nil
+	if err := s.repo.Update(user); err != nil {
+		return user, err
+	}
+	s.policy.Remember(user)
+
+	if err := s.sessions.RevokeUser(user.ID, models.RevokeReasonPasswordChanged); err != nil {
+		return user, err
+	}
 	return user, nil
 }
+
+// Unlock 解除因登录失败被锁定的账号，需要用户管理权限
+func (s *UserService) Unlock(id uint, currentUserID uint) error {
+	if err := s.validateManagePermission(id, currentUserID); err != nil {
+		return err
+	}
+	return s.guard.Unlock(id)
+}
+
+// ResetMFA 管理员为丢失身份验证器的用户停用多因素认证，用户登录后可重新绑定
+func (s *UserService) ResetMFA(id uint, currentUserID uint) error {
+	if err := s.validateManagePermission(id, currentUserID); err != nil {
+		return err
+	}
+	return s.repo.ResetMFA(id)
+}
+
+// validateManagePermission 需要用户管理权限，且可以修改目标用户
+func (s *UserService) validateManagePermission(id uint, currentUserID uint) error {
+	targetUser, err := s.repo.GetByID(id)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	currentUser, err := s.repo.GetByID(currentUserID)
+	if err != nil {
+		return errors.New("当前用户不存在")
+	}
+	if !s.roles.HasPermission(currentUser, authz.UserManage) {
+		return errors.New("没有权限管理该用户")
+	}
+	return s.validateUpdatePermission(currentUser, targetUser, &models.UserUpdateRequest{})
+}
+
+// authenticate 按配置顺序尝试各认证后端，后端不认识该用户、密码错误或暂时不可用时尝试下一个
+func (s *UserService) authenticate(username, password string) (*models.User, error) {
+	result := ErrInvalidCredentials
+	for _, authenticator := range s.authenticators {
+		user, err := authenticator.Authenticate(username, password)
+		switch {
+		case err == nil:
+			return user, nil
+		case errors.Is(err, ErrInvalidCredentials):
+			continue
+		case errors.Is(err, ErrAuthUnavailable):
+			log.Printf("认证后端 %s 不可用: %v", authenticator.Name(), err)
+			result = ErrAuthUnavailable
+		default:
+			return nil, err
+		}
+	}
+	return nil, result
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	commonutils "github.com/addp/common/utils"
+	"github.com/addp/system/internal/config"
+	"github.com/addp/system/internal/models"
+	"github.com/addp/system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// memUserStore 内存中的用户存储，读取时返回副本，与从数据库读取一致
+type memUserStore struct {
+	users map[uint]*models.User
+}
+
+func newMemUserStore() *memUserStore {
+	return &memUserStore{users: map[uint]*models.User{}}
+}
+
+func (m *memUserStore) get(id uint) (*models.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *user
+	return &found, nil
+}
+
+func (m *memUserStore) Create(user *models.User) error {
+	if user.ID == 0 {
+		user.ID = uint(len(m.users) + 1)
+	}
+	stored := *user
+	m.users[user.ID] = &stored
+	return nil
+}
+
+func (m *memUserStore) GetByID(id uint) (*models.User, error) { return m.get(id) }
+
+func (m *memUserStore) GetByUsername(username string) (*models.User, error) {
+	for id, user := range m.users {
+		if user.Username == username {
+			return m.get(id)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memUserStore) ListByTenant(uint, int, int) ([]models.User, error) { return nil, nil }
+func (m *memUserStore) ListServiceAccounts(uint) ([]models.User, error)    { return nil, nil }
+
+func (m *memUserStore) Update(user *models.User) error {
+	stored := *user
+	m.users[user.ID] = &stored
+	return nil
+}
+
+func (m *memUserStore) Delete(id uint) error {
+	delete(m.users, id)
+	return nil
+}
+
+func (m *memUserStore) IncrementFailedLogins(id uint, lockExpiredBefore time.Time) (int, error) {
+	user := m.users[id]
+	if user.LockedAt != nil && !user.LockedAt.After(lockExpiredBefore) {
+		user.FailedLoginCount, user.LockedAt = 0, nil
+	}
+	user.FailedLoginCount++
+	return user.FailedLoginCount, nil
+}
+
+func (m *memUserStore) Lock(id uint, at time.Time) error {
+	m.users[id].LockedAt = &at
+	return nil
+}
+
+func (m *memUserStore) ResetFailedLogins(id uint) error {
+	m.users[id].FailedLoginCount, m.users[id].LockedAt = 0, nil
+	return nil
+}
+
+func (m *memUserStore) AdvanceMFAStep(id uint, step int64) (bool, error) {
+	user := m.users[id]
+	if user.MFALastStep >= step {
+		return false, nil
+	}
+	user.MFALastStep = step
+	return true, nil
+}
+
+func (m *memUserStore) ResetMFA(id uint) error {
+	user := m.users[id]
+	user.MFAEnabled, user.MFASecret, user.MFALastStep = false, "", 0
+	return nil
+}
+
+func (m *memUserStore) AddPasswordHistory(uint, string, int) error      { return nil }
+func (m *memUserStore) ListPasswordHistory(uint, int) ([]string, error) { return nil, nil }
+
+// memChallenges 内存中的验证码登录
+type memChallenges struct {
+	challenges map[string]*models.MFAChallenge
+}
+
+func newMemChallenges() *memChallenges {
+	return &memChallenges{challenges: map[string]*models.MFAChallenge{}}
+}
+
+func (m *memChallenges) CreateChallenge(challenge *models.MFAChallenge) error {
+	m.challenges[challenge.TokenHash] = challenge
+	return nil
+}
+
+func (m *memChallenges) GetChallenge(tokenHash string) (*models.MFAChallenge, error) {
+	challenge, ok := m.challenges[tokenHash]
+	if !ok || time.Now().After(challenge.ExpiresAt) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return challenge, nil
+}
+
+func (m *memChallenges) IncrementAttempts(tokenHash string) (int, error) {
+	m.challenges[tokenHash].Attempts++
+	return m.challenges[tokenHash].Attempts, nil
+}
+
+func (m *memChallenges) DeleteChallenge(tokenHash string) (bool, error) {
+	_, ok := m.challenges[tokenHash]
+	delete(m.challenges, tokenHash)
+	return ok, nil
+}
+
+func (m *memChallenges) DeleteExpiredChallenges(time.Time) error { return nil }
+
+// authFixture 登录相关服务使用内存存储组装，时间和密钥按测试需要配置
+type authFixture struct {
+	cfg      *config.Config
+	users    *memUserStore
+	sessions *memSessions
+	tokens   *memTokens
+	guard    *LoginGuard
+	mfa      *MFAService
+	service  *UserService
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	cfg := &config.Config{
+		JWTSecret:               testJWTSecret,
+		TokenExpireMinutes:      30,
+		RefreshTokenExpireHours: 1,
+		EncryptionKey:           []byte("0123456789abcdef0123456789abcdef"),
+		MFAIssuer:               "ADDP",
+		PasswordMinLength:       8,
+		PasswordMaxAge:          90 * 24 * time.Hour,
+		LoginMaxFailedAttempts:  3,
+		LoginLockoutDuration:    15 * time.Minute,
+	}
+	f := &authFixture{cfg: cfg, users: newMemUserStore(), sessions: newMemSessions(), tokens: newMemTokens()}
+	sessions := &SessionService{repo: f.sessions, userRepo: f.users, cfg: cfg}
+	policy := &PasswordPolicy{cfg: cfg, repo: f.users}
+	f.guard = &LoginGuard{repo: f.users, maxAttempts: cfg.LoginMaxFailedAttempts, lockout: cfg.LoginLockoutDuration}
+	f.mfa = &MFAService{repo: newMemChallenges(), userRepo: f.users, guard: f.guard, issuer: cfg.MFAIssuer, encryptionKey: cfg.EncryptionKey}
+	f.service = &UserService{repo: f.users, sessions: sessions, policy: policy, guard: f.guard, mfa: f.mfa,
+		roles:          &RoleService{repo: newMemRoles(), userRepo: f.users},
+		tokens:         &APITokenService{repo: f.tokens, userRepo: f.users},
+		authenticators: []Authenticator{&LocalAuthenticator{repo: f.users, policy: policy, enabled: true}}}
+	return f
+}
+
+// addUser 创建本地用户；passwordAge 为密码已使用的时长，mfa 为 true 时启用多因素认证并返回 TOTP 密钥
+func (f *authFixture) addUser(t *testing.T, username, password string, passwordAge time.Duration, mfa bool) (*models.User, string) {
+	t.Helper()
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedAt := time.Now().Add(-passwordAge)
+	user := &models.User{Username: username, PasswordHash: hash, PasswordChangedAt: &changedAt, IsActive: true, UserType: models.UserTypeUser}
+	secret := ""
+	if mfa {
+		if secret, err = utils.GenerateTOTPSecret(); err != nil {
+			t.Fatal(err)
+		}
+		if user.MFASecret, err = commonutils.Encrypt(secret, f.cfg.EncryptionKey); err != nil {
+			t.Fatal(err)
+		}
+		user.MFAEnabled = true
+	}
+	if err := f.users.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	return user, secret
+}
+
+// totpAt 按 RFC 6238 计算 at 所在时间步的验证码，模拟身份验证器应用
+func totpAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+const (
+	testOldPassword = "Old-Passw0rd"
+	testNewPassword = "New-Passw0rd"
+)
+
+func TestChangePasswordOnlyWhenExpired(t *testing.T) {
+	f := newAuthFixture(t)
+	user, _ := f.addUser(t, "alice", testOldPassword, 24*time.Hour, false)
+
+	_, err := f.service.ChangePassword(&models.PasswordChangeRequest{Username: "alice", OldPassword: testOldPassword, NewPassword: testNewPassword})
+	if !errors.Is(err, ErrPasswordNotExpired) {
+		t.Fatalf("err = %v, want ErrPasswordNotExpired", err)
+	}
+	if stored := f.users.users[user.ID]; !utils.CheckPassword(testOldPassword, stored.PasswordHash) {
+		t.Fatal("password of unexpired account changed")
+	}
+
+	// 过期后可以修改，修改后旧密码失效、已有会话被吊销
+	expired, _ := f.addUser(t, "bob", testOldPassword, 100*24*time.Hour, false)
+	login, err := f.service.sessions.Create(expired, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.ChangePassword(&models.PasswordChangeRequest{Username: "bob", OldPassword: testOldPassword, NewPassword: testNewPassword}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if stored := f.users.users[expired.ID]; !utils.CheckPassword(testNewPassword, stored.PasswordHash) {
+		t.Fatal("password not changed")
+	}
+	jti, _ := accessJTI(t, login)
+	if revoked, _ := f.sessions.IsTokenRevoked(jti); !revoked {
+		t.Fatal("sessions not revoked after password change")
+	}
+}
+
+func TestChangePasswordRequiresMFA(t *testing.T) {
+	f := newAuthFixture(t)
+	user, secret := f.addUser(t, "alice", testOldPassword, 100*24*time.Hour, true)
+	req := func(code string) *models.PasswordChangeRequest {
+		return &models.PasswordChangeRequest{Username: "alice", OldPassword: testOldPassword, NewPassword: testNewPassword, MFACode: code}
+	}
+
+	// 只知道原密码不能修改
+	if _, err := f.service.ChangePassword(req("")); !errors.Is(err, ErrMFARequired) {
+		t.Fatalf("without code: err = %v, want ErrMFARequired", err)
+	}
+	wrong := "000000"
+	if wrong == totpAt(t, secret, time.Now()) {
+		wrong = "111111"
+	}
+	if _, err := f.service.ChangePassword(req(wrong)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("wrong code: err = %v, want ErrInvalidMFACode", err)
+	}
+	if got := f.users.users[user.ID].FailedLoginCount; got != 1 {
+		t.Fatalf("FailedLoginCount = %d, want wrong code counted", got)
+	}
+	if stored := f.users.users[user.ID]; !utils.CheckPassword(testOldPassword, stored.PasswordHash) {
+		t.Fatal("password changed without a valid code")
+	}
+
+	code := totpAt(t, secret, time.Now())
+	if _, err := f.service.ChangePassword(req(code)); err != nil {
+		t.Fatalf("valid code: %v", err)
+	}
+	stored := f.users.users[user.ID]
+	if !utils.CheckPassword(testNewPassword, stored.PasswordHash) || stored.FailedLoginCount != 0 {
+		t.Fatalf("password not changed or failures not cleared: %+v", stored)
+	}
+}
+
+func TestChangePasswordLocksAfterFailures(t *testing.T) {
+	f := newAuthFixture(t)
+	f.addUser(t, "alice", testOldPassword, 100*24*time.Hour, false)
+
+	bad := &models.PasswordChangeRequest{Username: "alice", OldPassword: "guess", NewPassword: testNewPassword}
+	for i := 1; i < f.cfg.LoginMaxFailedAttempts; i++ {
+		if _, err := f.service.ChangePassword(bad); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", i, err)
+		}
+	}
+	if _, err := f.service.ChangePassword(bad); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("last attempt: err = %v, want ErrAccountLocked", err)
+	}
+	// 锁定后正确的原密码也被拒绝
+	good := &models.PasswordChangeRequest{Username: "alice", OldPassword: testOldPassword, NewPassword: testNewPassword}
+	if _, err := f.service.ChangePassword(good); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("locked: err = %v, want ErrAccountLocked", err)
+	}
+}
+
+func TestTokenCannotChangePasswordOrCreateTokens(t *testing.T) {
+	f := newAuthFixture(t)
+	user, _ := f.addUser(t, "alice", testOldPassword, 24*time.Hour, false)
+	password := testNewPassword
+
+	// 使用访问令牌时不能修改密码，其他字段可以修改
+	if _, err := f.service.Update(user.ID, &models.UserUpdateRequest{Password: &password}, user.ID, false); !errors.Is(err, ErrSessionRequired) {
+		t.Fatalf("Update password with token: err = %v, want ErrSessionRequired", err)
+	}
+	if stored := f.users.users[user.ID]; !utils.CheckPassword(testOldPassword, stored.PasswordHash) {
+		t.Fatal("password changed with token")
+	}
+	fullName := "Alice"
+	if _, err := f.service.Update(user.ID, &models.UserUpdateRequest{FullName: &fullName}, user.ID, false); err != nil {
+		t.Fatalf("Update with token: %v", err)
+	}
+	if _, err := f.service.Update(user.ID, &models.UserUpdateRequest{Password: &password}, user.ID, true); err != nil {
+		t.Fatalf("Update password with session: %v", err)
+	}
+	if stored := f.users.users[user.ID]; !utils.CheckPassword(testNewPassword, stored.PasswordHash) {
+		t.Fatal("password not changed with session")
+	}
+
+	// 访问令牌不能再签发访问令牌
+	req := &models.APITokenCreateRequest{Name: "ci", Scopes: []string{"write"}}
+	if _, err := f.service.CreateToken(user.ID, req, user.ID, false); !errors.Is(err, ErrSessionRequired) {
+		t.Fatalf("CreateToken with token: err = %v, want ErrSessionRequired", err)
+	}
+	if len(f.tokens.tokens) != 0 {
+		t.Fatal("token created with token")
+	}
+	if _, err := f.service.CreateToken(user.ID, req, user.ID, true); err != nil {
+		t.Fatalf("CreateToken with session: %v", err)
+	}
+}
@@ -1,23 +1,35 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Claims 中的租户和用户类型供 Gateway 直接生成身份头，无需再查询用户；
+// jti（RegisteredClaims.ID）用于吊销单个访问令牌，sid 为签发令牌的登录会话
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	TenantID  *uint  `json:"tenant_id,omitempty"`
+	UserType  string `json:"user_type,omitempty"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username string, secret string, expireMinutes int) (string, error) {
+func GenerateToken(userID uint, username string, tenantID *uint, userType string, sessionID string, tokenID string, secret string, expiresAt time.Time) (string, error) {
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		TenantID:  tenantID,
+		UserType:  userType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expireMinutes) * time.Minute)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -40,4 +52,19 @@ func ParseToken(tokenString string, secret string) (*Claims, error) {
 	}
 
 	return nil, jwt.ErrSignatureInvalid
-}
\ No newline at end of file
+}
+
+// RandomToken 生成 n 字节随机数的十六进制串，用作令牌 ID 和刷新令牌
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken 刷新令牌只保存 SHA-256 哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
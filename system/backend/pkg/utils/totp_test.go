@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录 B 的 SHA1 密钥 "12345678901234567890" 的 Base32 编码
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestTOTPCodeRFC6238 RFC 6238 附录 B 的 SHA1 测试向量，取 8 位结果的后 6 位
+func TestTOTPCodeRFC6238(t *testing.T) {
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	key := []byte("12345678901234567890")
+	for _, tc := range cases {
+		if got := totpCode(key, tc.unix/totpPeriod); got != tc.code {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tc.unix, got, tc.code)
+		}
+		step, ok := VerifyTOTP(rfc6238Secret, tc.code, time.Unix(tc.unix, 0))
+		if !ok || step != tc.unix/totpPeriod {
+			t.Errorf("VerifyTOTP(T=%d) = (%d, %v), want (%d, true)", tc.unix, step, ok, tc.unix/totpPeriod)
+		}
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+
+	cases := []struct {
+		name   string
+		offset int64
+		ok     bool
+	}{
+		{"current step", 0, true},
+		{"one step behind", -1, true},
+		{"one step ahead", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			step, ok := VerifyTOTP(rfc6238Secret, totpCode(key, current+tc.offset), now)
+			if ok != tc.ok {
+				t.Fatalf("ok = %v, want %v", ok, tc.ok)
+			}
+			if ok && step != current+tc.offset {
+				t.Fatalf("step = %d, want %d", step, current+tc.offset)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTPRejectsMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	cases := []struct {
+		name, secret, code string
+	}{
+		{"short code", rfc6238Secret, "28708"},
+		{"long code", rfc6238Secret, "2870820"},
+		{"wrong code", rfc6238Secret, "287083"},
+		{"invalid secret", "not base32!", "287082"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, ok := VerifyTOTP(tc.secret, tc.code, now); ok {
+				t.Fatal("expected code to be rejected")
+			}
+		})
+	}
+	// 验证码前后的空白和小写密钥应被接受
+	if _, ok := VerifyTOTP("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", " 287082 ", now); !ok {
+		t.Fatal("expected lowercase secret with padded code to be accepted")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes (err %v), want 20", secret, len(key), err)
+	}
+}
@@ -5,11 +5,52 @@ export const authAPI = {
     return client.post('/auth/login', { username, password })
   },
 
+  // 启用多因素认证时，使用登录返回的 mfa_token 和验证码完成登录
+  verifyMFA: (mfaToken, code) => {
+    return client.post('/auth/mfa', { mfa_token: mfaToken, code })
+  },
+
+  // 使用原密码修改密码（密码过期后，不需要登录）
+  changePassword: (data) => {
+    return client.post('/auth/password', data)
+  },
+
   register: (data) => {
     return client.post('/auth/register', data)
   },
 
+  logout: () => {
+    return client.post('/auth/logout')
+  },
+
   getMe: () => {
     return client.get('/users/me')
+  },
+
+  // 单点登录配置（是否启用、按钮名称、是否允许用户名密码登录）
+  oidcConfig: () => {
+    return client.get('/auth/oidc')
+  },
+
+  // 已登录用户关联单点登录账号，返回提供方登录地址
+  oidcLink: (redirect = '/') => {
+    return client.post('/auth/oidc/link', null, { params: { redirect } })
+  },
+
+  getIdentities: () => {
+    return client.get('/users/me/identities')
+  },
+
+  // 绑定身份验证器：setup 返回密钥和 otpauth 地址，enable 用验证码确认
+  mfaSetup: () => {
+    return client.post('/users/me/mfa/setup')
+  },
+
+  mfaEnable: (code) => {
+    return client.post('/users/me/mfa/enable', { code })
+  },
+
+  mfaDisable: (code) => {
+    return client.post('/users/me/mfa/disable', { code })
   }
 }
\ No newline at end of file
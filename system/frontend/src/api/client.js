@@ -19,11 +19,29 @@ client.interceptors.request.use(
   }
 )
 
+// 并发请求同时过期时只刷新一次
+let refreshing = null
+
 client.interceptors.response.use(
   response => response,
-  error => {
-    if (error.response?.status === 401) {
+  async error => {
+    const original = error.config
+    // 登录、验证码等认证接口的 401 由页面自行提示，不跳转
+    if (error.response?.status === 401 && !original?.url?.startsWith('/auth/')) {
       const authStore = useAuthStore()
+      if (authStore.refreshToken && original && !original._retried) {
+        original._retried = true
+        try {
+          refreshing = refreshing || authStore.refresh()
+          const token = await refreshing
+          original.headers.Authorization = `Bearer ${token}`
+          return client(original)
+        } catch (refreshError) {
+          // 刷新令牌无效或会话已吊销，需要重新登录
+        } finally {
+          refreshing = null
+        }
+      }
       authStore.logout()
       window.location.href = '/login'
     }
@@ -9,5 +9,11 @@ export const logsAPI = {
 
   getById: (id) => {
     return client.get(`/logs/${id}`)
+  },
+
+  loginAttempts: (page = 1, pageSize = 20, userId = null) => {
+    const params = { page, page_size: pageSize }
+    if (userId) params.user_id = userId
+    return client.get('/logs/login-attempts', { params })
   }
 }
\ No newline at end of file
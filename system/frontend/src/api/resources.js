@@ -29,5 +29,18 @@ export const resourcesAPI = {
 
   testExistingConnection: (id) => {
     return client.post(`/resources/${id}/test`)
+  },
+
+  listGrants: (id) => {
+    return client.get(`/resources/${id}/grants`)
+  },
+
+  // data: { subject_type: 'user' | 'role' | 'tenant', subject_id, level: 'view' | 'preview' | 'connect' | 'manage' }
+  grant: (id, data) => {
+    return client.post(`/resources/${id}/grants`, data)
+  },
+
+  revokeGrant: (id, grantId) => {
+    return client.delete(`/resources/${id}/grants/${grantId}`)
   }
 }
\ No newline at end of file
@@ -23,5 +23,33 @@ export const usersAPI = {
 
   me: () => {
     return client.get('/users/me')
+  },
+
+  listServiceAccounts: () => {
+    return client.get('/users/service-accounts')
+  },
+
+  createServiceAccount: (data) => {
+    return client.post('/users/service-accounts', data)
+  },
+
+  listTokens: (id) => {
+    return client.get(`/users/${id}/tokens`)
+  },
+
+  createToken: (id, data) => {
+    return client.post(`/users/${id}/tokens`, data)
+  },
+
+  revokeToken: (id, tokenId) => {
+    return client.delete(`/users/${id}/tokens/${tokenId}`)
+  },
+
+  unlock: (id) => {
+    return client.post(`/users/${id}/unlock`)
+  },
+
+  resetMFA: (id) => {
+    return client.delete(`/users/${id}/mfa`)
   }
 }
\ No newline at end of file
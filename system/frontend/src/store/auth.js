@@ -1,9 +1,11 @@
+import axios from 'axios'
 import { defineStore } from 'pinia'
 import { authAPI } from '../api/auth'
 
 export const useAuthStore = defineStore('auth', {
   state: () => ({
     token: localStorage.getItem('token') || null,
+    refreshToken: localStorage.getItem('refresh_token') || null,
     user: null
   }),
 
@@ -12,13 +14,42 @@ export const useAuthStore = defineStore('auth', {
   },
 
   actions: {
+    // 启用多因素认证时返回 { mfa_required, mfa_token }，需要再调用 verifyMFA
     async login(username, password) {
       const response = await authAPI.login(username, password)
-      this.token = response.data.access_token
-      localStorage.setItem('token', this.token)
+      if (response.data.mfa_required) {
+        return response.data
+      }
+      this.setTokens(response.data)
+      await this.fetchUser()
+      return null
+    },
+
+    async verifyMFA(mfaToken, code) {
+      const response = await authAPI.verifyMFA(mfaToken, code)
+      this.setTokens(response.data)
       await this.fetchUser()
     },
 
+    setTokens(data) {
+      this.token = data.access_token
+      localStorage.setItem('token', this.token)
+      if (data.refresh_token) {
+        this.refreshToken = data.refresh_token
+        localStorage.setItem('refresh_token', this.refreshToken)
+      }
+    },
+
+    // 访问令牌过期时用刷新令牌换取新令牌（不经过 client 拦截器，避免循环）
+    async refresh() {
+      if (!this.refreshToken) {
+        throw new Error('no refresh token')
+      }
+      const response = await axios.post('/api/auth/refresh', { refresh_token: this.refreshToken })
+      this.setTokens(response.data)
+      return this.token
+    },
+
     setToken(token) {
       this.token = token
       localStorage.setItem('token', token)
@@ -29,10 +60,24 @@ export const useAuthStore = defineStore('auth', {
       this.user = response.data
     },
 
+    // 注销服务端会话，失败时也清除本地令牌
+    async signOut() {
+      if (this.token) {
+        try {
+          await authAPI.logout()
+        } catch (error) {
+          console.error('Logout failed:', error)
+        }
+      }
+      this.logout()
+    },
+
     logout() {
       this.token = null
+      this.refreshToken = null
       this.user = null
       localStorage.removeItem('token')
+      localStorage.removeItem('refresh_token')
     }
   }
 })
\ No newline at end of file
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/addp/common/authz"
+	"github.com/addp/common/metrics"
+	"github.com/addp/common/tracing"
+	"github.com/addp/transfer/internal/api"
+	"github.com/addp/transfer/internal/config"
+	"github.com/addp/transfer/internal/queue"
+	"github.com/addp/transfer/internal/repository"
+	"github.com/addp/transfer/internal/scheduler"
+	"github.com/addp/transfer/internal/service"
+)
+
+func main() {
+	// 加载配置
+	cfg := config.Load()
+
+	// 配置 OTEL_EXPORTER_OTLP_ENDPOINT 时导出 trace
+	defer tracing.Init("transfer")()
+
+	// 初始化数据库
+	db, err := repository.InitDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB("transfer", sqlDB)
+	}
+	log.Println("Database initialized successfully")
+
+	// 初始化任务队列
+	redisClient, err := queue.NewRedisClient(cfg.RedisAddr(), cfg.RedisPassword, cfg.RedisDB)
+	if err != nil {
+		log.Fatalf("Failed to initialize redis: %v", err)
+	}
+	defer redisClient.Close()
+	taskQueue := queue.NewQueue(redisClient, cfg.TaskQueueName)
+
+	// 初始化服务
+	taskRepo := repository.NewTaskRepository(db)
+	executionRepo := repository.NewExecutionRepository(db)
+	resourceService := service.NewResourceService(cfg.SystemServiceURL, cfg.InternalAPIKey)
+	checkpointRepo := repository.NewCheckpointRepository(db)
+	metadataService := service.NewMetadataService(repository.NewMetadataRepository(db, cfg.MetaSchema))
+	taskService := service.NewTaskService(taskRepo, executionRepo, checkpointRepo, resourceService, metadataService, taskQueue)
+
+	// 启动定时调度
+	if cfg.SchedulerEnabled {
+		taskScheduler := scheduler.NewScheduler(taskRepo, taskService, cfg.SchedulerInterval, cfg.MaxCatchUpRuns)
+		go taskScheduler.Run(context.Background())
+	}
+
+	// 未配置权限检查时拒绝启动，不以无权限检查的状态对外服务
+	checker, err := authz.NewSystemChecker(cfg.SystemServiceURL, cfg.InternalAPIKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize permission checker: %v", err)
+	}
+
+	// Prometheus 指标只在内部端口上提供
+	metrics.Serve("transfer", cfg.MetricsPort)
+
+	// 设置路由
+	router := api.SetupRouter(cfg, db, redisClient, checker, taskService)
+
+	// 启动服务器
+	addr := fmt.Sprintf(":%s", cfg.Port)
+	log.Printf("Transfer service starting on %s", addr)
+
+	if err := router.Run(addr); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
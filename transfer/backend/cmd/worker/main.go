@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/addp/common/metrics"
+	"github.com/addp/transfer/internal/config"
+	"github.com/addp/transfer/internal/queue"
+	"github.com/addp/transfer/internal/repository"
+	"github.com/addp/transfer/internal/service"
+	"github.com/addp/transfer/internal/worker"
+)
+
+func main() {
+	// 加载配置
+	cfg := config.Load()
+
+	// 初始化数据库
+	db, err := repository.InitDatabase(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB("transfer", sqlDB)
+	}
+	log.Println("Database initialized successfully")
+
+	// 初始化任务队列
+	redisClient, err := queue.NewRedisClient(cfg.RedisAddr(), cfg.RedisPassword, cfg.RedisDB)
+	if err != nil {
+		log.Fatalf("Failed to initialize redis: %v", err)
+	}
+	defer redisClient.Close()
+	taskQueue := queue.NewQueue(redisClient, cfg.TaskQueueName)
+
+	taskRepo := repository.NewTaskRepository(db)
+	executionRepo := repository.NewExecutionRepository(db)
+	resourceService := service.NewResourceService(cfg.SystemServiceURL, cfg.InternalAPIKey)
+	checkpointRepo := repository.NewCheckpointRepository(db)
+	metadataService := service.NewMetadataService(repository.NewMetadataRepository(db, cfg.MetaSchema))
+	executor := worker.NewExecutor(resourceService, metadataService, taskRepo, checkpointRepo, cfg.BatchSize)
+	pool := worker.NewPool(cfg, taskQueue, taskRepo, executionRepo, executor)
+
+	// Prometheus 指标（传输行数、字节数和执行耗时）
+	metrics.Serve("transfer-worker", cfg.WorkerMetricsPort)
+
+	// 收到退出信号后停止取任务，正在执行的任务交回队列
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool.Run(ctx)
+}
@@ -0,0 +1,415 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/addp/transfer/internal/middleware"
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sseKeepAliveInterval = 15 * time.Second
+	ssePollInterval      = 10 * time.Second
+)
+
+type Handler struct {
+	taskService *service.TaskService
+}
+
+func NewHandler(taskService *service.TaskService) *Handler {
+	return &Handler{taskService: taskService}
+}
+
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondWithError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound), errors.Is(err, service.ErrExecutionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTaskRunning), errors.Is(err, service.ErrInvalidTaskState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTaskConfig):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateTask 创建传输任务
+// POST /api/tasks
+func (h *Handler) CreateTask(c *gin.Context) {
+	var req models.TaskCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.taskService.Create(&req, middleware.GetTenantID(c), middleware.GetUserID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": task})
+}
+
+// ListTasks 获取任务列表
+// GET /api/tasks
+func (h *Handler) ListTasks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	tasks, total, err := h.taskService.List(middleware.GetTenantID(c), models.TaskListQuery{
+		Page:     page,
+		PageSize: pageSize,
+		Status:   c.Query("status"),
+		Type:     c.Query("type"),
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tasks, "total": total})
+}
+
+// GetTask 获取任务详情
+// GET /api/tasks/:id
+func (h *Handler) GetTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	task, err := h.taskService.GetByID(id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": task})
+}
+
+// UpdateTask 更新任务配置
+// PUT /api/tasks/:id
+func (h *Handler) UpdateTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req models.TaskUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.taskService.Update(id, &req, middleware.GetTenantID(c), middleware.GetUserID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": task})
+}
+
+// DeleteTask 删除任务
+// DELETE /api/tasks/:id
+func (h *Handler) DeleteTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.Delete(id, middleware.GetTenantID(c)); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+}
+
+// RunTask 立即执行任务
+// POST /api/tasks/:id/run
+func (h *Handler) RunTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	execution, err := h.taskService.Run(c.Request.Context(), id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": execution})
+}
+
+// PauseTask 暂停任务
+// POST /api/tasks/:id/pause
+func (h *Handler) PauseTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.Pause(id, middleware.GetTenantID(c)); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "pause requested"})
+}
+
+// ResumeTask 恢复已暂停的任务
+// POST /api/tasks/:id/resume
+func (h *Handler) ResumeTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	execution, err := h.taskService.Resume(c.Request.Context(), id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": execution})
+}
+
+// CancelTask 取消任务的当前执行
+// POST /api/tasks/:id/cancel
+func (h *Handler) CancelTask(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.Cancel(id, middleware.GetTenantID(c)); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cancel requested"})
+}
+
+// GetTaskProgress 获取任务进度
+// GET /api/tasks/:id/progress
+func (h *Handler) GetTaskProgress(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	progress, err := h.taskService.GetProgress(id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": progress})
+}
+
+// ListTaskExecutions 获取任务的执行历史
+// GET /api/tasks/:id/executions
+func (h *Handler) ListTaskExecutions(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	executions, total, err := h.taskService.ListExecutions(id, middleware.GetTenantID(c), page, pageSize)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": executions, "total": total})
+}
+
+// ListRunningTasks 获取运行中的执行
+// GET /api/tasks/running
+func (h *Handler) ListRunningTasks(c *gin.Context) {
+	executions, err := h.taskService.ListRunning(middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": executions})
+}
+
+// GetStatistics 获取任务统计
+// GET /api/tasks/statistics
+func (h *Handler) GetStatistics(c *gin.Context) {
+	stats, err := h.taskService.GetStatistics(middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// GetExecution 获取执行详情
+// GET /api/executions/:id
+func (h *Handler) GetExecution(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	execution, err := h.taskService.GetExecution(id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": execution})
+}
+
+// GetExecutionLogs 获取执行日志
+// GET /api/executions/:id/logs
+func (h *Handler) GetExecutionLogs(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	logs, err := h.taskService.GetExecutionLogs(id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs})
+}
+
+// GetExecutionProgress 获取执行的实时进度（吞吐量、预计剩余时间）
+// GET /api/executions/:id/progress
+func (h *Handler) GetExecutionProgress(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	progress, err := h.taskService.GetExecutionProgress(c.Request.Context(), id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": progress})
+}
+
+// StreamExecutionEvents 以 Server-Sent Events 推送执行进度，执行结束后发送 end 事件并关闭
+// GET /api/executions/:id/events
+func (h *Handler) StreamExecutionEvents(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	tenantID := middleware.GetTenantID(c)
+
+	// 先订阅再读取当前进度，避免两者之间的更新丢失
+	updates, closeFn, err := h.taskService.SubscribeExecutionProgress(ctx, id, tenantID)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	defer closeFn()
+
+	current, err := h.taskService.GetExecutionProgress(ctx, id, tenantID)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// send 推送一次进度，执行已结束时追加 end 事件并返回 true
+	send := func(progress *models.ExecutionProgress) bool {
+		c.SSEvent("progress", progress)
+		if progress.Finished() {
+			c.SSEvent("end", progress)
+		}
+		c.Writer.Flush()
+		return progress.Finished()
+	}
+	if send(current) {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+	// 兜底轮询：Worker 异常退出或结束通知丢失时仍能结束事件流
+	poll := time.NewTicker(ssePollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case progress, ok := <-updates:
+			if !ok {
+				return
+			}
+			if send(progress) {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-poll.C:
+			progress, err := h.taskService.GetExecutionProgress(ctx, id, tenantID)
+			if err != nil {
+				return
+			}
+			if progress.Finished() {
+				send(progress)
+				return
+			}
+		}
+	}
+}
+
+// RetryExecution 重试失败的执行
+// POST /api/executions/:id/retry
+func (h *Handler) RetryExecution(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	execution, err := h.taskService.RetryExecution(c.Request.Context(), id, middleware.GetTenantID(c))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": execution})
+}
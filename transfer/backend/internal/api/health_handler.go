@@ -0,0 +1,31 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/addp/common/health"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// healthHandler 检查数据库和任务队列使用的 Redis，任一不可用时返回 503
+func healthHandler(db *gorm.DB, redisClient *redis.Client) gin.HandlerFunc {
+	checker := health.NewChecker("transfer", 2*time.Second).
+		Add("database", true, func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}).
+		Add("redis", true, func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		c.JSON(report.HTTPStatus(), report)
+	}
+}
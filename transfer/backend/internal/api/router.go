@@ -0,0 +1,76 @@
+package api
+
+import (
+	"github.com/addp/common/authz"
+	"github.com/addp/transfer/internal/config"
+	"github.com/addp/transfer/internal/middleware"
+	"github.com/addp/transfer/internal/service"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+func SetupRouter(cfg *config.Config, db *gorm.DB, redisClient *redis.Client, checker *authz.Checker, taskService *service.TaskService) *gin.Engine {
+	router := gin.New()
+
+	// 访问日志附带请求 ID，请求 ID 和 trace 随调用传递给其他服务
+	router.Use(middleware.Logger(), gin.Recovery(), middleware.RequestID(), middleware.Metrics())
+
+	// CORS配置
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowCredentials = true
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	corsConfig.AllowAllOrigins = true
+	router.Use(cors.New(corsConfig))
+
+	handler := NewHandler(taskService)
+
+	// 健康检查
+	router.GET("/health", healthHandler(db, redisClient))
+
+	// 按 System 中分配的角色检查权限
+	read := middleware.RequirePermission(checker, authz.TransferRead)
+	manage := middleware.RequirePermission(checker, authz.TransferManage)
+	run := middleware.RequirePermission(checker, authz.TransferRun)
+
+	// API路由组（需要认证）
+	api := router.Group("/api")
+	api.Use(middleware.AuthMiddleware(cfg.SystemServiceURL, cfg.IdentitySecret))
+	{
+		// 任务管理
+		tasks := api.Group("/tasks")
+		{
+			tasks.POST("", manage, handler.CreateTask)
+			tasks.GET("", read, handler.ListTasks)
+			tasks.GET("/running", read, handler.ListRunningTasks)
+			tasks.GET("/statistics", read, handler.GetStatistics)
+			tasks.GET("/:id", read, handler.GetTask)
+			tasks.PUT("/:id", manage, handler.UpdateTask)
+			tasks.DELETE("/:id", manage, handler.DeleteTask)
+
+			// 任务控制
+			tasks.POST("/:id/run", run, handler.RunTask)
+			tasks.POST("/:id/start", run, handler.RunTask)
+			tasks.POST("/:id/pause", run, handler.PauseTask)
+			tasks.POST("/:id/resume", run, handler.ResumeTask)
+			tasks.POST("/:id/cancel", run, handler.CancelTask)
+			tasks.POST("/:id/stop", run, handler.CancelTask)
+
+			tasks.GET("/:id/progress", read, handler.GetTaskProgress)
+			tasks.GET("/:id/executions", read, handler.ListTaskExecutions)
+		}
+
+		// 任务执行
+		executions := api.Group("/executions")
+		{
+			executions.GET("/:id", read, handler.GetExecution)
+			executions.GET("/:id/logs", read, handler.GetExecutionLogs)
+			executions.GET("/:id/progress", read, handler.GetExecutionProgress)
+			executions.GET("/:id/events", read, handler.StreamExecutionEvents)
+			executions.POST("/:id/retry", run, handler.RetryExecution)
+		}
+	}
+
+	return router
+}
@@ -13,30 +13,60 @@ type Config struct {
 	// Transfer 模块特有配置
 	Port            string
 	DBSchema        string
+	MetaSchema      string // Meta 模块的 schema，用于读取扫描的表结构
+	InternalAPIKey  string // 服务间调用的 API Key
 	RedisHost       string
 	RedisPort       string
 	RedisPassword   string
+	RedisDB         int
 	WorkerCount     int
 	MaxRetries      int
 	RetryDelay      time.Duration
 	TaskQueueName   string
 	ConcurrentTasks int
+
+	// API 服务和 Worker 进程的 Prometheus 指标端口（内部端口，不经过服务端口暴露），为空时不监听
+	MetricsPort       string
+	WorkerMetricsPort string
+
+	// 传输执行配置
+	BatchSize         int
+	ExecutionTimeout  time.Duration
+	HeartbeatInterval time.Duration
+	ProgressInterval  time.Duration // 实时进度发布间隔
+
+	// 调度配置
+	SchedulerEnabled  bool
+	SchedulerInterval time.Duration
+	MaxCatchUpRuns    int
 }
 
 func Load() *Config {
 	systemURL := commonConfig.GetEnv("SYSTEM_SERVICE_URL", "http://localhost:8080")
 
 	cfg := &Config{
-		Port:            commonConfig.GetEnv("PORT", "8083"),
-		DBSchema:        commonConfig.GetEnv("DB_SCHEMA", "transfer"),
-		RedisHost:       commonConfig.GetEnv("REDIS_HOST", "localhost"),
-		RedisPort:       commonConfig.GetEnv("REDIS_PORT", "6379"),
-		RedisPassword:   commonConfig.GetEnv("REDIS_PASSWORD", ""),
-		WorkerCount:     commonConfig.GetEnvInt("WORKER_COUNT", 5),
-		MaxRetries:      commonConfig.GetEnvInt("MAX_RETRIES", 3),
-		RetryDelay:      commonConfig.GetEnvDuration("RETRY_DELAY", "30s"),
-		TaskQueueName:   commonConfig.GetEnv("TASK_QUEUE_NAME", "transfer:tasks"),
-		ConcurrentTasks: commonConfig.GetEnvInt("CONCURRENT_TASKS", 10),
+		Port:              commonConfig.GetEnv("PORT", "8083"),
+		DBSchema:          commonConfig.GetEnv("DB_SCHEMA", "transfer"),
+		MetaSchema:        commonConfig.GetEnv("META_DB_SCHEMA", "metadata"),
+		InternalAPIKey:    commonConfig.GetEnv("INTERNAL_API_KEY", ""),
+		RedisHost:         commonConfig.GetEnv("REDIS_HOST", "localhost"),
+		RedisPort:         commonConfig.GetEnv("REDIS_PORT", "6379"),
+		RedisPassword:     commonConfig.GetEnv("REDIS_PASSWORD", ""),
+		RedisDB:           commonConfig.GetEnvInt("REDIS_DB", 0),
+		WorkerCount:       commonConfig.GetEnvInt("WORKER_COUNT", 5),
+		MaxRetries:        commonConfig.GetEnvInt("MAX_RETRIES", 3),
+		RetryDelay:        commonConfig.GetEnvDuration("RETRY_DELAY", "30s"),
+		TaskQueueName:     commonConfig.GetEnv("TASK_QUEUE_NAME", "transfer:tasks"),
+		ConcurrentTasks:   commonConfig.GetEnvInt("CONCURRENT_TASKS", 10),
+		BatchSize:         commonConfig.GetEnvInt("TRANSFER_BATCH_SIZE", 1000),
+		ExecutionTimeout:  commonConfig.GetEnvDuration("TRANSFER_TIMEOUT", "3600s"),
+		HeartbeatInterval: commonConfig.GetEnvDuration("HEARTBEAT_INTERVAL", "10s"),
+		ProgressInterval:  commonConfig.GetEnvDuration("PROGRESS_INTERVAL", "1s"),
+		SchedulerEnabled:  commonConfig.GetEnvBool("SCHEDULER_ENABLED", true),
+		SchedulerInterval: commonConfig.GetEnvDuration("SCHEDULER_INTERVAL", "30s"),
+		MaxCatchUpRuns:    commonConfig.GetEnvInt("SCHEDULER_MAX_CATCHUP", 10),
+		MetricsPort:       commonConfig.GetEnv("METRICS_PORT", "9095"),
+		WorkerMetricsPort: commonConfig.GetEnv("WORKER_METRICS_PORT", "9093"),
 	}
 
 	// 设置 BaseConfig 字段
@@ -58,5 +88,14 @@ func Load() *Config {
 		commonConfig.LoadLocalConfig(&cfg.BaseConfig)
 	}
 
+	if cfg.InternalAPIKey == "" {
+		cfg.InternalAPIKey = cfg.BaseConfig.InternalAPIKey
+	}
+
 	return cfg
 }
+
+// RedisAddr 返回 Redis 连接地址
+func (c *Config) RedisAddr() string {
+	return c.RedisHost + ":" + c.RedisPort
+}
@@ -0,0 +1,273 @@
+package connector
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// csvDelimiters 返回分隔符和引号字符
+func csvDelimiters(opts models.FileOptions) (rune, rune, error) {
+	delimiter, err := singleRune("file.delimiter", opts.Delimiter, ',')
+	if err != nil {
+		return 0, 0, err
+	}
+	quote, err := singleRune("file.quote", opts.Quote, '"')
+	if err != nil {
+		return 0, 0, err
+	}
+	if delimiter == quote {
+		return 0, 0, fmt.Errorf("file.delimiter and file.quote must be different")
+	}
+	return delimiter, quote, nil
+}
+
+// csvReader 按分隔符和引号字符解析 CSV，引号内的引号写作两个引号，每行字段数须与首行一致
+type csvReader struct {
+	reader    *bufio.Reader
+	delimiter rune
+	quote     rune
+	line      int
+	fields    int
+}
+
+func (r *csvReader) read() ([]string, error) {
+	for {
+		record, err := r.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			// 空行
+			continue
+		}
+		if r.fields == 0 {
+			r.fields = len(record)
+		} else if len(record) != r.fields {
+			return nil, fmt.Errorf("record on line %d: wrong number of fields", r.line)
+		}
+		return record, nil
+	}
+}
+
+// readRecord 读取一条记录，空行返回 nil
+func (r *csvReader) readRecord() ([]string, error) {
+	r.line++
+	start := r.line
+
+	var record []string
+	var field strings.Builder
+	started, quoted, inQuotes := false, false, false
+	for {
+		c, _, err := r.reader.ReadRune()
+		if err == io.EOF {
+			if inQuotes {
+				return nil, fmt.Errorf("record on line %d: unterminated quoted field", start)
+			}
+			if !started {
+				return nil, io.EOF
+			}
+			return append(record, field.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if inQuotes {
+			if c == r.quote {
+				next, _, err := r.reader.ReadRune()
+				if err == nil && next == r.quote {
+					field.WriteRune(c)
+					continue
+				}
+				if err == nil {
+					r.reader.UnreadRune()
+				}
+				inQuotes = false
+				continue
+			}
+			if c == '\n' {
+				r.line++
+			}
+			field.WriteRune(c)
+			continue
+		}
+
+		switch c {
+		case r.delimiter:
+			record = append(record, field.String())
+			field.Reset()
+			started, quoted = true, false
+		case '\r':
+			if next, _, err := r.reader.ReadRune(); err == nil {
+				r.reader.UnreadRune()
+				if next == '\n' {
+					continue
+				}
+			}
+			field.WriteRune(c)
+			started = true
+		case '\n':
+			if !started {
+				return nil, nil
+			}
+			return append(record, field.String()), nil
+		default:
+			if c == r.quote && field.Len() == 0 && !quoted {
+				inQuotes, quoted = true, true
+			} else {
+				field.WriteRune(c)
+			}
+			started = true
+		}
+	}
+}
+
+// newCSVDecoder 首行为表头，空字段按 NULL 处理，infer 时按样本推断列类型
+func newCSVDecoder(r *bufio.Reader, opts models.FileOptions, infer bool) (recordDecoder, error) {
+	delimiter, quote, err := csvDelimiters(opts)
+	if err != nil {
+		return nil, err
+	}
+	reader := &csvReader{reader: r, delimiter: delimiter, quote: quote}
+
+	header, err := reader.read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("csv file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make([]Column, len(header))
+	for i, name := range header {
+		columns[i] = Column{Name: strings.TrimSpace(name)}
+	}
+
+	next := func() ([]interface{}, error) {
+		record, err := reader.read()
+		if err != nil {
+			return nil, err
+		}
+		row := make([]interface{}, len(record))
+		for i, field := range record {
+			if field != "" {
+				row[i] = field
+			}
+		}
+		return row, nil
+	}
+	return newSampleDecoder(columns, next, infer)
+}
+
+// csvEncoder 写入表头和数据行，二进制列使用 base64 编码
+type csvEncoder struct {
+	writer        *textWriter
+	columns       []Column
+	delimiter     rune
+	quote         rune
+	headerWritten bool
+}
+
+func newCSVEncoder(w io.Writer, columns []Column, opts models.FileOptions, headerWritten bool) (*csvEncoder, error) {
+	delimiter, quote, err := csvDelimiters(opts)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := textEncoding(opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &csvEncoder{
+		writer:        newTextWriter(w, enc),
+		columns:       columns,
+		delimiter:     delimiter,
+		quote:         quote,
+		headerWritten: headerWritten,
+	}, nil
+}
+
+func (e *csvEncoder) contentType() string {
+	return "text/csv"
+}
+
+func (e *csvEncoder) writeHeader() error {
+	header := make([]string, len(e.columns))
+	for i, col := range e.columns {
+		header[i] = col.Name
+	}
+	e.headerWritten = true
+	return e.writeRecord(header)
+}
+
+// writeRecord 包含分隔符、引号、换行或以空白开头的字段加引号
+func (e *csvEncoder) writeRecord(record []string) error {
+	var line strings.Builder
+	quote := string(e.quote)
+	for i, field := range record {
+		if i > 0 {
+			line.WriteRune(e.delimiter)
+		}
+		if field != "" && (strings.ContainsRune(field, e.delimiter) || strings.ContainsAny(field, quote+"\r\n") ||
+			field[0] == ' ' || field[0] == '\t') {
+			line.WriteString(quote)
+			line.WriteString(strings.ReplaceAll(field, quote, quote+quote))
+			line.WriteString(quote)
+		} else {
+			line.WriteString(field)
+		}
+	}
+	line.WriteByte('\n')
+	return e.writer.writeString(line.String())
+}
+
+func (e *csvEncoder) encode(row []interface{}) error {
+	if !e.headerWritten {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(row))
+	for i, v := range row {
+		record[i] = formatValue(v)
+	}
+	return e.writeRecord(record)
+}
+
+func (e *csvEncoder) flush() error {
+	return nil
+}
+
+func (e *csvEncoder) close() error {
+	if !e.headerWritten {
+		// 空结果集也输出表头
+		return e.writeHeader()
+	}
+	return nil
+}
+
+func (e *csvEncoder) resumable() bool {
+	return true
+}
+
+// formatValue 将值格式化为文本
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
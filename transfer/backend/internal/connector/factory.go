@@ -0,0 +1,53 @@
+package connector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	commonModels "github.com/addp/common/models"
+)
+
+// Factory 根据资源信息创建连接器
+type Factory func(resource *commonModels.Resource) (Connector, error)
+
+type registration struct {
+	kind    string
+	factory Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// Register 注册连接器，resourceTypes 不区分大小写
+func Register(kind string, factory Factory, resourceTypes ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, resourceType := range resourceTypes {
+		registry[strings.ToLower(resourceType)] = registration{kind: kind, factory: factory}
+	}
+}
+
+// KindOf 返回资源类型对应的连接器类别
+func KindOf(resourceType string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	reg, ok := registry[strings.ToLower(resourceType)]
+	return reg.kind, ok
+}
+
+// New 创建对应资源类型的连接器
+func New(resource *commonModels.Resource) (Connector, error) {
+	registryMu.RLock()
+	reg, ok := registry[strings.ToLower(resource.ResourceType)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported resource type: %s", resource.ResourceType)
+	}
+	return reg.factory(resource)
+}
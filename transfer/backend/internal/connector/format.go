@@ -0,0 +1,217 @@
+package connector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/addp/transfer/internal/models"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// 对象文件格式
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// recordDecoder 将对象内容解码为记录
+type recordDecoder interface {
+	columns() []Column
+	decode() ([]interface{}, error)
+}
+
+// rowCounter 能从文件元数据获取总行数的解码器（Parquet）
+type rowCounter interface {
+	numRows() int64
+}
+
+// recordEncoder 将记录编码为对象内容
+type recordEncoder interface {
+	contentType() string
+	encode(row []interface{}) error
+	// flush 将已编码的记录写入底层 Writer
+	flush() error
+	// close 写入剩余内容（如空结果集的表头、Parquet 文件尾）
+	close() error
+	// resumable 续传时能否在已上传的分片之后接着写入（Parquet 文件尾记录了全部行组，不支持）
+	resumable() bool
+}
+
+// objectSource 对象内容，文本格式顺序读取，Parquet 需要随机读取
+type objectSource interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// NormalizeFormat 返回规范的文件格式名，空值为 csv
+func NormalizeFormat(format string) string {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case "":
+		return FormatCSV
+	case "ndjson":
+		return FormatJSONL
+	default:
+		return format
+	}
+}
+
+// ValidateFileEndpoint 校验对象存储的文件格式、格式选项和分区配置，分区只用于写入
+func ValidateFileEndpoint(endpoint models.EndpointConfig, write bool) error {
+	format := NormalizeFormat(endpoint.Format)
+	switch format {
+	case FormatCSV, FormatJSONL, FormatParquet:
+	default:
+		return fmt.Errorf("unsupported file format: %s", endpoint.Format)
+	}
+
+	opts := fileOptionsOf(endpoint)
+	if _, _, err := csvDelimiters(opts); err != nil {
+		return err
+	}
+	if _, err := textEncoding(opts.Encoding); err != nil {
+		return err
+	}
+	if _, err := parquetCodec(opts.Compression); err != nil {
+		return err
+	}
+
+	if partition := endpoint.Partition; partition != nil {
+		if !write {
+			return fmt.Errorf("partition is only supported for object storage target")
+		}
+		for _, column := range partition.Columns {
+			if strings.TrimSpace(column) == "" {
+				return fmt.Errorf("partition column name must not be empty")
+			}
+		}
+		if partition.MaxRowsPerFile < 0 {
+			return fmt.Errorf("partition.max_rows_per_file must not be negative")
+		}
+	}
+	return nil
+}
+
+func fileOptionsOf(endpoint models.EndpointConfig) models.FileOptions {
+	if endpoint.File == nil {
+		return models.FileOptions{}
+	}
+	return *endpoint.File
+}
+
+func newDecoder(endpoint models.EndpointConfig, src objectSource, size int64) (recordDecoder, error) {
+	opts := fileOptionsOf(endpoint)
+	infer := opts.InferSchema == nil || *opts.InferSchema
+
+	switch NormalizeFormat(endpoint.Format) {
+	case FormatCSV:
+		r, err := decodeText(src, opts.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		return newCSVDecoder(r, opts, infer)
+	case FormatJSONL:
+		r, err := decodeText(src, opts.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		return newJSONLDecoder(r, infer)
+	case FormatParquet:
+		return newParquetDecoder(src, size)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %s", endpoint.Format)
+	}
+}
+
+// newEncoder 创建编码器，resuming 表示续传（文件头已写入）；fields 为源端字段信息，用于确定 Parquet 列类型
+func newEncoder(endpoint models.EndpointConfig, w io.Writer, columns []Column, fields []models.FieldMeta, resuming bool) (recordEncoder, error) {
+	opts := fileOptionsOf(endpoint)
+
+	switch NormalizeFormat(endpoint.Format) {
+	case FormatCSV:
+		return newCSVEncoder(w, columns, opts, resuming)
+	case FormatJSONL:
+		enc, err := textEncoding(opts.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		return newJSONLEncoder(w, columns, enc), nil
+	case FormatParquet:
+		return newParquetEncoder(w, columns, fields, opts)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %s", endpoint.Format)
+	}
+}
+
+// textEncoding 按名称查找字符编码，UTF-8 返回 nil
+func textEncoding(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported encoding: %s", name)
+	}
+	return enc, nil
+}
+
+// decodeText 将文本内容转换为 UTF-8，并去掉 UTF-8 BOM
+func decodeText(r io.Reader, name string) (*bufio.Reader, error) {
+	enc, err := textEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	if enc != nil {
+		r = enc.NewDecoder().Reader(r)
+	}
+
+	reader := bufio.NewReaderSize(r, 64<<10)
+	if first, _, err := reader.ReadRune(); err == nil && first != '\uFEFF' {
+		reader.UnreadRune()
+	}
+	return reader, nil
+}
+
+// textWriter 按字符编码写入文本行
+type textWriter struct {
+	writer  io.Writer
+	encoder *encoding.Encoder
+}
+
+func newTextWriter(w io.Writer, enc encoding.Encoding) *textWriter {
+	writer := &textWriter{writer: w}
+	if enc != nil {
+		writer.encoder = enc.NewEncoder()
+	}
+	return writer
+}
+
+func (w *textWriter) writeString(s string) error {
+	if w.encoder == nil {
+		_, err := io.WriteString(w.writer, s)
+		return err
+	}
+	b, err := w.encoder.String(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode text: %w", err)
+	}
+	_, err = io.WriteString(w.writer, b)
+	return err
+}
+
+// singleRune 解析单个字符的选项，空值使用默认值
+func singleRune(name, value string, fallback rune) (rune, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	if size != len(value) || r == utf8.RuneError || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("%s must be a single character", name)
+	}
+	return r, nil
+}
@@ -0,0 +1,84 @@
+package connector
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ewkbSRIDFlag EWKB 几何类型中表示包含 SRID 的标志位
+const ewkbSRIDFlag = 0x20000000
+
+var errInvalidGeometry = errors.New("invalid geometry value")
+
+// wkbByteOrder WKB 首字节指定的字节序
+type wkbByteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+func byteOrder(flag byte) (wkbByteOrder, error) {
+	switch flag {
+	case 0:
+		return binary.BigEndian, nil
+	case 1:
+		return binary.LittleEndian, nil
+	default:
+		return nil, errInvalidGeometry
+	}
+}
+
+// parseEWKB 解析 PostGIS 的 EWKB，返回 SRID 和标准 WKB
+func parseEWKB(b []byte) (uint32, []byte, error) {
+	if len(b) < 5 {
+		return 0, nil, errInvalidGeometry
+	}
+	order, err := byteOrder(b[0])
+	if err != nil {
+		return 0, nil, err
+	}
+	geometryType := order.Uint32(b[1:5])
+	if geometryType&ewkbSRIDFlag == 0 {
+		return 0, b, nil
+	}
+	if len(b) < 9 {
+		return 0, nil, errInvalidGeometry
+	}
+
+	srid := order.Uint32(b[5:9])
+	wkb := make([]byte, 0, len(b)-4)
+	wkb = append(wkb, b[0])
+	wkb = order.AppendUint32(wkb, geometryType&^ewkbSRIDFlag)
+	wkb = append(wkb, b[9:]...)
+	return srid, wkb, nil
+}
+
+// buildEWKB 将 SRID 写入 WKB 生成 EWKB，SRID 为 0 时返回原 WKB
+func buildEWKB(srid uint32, wkb []byte) []byte {
+	if srid == 0 || len(wkb) < 5 {
+		return wkb
+	}
+	order, err := byteOrder(wkb[0])
+	if err != nil {
+		return wkb
+	}
+
+	ewkb := make([]byte, 0, len(wkb)+4)
+	ewkb = append(ewkb, wkb[0])
+	ewkb = order.AppendUint32(ewkb, order.Uint32(wkb[1:5])|ewkbSRIDFlag)
+	ewkb = order.AppendUint32(ewkb, srid)
+	return append(ewkb, wkb[5:]...)
+}
+
+// parseMySQLGeometry 解析 MySQL 内部几何格式：小端 4 字节 SRID + WKB
+func parseMySQLGeometry(b []byte) (uint32, []byte, error) {
+	if len(b) < 9 {
+		return 0, nil, errInvalidGeometry
+	}
+	return binary.LittleEndian.Uint32(b[:4]), b[4:], nil
+}
+
+func buildMySQLGeometry(srid uint32, wkb []byte) []byte {
+	b := make([]byte, 0, len(wkb)+4)
+	b = binary.LittleEndian.AppendUint32(b, srid)
+	return append(b, wkb...)
+}
@@ -0,0 +1,263 @@
+package connector
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inferSampleRows 推断列类型时读取的样本行数
+const inferSampleRows = 1000
+
+// 推断出的列类型，写入 Column.DatabaseType，建表时由 parseType 转换为中间类型
+const (
+	inferredBool        = "BOOLEAN"
+	inferredInt         = "BIGINT"
+	inferredFloat       = "DOUBLE"
+	inferredDate        = "DATE"
+	inferredTimestamp   = "TIMESTAMP"
+	inferredTimestampTZ = "TIMESTAMPTZ"
+	inferredJSON        = "JSON"
+	inferredText        = "TEXT"
+)
+
+var (
+	timestampLayouts = []string{
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999",
+		"2006/01/02 15:04:05",
+	}
+	timestampTZLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999-07",
+	}
+)
+
+// temporalRank 日期时间类型合并时取范围更大的类型
+var temporalRank = map[string]int{inferredDate: 1, inferredTimestamp: 2, inferredTimestampTZ: 3}
+
+// sampleDecoder 读取样本行推断列类型，再按推断的类型转换每一行
+// 文本格式的原始值为 string，JSONL 另有 bool、int64、float64 和 json.RawMessage（嵌套对象或数组）
+type sampleDecoder struct {
+	header []Column
+	kinds  []string
+	sample [][]interface{}
+	next   func() ([]interface{}, error)
+}
+
+func newSampleDecoder(columns []Column, next func() ([]interface{}, error), infer bool) (*sampleDecoder, error) {
+	d := &sampleDecoder{header: columns, next: next}
+	if !infer {
+		return d, nil
+	}
+
+	for len(d.sample) < inferSampleRows {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.sample = append(d.sample, row)
+	}
+
+	d.kinds = make([]string, len(columns))
+	for _, row := range d.sample {
+		for i := range d.kinds {
+			if i < len(row) {
+				d.kinds[i] = mergeKind(d.kinds[i], classifyValue(row[i]))
+			}
+		}
+	}
+	for i := range d.kinds {
+		if d.kinds[i] == "" {
+			// 样本中全部为空
+			d.kinds[i] = inferredText
+		}
+		d.header[i].DatabaseType = d.kinds[i]
+	}
+	return d, nil
+}
+
+func (d *sampleDecoder) columns() []Column {
+	return d.header
+}
+
+func (d *sampleDecoder) decode() ([]interface{}, error) {
+	var row []interface{}
+	if len(d.sample) > 0 {
+		row, d.sample = d.sample[0], d.sample[1:]
+	} else {
+		var err error
+		if row, err = d.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, value := range row {
+		kind := ""
+		if i < len(d.kinds) {
+			kind = d.kinds[i]
+		}
+		row[i] = convertInferred(value, kind)
+	}
+	return row, nil
+}
+
+// classifyValue 返回单个值的类型，空值返回空串
+func classifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return inferredBool
+	case int64:
+		return inferredInt
+	case float64:
+		return inferredFloat
+	case json.RawMessage:
+		return inferredJSON
+	case string:
+		return classifyText(v)
+	default:
+		return inferredText
+	}
+}
+
+func classifyText(text string) string {
+	s := strings.TrimSpace(text)
+	switch {
+	case s == "":
+		return inferredText
+	case isInteger(s):
+		return inferredInt
+	case isFloat(s):
+		return inferredFloat
+	case strings.EqualFold(s, "true") || strings.EqualFold(s, "false"):
+		return inferredBool
+	}
+	if _, err := time.Parse(time.DateOnly, s); err == nil {
+		return inferredDate
+	}
+	if _, ok := parseTimestamp(s, timestampLayouts); ok {
+		return inferredTimestamp
+	}
+	if _, ok := parseTimestamp(s, timestampTZLayouts); ok {
+		return inferredTimestampTZ
+	}
+	return inferredText
+}
+
+// isInteger 有前导零的数字（如编号、邮编）按文本处理
+func isInteger(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if len(digits) > 1 && digits[0] == '0' {
+		return false
+	}
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// isFloat 超出 int64 范围的整数不含小数点，按文本处理以免丢失精度
+func isFloat(s string) bool {
+	if !strings.ContainsAny(s, ".eE") {
+		return false
+	}
+	digits := strings.TrimPrefix(s, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func parseTimestamp(s string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// mergeKind 合并两个值的类型：整数与小数合并为小数，日期与时间戳合并为时间戳，其余不一致时为文本
+func mergeKind(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	}
+
+	if (a == inferredInt || a == inferredFloat) && (b == inferredInt || b == inferredFloat) {
+		return inferredFloat
+	}
+	if temporalRank[a] > 0 && temporalRank[b] > 0 {
+		if temporalRank[a] > temporalRank[b] {
+			return a
+		}
+		return b
+	}
+	return inferredText
+}
+
+// convertInferred 按推断的类型转换值，转换失败（样本之后出现的异常值）时保留原文本
+func convertInferred(value interface{}, kind string) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case json.RawMessage:
+		return string(v)
+	case string:
+		s := strings.TrimSpace(v)
+		switch kind {
+		case inferredInt:
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n
+			}
+		case inferredFloat:
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f
+			}
+		case inferredBool:
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		case inferredDate:
+			if t, err := time.Parse(time.DateOnly, s); err == nil {
+				return t
+			}
+		case inferredTimestamp, inferredTimestampTZ:
+			if t, ok := parseTimestamp(s, timestampLayouts); ok {
+				return t
+			}
+			if t, ok := parseTimestamp(s, timestampTZLayouts); ok {
+				return t
+			}
+			if t, err := time.Parse(time.DateOnly, s); err == nil {
+				return t
+			}
+		}
+		return v
+	case int64:
+		switch kind {
+		case inferredFloat:
+			return float64(v)
+		case inferredText:
+			return strconv.FormatInt(v, 10)
+		}
+	case float64:
+		if kind == inferredText {
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case bool:
+		if kind == inferredText {
+			return strconv.FormatBool(v)
+		}
+	}
+	return value
+}
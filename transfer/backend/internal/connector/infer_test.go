@@ -0,0 +1,206 @@
+package connector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// rowSource 按顺序返回给定的行，结束时返回 io.EOF
+func rowSource(rows [][]interface{}) func() ([]interface{}, error) {
+	return func() ([]interface{}, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}
+}
+
+// inferColumn 推断单列样本的类型并返回转换后的值
+func inferColumn(t *testing.T, values ...interface{}) (string, []interface{}) {
+	t.Helper()
+	rows := make([][]interface{}, len(values))
+	for i, value := range values {
+		rows[i] = []interface{}{value}
+	}
+	d, err := newSampleDecoder([]Column{{Name: "c"}}, rowSource(rows), true)
+	if err != nil {
+		t.Fatalf("newSampleDecoder: %v", err)
+	}
+	var got []interface{}
+	for {
+		row, err := d.decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got = append(got, row[0])
+	}
+	return d.columns()[0].DatabaseType, got
+}
+
+func TestSampleDecoderInference(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	wall := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	instant := time.Date(2026, 3, 1, 4, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		values []interface{}
+		kind   string
+		want   []interface{}
+	}{
+		{"integers", []interface{}{"1", "-2", " 3 "}, inferredInt, []interface{}{int64(1), int64(-2), int64(3)}},
+		{"mixed integer and float", []interface{}{"1", "2.5", "-0.5"}, inferredFloat, []interface{}{1.0, 2.5, -0.5}},
+		{"mixed integer and text", []interface{}{"1", "abc"}, inferredText, []interface{}{"1", "abc"}},
+		{"mixed bool and integer", []interface{}{"true", "1"}, inferredText, []interface{}{"true", "1"}},
+		{"leading zeros stay text", []interface{}{"007", "010"}, inferredText, []interface{}{"007", "010"}},
+		{"leading zero float", []interface{}{"0.5", "01.5"}, inferredText, []interface{}{"0.5", "01.5"}},
+		{"integer out of int64 range", []interface{}{"99999999999999999999"}, inferredText, []interface{}{"99999999999999999999"}},
+		{"bools", []interface{}{"true", "FALSE"}, inferredBool, []interface{}{true, false}},
+		{"all null", []interface{}{nil, nil}, inferredText, []interface{}{nil, nil}},
+		{"nulls around integers", []interface{}{nil, "1", nil}, inferredInt, []interface{}{nil, int64(1), nil}},
+		{"empty string is text", []interface{}{"1", ""}, inferredText, []interface{}{"1", ""}},
+		{"dates", []interface{}{"2026-03-01"}, inferredDate, []interface{}{day}},
+		{"timestamps", []interface{}{"2026-03-01 12:30:00", "2026-03-01T12:30:00"}, inferredTimestamp, []interface{}{wall, wall}},
+		{"date widened to timestamp", []interface{}{"2026-03-01", "2026-03-01 12:30:00"}, inferredTimestamp, []interface{}{day, wall}},
+		{"timestamp with zone", []interface{}{"2026-03-01T12:30:00+08:00", "2026-03-01 12:30:00+08"}, inferredTimestampTZ, []interface{}{instant, instant}},
+		{"timestamp widened to timestamptz", []interface{}{"2026-03-01 12:30:00", "2026-03-01T04:30:00Z"}, inferredTimestampTZ, []interface{}{wall, instant}},
+		{"timestamp and text", []interface{}{"2026-03-01 12:30:00", "soon"}, inferredText, []interface{}{"2026-03-01 12:30:00", "soon"}},
+		{"jsonl typed values", []interface{}{int64(1), 2.5}, inferredFloat, []interface{}{1.0, 2.5}},
+		{"jsonl values mixed with text", []interface{}{int64(1), true, "x"}, inferredText, []interface{}{"1", "true", "x"}},
+		{"jsonl nested", []interface{}{json.RawMessage(`{"a":1}`), nil}, inferredJSON, []interface{}{`{"a":1}`, nil}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, got := inferColumn(t, tc.values...)
+			if kind != tc.kind {
+				t.Fatalf("kind = %s, want %s", kind, tc.kind)
+			}
+			for i := range got {
+				if g, ok := got[i].(time.Time); ok {
+					// time.Parse 的时区信息不同，按时刻比较
+					if w, ok := tc.want[i].(time.Time); !ok || !g.Equal(w) {
+						t.Fatalf("row %d = %v, want %v", i, got[i], tc.want[i])
+					}
+					continue
+				}
+				if !reflect.DeepEqual(got[i], tc.want[i]) {
+					t.Fatalf("row %d = %#v, want %#v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSampleDecoderAfterSample(t *testing.T) {
+	// 样本之后出现的无法转换的值保留原文本
+	values := make([]interface{}, 0, inferSampleRows+2)
+	for i := 0; i < inferSampleRows; i++ {
+		values = append(values, "1")
+	}
+	values = append(values, "2", "n/a")
+
+	kind, got := inferColumn(t, values...)
+	if kind != inferredInt {
+		t.Fatalf("kind = %s, want %s", kind, inferredInt)
+	}
+	if len(got) != len(values) {
+		t.Fatalf("decoded %d rows, want %d", len(got), len(values))
+	}
+	if got[inferSampleRows] != int64(2) || got[inferSampleRows+1] != "n/a" {
+		t.Fatalf("rows after sample = %#v", got[inferSampleRows:])
+	}
+}
+
+func TestSampleDecoderWithoutInference(t *testing.T) {
+	d, err := newSampleDecoder([]Column{{Name: "c"}}, rowSource([][]interface{}{{"1"}}), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.columns()[0].DatabaseType; got != "" {
+		t.Fatalf("type = %q, want empty", got)
+	}
+	row, err := d.decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row[0] != "1" {
+		t.Fatalf("value = %#v, want original text", row[0])
+	}
+}
+
+func TestTextFormatRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	columns := []Column{
+		{Name: "id", DatabaseType: "BIGINT"},
+		{Name: "score", DatabaseType: "DOUBLE"},
+		{Name: "code", DatabaseType: "TEXT"},
+		{Name: "created_at", DatabaseType: "TIMESTAMPTZ"},
+		{Name: "note", DatabaseType: "TEXT"},
+	}
+	rows := [][]interface{}{
+		{int64(1), 1.5, "007", at, "a,\"b\"\nc"},
+		{int64(2), 3.0, "010", at, nil},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			endpoint := models.EndpointConfig{Format: format}
+			var buf bytes.Buffer
+			encoder, err := newEncoder(endpoint, &buf, columns, nil, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := encoder.encode(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := encoder.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			decoder, err := newDecoder(endpoint, bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kinds []string
+			for _, col := range decoder.columns() {
+				kinds = append(kinds, col.DatabaseType)
+			}
+			wantKinds := []string{inferredInt, inferredFloat, inferredText, inferredTimestampTZ, inferredText}
+			if !reflect.DeepEqual(kinds, wantKinds) {
+				t.Fatalf("kinds = %v, want %v", kinds, wantKinds)
+			}
+
+			for i := range rows {
+				row, err := decoder.decode()
+				if err != nil {
+					t.Fatalf("row %d: %v", i, err)
+				}
+				if row[0] != rows[i][0] || row[1] != rows[i][1] || row[2] != rows[i][2] {
+					t.Fatalf("row %d = %#v, want %#v", i, row, rows[i])
+				}
+				if ts, ok := row[3].(time.Time); !ok || !ts.Equal(at) {
+					t.Fatalf("row %d created_at = %#v, want %v", i, row[3], at)
+				}
+				if i == 0 && row[4] != rows[0][4] {
+					t.Fatalf("note = %#v, want %#v", row[4], rows[0][4])
+				}
+			}
+			if _, err := decoder.decode(); err != io.EOF {
+				t.Fatalf("expected io.EOF, got %v", err)
+			}
+		})
+	}
+}
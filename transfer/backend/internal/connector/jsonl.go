@@ -0,0 +1,233 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+)
+
+// jsonlObject 保留字段顺序的 JSON 对象
+type jsonlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// jsonlReader 每行一个 JSON 对象，跳过空行
+type jsonlReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (r *jsonlReader) read() (*jsonlObject, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+		r.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		object, parseErr := parseJSONObject(line)
+		if parseErr != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, parseErr)
+		}
+		return object, nil
+	}
+}
+
+// parseJSONObject 解析一行 JSON 对象，数字转换为 int64/float64，嵌套对象和数组保留为 json.RawMessage
+func parseJSONObject(data []byte) (*jsonlObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	object := &jsonlObject{values: make(map[string]interface{})}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		value, err := jsonValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := object.values[key]; !ok {
+			object.keys = append(object.keys, key)
+		}
+		object.values[key] = value
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func jsonValue(raw json.RawMessage) (interface{}, error) {
+	switch raw[0] {
+	case '{', '[':
+		return raw, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if number, ok := value.(json.Number); ok {
+		if n, err := number.Int64(); err == nil {
+			return n, nil
+		}
+		if f, err := number.Float64(); err == nil && strings.ContainsAny(number.String(), ".eE") {
+			return f, nil
+		}
+		// 超出 int64 范围的整数保留文本
+		return number.String(), nil
+	}
+	return value, nil
+}
+
+// newJSONLDecoder 列为样本行中出现过的字段（按首次出现的顺序），样本之后新出现的字段被忽略
+func newJSONLDecoder(r *bufio.Reader, infer bool) (recordDecoder, error) {
+	reader := &jsonlReader{reader: r}
+
+	var sample []*jsonlObject
+	index := make(map[string]int)
+	var columns []Column
+	for len(sample) < inferSampleRows {
+		object, err := reader.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jsonl: %w", err)
+		}
+		sample = append(sample, object)
+		for _, key := range object.keys {
+			if _, ok := index[key]; !ok {
+				index[key] = len(columns)
+				columns = append(columns, Column{Name: key})
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("jsonl file is empty")
+	}
+
+	toRow := func(object *jsonlObject) []interface{} {
+		row := make([]interface{}, len(columns))
+		for key, value := range object.values {
+			if i, ok := index[key]; ok {
+				row[i] = value
+			}
+		}
+		return row
+	}
+	next := func() ([]interface{}, error) {
+		if len(sample) > 0 {
+			object := sample[0]
+			sample = sample[1:]
+			return toRow(object), nil
+		}
+		object, err := reader.read()
+		if err != nil {
+			if err != io.EOF {
+				err = fmt.Errorf("failed to read jsonl: %w", err)
+			}
+			return nil, err
+		}
+		return toRow(object), nil
+	}
+	return newSampleDecoder(columns, next, infer)
+}
+
+// jsonlEncoder 每行输出一个 JSON 对象，字段顺序与列一致；二进制值使用 base64 编码，JSON 列原样嵌入
+type jsonlEncoder struct {
+	writer    *textWriter
+	names     []string
+	jsonTypes []bool
+	buffer    bytes.Buffer
+}
+
+func newJSONLEncoder(w io.Writer, columns []Column, enc encoding.Encoding) *jsonlEncoder {
+	e := &jsonlEncoder{
+		writer:    newTextWriter(w, enc),
+		names:     make([]string, len(columns)),
+		jsonTypes: make([]bool, len(columns)),
+	}
+	for i, col := range columns {
+		name, _ := json.Marshal(col.Name)
+		e.names[i] = string(name)
+		e.jsonTypes[i] = specFromColumn(col).kind == typeJSON
+	}
+	return e
+}
+
+func (e *jsonlEncoder) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (e *jsonlEncoder) encode(row []interface{}) error {
+	e.buffer.Reset()
+	e.buffer.WriteByte('{')
+	for i, value := range row {
+		if i >= len(e.names) {
+			break
+		}
+		if i > 0 {
+			e.buffer.WriteByte(',')
+		}
+		e.buffer.WriteString(e.names[i])
+		e.buffer.WriteByte(':')
+		data, err := e.marshal(value, e.jsonTypes[i])
+		if err != nil {
+			return err
+		}
+		e.buffer.Write(data)
+	}
+	e.buffer.WriteString("}\n")
+	return e.writer.writeString(e.buffer.String())
+}
+
+func (e *jsonlEncoder) marshal(value interface{}, jsonType bool) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		if jsonType && json.Valid([]byte(v)) {
+			return []byte(v), nil
+		}
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// NaN、Inf 无法用 JSON 数字表示
+			return []byte(strconv.Quote(strconv.FormatFloat(v, 'g', -1, 64))), nil
+		}
+	}
+	return json.Marshal(value)
+}
+
+func (e *jsonlEncoder) flush() error {
+	return nil
+}
+
+func (e *jsonlEncoder) close() error {
+	return nil
+}
+
+func (e *jsonlEncoder) resumable() bool {
+	return true
+}
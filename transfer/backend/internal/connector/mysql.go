@@ -0,0 +1,188 @@
+package connector
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	commonModels "github.com/addp/common/models"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	Register(KindDatabase, NewMySQLConnector, "mysql")
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) driverName() string {
+	return "mysql"
+}
+
+func (mysqlDialect) quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (mysqlDialect) placeholder(index int) string {
+	return "?"
+}
+
+func (mysqlDialect) primaryKeyQuery(schema, table, quotedTable string) (string, []interface{}) {
+	return `SELECT COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION`, []interface{}{schema, table}
+}
+
+// upsertClause MySQL 按表上的主键/唯一索引判断冲突，keys 仅用于排除不需要更新的列
+func (d mysqlDialect) upsertClause(keys, columns []string) string {
+	isKey := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		isKey[key] = true
+	}
+
+	var updates []string
+	for _, col := range columns {
+		if !isKey[col] {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", d.quote(col), d.quote(col)))
+		}
+	}
+	if len(updates) == 0 {
+		// 所有列都是主键时用无副作用的赋值忽略冲突
+		updates = append(updates, fmt.Sprintf("%s = %s", d.quote(keys[0]), d.quote(keys[0])))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+}
+
+func (mysqlDialect) tableExistsQuery(schema, table, quotedTable string) (string, []interface{}) {
+	return `SELECT COUNT(*) > 0
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?`, []interface{}{schema, table}
+}
+
+// timeLiteral MySQL 8.0.19 之前不接受带偏移的时间文本；驱动按 UTC 解析 DATETIME/TIMESTAMP（loc 默认 UTC），
+// 统一转换为 UTC 后不带偏移输出，与读出的值一致
+func (mysqlDialect) timeLiteral(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.999999")
+}
+
+// columnType 长度未知的字符串在主键/唯一列上使用 VARCHAR(255)，其余使用 LONGTEXT
+func (mysqlDialect) columnType(spec columnSpec, key bool) string {
+	unsigned := ""
+	if spec.unsigned {
+		unsigned = " UNSIGNED"
+	}
+
+	switch spec.kind {
+	case typeBool:
+		return "TINYINT(1)"
+	case typeInt16:
+		return "SMALLINT" + unsigned
+	case typeInt32:
+		return "INT" + unsigned
+	case typeInt64:
+		return "BIGINT" + unsigned
+	case typeDecimal:
+		// 未指定精度的 NUMERIC 使用 MySQL 支持的最大精度
+		precision, scale := spec.precision, spec.scale
+		if precision <= 0 || precision > 65 {
+			precision, scale = 65, 30
+		}
+		if scale > 30 {
+			scale = 30
+		}
+		return fmt.Sprintf("DECIMAL(%d,%d)", precision, scale)
+	case typeFloat32:
+		return "FLOAT"
+	case typeFloat64:
+		return "DOUBLE"
+	case typeChar:
+		if spec.length > 0 && spec.length <= 255 {
+			return fmt.Sprintf("CHAR(%d)", spec.length)
+		}
+		return mysqlStringType(spec.length, key)
+	case typeString:
+		return mysqlStringType(spec.length, key)
+	case typeText:
+		switch spec.subtype {
+		case "tinytext", "text", "mediumtext", "longtext":
+			return strings.ToUpper(spec.subtype)
+		}
+		return mysqlStringType(0, key)
+	case typeBinary:
+		switch spec.subtype {
+		case "tinyblob", "blob", "mediumblob", "longblob":
+			return strings.ToUpper(spec.subtype)
+		case "binary", "varbinary":
+			if spec.length > 0 {
+				return fmt.Sprintf("%s(%d)", strings.ToUpper(spec.subtype), spec.length)
+			}
+		}
+		if key {
+			return "VARBINARY(255)"
+		}
+		return "LONGBLOB"
+	case typeDate:
+		return "DATE"
+	case typeTime:
+		return "TIME(6)"
+	case typeTimestamp, typeTimestampTZ:
+		// TIMESTAMP 只能表示到 2038 年，统一使用 DATETIME
+		return "DATETIME(6)"
+	case typeJSON:
+		return "JSON"
+	case typeUUID:
+		return "CHAR(36)"
+	case typeGeometry:
+		if spec.subtype != "" {
+			return strings.ToUpper(spec.subtype)
+		}
+		return "GEOMETRY"
+	case typeEnum:
+		if spec.subtype != "" {
+			return spec.subtype
+		}
+		return mysqlStringType(0, key)
+	default:
+		return mysqlStringType(0, key)
+	}
+}
+
+func mysqlStringType(length int, key bool) string {
+	switch {
+	case length > 0 && length <= 16383:
+		return fmt.Sprintf("VARCHAR(%d)", length)
+	case key:
+		// 索引列不能是 TEXT
+		return "VARCHAR(255)"
+	default:
+		return "LONGTEXT"
+	}
+}
+
+// geometryValue 转换为 MySQL 的内部几何格式（4 字节 SRID + WKB）
+func (mysqlDialect) geometryValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		// PostgreSQL 源端读出的十六进制 EWKB
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported geometry value %.32q", v)
+		}
+		srid, wkb, err := parseEWKB(b)
+		if err != nil {
+			return nil, err
+		}
+		return buildMySQLGeometry(srid, wkb), nil
+	default:
+		return nil, fmt.Errorf("unsupported geometry value type %T", value)
+	}
+}
+
+// NewMySQLConnector 创建 MySQL 连接器
+func NewMySQLConnector(resource *commonModels.Resource) (Connector, error) {
+	return openSQL(resource, mysqlDialect{})
+}
@@ -0,0 +1,657 @@
+package connector
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// parquetRowGroupRows 写入时每个行组的行数，行组在内存中缓冲，写满后编码输出
+const parquetRowGroupRows = 100000
+
+// parquetReadRows 读取时每次从行组解码的行数
+const parquetReadRows = 256
+
+// parquetCodec 按名称返回压缩算法
+func parquetCodec(name string) (compress.Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "snappy":
+		return &parquet.Snappy, nil
+	case "gzip":
+		return &parquet.Gzip, nil
+	case "zstd":
+		return &parquet.Zstd, nil
+	case "none", "uncompressed":
+		return &parquet.Uncompressed, nil
+	default:
+		return nil, fmt.Errorf("unsupported parquet compression: %s", name)
+	}
+}
+
+// parquetField 文件的顶层字段，嵌套字段（结构体、列表、Map）按 JSON 文本输出
+type parquetField struct {
+	name   string
+	typ    parquet.Type
+	column int // 顶层基本类型字段对应的叶子列
+	nested bool
+}
+
+// parquetDecoder 按行组顺序读取 Parquet 文件
+type parquetDecoder struct {
+	reader  *parquet.Reader
+	schema  *parquet.Schema
+	header  []Column
+	fields  []parquetField
+	nested  bool
+	leaves  int // 叶子列总数
+	rows    []parquet.Row
+	pending []parquet.Row
+	total   int64
+}
+
+func newParquetDecoder(src io.ReaderAt, size int64) (*parquetDecoder, error) {
+	file, err := parquet.OpenFile(src, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+
+	schema := file.Schema()
+	d := &parquetDecoder{
+		reader: parquet.NewReader(file),
+		schema: schema,
+		rows:   make([]parquet.Row, parquetReadRows),
+		total:  file.NumRows(),
+	}
+	column := 0
+	for _, node := range schema.Fields() {
+		field := parquetField{name: node.Name(), typ: node.Type(), column: column, nested: !node.Leaf() || node.Repeated()}
+		d.fields = append(d.fields, field)
+		d.header = append(d.header, parquetColumn(field))
+		d.nested = d.nested || field.nested
+		column += leafCount(node)
+	}
+	d.leaves = column
+	return d, nil
+}
+
+func leafCount(node parquet.Node) int {
+	if node.Leaf() {
+		return 1
+	}
+	count := 0
+	for _, field := range node.Fields() {
+		count += leafCount(field)
+	}
+	return count
+}
+
+// parquetColumn 由字段的逻辑类型推断列类型
+func parquetColumn(field parquetField) Column {
+	col := Column{Name: field.name}
+	if field.nested {
+		col.DatabaseType = inferredJSON
+		return col
+	}
+
+	if lt := field.typ.LogicalType(); lt != nil {
+		switch {
+		case lt.UTF8 != nil, lt.Enum != nil:
+			col.DatabaseType = "TEXT"
+		case lt.Json != nil:
+			col.DatabaseType = "JSON"
+		case lt.UUID != nil:
+			col.DatabaseType = "UUID"
+		case lt.Decimal != nil:
+			col.DatabaseType = fmt.Sprintf("DECIMAL(%d,%d)", lt.Decimal.Precision, lt.Decimal.Scale)
+		case lt.Date != nil:
+			col.DatabaseType = "DATE"
+		case lt.Time != nil:
+			col.DatabaseType = "TIME"
+		case lt.Timestamp != nil:
+			col.DatabaseType = "TIMESTAMP"
+			if lt.Timestamp.IsAdjustedToUTC {
+				col.DatabaseType = "TIMESTAMPTZ"
+			}
+		case lt.Integer != nil:
+			switch {
+			case lt.Integer.BitWidth == 64 && !lt.Integer.IsSigned:
+				col.DatabaseType = "UNSIGNED BIGINT"
+			case lt.Integer.BitWidth <= 16:
+				col.DatabaseType = "SMALLINT"
+			case lt.Integer.BitWidth == 32 && lt.Integer.IsSigned:
+				col.DatabaseType = "INT"
+			default:
+				col.DatabaseType = "BIGINT"
+			}
+		}
+		if col.DatabaseType != "" {
+			return col
+		}
+	}
+
+	switch field.typ.Kind() {
+	case parquet.Boolean:
+		col.DatabaseType = "BOOLEAN"
+	case parquet.Int32:
+		col.DatabaseType = "INT"
+	case parquet.Int64:
+		col.DatabaseType = "BIGINT"
+	case parquet.Int96:
+		col.DatabaseType = "TIMESTAMP"
+	case parquet.Float:
+		col.DatabaseType = "REAL"
+	case parquet.Double:
+		col.DatabaseType = "DOUBLE"
+	default:
+		col.DatabaseType, col.Binary = "BYTEA", true
+	}
+	return col
+}
+
+func (d *parquetDecoder) columns() []Column {
+	return d.header
+}
+
+func (d *parquetDecoder) numRows() int64 {
+	return d.total
+}
+
+func (d *parquetDecoder) decode() ([]interface{}, error) {
+	if len(d.pending) == 0 {
+		n, err := d.reader.ReadRows(d.rows)
+		if n == 0 {
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		d.pending = d.rows[:n]
+	}
+	row := d.pending[0]
+	d.pending = d.pending[1:]
+
+	// 顶层基本类型字段不重复，每个叶子列只有一个值
+	leaves := make([]parquet.Value, d.leaves)
+	for _, value := range row {
+		leaves[value.Column()] = value
+	}
+
+	var object map[string]interface{}
+	if d.nested {
+		object = make(map[string]interface{}, len(d.fields))
+		if err := d.schema.Reconstruct(&object, row); err != nil {
+			return nil, fmt.Errorf("failed to decode parquet row: %w", err)
+		}
+	}
+
+	result := make([]interface{}, len(d.fields))
+	for i, field := range d.fields {
+		if field.nested {
+			if value := object[field.name]; value != nil {
+				data, err := json.Marshal(textBytes(value))
+				if err != nil {
+					return nil, err
+				}
+				result[i] = string(data)
+			}
+			continue
+		}
+		result[i] = parquetLeafValue(leaves[field.column], field.typ)
+	}
+	return result, nil
+}
+
+// textBytes 将嵌套值中的字节数组转换为文本，避免 JSON 序列化为 base64
+func textBytes(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = textBytes(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = textBytes(item)
+		}
+	}
+	return value
+}
+
+// parquetLeafValue 将叶子列的值转换为 Go 值，时间类型统一为 UTC
+func parquetLeafValue(value parquet.Value, typ parquet.Type) interface{} {
+	if value.IsNull() {
+		return nil
+	}
+
+	if lt := typ.LogicalType(); lt != nil {
+		switch {
+		case lt.UTF8 != nil, lt.Enum != nil, lt.Json != nil:
+			return string(value.ByteArray())
+		case lt.UUID != nil:
+			if b := value.ByteArray(); len(b) == 16 {
+				return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+			}
+		case lt.Decimal != nil:
+			return formatDecimal(parquetUnscaled(value), int(lt.Decimal.Scale))
+		case lt.Date != nil:
+			return time.Unix(int64(value.Int32())*86400, 0).UTC()
+		case lt.Time != nil:
+			nanos := value.Int64()
+			switch {
+			case lt.Time.Unit.Millis != nil:
+				nanos = int64(value.Int32()) * int64(time.Millisecond)
+			case lt.Time.Unit.Micros != nil:
+				nanos *= int64(time.Microsecond)
+			}
+			return time.Unix(0, nanos).UTC().Format("15:04:05.999999999")
+		case lt.Timestamp != nil:
+			unit := time.Nanosecond
+			switch {
+			case lt.Timestamp.Unit.Millis != nil:
+				unit = time.Millisecond
+			case lt.Timestamp.Unit.Micros != nil:
+				unit = time.Microsecond
+			}
+			return time.Unix(0, value.Int64()*int64(unit)).UTC()
+		case lt.Integer != nil:
+			if lt.Integer.BitWidth == 64 && !lt.Integer.IsSigned {
+				return uint64(value.Int64())
+			}
+		}
+	}
+
+	switch value.Kind() {
+	case parquet.Boolean:
+		return value.Boolean()
+	case parquet.Int32:
+		return int64(value.Int32())
+	case parquet.Int64:
+		return value.Int64()
+	case parquet.Int96:
+		return int96Time(value.Int96())
+	case parquet.Float:
+		return float64(value.Float())
+	case parquet.Double:
+		return value.Double()
+	default:
+		// 非空的空字节数组不能返回 nil，否则写入数据库时成为 NULL
+		return append([]byte{}, value.ByteArray()...)
+	}
+}
+
+// int96Time 旧版 Impala/Spark 的 INT96 时间戳：前 8 字节为当天纳秒数，后 4 字节为儒略日
+func int96Time(v deprecated.Int96) time.Time {
+	nanos := int64(v[1])<<32 | int64(v[0])
+	days := int64(v[2]) - 2440588
+	return time.Unix(days*86400, nanos).UTC()
+}
+
+// parquetUnscaled 返回 decimal 的非标度值，定长字节数组为大端补码
+func parquetUnscaled(value parquet.Value) *big.Int {
+	switch value.Kind() {
+	case parquet.Int32:
+		return big.NewInt(int64(value.Int32()))
+	case parquet.Int64:
+		return big.NewInt(value.Int64())
+	}
+	b := value.ByteArray()
+	n := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return n
+}
+
+// formatDecimal 按小数位格式化非标度值
+func formatDecimal(unscaled *big.Int, scale int) string {
+	text := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(text) <= scale {
+			text = strings.Repeat("0", scale-len(text)+1) + text
+		}
+		text = text[:len(text)-scale] + "." + text[len(text)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		text = "-" + text
+	}
+	return text
+}
+
+// parquetEncoder 列均为可空，类型由源端字段信息或驱动类型确定：
+// 精度不超过 18 的 decimal 写为 DECIMAL(INT64)，时间戳为微秒精度，其余无法精确表示的类型写为字符串
+type parquetEncoder struct {
+	writer  *parquet.Writer
+	specs   []columnSpec // 各列实际写入的类型
+	pending []parquet.Row
+}
+
+func newParquetEncoder(w io.Writer, columns []Column, fields []models.FieldMeta, opts models.FileOptions) (*parquetEncoder, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("parquet file requires at least one column")
+	}
+	codec, err := parquetCodec(opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := fieldsByName(fields)
+	group := make(parquet.Group, len(columns))
+	specs := make([]columnSpec, len(columns))
+	for i, col := range columns {
+		if _, ok := group[col.Name]; ok {
+			return nil, fmt.Errorf("duplicate column %s", col.Name)
+		}
+		specs[i] = columnSpecOf(col, byName)
+		group[col.Name] = parquet.Optional(parquetNode(&specs[i]))
+	}
+
+	schema := parquet.NewSchema("schema", newOrderedGroup(group, columns))
+	return &parquetEncoder{
+		writer: parquet.NewWriter(w, schema, parquet.Compression(codec), parquet.MaxRowsPerRowGroup(parquetRowGroupRows)),
+		specs:  specs,
+	}, nil
+}
+
+// orderedGroup 按源端列顺序排列字段的分组（parquet.Group 按字段名排序）
+type orderedGroup struct {
+	parquet.Group
+	fields []parquet.Field
+}
+
+func newOrderedGroup(group parquet.Group, columns []Column) orderedGroup {
+	byName := make(map[string]parquet.Field, len(group))
+	for _, field := range group.Fields() {
+		byName[field.Name()] = field
+	}
+	fields := make([]parquet.Field, len(columns))
+	for i, col := range columns {
+		fields[i] = byName[col.Name]
+	}
+	return orderedGroup{Group: group, fields: fields}
+}
+
+func (g orderedGroup) Fields() []parquet.Field {
+	return g.fields
+}
+
+// parquetNode 中间类型对应的 Parquet 类型，spec.kind 调整为实际写入的类型
+func parquetNode(spec *columnSpec) parquet.Node {
+	switch spec.kind {
+	case typeBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case typeInt16, typeInt32:
+		if spec.unsigned && spec.kind == typeInt32 {
+			spec.kind = typeInt64
+			return parquet.Int(64)
+		}
+		spec.kind = typeInt32
+		return parquet.Int(32)
+	case typeInt64:
+		if spec.unsigned {
+			return parquet.Uint(64)
+		}
+		return parquet.Int(64)
+	case typeDecimal:
+		if spec.precision > 0 && spec.precision <= 18 {
+			return parquet.Decimal(spec.scale, spec.precision, parquet.Int64Type)
+		}
+	case typeFloat32:
+		return parquet.Leaf(parquet.FloatType)
+	case typeFloat64:
+		return parquet.Leaf(parquet.DoubleType)
+	case typeBinary:
+		return parquet.Leaf(parquet.ByteArrayType)
+	case typeDate:
+		return parquet.Date()
+	case typeTimestamp:
+		return parquet.TimestampAdjusted(parquet.Microsecond, false)
+	case typeTimestampTZ:
+		return parquet.Timestamp(parquet.Microsecond)
+	case typeJSON:
+		return parquet.JSON()
+	}
+	spec.kind = typeString
+	return parquet.String()
+}
+
+func (e *parquetEncoder) contentType() string {
+	return "application/vnd.apache.parquet"
+}
+
+func (e *parquetEncoder) encode(row []interface{}) error {
+	values := make(parquet.Row, len(e.specs))
+	for i, spec := range e.specs {
+		var value interface{}
+		if i < len(row) {
+			value = row[i]
+		}
+		v, err := parquetWriteValue(value, spec)
+		if err != nil {
+			return err
+		}
+		definition := 1
+		if v.IsNull() {
+			definition = 0
+		}
+		values[i] = v.Level(0, definition, i)
+	}
+	e.pending = append(e.pending, values)
+	return nil
+}
+
+// flush 将编码的行交给 Parquet Writer，行组写满后才输出到底层 Writer
+func (e *parquetEncoder) flush() error {
+	if len(e.pending) == 0 {
+		return nil
+	}
+	_, err := e.writer.WriteRows(e.pending)
+	e.pending = e.pending[:0]
+	return err
+}
+
+func (e *parquetEncoder) close() error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+	return e.writer.Close()
+}
+
+func (e *parquetEncoder) resumable() bool {
+	return false
+}
+
+// parquetWriteValue 按列类型转换值
+func parquetWriteValue(value interface{}, spec columnSpec) (parquet.Value, error) {
+	if value == nil {
+		return parquet.NullValue(), nil
+	}
+
+	switch spec.kind {
+	case typeBool:
+		b, err := toBool(value)
+		return parquet.BooleanValue(b), err
+	case typeInt32:
+		n, err := toInt64(value)
+		return parquet.Int32Value(int32(n)), err
+	case typeInt64:
+		n, err := toInt64(value)
+		return parquet.Int64Value(n), err
+	case typeDecimal:
+		n, err := toUnscaled(value, spec.scale)
+		return parquet.Int64Value(n), err
+	case typeFloat32:
+		f, err := toFloat64(value)
+		return parquet.FloatValue(float32(f)), err
+	case typeFloat64:
+		f, err := toFloat64(value)
+		return parquet.DoubleValue(f), err
+	case typeDate:
+		t, err := toTime(value)
+		days := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+		return parquet.Int32Value(int32(days)), err
+	case typeTimestamp:
+		t, err := toTime(value)
+		// 不带时区的时间戳按本地时间的字面值写入
+		local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+		return parquet.Int64Value(local.UnixMicro()), err
+	case typeTimestampTZ:
+		t, err := toTime(value)
+		return parquet.Int64Value(t.UnixMicro()), err
+	case typeBinary:
+		if b, ok := value.([]byte); ok {
+			return parquet.ByteArrayValue(b), nil
+		}
+		return parquet.ByteArrayValue([]byte(formatValue(value))), nil
+	case typeJSON:
+		if s, ok := value.(string); ok {
+			return parquet.ByteArrayValue([]byte(s)), nil
+		}
+		data, err := json.Marshal(value)
+		return parquet.ByteArrayValue(data), err
+	default:
+		if b, ok := value.([]byte); ok {
+			// 几何等二进制值按十六进制文本写入
+			return parquet.ByteArrayValue([]byte(hex.EncodeToString(b))), nil
+		}
+		return parquet.ByteArrayValue([]byte(formatValue(value))), nil
+	}
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(v))
+	default:
+		n, err := toInt64(value)
+		return n != 0, err
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint8:
+		return int64(v), nil
+	case uint:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	case float32:
+		if v == float32(int64(v)) {
+			return int64(v), nil
+		}
+	case string:
+		s := strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return int64(n), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot convert %v to integer", value)
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		n, err := toInt64(value)
+		return float64(n), err
+	}
+}
+
+// toUnscaled 将 decimal 值转换为按 scale 放大后的整数，多余的小数位四舍五入
+func toUnscaled(value interface{}, scale int) (int64, error) {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = strings.TrimSpace(v)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		text = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		n, err := toInt64(value)
+		if err != nil {
+			return 0, err
+		}
+		text = strconv.FormatInt(n, 10)
+	}
+
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return 0, fmt.Errorf("cannot convert %q to decimal", text)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+
+	// 四舍五入（远离零）
+	num, den := r.Num(), r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("decimal %s out of range", text)
+	}
+	return quo.Int64(), nil
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if t, ok := parseTimestamp(s, timestampTZLayouts); ok {
+			return t, nil
+		}
+		if t, ok := parseTimestamp(s, timestampLayouts); ok {
+			return t, nil
+		}
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %v to time", value)
+}
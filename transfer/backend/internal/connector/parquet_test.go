@@ -0,0 +1,146 @@
+package connector
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// roundTripParquet 编码后从同一缓冲区读回全部行
+func roundTripParquet(t *testing.T, columns []Column, rows [][]interface{}, opts models.FileOptions) ([]Column, [][]interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder, err := newParquetEncoder(&buf, columns, nil, opts)
+	if err != nil {
+		t.Fatalf("newParquetEncoder: %v", err)
+	}
+	for _, row := range rows {
+		if err := encoder.encode(row); err != nil {
+			t.Fatalf("encode %v: %v", row, err)
+		}
+	}
+	if err := encoder.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	decoder, err := newParquetDecoder(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("newParquetDecoder: %v", err)
+	}
+	if decoder.numRows() != int64(len(rows)) {
+		t.Fatalf("numRows = %d, want %d", decoder.numRows(), len(rows))
+	}
+	var got [][]interface{}
+	for {
+		row, err := decoder.decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got = append(got, row)
+	}
+	return decoder.columns(), got
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	at := time.Date(2026, 3, 1, 12, 30, 45, 123456000, shanghai)
+
+	columns := []Column{
+		{Name: "id", DatabaseType: "BIGINT"},
+		{Name: "qty", DatabaseType: "INT"},
+		{Name: "score", DatabaseType: "DOUBLE"},
+		{Name: "active", DatabaseType: "BOOLEAN"},
+		{Name: "name", DatabaseType: "TEXT"},
+		{Name: "amount", DatabaseType: "DECIMAL(10,2)"},
+		{Name: "day", DatabaseType: "DATE"},
+		{Name: "local_at", DatabaseType: "TIMESTAMP"},
+		{Name: "created_at", DatabaseType: "TIMESTAMPTZ"},
+		{Name: "attrs", DatabaseType: "JSON"},
+		{Name: "payload", DatabaseType: "BYTEA", Binary: true},
+	}
+	rows := [][]interface{}{
+		{int64(1), int64(7), 1.5, true, "北京", "123.45", at, at, at, `{"a":1}`, []byte{0x00, 0xff}},
+		{int64(2), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil},
+		{int64(-3), int64(-7), -0.25, false, "", "-0.05", at, at, at, `[]`, []byte{}},
+	}
+
+	header, got := roundTripParquet(t, columns, rows, models.FileOptions{})
+
+	wantTypes := []string{"BIGINT", "INT", "DOUBLE", "BOOLEAN", "TEXT", "DECIMAL(10,2)", "DATE", "TIMESTAMP", "TIMESTAMPTZ", "JSON", "BYTEA"}
+	for i, col := range header {
+		if col.Name != columns[i].Name || col.DatabaseType != wantTypes[i] {
+			t.Errorf("column %d = %s %s, want %s %s", i, col.Name, col.DatabaseType, columns[i].Name, wantTypes[i])
+		}
+	}
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	// 不带时区的时间戳保留本地时间的字面值，带时区的保留时刻
+	wall := time.Date(2026, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	instant := at.UTC()
+	want := [][]interface{}{
+		{int64(1), int64(7), 1.5, true, "北京", "123.45", day, wall, instant, `{"a":1}`, []byte{0x00, 0xff}},
+		{int64(2), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil},
+		{int64(-3), int64(-7), -0.25, false, "", "-0.05", day, wall, instant, `[]`, []byte{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParquetRoundTripCompression(t *testing.T) {
+	columns := []Column{{Name: "id", DatabaseType: "BIGINT"}, {Name: "name", DatabaseType: "TEXT"}}
+	rows := [][]interface{}{{int64(1), "a"}, {int64(2), nil}}
+
+	for _, codec := range []string{"", "snappy", "gzip", "zstd", "none"} {
+		t.Run(codec, func(t *testing.T) {
+			_, got := roundTripParquet(t, columns, rows, models.FileOptions{Compression: codec})
+			if !reflect.DeepEqual(got, rows) {
+				t.Fatalf("rows = %#v, want %#v", got, rows)
+			}
+		})
+	}
+
+	if _, err := newParquetEncoder(io.Discard, columns, nil, models.FileOptions{Compression: "lz4"}); err == nil {
+		t.Fatal("expected unsupported compression to fail")
+	}
+}
+
+func TestParquetEncoderUsesFieldMeta(t *testing.T) {
+	// 源端字段信息优先于驱动类型：numeric(6,3) 写为 DECIMAL 而非文本
+	columns := []Column{{Name: "price", DatabaseType: "TEXT"}}
+	fields := []models.FieldMeta{{Name: "price", DataType: "numeric", ColumnType: "numeric", NumericPrecision: 6, NumericScale: 3}}
+
+	var buf bytes.Buffer
+	encoder, err := newParquetEncoder(&buf, columns, fields, models.FileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.encode([]interface{}{"1.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := newParquetDecoder(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoder.columns()[0].DatabaseType; got != "DECIMAL(6,3)" {
+		t.Fatalf("type = %s, want DECIMAL(6,3)", got)
+	}
+	row, err := decoder.decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row[0] != "1.500" {
+		t.Fatalf("value = %#v, want 1.500", row[0])
+	}
+}
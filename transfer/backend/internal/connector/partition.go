@@ -0,0 +1,307 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"github.com/minio/minio-go/v7"
+)
+
+// maxOpenPartitions 同时写入的分区文件上限，超过时先结束已打开的文件，之后的数据写入新的 part 文件
+const maxOpenPartitions = 32
+
+// hiveDefaultPartition 分区值为 NULL 时的目录名（与 Hive 一致）
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// partitionWriter 按分区列把记录写入 <path>/<列>=<值>/part-NNNN.<格式>，所有文件在 Commit 时才完成上传；
+// 各文件的分片上传状态无法保存到断点，失败后从头重新执行
+type partitionWriter struct {
+	core      *minio.Core
+	bucket    string
+	prefix    string
+	extension string
+	endpoint  models.EndpointConfig
+	columns   []Column
+	fields    []models.FieldMeta
+	overwrite bool
+
+	partitionNames   []string
+	partitionIndexes []int
+	dataIndexes      []int
+	timeFormat       string
+	maxRows          int64
+
+	open      map[string]*objectFile
+	sequences map[string]int // 各分区目录已使用的最大 part 序号
+	finished  []*objectFile
+	committed bool
+}
+
+func (c *s3Connector) openPartitionWriter(ctx context.Context, endpoint models.EndpointConfig, columns []Column, opts WriteOptions) (Writer, error) {
+	bucket, prefix, err := objectLocation(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	partition := endpoint.Partition
+
+	w := &partitionWriter{
+		core:       c.core,
+		bucket:     bucket,
+		prefix:     strings.TrimRight(prefix, "/"),
+		extension:  NormalizeFormat(endpoint.Format),
+		endpoint:   endpoint,
+		fields:     opts.Fields,
+		overwrite:  opts.WriteMode == models.WriteModeOverwrite,
+		timeFormat: partition.TimeFormat,
+		maxRows:    partition.MaxRowsPerFile,
+		open:       make(map[string]*objectFile),
+		sequences:  make(map[string]int),
+	}
+	if w.timeFormat == "" {
+		w.timeFormat = time.DateOnly
+	}
+
+	isPartition := make(map[string]bool, len(partition.Columns))
+	for _, name := range partition.Columns {
+		index := -1
+		for i, col := range columns {
+			if col.Name == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("partition column %s not found", name)
+		}
+		isPartition[name] = true
+		w.partitionNames = append(w.partitionNames, name)
+		w.partitionIndexes = append(w.partitionIndexes, index)
+	}
+	for i, col := range columns {
+		if !isPartition[col.Name] {
+			w.columns = append(w.columns, col)
+			w.dataIndexes = append(w.dataIndexes, i)
+		}
+	}
+	if len(w.columns) == 0 {
+		return nil, fmt.Errorf("no columns left to write after removing partition columns")
+	}
+	return w, nil
+}
+
+func (w *partitionWriter) Write(ctx context.Context, batch *Batch) error {
+	for _, row := range batch.Rows {
+		dir := w.partitionDir(row)
+		file, err := w.fileFor(ctx, dir)
+		if err != nil {
+			return err
+		}
+
+		data := make([]interface{}, len(w.dataIndexes))
+		for i, index := range w.dataIndexes {
+			data[i] = row[index]
+		}
+		if err := file.encoder.encode(data); err != nil {
+			return fmt.Errorf("failed to encode object %s/%s: %w", w.bucket, file.key, err)
+		}
+		file.rows++
+		if w.maxRows > 0 && file.rows >= w.maxRows {
+			if err := w.roll(ctx, dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, file := range w.open {
+		if err := file.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partitionDir 返回行所属的分区目录，如 dt=2024-01-01/region=cn
+func (w *partitionWriter) partitionDir(row []interface{}) string {
+	if len(w.partitionIndexes) == 0 {
+		return ""
+	}
+	parts := make([]string, len(w.partitionIndexes))
+	for i, index := range w.partitionIndexes {
+		var value string
+		switch v := row[index].(type) {
+		case nil:
+			value = hiveDefaultPartition
+		case time.Time:
+			value = escapePartitionValue(v.Format(w.timeFormat))
+		default:
+			value = escapePartitionValue(formatValue(v))
+		}
+		parts[i] = w.partitionNames[i] + "=" + value
+	}
+	return strings.Join(parts, "/")
+}
+
+// escapePartitionValue 转义目录名中的特殊字符（与 Hive 的分区路径转义一致）
+func escapePartitionValue(value string) string {
+	if value == "" {
+		return hiveDefaultPartition
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// fileFor 返回分区当前写入的文件，没有时创建下一个 part 文件
+func (w *partitionWriter) fileFor(ctx context.Context, dir string) (*objectFile, error) {
+	if file, ok := w.open[dir]; ok {
+		return file, nil
+	}
+	if len(w.open) >= maxOpenPartitions {
+		for open := range w.open {
+			if err := w.roll(ctx, open); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sequence, ok := w.sequences[dir]
+	if !ok && !w.overwrite {
+		// 追加写入时从已有的最大序号之后继续编号
+		var err error
+		if sequence, err = w.lastSequence(ctx, dir); err != nil {
+			return nil, err
+		}
+	}
+	sequence++
+	w.sequences[dir] = sequence
+
+	file := &objectFile{
+		core:   w.core,
+		bucket: w.bucket,
+		key:    path.Join(w.prefix, dir, fmt.Sprintf("part-%04d.%s", sequence, w.extension)),
+	}
+	encoder, err := newEncoder(w.endpoint, &file.buffer, w.columns, w.fields, false)
+	if err != nil {
+		return nil, err
+	}
+	file.encoder = encoder
+	w.open[dir] = file
+	return file, nil
+}
+
+// roll 结束分区当前的文件，文件在 Commit 时完成上传
+func (w *partitionWriter) roll(ctx context.Context, dir string) error {
+	file := w.open[dir]
+	delete(w.open, dir)
+	if err := file.finish(ctx); err != nil {
+		return err
+	}
+	w.finished = append(w.finished, file)
+	return nil
+}
+
+func (w *partitionWriter) dirPrefix(dir string) string {
+	if prefix := path.Join(w.prefix, dir); prefix != "" {
+		return prefix + "/"
+	}
+	return ""
+}
+
+// partFiles 列出分区目录下的 part 文件及其序号
+func (w *partitionWriter) partFiles(ctx context.Context, dir string) (map[string]int, error) {
+	prefix := w.dirPrefix(dir)
+	files := make(map[string]int)
+	for object := range w.core.Client.ListObjects(ctx, w.bucket, minio.ListObjectsOptions{Prefix: prefix + "part-"}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list %s/%s: %w", w.bucket, prefix, object.Err)
+		}
+		name := strings.TrimPrefix(object.Key, prefix+"part-")
+		if end := strings.IndexByte(name, '.'); end > 0 {
+			if sequence, err := strconv.Atoi(name[:end]); err == nil {
+				files[object.Key] = sequence
+			}
+		}
+	}
+	return files, nil
+}
+
+func (w *partitionWriter) lastSequence(ctx context.Context, dir string) (int, error) {
+	files, err := w.partFiles(ctx, dir)
+	if err != nil {
+		return 0, err
+	}
+	last := 0
+	for _, sequence := range files {
+		last = max(last, sequence)
+	}
+	return last, nil
+}
+
+// State 分区文件不保存断点
+func (w *partitionWriter) State() (*WriterState, bool) {
+	return nil, false
+}
+
+// Commit 完成所有文件的上传；覆盖写入时删除本次写入的分区中之前留下的其他 part 文件
+func (w *partitionWriter) Commit(ctx context.Context) error {
+	for dir := range w.open {
+		if err := w.roll(ctx, dir); err != nil {
+			return err
+		}
+	}
+
+	written := make(map[string]bool, len(w.finished))
+	for _, file := range w.finished {
+		if err := file.complete(ctx); err != nil {
+			return err
+		}
+		written[file.key] = true
+	}
+	w.committed = true
+
+	if !w.overwrite {
+		return nil
+	}
+	for dir := range w.sequences {
+		files, err := w.partFiles(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for key := range files {
+			if written[key] {
+				continue
+			}
+			if err := w.core.Client.RemoveObject(ctx, w.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+				return fmt.Errorf("failed to remove stale object %s/%s: %w", w.bucket, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close 未 Commit 时放弃所有未完成的分片上传
+func (w *partitionWriter) Close() error {
+	if w.committed {
+		return nil
+	}
+	ctx := context.Background()
+	for _, file := range w.finished {
+		file.abort(ctx)
+	}
+	for _, file := range w.open {
+		file.abort(ctx)
+	}
+	return nil
+}
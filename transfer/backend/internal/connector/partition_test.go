@@ -0,0 +1,202 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+func TestEscapePartitionValue(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"cn", "cn"},
+		{"", hiveDefaultPartition},
+		{"a/b", "a%2Fb"},
+		{"k=v", "k%3Dv"},
+		{"50%", "50%25"},
+		{"10:30", "10%3A30"},
+		{"a b-c_d.e", "a b-c_d.e"},
+		{"line\nbreak", "line%0Abreak"},
+		{"北京", "北京"},
+		{`"#'*?\{[]^`, "%22%23%27%2A%3F%5C%7B%5B%5D%5E"},
+	}
+	for _, tc := range cases {
+		got := escapePartitionValue(tc.value)
+		if got != tc.want {
+			t.Errorf("escapePartitionValue(%q) = %q, want %q", tc.value, got, tc.want)
+			continue
+		}
+		if tc.value == "" {
+			continue
+		}
+		if back, err := url.PathUnescape(got); err != nil || back != tc.value {
+			t.Errorf("unescape %q = %q, %v, want %q", got, back, err, tc.value)
+		}
+	}
+}
+
+func TestPartitionDir(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	w := &partitionWriter{
+		partitionNames:   []string{"dt", "region"},
+		partitionIndexes: []int{1, 2},
+		timeFormat:       "2006-01-02",
+	}
+
+	cases := []struct {
+		name string
+		row  []interface{}
+		want string
+	}{
+		{"time and text", []interface{}{int64(1), time.Date(2026, 3, 1, 23, 0, 0, 0, shanghai), "cn"}, "dt=2026-03-01/region=cn"},
+		{"null values", []interface{}{int64(1), nil, nil}, "dt=" + hiveDefaultPartition + "/region=" + hiveDefaultPartition},
+		{"empty text", []interface{}{int64(1), "2026-03-01", ""}, "dt=2026-03-01/region=" + hiveDefaultPartition},
+		{"numbers", []interface{}{int64(1), int64(20260301), 1.5}, "dt=20260301/region=1.5"},
+		{"escaped", []interface{}{int64(1), "2026/03/01", "a=b"}, "dt=2026%2F03%2F01/region=a%3Db"},
+	}
+	for _, tc := range cases {
+		if got := w.partitionDir(tc.row); got != tc.want {
+			t.Errorf("%s: partitionDir = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	w.timeFormat = "2006/01"
+	if got := w.partitionDir([]interface{}{nil, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), "cn"}); got != "dt=2026%2F03/region=cn" {
+		t.Errorf("custom time format: partitionDir = %q", got)
+	}
+}
+
+func TestOpenPartitionWriterColumns(t *testing.T) {
+	columns := []Column{{Name: "id"}, {Name: "dt"}, {Name: "v"}}
+	endpoint := func(partition *models.PartitionConfig) models.EndpointConfig {
+		return models.EndpointConfig{Bucket: "b", Path: "out/", Format: "parquet", Partition: partition}
+	}
+	c := &s3Connector{}
+
+	writer, err := c.openPartitionWriter(context.Background(), endpoint(&models.PartitionConfig{Columns: []string{"dt"}}), columns, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := writer.(*partitionWriter)
+	if w.prefix != "out" || w.timeFormat != time.DateOnly || w.extension != FormatParquet {
+		t.Fatalf("prefix = %q, timeFormat = %q, extension = %q", w.prefix, w.timeFormat, w.extension)
+	}
+	if !reflect.DeepEqual(w.dataIndexes, []int{0, 2}) || !reflect.DeepEqual(w.partitionIndexes, []int{1}) {
+		t.Fatalf("dataIndexes = %v, partitionIndexes = %v", w.dataIndexes, w.partitionIndexes)
+	}
+
+	if _, err := c.openPartitionWriter(context.Background(), endpoint(&models.PartitionConfig{Columns: []string{"missing"}}), columns, WriteOptions{}); err == nil {
+		t.Fatal("expected unknown partition column to fail")
+	}
+	if _, err := c.openPartitionWriter(context.Background(), endpoint(&models.PartitionConfig{Columns: []string{"id", "dt", "v"}}), columns, WriteOptions{}); err == nil {
+		t.Fatal("expected partitioning every column to fail")
+	}
+}
+
+// TestPartitionWriterRoundTrip 写入分区文件后逐个读回，分区列只出现在目录名中；
+// 覆盖写入不查询已有的 part 文件，数据量不足一个分片时也不上传，因此无需对象存储
+func TestPartitionWriterRoundTrip(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	columns := []Column{
+		{Name: "id", DatabaseType: "BIGINT"},
+		{Name: "dt", DatabaseType: "DATE"},
+		{Name: "name", DatabaseType: "TEXT"},
+	}
+	rows := [][]interface{}{
+		{int64(1), day1, "a"},
+		{int64(2), day2, nil},
+		{int64(3), day1, "c"},
+		{int64(4), nil, "d"},
+	}
+	want := map[string][][]interface{}{
+		"dt=2026-03-01":              {{int64(1), "a"}, {int64(3), "c"}},
+		"dt=2026-03-02":              {{int64(2), nil}},
+		"dt=" + hiveDefaultPartition: {{int64(4), "d"}},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL, FormatParquet} {
+		t.Run(format, func(t *testing.T) {
+			endpoint := models.EndpointConfig{
+				Bucket:    "b",
+				Path:      "out",
+				Format:    format,
+				Partition: &models.PartitionConfig{Columns: []string{"dt"}},
+			}
+			writer, err := (&s3Connector{}).openPartitionWriter(context.Background(), endpoint, columns,
+				WriteOptions{WriteMode: models.WriteModeOverwrite})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := writer.(*partitionWriter)
+			if err := w.Write(context.Background(), &Batch{Rows: rows}); err != nil {
+				t.Fatal(err)
+			}
+
+			var dirs []string
+			for dir := range w.open {
+				dirs = append(dirs, dir)
+			}
+			sort.Strings(dirs)
+			wantDirs := []string{"dt=" + hiveDefaultPartition, "dt=2026-03-01", "dt=2026-03-02"}
+			sort.Strings(wantDirs)
+			if !reflect.DeepEqual(dirs, wantDirs) {
+				t.Fatalf("partitions = %v, want %v", dirs, wantDirs)
+			}
+
+			for _, dir := range dirs {
+				file := w.open[dir]
+				if wantKey := "out/" + dir + "/part-0001." + format; file.key != wantKey {
+					t.Fatalf("key = %q, want %q", file.key, wantKey)
+				}
+				if err := file.encoder.close(); err != nil {
+					t.Fatal(err)
+				}
+				data := file.buffer.Bytes()
+				decoder, err := newDecoder(endpoint, bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var names []string
+				for _, col := range decoder.columns() {
+					names = append(names, col.Name)
+				}
+				if !reflect.DeepEqual(names, []string{"id", "name"}) {
+					t.Fatalf("%s columns = %v", dir, names)
+				}
+
+				var got [][]interface{}
+				for {
+					row, err := decoder.decode()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, row)
+				}
+				if format == FormatCSV {
+					// CSV 无法区分空串与 NULL
+					for _, row := range got {
+						if row[1] == "" {
+							row[1] = nil
+						}
+					}
+				}
+				if !reflect.DeepEqual(got, want[dir]) {
+					t.Fatalf("%s rows = %#v, want %#v", dir, got, want[dir])
+				}
+			}
+		})
+	}
+}
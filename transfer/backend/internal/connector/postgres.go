@@ -0,0 +1,158 @@
+package connector
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	commonModels "github.com/addp/common/models"
+	_ "github.com/lib/pq"
+)
+
+func init() {
+	Register(KindDatabase, NewPostgresConnector, "postgresql")
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) driverName() string {
+	return "postgres"
+}
+
+func (postgresDialect) quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (postgresDialect) placeholder(index int) string {
+	return fmt.Sprintf("$%d", index)
+}
+
+func (postgresDialect) primaryKeyQuery(schema, table, quotedTable string) (string, []interface{}) {
+	return `SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, []interface{}{quotedTable}
+}
+
+// upsertClause 所有列都是主键时忽略冲突
+func (d postgresDialect) upsertClause(keys, columns []string) string {
+	quotedKeys := make([]string, len(keys))
+	isKey := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		quotedKeys[i] = d.quote(key)
+		isKey[key] = true
+	}
+
+	var updates []string
+	for _, col := range columns {
+		if !isKey[col] {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", d.quote(col), d.quote(col)))
+		}
+	}
+	if len(updates) == 0 {
+		return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", strings.Join(quotedKeys, ", "))
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(quotedKeys, ", "), strings.Join(updates, ", "))
+}
+
+func (postgresDialect) tableExistsQuery(schema, table, quotedTable string) (string, []interface{}) {
+	return "SELECT to_regclass($1) IS NOT NULL", []interface{}{quotedTable}
+}
+
+// columnType 几何列需要目标库已安装 PostGIS 扩展，无符号整数使用更宽的类型
+func (postgresDialect) columnType(spec columnSpec, key bool) string {
+	switch spec.kind {
+	case typeBool:
+		return "BOOLEAN"
+	case typeInt16:
+		if spec.unsigned {
+			return "INTEGER"
+		}
+		return "SMALLINT"
+	case typeInt32:
+		if spec.unsigned {
+			return "BIGINT"
+		}
+		return "INTEGER"
+	case typeInt64:
+		if spec.unsigned {
+			return "NUMERIC(20,0)"
+		}
+		return "BIGINT"
+	case typeDecimal:
+		if spec.precision > 0 {
+			return fmt.Sprintf("NUMERIC(%d,%d)", spec.precision, spec.scale)
+		}
+		return "NUMERIC"
+	case typeFloat32:
+		return "REAL"
+	case typeFloat64:
+		return "DOUBLE PRECISION"
+	case typeChar:
+		if spec.length > 0 {
+			return fmt.Sprintf("CHAR(%d)", spec.length)
+		}
+		return "VARCHAR"
+	case typeString:
+		if spec.length > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", spec.length)
+		}
+		return "VARCHAR"
+	case typeBinary:
+		return "BYTEA"
+	case typeDate:
+		return "DATE"
+	case typeTime:
+		return "TIME"
+	case typeTimestamp:
+		return "TIMESTAMP"
+	case typeTimestampTZ:
+		return "TIMESTAMPTZ"
+	case typeJSON:
+		return "JSONB"
+	case typeUUID:
+		return "UUID"
+	case typeGeometry:
+		if spec.subtype != "" {
+			subtype := spec.subtype
+			if subtype == "geomcollection" {
+				subtype = "geometrycollection"
+			}
+			return fmt.Sprintf("geometry(%s)", strings.ToUpper(subtype))
+		}
+		return "geometry"
+	case typeArray:
+		if spec.subtype != "" {
+			return spec.subtype + "[]"
+		}
+		return "TEXT"
+	default:
+		return "TEXT"
+	}
+}
+
+// timeLiteral 带时区偏移：timestamptz 按时刻比较，timestamp 转换时忽略偏移、保留读出的本地时间
+func (postgresDialect) timeLiteral(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05.999999-07:00")
+}
+
+// geometryValue 转换为 PostGIS 接受的十六进制 EWKB 文本
+func (postgresDialect) geometryValue(value interface{}) (interface{}, error) {
+	b, ok := value.([]byte)
+	if !ok {
+		// PostgreSQL 源端读出的已是十六进制 EWKB，WKT/EWKT 文本也可直接写入
+		return value, nil
+	}
+	srid, wkb, err := parseMySQLGeometry(b)
+	if err != nil {
+		return nil, err
+	}
+	return hex.EncodeToString(buildEWKB(srid, wkb)), nil
+}
+
+// NewPostgresConnector 创建 PostgreSQL 连接器
+func NewPostgresConnector(resource *commonModels.Resource) (Connector, error) {
+	return openSQL(resource, postgresDialect{})
+}
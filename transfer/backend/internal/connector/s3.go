@@ -0,0 +1,391 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	commonModels "github.com/addp/common/models"
+	"github.com/addp/transfer/internal/models"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func init() {
+	Register(KindObjectStorage, NewS3Connector, "s3", "minio", "oss", "object_storage", "object-storage")
+}
+
+type s3Config struct {
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Region    string `json:"region"`
+	UseSSL    bool   `json:"use_ssl"`
+	PathStyle bool   `json:"path_style"`
+}
+
+// partSize 分片上传时单个分片的最小大小（S3 要求除最后一个分片外不小于 5MiB）
+const partSize = 8 << 20
+
+// s3Connector 对象存储连接器，对象内容按文件格式编解码为记录
+type s3Connector struct {
+	client *minio.Client
+	core   *minio.Core
+}
+
+// NewS3Connector 创建对象存储连接器
+func NewS3Connector(resource *commonModels.Resource) (Connector, error) {
+	connStr, err := commonModels.BuildConnectionString(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg s3Config
+	if err := json.Unmarshal([]byte(connStr), &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Endpoint == "" {
+		return nil, errors.New("missing endpoint for object storage")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("missing access_key or secret_key for object storage")
+	}
+
+	opts := &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	}
+	if cfg.Region != "" {
+		opts.Region = cfg.Region
+	}
+	if cfg.PathStyle {
+		opts.BucketLookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(cfg.Endpoint, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Connector{client: client, core: &minio.Core{Client: client}}, nil
+}
+
+func (c *s3Connector) Kind() string {
+	return KindObjectStorage
+}
+
+func (c *s3Connector) Close() error {
+	return nil
+}
+
+func objectLocation(endpoint models.EndpointConfig) (string, string, error) {
+	bucket := strings.TrimSpace(endpoint.Bucket)
+	key := strings.TrimLeft(strings.TrimSpace(endpoint.Path), "/")
+	if bucket == "" || key == "" {
+		return "", "", errors.New("bucket and path are required")
+	}
+	return bucket, key, nil
+}
+
+// OpenReader 续传时跳过已读取的记录
+func (c *s3Connector) OpenReader(ctx context.Context, endpoint models.EndpointConfig, opts ReadOptions) (Reader, error) {
+	bucket, key, err := objectLocation(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := c.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object %s/%s: %w", bucket, key, err)
+	}
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to open object %s/%s: %w", bucket, key, err)
+	}
+
+	counter := &countingReader{source: object}
+	decoder, err := newDecoder(endpoint, counter, info.Size)
+	if err != nil {
+		object.Close()
+		return nil, fmt.Errorf("failed to open object %s/%s: %w", bucket, key, err)
+	}
+
+	reader := &objectReader{object: object, counter: counter, size: info.Size, decoder: decoder, batchSize: opts.BatchSize}
+	if reader.batchSize <= 0 {
+		reader.batchSize = 1000
+	}
+	if opts.Resume != nil {
+		for reader.offset < opts.Resume.Offset {
+			if _, err := decoder.decode(); err != nil {
+				object.Close()
+				if err == io.EOF {
+					return nil, fmt.Errorf("%w: object %s/%s is shorter than checkpoint", ErrResumeUnavailable, bucket, key)
+				}
+				return nil, err
+			}
+			reader.offset++
+		}
+	}
+	return reader, nil
+}
+
+// OpenWriter 使用分片上传写入对象，已上传的分片可在续传时复用；配置分区时写入多个 part 文件
+func (c *s3Connector) OpenWriter(ctx context.Context, endpoint models.EndpointConfig, columns []Column, opts WriteOptions) (Writer, error) {
+	if endpoint.Partition != nil {
+		return c.openPartitionWriter(ctx, endpoint, columns, opts)
+	}
+
+	bucket, key, err := objectLocation(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if opts.WriteMode == models.WriteModeAppend {
+		// 对象存储不支持追加，已存在的对象拒绝覆盖
+		if _, err := c.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{}); err == nil {
+			return nil, fmt.Errorf("object %s/%s already exists, use write_mode=overwrite to replace it", bucket, key)
+		}
+	}
+
+	file := &objectFile{core: c.core, bucket: bucket, key: key}
+	if state := opts.Resume; state != nil && state.UploadID != "" {
+		if err := file.restore(ctx, state); err != nil {
+			return nil, err
+		}
+	}
+
+	// 续传时表头已在之前的分片中写入
+	file.encoder, err = newEncoder(endpoint, &file.buffer, columns, opts.Fields, len(file.parts) > 0)
+	if err != nil {
+		return nil, err
+	}
+	return &objectWriter{file: file}, nil
+}
+
+// objectReader 按批读取对象中的记录
+type objectReader struct {
+	object    *minio.Object
+	counter   *countingReader
+	size      int64
+	decoder   recordDecoder
+	batchSize int
+	offset    int64
+}
+
+func (r *objectReader) Columns() []Column {
+	return r.decoder.columns()
+}
+
+// EstimateCount Parquet 从文件元数据获取行数，文本格式未知
+func (r *objectReader) EstimateCount(ctx context.Context) (int64, error) {
+	if counter, ok := r.decoder.(rowCounter); ok {
+		return counter.numRows(), nil
+	}
+	return 0, nil
+}
+
+func (r *objectReader) Read(ctx context.Context) (*Batch, error) {
+	batch := &Batch{Columns: r.decoder.columns()}
+	for len(batch.Rows) < r.batchSize {
+		row, err := r.decoder.decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		batch.Rows = append(batch.Rows, row)
+	}
+	if len(batch.Rows) == 0 {
+		return nil, io.EOF
+	}
+	r.offset += int64(len(batch.Rows))
+	batch.Position = &Position{Offset: r.offset}
+	return batch, nil
+}
+
+// SourceBytes 解码器有缓冲，已读取字节数可能略超前于已返回的记录
+func (r *objectReader) SourceBytes() (int64, int64) {
+	return r.counter.count.Load(), r.size
+}
+
+func (r *objectReader) Close() error {
+	return r.object.Close()
+}
+
+// countingReader 统计已读取的字节数（进度由心跳协程读取）
+type countingReader struct {
+	source objectSource
+	count  atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+func (r *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.source.ReadAt(p, off)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+// objectWriter 写入单个对象
+type objectWriter struct {
+	file *objectFile
+}
+
+func (w *objectWriter) Write(ctx context.Context, batch *Batch) error {
+	for _, row := range batch.Rows {
+		if err := w.file.encoder.encode(row); err != nil {
+			return fmt.Errorf("failed to encode object %s/%s: %w", w.file.bucket, w.file.key, err)
+		}
+	}
+	return w.file.flush(ctx)
+}
+
+// State 缓冲区为空时已写入的批次都在已上传的分片中；不支持续传的格式不保存断点
+func (w *objectWriter) State() (*WriterState, bool) {
+	if !w.file.encoder.resumable() {
+		return nil, false
+	}
+	if w.file.uploadID == "" {
+		return nil, w.file.buffer.Len() == 0
+	}
+	return &WriterState{
+		UploadID: w.file.uploadID,
+		Parts:    append([]PartInfo(nil), w.file.parts...),
+	}, w.file.buffer.Len() == 0
+}
+
+func (w *objectWriter) Commit(ctx context.Context) error {
+	return w.file.commit(ctx)
+}
+
+// Close 未完成的分片上传保留给续传使用，过期后由对象存储清理
+func (w *objectWriter) Close() error {
+	return nil
+}
+
+// objectFile 正在写入的对象：记录编码到缓冲区，在批次边界累计满一个分片后上传
+type objectFile struct {
+	core     *minio.Core
+	bucket   string
+	key      string
+	encoder  recordEncoder
+	buffer   bytes.Buffer
+	uploadID string
+	parts    []PartInfo
+	rows     int64
+}
+
+// restore 校验续传的分片上传仍然存在
+func (f *objectFile) restore(ctx context.Context, state *WriterState) error {
+	result, err := f.core.ListObjectParts(ctx, f.bucket, f.key, state.UploadID, 0, 10000)
+	if err != nil {
+		return fmt.Errorf("%w: multipart upload %s: %v", ErrResumeUnavailable, state.UploadID, err)
+	}
+	uploaded := make(map[int]string, len(result.ObjectParts))
+	for _, part := range result.ObjectParts {
+		uploaded[part.PartNumber] = part.ETag
+	}
+	for _, part := range state.Parts {
+		if etag, ok := uploaded[part.Number]; !ok || strings.Trim(etag, `"`) != strings.Trim(part.ETag, `"`) {
+			return fmt.Errorf("%w: part %d of upload %s is missing", ErrResumeUnavailable, part.Number, state.UploadID)
+		}
+	}
+
+	f.uploadID = state.UploadID
+	f.parts = append([]PartInfo(nil), state.Parts...)
+	return nil
+}
+
+// flush 批次编码完成后调用，缓冲满一个分片时上传
+func (f *objectFile) flush(ctx context.Context) error {
+	if err := f.encoder.flush(); err != nil {
+		return err
+	}
+	if f.buffer.Len() >= partSize {
+		return f.uploadPart(ctx)
+	}
+	return nil
+}
+
+func (f *objectFile) uploadPart(ctx context.Context) error {
+	if f.uploadID == "" {
+		uploadID, err := f.core.NewMultipartUpload(ctx, f.bucket, f.key, minio.PutObjectOptions{
+			ContentType: f.encoder.contentType(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start upload of %s/%s: %w", f.bucket, f.key, err)
+		}
+		f.uploadID = uploadID
+	}
+
+	number := len(f.parts) + 1
+	size := int64(f.buffer.Len())
+	part, err := f.core.PutObjectPart(ctx, f.bucket, f.key, f.uploadID, number,
+		bytes.NewReader(f.buffer.Bytes()), size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d of %s/%s: %w", number, f.bucket, f.key, err)
+	}
+	f.parts = append(f.parts, PartInfo{Number: number, ETag: part.ETag, Size: size})
+	f.buffer.Reset()
+	return nil
+}
+
+// finish 写入文件尾并上传剩余内容作为最后一个分片，完成上传前对象不可见
+func (f *objectFile) finish(ctx context.Context) error {
+	if err := f.encoder.close(); err != nil {
+		return err
+	}
+	if f.buffer.Len() > 0 || f.uploadID == "" {
+		return f.uploadPart(ctx)
+	}
+	return nil
+}
+
+// complete 完成分片上传
+func (f *objectFile) complete(ctx context.Context) error {
+	completed := make([]minio.CompletePart, len(f.parts))
+	for i, part := range f.parts {
+		completed[i] = minio.CompletePart{PartNumber: part.Number, ETag: part.ETag}
+	}
+	if _, err := f.core.CompleteMultipartUpload(ctx, f.bucket, f.key, f.uploadID, completed, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete upload of %s/%s: %w", f.bucket, f.key, err)
+	}
+	return nil
+}
+
+// commit 完成写入，数据量不足一个分片时直接上传
+func (f *objectFile) commit(ctx context.Context) error {
+	if f.uploadID != "" {
+		if err := f.finish(ctx); err != nil {
+			return err
+		}
+		return f.complete(ctx)
+	}
+
+	if err := f.encoder.close(); err != nil {
+		return err
+	}
+	_, err := f.core.Client.PutObject(ctx, f.bucket, f.key, bytes.NewReader(f.buffer.Bytes()), int64(f.buffer.Len()),
+		minio.PutObjectOptions{ContentType: f.encoder.contentType()})
+	if err != nil {
+		return fmt.Errorf("failed to upload object %s/%s: %w", f.bucket, f.key, err)
+	}
+	return nil
+}
+
+// abort 放弃未完成的分片上传
+func (f *objectFile) abort(ctx context.Context) error {
+	if f.uploadID == "" {
+		return nil
+	}
+	return f.core.AbortMultipartUpload(ctx, f.bucket, f.key, f.uploadID)
+}
@@ -0,0 +1,639 @@
+package connector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	commonModels "github.com/addp/common/models"
+	"github.com/addp/transfer/internal/models"
+)
+
+// maxStatementParams 单条 INSERT 的参数上限（PostgreSQL 限制为 65535）
+const maxStatementParams = 60000
+
+// dialect 描述不同数据库的驱动名、标识符引用和占位符差异
+type dialect interface {
+	driverName() string
+	quote(identifier string) string
+	placeholder(index int) string
+	// primaryKeyQuery 返回查询主键列（按顺序）的 SQL，table 为已引用的完整表名
+	primaryKeyQuery(schema, table, quotedTable string) (string, []interface{})
+	// upsertClause 返回主键冲突时更新非主键列的子句
+	upsertClause(keys, columns []string) string
+	// tableExistsQuery 返回判断表是否存在的 SQL（结果为布尔值）
+	tableExistsQuery(schema, table, quotedTable string) (string, []interface{})
+	// columnType 将中间类型转换为建表使用的列类型，key 表示列属于主键
+	columnType(spec columnSpec, key bool) string
+	// geometryValue 将源端读出的几何值转换为目标库可写入的格式
+	geometryValue(value interface{}) (interface{}, error)
+	// timeLiteral 将主键/水位中的时间格式化为该数据库能与列值比较的文本
+	timeLiteral(t time.Time) string
+}
+
+// sqlConnector 关系型数据库连接器的通用实现
+type sqlConnector struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// openSQL 根据资源信息打开数据库连接
+func openSQL(resource *commonModels.Resource, d dialect) (*sqlConnector, error) {
+	connStr, err := commonModels.BuildConnectionString(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(d.driverName(), connStr)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(4)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", resource.Name, err)
+	}
+	return &sqlConnector{db: db, dialect: d}, nil
+}
+
+func (c *sqlConnector) Kind() string {
+	return KindDatabase
+}
+
+func (c *sqlConnector) Close() error {
+	return c.db.Close()
+}
+
+// tableName 返回带 schema 的完整表名
+func (c *sqlConnector) tableName(endpoint models.EndpointConfig) (string, error) {
+	if endpoint.Table == "" {
+		return "", fmt.Errorf("table is required")
+	}
+	if endpoint.Schema == "" {
+		return c.dialect.quote(endpoint.Table), nil
+	}
+	return c.dialect.quote(endpoint.Schema) + "." + c.dialect.quote(endpoint.Table), nil
+}
+
+// primaryKey 查询表的主键列，无主键时返回空
+func (c *sqlConnector) primaryKey(ctx context.Context, endpoint models.EndpointConfig, table string) ([]string, error) {
+	query, args := c.dialect.primaryKeyQuery(endpoint.Schema, endpoint.Table, table)
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query primary key of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// highWatermark 查询水位列当前的最大值（限定在 after 之后），没有数据时返回空
+func (c *sqlConnector) highWatermark(ctx context.Context, table, column, after string) (string, error) {
+	query := fmt.Sprintf("SELECT MAX(%s) FROM %s", c.dialect.quote(column), table)
+	var args []interface{}
+	if after != "" {
+		query += fmt.Sprintf(" WHERE %s > %s", c.dialect.quote(column), c.dialect.placeholder(1))
+		args = append(args, after)
+	}
+
+	var value interface{}
+	if err := c.db.QueryRowContext(ctx, query, args...).Scan(&value); err != nil {
+		return "", fmt.Errorf("failed to query watermark of %s: %w", table, err)
+	}
+	if value == nil {
+		return "", nil
+	}
+	return formatKeyValue(c.dialect, NormalizeValue(value, false)), nil
+}
+
+// OpenReader 有主键时按主键顺序读取，续传时从上次的主键之后开始；
+// 无主键的表没有稳定的读取顺序，不保存断点，中断后从头读取
+// 增量读取时按 (水位列, 主键) 排序，读取范围为 (After, 开始时的最大值]；
+// 没有主键时水位列的值可能重复，同样不保存断点
+func (c *sqlConnector) OpenReader(ctx context.Context, endpoint models.EndpointConfig, opts ReadOptions) (Reader, error) {
+	table, err := c.tableName(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	keyColumns, err := c.primaryKey(ctx, endpoint, table)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &sqlReader{connector: c, table: table, batchSize: opts.BatchSize, resumable: len(keyColumns) > 0}
+	if reader.batchSize <= 0 {
+		reader.batchSize = 1000
+	}
+
+	// 增量范围条件（同时用于计数）
+	var conditions []string
+	var args []interface{}
+	if inc := opts.Incremental; inc != nil {
+		if inc.Column == "" {
+			return nil, fmt.Errorf("watermark column is required")
+		}
+		upper := ""
+		if opts.Resume != nil {
+			upper = opts.Resume.WatermarkTo
+		}
+		if upper == "" {
+			if upper, err = c.highWatermark(ctx, table, inc.Column, inc.After); err != nil {
+				return nil, err
+			}
+		}
+
+		column := c.dialect.quote(inc.Column)
+		if upper == "" {
+			// 没有新数据，保持原水位
+			conditions = append(conditions, "1 = 0")
+			reader.watermark = inc.After
+		} else {
+			if inc.After != "" {
+				args = append(args, inc.After)
+				conditions = append(conditions, fmt.Sprintf("%s > %s", column, c.dialect.placeholder(len(args))))
+			}
+			args = append(args, upper)
+			conditions = append(conditions, fmt.Sprintf("%s <= %s", column, c.dialect.placeholder(len(args))))
+			reader.watermark = upper
+		}
+		reader.watermarkTo = upper
+
+		orderColumns := []string{inc.Column}
+		for _, key := range keyColumns {
+			if key != inc.Column {
+				orderColumns = append(orderColumns, key)
+			}
+		}
+		keyColumns = orderColumns
+	}
+	if len(conditions) > 0 {
+		reader.filter = " WHERE " + strings.Join(conditions, " AND ")
+		reader.filterArgs = append([]interface{}(nil), args...)
+	}
+
+	var offset int64
+	if resume := opts.Resume; resume != nil {
+		// 旧版本为无主键的表按行偏移或水位列保存的断点也从头读取
+		if !reader.resumable || len(resume.Keys) == 0 {
+			return nil, fmt.Errorf("%w: %s has no primary key", ErrResumeUnavailable, table)
+		}
+		if !equalStrings(resume.KeyColumns, keyColumns) {
+			return nil, fmt.Errorf("%w: primary key of %s changed", ErrResumeUnavailable, table)
+		}
+		offset = resume.Offset
+	}
+
+	query := "SELECT * FROM " + table
+	if len(keyColumns) > 0 {
+		quotedKeys := make([]string, len(keyColumns))
+		for i, col := range keyColumns {
+			quotedKeys[i] = c.dialect.quote(col)
+		}
+		if opts.Resume != nil {
+			placeholders := make([]string, len(keyColumns))
+			for i, key := range opts.Resume.Keys {
+				args = append(args, key)
+				placeholders[i] = c.dialect.placeholder(len(args))
+			}
+			conditions = append(conditions,
+				fmt.Sprintf("(%s) > (%s)", strings.Join(quotedKeys, ", "), strings.Join(placeholders, ", ")))
+		}
+		if len(conditions) > 0 {
+			query += " WHERE " + strings.Join(conditions, " AND ")
+		}
+		query += " ORDER BY " + strings.Join(quotedKeys, ", ")
+	} else {
+		query += reader.filter
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", table, err)
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	columns := make([]Column, len(columnTypes))
+	columnIndex := make(map[string]int, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = Column{
+			Name:         ct.Name(),
+			DatabaseType: ct.DatabaseTypeName(),
+			Binary:       isBinaryType(ct.DatabaseTypeName()),
+		}
+		columnIndex[ct.Name()] = i
+	}
+
+	keyIndexes := make([]int, 0, len(keyColumns))
+	for _, col := range keyColumns {
+		index, ok := columnIndex[col]
+		if !ok {
+			rows.Close()
+			return nil, fmt.Errorf("column %s not found in %s", col, table)
+		}
+		keyIndexes = append(keyIndexes, index)
+	}
+
+	reader.rows = rows
+	reader.columns = columns
+	reader.keyColumns = keyColumns
+	reader.keyIndexes = keyIndexes
+	reader.offset = offset
+	return reader, nil
+}
+
+func (c *sqlConnector) OpenWriter(ctx context.Context, endpoint models.EndpointConfig, columns []Column, opts WriteOptions) (Writer, error) {
+	table, err := c.tableName(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.createTable(ctx, endpoint, table, columns, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.WriteMode == models.WriteModeOverwrite && !opts.Resuming {
+		if _, err := c.db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return nil, fmt.Errorf("failed to clear target table %s: %w", table, err)
+		}
+	}
+
+	writer := &sqlWriter{connector: c, table: table}
+	fields := fieldsByName(opts.Fields)
+	for i, col := range columns {
+		if columnSpecOf(col, fields).kind == typeGeometry {
+			writer.geometryColumns = append(writer.geometryColumns, i)
+		}
+	}
+	if opts.WriteMode == models.WriteModeUpsert {
+		keys := opts.KeyColumns
+		if len(keys) == 0 {
+			// 未指定时使用目标表的主键
+			if keys, err = c.primaryKey(ctx, endpoint, table); err != nil {
+				return nil, err
+			}
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("upsert into %s requires key columns or a primary key", table)
+		}
+		writer.upsertKeys = keys
+	}
+	return writer, nil
+}
+
+// createTable 目标表不存在时按源端字段信息建表
+// 有 Meta 扫描结果的列按字段类型、可空和主键建表，其余列按驱动返回的类型推断
+func (c *sqlConnector) createTable(ctx context.Context, endpoint models.EndpointConfig, table string, columns []Column, opts WriteOptions) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	query, args := c.dialect.tableExistsQuery(endpoint.Schema, endpoint.Table, table)
+	var exists bool
+	if err := c.db.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check target table %s: %w", table, err)
+	}
+	if exists {
+		return nil
+	}
+
+	fields := fieldsByName(opts.Fields)
+	keys := createKeys(columns, opts)
+	isKey := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		isKey[key] = true
+	}
+
+	definitions := make([]string, 0, len(columns)+1)
+	for _, col := range columns {
+		definition := c.dialect.quote(col.Name) + " " + c.dialect.columnType(columnSpecOf(col, fields), isKey[col.Name])
+		if field, ok := fields[col.Name]; isKey[col.Name] || (ok && !field.IsNullable) {
+			definition += " NOT NULL"
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(keys) > 0 {
+		quotedKeys := make([]string, len(keys))
+		for i, key := range keys {
+			quotedKeys[i] = c.dialect.quote(key)
+		}
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(quotedKeys, ", ")+")")
+	}
+
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n  %s\n)", table, strings.Join(definitions, ",\n  "))
+	if _, err := c.db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to create target table %s: %w", table, err)
+	}
+	return nil
+}
+
+// createKeys 建表使用的主键：源表主键列都保留时使用源表主键，否则使用 upsert 主键
+func createKeys(columns []Column, opts WriteOptions) []string {
+	present := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		present[col.Name] = true
+	}
+
+	var keys []string
+	for _, field := range opts.Fields {
+		if field.IsPrimaryKey {
+			if !present[field.Name] {
+				keys = nil
+				break
+			}
+			keys = append(keys, field.Name)
+		}
+	}
+	if len(keys) > 0 {
+		return keys
+	}
+	for _, key := range opts.KeyColumns {
+		if !present[key] {
+			return nil
+		}
+	}
+	return opts.KeyColumns
+}
+
+func fieldsByName(fields []models.FieldMeta) map[string]models.FieldMeta {
+	result := make(map[string]models.FieldMeta, len(fields))
+	for _, field := range fields {
+		result[field.Name] = field
+	}
+	return result
+}
+
+// columnSpecOf 优先使用 Meta 扫描的字段类型
+func columnSpecOf(column Column, fields map[string]models.FieldMeta) columnSpec {
+	if field, ok := fields[column.Name]; ok {
+		return specFromField(field)
+	}
+	return specFromColumn(column)
+}
+
+// sqlReader 基于游标的流式读取
+type sqlReader struct {
+	connector   *sqlConnector
+	table       string
+	rows        *sql.Rows
+	columns     []Column
+	batchSize   int
+	keyColumns  []string
+	keyIndexes  []int
+	resumable   bool // 排序列包含主键，能唯一确定读取位置
+	offset      int64
+	filter      string // 增量范围条件
+	filterArgs  []interface{}
+	watermark   string // 读完后的高水位
+	watermarkTo string // 本次读取范围的水位上界
+}
+
+func (r *sqlReader) Columns() []Column {
+	return r.columns
+}
+
+func (r *sqlReader) EstimateCount(ctx context.Context) (int64, error) {
+	var total int64
+	err := r.connector.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+r.table+r.filter, r.filterArgs...).Scan(&total)
+	return total, err
+}
+
+// HighWatermark 返回增量读取完成后的水位
+func (r *sqlReader) HighWatermark() string {
+	return r.watermark
+}
+
+func (r *sqlReader) Read(ctx context.Context) (*Batch, error) {
+	batch := &Batch{Columns: r.columns}
+	for len(batch.Rows) < r.batchSize && r.rows.Next() {
+		values := make([]interface{}, len(r.columns))
+		pointers := make([]interface{}, len(r.columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := r.rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		for i, v := range values {
+			values[i] = NormalizeValue(v, r.columns[i].Binary)
+		}
+		batch.Rows = append(batch.Rows, values)
+	}
+	if err := r.rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", r.table, err)
+	}
+	if len(batch.Rows) == 0 {
+		return nil, io.EOF
+	}
+
+	r.offset += int64(len(batch.Rows))
+	// 排序列不包含主键时不返回读取位置，不保存断点
+	if !r.resumable {
+		return batch, nil
+	}
+	batch.Position = &Position{KeyColumns: r.keyColumns, Offset: r.offset, WatermarkTo: r.watermarkTo}
+	last := batch.Rows[len(batch.Rows)-1]
+	batch.Position.Keys = make([]string, len(r.keyIndexes))
+	for i, index := range r.keyIndexes {
+		batch.Position.Keys[i] = formatKeyValue(r.connector.dialect, last[index])
+	}
+	return batch, nil
+}
+
+func (r *sqlReader) Close() error {
+	return r.rows.Close()
+}
+
+// sqlWriter 每个批次在一个事务中以多行 INSERT 写入
+type sqlWriter struct {
+	connector       *sqlConnector
+	table           string
+	upsertKeys      []string // 非空时按这些列冲突更新
+	geometryColumns []int    // 需要转换格式的几何列
+}
+
+func (w *sqlWriter) Write(ctx context.Context, batch *Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	columnNames := batchColumnNames(batch)
+	rowsPerStatement := batch.Len()
+	if len(columnNames) > 0 && rowsPerStatement*len(columnNames) > maxStatementParams {
+		rowsPerStatement = maxStatementParams / len(columnNames)
+	}
+
+	tx, err := w.connector.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < batch.Len(); start += rowsPerStatement {
+		end := start + rowsPerStatement
+		if end > batch.Len() {
+			end = batch.Len()
+		}
+		args := make([]interface{}, 0, (end-start)*len(columnNames))
+		for _, row := range batch.Rows[start:end] {
+			rowArgs, err := w.rowArgs(columnNames, row)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			args = append(args, rowArgs...)
+		}
+		if _, err := tx.ExecContext(ctx, w.insertStatement(columnNames, end-start), args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to write batch into %s: %w", w.table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// WriteEach 逐行写入（每行单独提交），用于批量写入失败后定位失败的行
+func (w *sqlWriter) WriteEach(ctx context.Context, batch *Batch) ([]RowError, error) {
+	columnNames := batchColumnNames(batch)
+	statement := w.insertStatement(columnNames, 1)
+
+	var rowErrors []RowError
+	for i, row := range batch.Rows {
+		args, err := w.rowArgs(columnNames, row)
+		if err == nil {
+			_, err = w.connector.db.ExecContext(ctx, statement, args...)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			rowErrors = append(rowErrors, RowError{Row: i, Err: err})
+		}
+	}
+	return rowErrors, nil
+}
+
+// rowArgs 返回一行的写入参数，几何列转换为目标库的格式
+func (w *sqlWriter) rowArgs(columnNames []string, row []interface{}) ([]interface{}, error) {
+	if len(w.geometryColumns) == 0 {
+		return row, nil
+	}
+	args := append([]interface{}(nil), row...)
+	for _, index := range w.geometryColumns {
+		if args[index] == nil {
+			continue
+		}
+		value, err := w.connector.dialect.geometryValue(args[index])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", columnNames[index], err)
+		}
+		args[index] = value
+	}
+	return args, nil
+}
+
+func (w *sqlWriter) insertStatement(columnNames []string, rowCount int) string {
+	statement := buildInsert(w.connector.dialect, w.table, columnNames, rowCount)
+	if len(w.upsertKeys) > 0 {
+		statement += " " + w.connector.dialect.upsertClause(w.upsertKeys, columnNames)
+	}
+	return statement
+}
+
+func batchColumnNames(batch *Batch) []string {
+	names := make([]string, len(batch.Columns))
+	for i, col := range batch.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// State 每个批次在事务中提交，写入后即已持久化
+func (w *sqlWriter) State() (*WriterState, bool) {
+	return nil, true
+}
+
+func (w *sqlWriter) Commit(ctx context.Context) error {
+	return nil
+}
+
+func (w *sqlWriter) Close() error {
+	return nil
+}
+
+// buildInsert 构造多行 INSERT 语句
+func buildInsert(d dialect, table string, columns []string, rowCount int) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = d.quote(col)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(table)
+	sb.WriteString(" (")
+	sb.WriteString(strings.Join(quoted, ", "))
+	sb.WriteString(") VALUES ")
+
+	index := 1
+	for r := 0; r < rowCount; r++ {
+		if r > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for c := range columns {
+			if c > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(d.placeholder(index))
+			index++
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// formatKeyValue 将主键/水位值格式化为数据库能隐式转换回原类型的文本
+func formatKeyValue(d dialect, value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		return d.timeLiteral(t)
+	}
+	return formatValue(value)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isBinaryType 判断列是否为二进制类型
+func isBinaryType(databaseType string) bool {
+	switch strings.ToUpper(databaseType) {
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "GEOMETRY":
+		return true
+	default:
+		return false
+	}
+}
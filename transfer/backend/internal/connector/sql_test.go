@@ -0,0 +1,32 @@
+package connector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatKeyValue(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	ts := time.Date(2026, 1, 1, 12, 0, 0, 500000000, shanghai)
+
+	cases := []struct {
+		name    string
+		dialect dialect
+		value   interface{}
+		want    string
+	}{
+		{"mysql time normalized to UTC without offset", mysqlDialect{}, ts, "2026-01-01 04:00:00.5"},
+		{"mysql UTC time", mysqlDialect{}, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "2026-01-01 00:00:00"},
+		{"postgres time keeps offset", postgresDialect{}, ts, "2026-01-01 12:00:00.5+08:00"},
+		{"postgres UTC time", postgresDialect{}, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "2026-01-01 00:00:00+00:00"},
+		{"integer key", mysqlDialect{}, int64(42), "42"},
+		{"string key", postgresDialect{}, "abc", "abc"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatKeyValue(tc.dialect, tc.value); got != tc.want {
+				t.Fatalf("formatKeyValue = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
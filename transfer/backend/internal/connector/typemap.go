@@ -0,0 +1,150 @@
+package connector
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// 跨数据库的中间类型，建表时由各方言转换为具体类型（对照表见 README）
+const (
+	typeBool        = "bool"
+	typeInt16       = "int16"
+	typeInt32       = "int32"
+	typeInt64       = "int64"
+	typeDecimal     = "decimal"
+	typeFloat32     = "float32"
+	typeFloat64     = "float64"
+	typeChar        = "char"
+	typeString      = "string"
+	typeText        = "text"
+	typeBinary      = "binary"
+	typeDate        = "date"
+	typeTime        = "time"
+	typeTimestamp   = "timestamp"
+	typeTimestampTZ = "timestamptz"
+	typeJSON        = "json"
+	typeUUID        = "uuid"
+	typeGeometry    = "geometry"
+	typeArray       = "array"
+	typeEnum        = "enum"
+)
+
+// columnSpec 列的中间类型描述
+type columnSpec struct {
+	kind      string
+	length    int    // 字符/二进制长度，0 表示未知
+	precision int    // decimal 精度，0 表示未指定
+	scale     int    // decimal 小数位
+	unsigned  bool   // MySQL 无符号整数，PostgreSQL 没有无符号类型，建表时使用更宽的类型
+	subtype   string // 几何子类型、数组元素类型或 MySQL 原始类型（text/blob 的大小、enum 的取值）
+}
+
+// specFromField 由 Meta 扫描的字段信息推断中间类型
+// PostgreSQL 的 column_type 为 udt_name（如 int4、varchar、geometry），MySQL 的为完整类型（如 decimal(10,2) unsigned）
+func specFromField(field models.FieldMeta) columnSpec {
+	dataType := strings.ToLower(strings.TrimSpace(field.DataType))
+	columnType := strings.ToLower(strings.TrimSpace(field.ColumnType))
+	if columnType == "" {
+		columnType = dataType
+	}
+	if dataType == "array" {
+		return columnSpec{kind: typeArray, subtype: strings.TrimPrefix(columnType, "_")}
+	}
+
+	spec := parseType(columnType)
+	if spec.kind == typeDecimal && spec.precision == 0 && field.NumericPrecision > 0 {
+		spec.precision = field.NumericPrecision
+		spec.scale = field.NumericScale
+	}
+	return spec
+}
+
+// specFromColumn 没有元数据时由驱动返回的列类型推断（如 VARCHAR、INT4、UNSIGNED BIGINT）
+func specFromColumn(column Column) columnSpec {
+	return parseType(strings.ToLower(column.DatabaseType))
+}
+
+// parseType 解析类型名，无法识别的类型按文本处理
+func parseType(typeName string) columnSpec {
+	var spec columnSpec
+	name := strings.TrimSpace(typeName)
+	if strings.HasPrefix(name, "unsigned ") || strings.HasSuffix(name, " unsigned") || strings.Contains(name, " unsigned ") {
+		spec.unsigned = true
+		name = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(name, "unsigned", ""), "zerofill", ""))
+	}
+
+	var args []int
+	if open := strings.Index(name, "("); open >= 0 {
+		if end := strings.LastIndex(name, ")"); end > open {
+			for _, part := range strings.Split(name[open+1:end], ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+					args = append(args, n)
+				}
+			}
+			name = strings.TrimSpace(name[:open] + name[end+1:])
+		}
+	}
+	arg := func(i int) int {
+		if i < len(args) {
+			return args[i]
+		}
+		return 0
+	}
+
+	switch name {
+	case "bool", "boolean":
+		spec.kind = typeBool
+	case "tinyint":
+		// MySQL 约定 tinyint(1) 表示布尔
+		if arg(0) == 1 && !spec.unsigned {
+			spec.kind = typeBool
+		} else {
+			spec.kind = typeInt16
+		}
+	case "smallint", "int2", "smallserial", "year":
+		spec.kind = typeInt16
+	case "mediumint", "int", "integer", "int4", "serial":
+		spec.kind = typeInt32
+	case "bigint", "int8", "bigserial":
+		spec.kind = typeInt64
+	case "decimal", "numeric", "dec":
+		spec.kind, spec.precision, spec.scale = typeDecimal, arg(0), arg(1)
+	case "real", "float4", "float":
+		spec.kind = typeFloat32
+	case "double", "double precision", "float8":
+		spec.kind = typeFloat64
+	case "char", "character", "bpchar", "nchar":
+		spec.kind, spec.length = typeChar, arg(0)
+	case "varchar", "character varying", "nvarchar":
+		spec.kind, spec.length = typeString, arg(0)
+	case "text", "tinytext", "mediumtext", "longtext", "citext":
+		spec.kind, spec.subtype = typeText, name
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob":
+		spec.kind, spec.subtype = typeBinary, name
+	case "binary", "varbinary":
+		spec.kind, spec.length, spec.subtype = typeBinary, arg(0), name
+	case "date":
+		spec.kind = typeDate
+	case "time", "time without time zone", "timetz", "time with time zone":
+		spec.kind = typeTime
+	case "timestamp", "timestamp without time zone", "datetime":
+		spec.kind = typeTimestamp
+	case "timestamptz", "timestamp with time zone":
+		spec.kind = typeTimestampTZ
+	case "json", "jsonb":
+		spec.kind = typeJSON
+	case "uuid":
+		spec.kind = typeUUID
+	case "geometry", "geography":
+		spec.kind, spec.subtype = typeGeometry, ""
+	case "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection", "geomcollection":
+		spec.kind, spec.subtype = typeGeometry, name
+	case "enum", "set":
+		spec.kind, spec.subtype = typeEnum, typeName
+	default:
+		spec = columnSpec{kind: typeText}
+	}
+	return spec
+}
@@ -0,0 +1,182 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// 连接器类别
+const (
+	KindDatabase      = "database"
+	KindObjectStorage = "object_storage"
+)
+
+// ErrResumeUnavailable 断点状态已失效（如分片上传已被清理），需要从头开始
+var ErrResumeUnavailable = errors.New("checkpoint is no longer resumable")
+
+// Column 列描述
+type Column struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"database_type,omitempty"`
+	Binary       bool   `json:"binary,omitempty"` // 二进制列保留 []byte，其余 []byte 按字符串处理
+}
+
+// Batch 一批记录，Rows 中每行的值与 Columns 一一对应
+type Batch struct {
+	Columns  []Column
+	Rows     [][]interface{}
+	Position *Position // 读完本批次后的读取位置
+}
+
+// Position 读取位置，用于断点续传
+type Position struct {
+	KeyColumns []string `json:"key_columns,omitempty"` // 按主键续传时的主键列
+	Keys       []string `json:"keys,omitempty"`        // 最后一行的主键值（文本形式）
+	Offset     int64    `json:"offset"`                // 已读取的行数
+	// WatermarkTo 增量读取本次的水位上界，续传时沿用以保证读取范围不变
+	WatermarkTo string `json:"watermark_to,omitempty"`
+}
+
+// WriterState 写入端的续传状态（对象存储分片上传）
+type WriterState struct {
+	UploadID string     `json:"upload_id,omitempty"`
+	Parts    []PartInfo `json:"parts,omitempty"`
+}
+
+// PartInfo 已上传的分片
+type PartInfo struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// Len 返回批次行数
+func (b *Batch) Len() int {
+	return len(b.Rows)
+}
+
+// Size 粗略估算批次占用的字节数，用于吞吐统计
+func (b *Batch) Size() int64 {
+	var size int64
+	for _, row := range b.Rows {
+		for _, v := range row {
+			size += ValueSize(v)
+		}
+	}
+	return size
+}
+
+// ReadOptions 读取选项
+type ReadOptions struct {
+	BatchSize   int
+	Resume      *Position    // 非空时从该位置之后继续读取
+	Incremental *Incremental // 非空时只读取水位之后的数据
+}
+
+// Incremental 增量读取条件：读取 Column > After（After 为空时读取全部）且不超过本次开始时的最大值
+type Incremental struct {
+	Column string
+	After  string
+}
+
+// WriteOptions 写入选项
+type WriteOptions struct {
+	WriteMode  string       // append / overwrite / upsert
+	KeyColumns []string     // upsert 使用的主键，为空时从目标表结构中获取
+	Resuming   bool         // 断点续传时不再清空目标
+	Resume     *WriterState // 续传时恢复的写入状态
+
+	// Fields 源端字段信息（Meta 扫描结果），用于自动建表和几何类型转换
+	Fields []models.FieldMeta
+}
+
+// Reader 流式读取源端数据
+type Reader interface {
+	// Columns 返回源端列信息
+	Columns() []Column
+	// EstimateCount 预估总行数，未知时返回 0
+	EstimateCount(ctx context.Context) (int64, error)
+	// Read 读取下一批数据，读完时返回 io.EOF
+	Read(ctx context.Context) (*Batch, error)
+	// Close 释放读取资源
+	Close() error
+}
+
+// WatermarkReader 支持增量读取的 Reader，读完后返回新的高水位
+type WatermarkReader interface {
+	Reader
+	HighWatermark() string
+}
+
+// Writer 流式写入目标端数据
+type Writer interface {
+	// Write 写入一个批次
+	Write(ctx context.Context, batch *Batch) error
+	// State 返回当前写入状态，durable 表示已写入的批次均已持久化（可以保存断点）
+	State() (state *WriterState, durable bool)
+	// Commit 完成写入
+	Commit(ctx context.Context) error
+	// Close 释放写入资源，未 Commit 时放弃写入
+	Close() error
+}
+
+// SizedReader 能按源文件字节数报告进度的 Reader（对象存储），行数未知时用于计算进度
+type SizedReader interface {
+	Reader
+	// SourceBytes 返回已读取的字节数和源对象总字节数
+	SourceBytes() (read, total int64)
+}
+
+// RowError 批次中单行的失败原因
+type RowError struct {
+	Row int // 行在批次中的下标
+	Err error
+}
+
+// RowWriter 支持逐行写入的 Writer，批量写入失败时用于隔离失败的行
+type RowWriter interface {
+	Writer
+	// WriteEach 逐行写入批次，返回写入失败的行；其余行已写入
+	WriteEach(ctx context.Context, batch *Batch) ([]RowError, error)
+}
+
+// Connector 数据源连接器，每种 resource_type 对应一个实现
+type Connector interface {
+	// Kind 返回连接器类别（database / object_storage）
+	Kind() string
+	// OpenReader 打开源端读取器
+	OpenReader(ctx context.Context, endpoint models.EndpointConfig, opts ReadOptions) (Reader, error)
+	// OpenWriter 打开目标端写入器，columns 为源端列信息
+	OpenWriter(ctx context.Context, endpoint models.EndpointConfig, columns []Column, opts WriteOptions) (Writer, error)
+	// Close 关闭连接
+	Close() error
+}
+
+// NormalizeValue 将驱动返回的值转换为可跨库写入的类型
+func NormalizeValue(value interface{}, binary bool) interface{} {
+	if b, ok := value.([]byte); ok && !binary {
+		return string(b)
+	}
+	return value
+}
+
+// ValueSize 粗略估算值占用的字节数
+func ValueSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	case time.Time:
+		return 8
+	case bool:
+		return 1
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/addp/common/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按路由模板和状态码统计请求数和耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveHTTP(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
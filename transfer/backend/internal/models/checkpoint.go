@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultPartition 单流传输使用的分区名
+const DefaultPartition = "default"
+
+// TaskCheckpoint 任务断点（对应 transfer.task_checkpoints 表）
+// 每个已提交的批次后更新，Worker 崩溃或重启后从断点继续而不是从头开始
+type TaskCheckpoint struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TaskID          uint      `gorm:"not null;uniqueIndex:idx_checkpoints_task_partition" json:"task_id"`
+	Partition       string    `gorm:"size:255;not null;uniqueIndex:idx_checkpoints_task_partition" json:"partition"`
+	ExecutionID     uint      `gorm:"not null" json:"execution_id"`
+	Position        JSONMap   `gorm:"type:jsonb" json:"position"`     // 源端读取位置（主键水位或行偏移）
+	WriterState     JSONMap   `gorm:"type:jsonb" json:"writer_state"` // 目标端状态（分片上传 ID 与已上传分片）
+	RecordsRead     int64     `gorm:"default:0" json:"records_read"`
+	RecordsWritten  int64     `gorm:"default:0" json:"records_written"`
+	RecordsRejected int64     `gorm:"default:0" json:"records_rejected"`
+	BytesRead       int64     `gorm:"default:0" json:"bytes_read"`
+	BytesWritten    int64     `gorm:"default:0" json:"bytes_written"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (TaskCheckpoint) TableName() string {
+	return "task_checkpoints"
+}
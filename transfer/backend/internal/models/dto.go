@@ -0,0 +1,50 @@
+package models
+
+// TaskCreateRequest 创建任务请求
+type TaskCreateRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	Type     string  `json:"type" binding:"required,oneof=import export sync"`
+	SourceID uint    `json:"source_id" binding:"required"`
+	TargetID uint    `json:"target_id" binding:"required"`
+	Config   JSONMap `json:"config" binding:"required"`
+	Schedule string  `json:"schedule"`
+
+	OverlapPolicy string `json:"overlap_policy" binding:"omitempty,oneof=skip queue cancel_previous"`
+	CatchUp       string `json:"catch_up" binding:"omitempty,oneof=none latest all"`
+}
+
+// TaskUpdateRequest 更新任务请求
+type TaskUpdateRequest struct {
+	Name     *string  `json:"name"`
+	SourceID *uint    `json:"source_id"`
+	TargetID *uint    `json:"target_id"`
+	Config   *JSONMap `json:"config"`
+	Schedule *string  `json:"schedule"`
+
+	OverlapPolicy *string `json:"overlap_policy" binding:"omitempty,oneof=skip queue cancel_previous"`
+	CatchUp       *string `json:"catch_up" binding:"omitempty,oneof=none latest all"`
+}
+
+// TaskListQuery 任务列表查询条件
+type TaskListQuery struct {
+	Page     int
+	PageSize int
+	Status   string
+	Type     string
+}
+
+// TaskStatistics 任务统计
+type TaskStatistics struct {
+	TotalTasks      int64            `json:"total_tasks"`
+	TasksByStatus   map[string]int64 `json:"tasks_by_status"`
+	TotalExecutions int64            `json:"total_executions"`
+	RunningCount    int64            `json:"running_count"`
+	RecordsWritten  int64            `json:"records_written"`
+}
+
+// ExecutionLogsResponse 执行日志
+type ExecutionLogsResponse struct {
+	ExecutionID uint   `json:"execution_id"`
+	Status      string `json:"status"`
+	Logs        string `json:"logs"`
+}
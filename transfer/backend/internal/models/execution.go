@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// 执行状态
+const (
+	ExecutionStatusQueued    = "queued"
+	ExecutionStatusRunning   = "running"
+	ExecutionStatusSuccess   = "success"
+	ExecutionStatusFailed    = "failed"
+	ExecutionStatusPaused    = "paused"
+	ExecutionStatusCancelled = "cancelled"
+	ExecutionStatusSkipped   = "skipped" // 调度触发时上一次执行未结束，按策略跳过
+)
+
+// 触发方式
+const (
+	TriggerManual   = "manual"
+	TriggerRetry    = "retry"
+	TriggerResume   = "resume"
+	TriggerSchedule = "schedule"
+)
+
+// 停止请求（由 API 写入，Worker 轮询后中断执行）
+const (
+	StopRequestPause  = "pause"
+	StopRequestCancel = "cancel"
+)
+
+// TaskExecution 任务执行记录（对应 transfer.task_executions 表）
+type TaskExecution struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	TaskID          uint       `gorm:"not null;index:idx_executions_task;uniqueIndex:idx_executions_task_scheduled" json:"task_id"`
+	TenantID        uint       `gorm:"not null;default:0;index" json:"tenant_id"`
+	Status          string     `gorm:"size:20;not null;index:idx_executions_status" json:"status"`
+	TriggerType     string     `gorm:"size:20;default:'manual'" json:"trigger_type"`
+	Attempt         int        `gorm:"default:1" json:"attempt"`
+	ScheduledAt     *time.Time `gorm:"uniqueIndex:idx_executions_task_scheduled" json:"scheduled_at,omitempty"` // 调度触发的计划时间，用于多实例去重
+	StopRequest     string     `gorm:"size:20" json:"stop_request,omitempty"`
+	WorkerID        string     `gorm:"size:128" json:"worker_id,omitempty"`
+	HeartbeatAt     *time.Time `json:"heartbeat_at,omitempty"`
+	StartTime       *time.Time `gorm:"index:idx_executions_start_time" json:"start_time,omitempty"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+	Phase           string     `gorm:"size:20" json:"phase,omitempty"`
+	TotalRecords    int64      `gorm:"default:0" json:"total_records"` // 预估总行数，未知时为 0
+	RecordsRead     int64      `gorm:"default:0" json:"records_read"`
+	RecordsWritten  int64      `gorm:"default:0" json:"records_written"`
+	RecordsRejected int64      `gorm:"default:0" json:"records_rejected"` // 转换或写入失败、计入错误预算的行数
+	BytesRead       int64      `gorm:"default:0" json:"bytes_read"`
+	BytesWritten    int64      `gorm:"default:0" json:"bytes_written"`
+	ErrorMsg        string     `gorm:"type:text" json:"error_msg,omitempty"`
+	Logs            string     `gorm:"type:text" json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (TaskExecution) TableName() string {
+	return "task_executions"
+}
+
+// IsFinished 执行是否已结束
+func (e *TaskExecution) IsFinished() bool {
+	switch e.Status {
+	case ExecutionStatusSuccess, ExecutionStatusFailed, ExecutionStatusPaused, ExecutionStatusCancelled, ExecutionStatusSkipped:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// JSONMap 提供基础的 JSONB 映射能力
+type JSONMap map[string]interface{}
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+func (m *JSONMap) Scan(value interface{}) error {
+	if value == nil {
+		*m = JSONMap{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return err
+	}
+	*m = JSONMap(data)
+	return nil
+}
+
+// Decode 将 JSONMap 解码到结构体
+func (m JSONMap) Decode(target interface{}) error {
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// ToJSONMap 将结构体编码为 JSONMap
+func ToJSONMap(source interface{}) (JSONMap, error) {
+	bytes, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	var data JSONMap
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,56 @@
+package models
+
+// FieldMeta Meta 模块扫描的字段信息（metadata.meta_item.attributes.fields 中的元素）
+type FieldMeta struct {
+	Name             string `json:"name"`
+	OrdinalPosition  int    `json:"ordinal_position"`
+	DataType         string `json:"data_type"`
+	ColumnType       string `json:"column_type"`
+	IsNullable       bool   `json:"is_nullable"`
+	DefaultValue     string `json:"default_value"`
+	Comment          string `json:"comment"`
+	IsPrimaryKey     bool   `json:"is_primary_key"`
+	IsUniqueKey      bool   `json:"is_unique_key"`
+	CharacterSet     string `json:"character_set"`
+	Collation        string `json:"collation"`
+	NumericPrecision int    `json:"numeric_precision"`
+	NumericScale     int    `json:"numeric_scale"`
+}
+
+// TableMeta Meta 模块扫描的表信息
+type TableMeta struct {
+	Schema   string      `json:"schema"`
+	Name     string      `json:"name"`
+	Fields   []FieldMeta `json:"fields"`
+	RowCount int64       `json:"-"` // meta_item.row_count，未知时为 0
+}
+
+// PrimaryKeys 返回主键列（按字段顺序）
+func (t *TableMeta) PrimaryKeys() []string {
+	var keys []string
+	for _, field := range t.Fields {
+		if field.IsPrimaryKey {
+			keys = append(keys, field.Name)
+		}
+	}
+	return keys
+}
+
+// FieldNames 返回所有字段名（按字段顺序）
+func (t *TableMeta) FieldNames() []string {
+	names := make([]string, len(t.Fields))
+	for i, field := range t.Fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// Field 按名称查找字段
+func (t *TableMeta) Field(name string) (*FieldMeta, bool) {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// 执行阶段
+const (
+	PhaseQueued       = "queued"
+	PhasePreparing    = "preparing"    // 连接源端/目标端、预估总量
+	PhaseTransferring = "transferring" // 逐批读写
+	PhaseCommitting   = "committing"   // 完成写入（如合并分片上传）
+	PhaseFinished     = "finished"
+)
+
+// ExecutionProgress 执行的实时进度快照
+// 运行中由 Worker 每秒发布到 Redis，执行结束后由执行记录生成
+type ExecutionProgress struct {
+	ExecutionID     uint    `json:"execution_id"`
+	TaskID          uint    `json:"task_id"`
+	Status          string  `json:"status"`
+	Phase           string  `json:"phase"`
+	RecordsRead     int64   `json:"records_read"`
+	RecordsWritten  int64   `json:"records_written"`
+	RecordsRejected int64   `json:"records_rejected"`
+	BytesRead       int64   `json:"bytes_read"`
+	BytesWritten    int64   `json:"bytes_written"`
+	TotalRecords    int64   `json:"total_records,omitempty"`     // 预估总行数（Meta 扫描的行数或 COUNT）
+	TotalBytes      int64   `json:"total_bytes,omitempty"`       // 源对象大小
+	SourceBytesRead int64   `json:"source_bytes_read,omitempty"` // 已读取的源对象字节数
+	Percent         float64 `json:"percent"`
+	RowsPerSecond   float64 `json:"rows_per_second"`
+	BytesPerSecond  float64 `json:"bytes_per_second"`
+	ETASeconds      *int64  `json:"eta_seconds,omitempty"` // 总量未知或速率为 0 时为空
+
+	StartTime *time.Time `json:"start_time,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Finished 快照对应的执行是否已结束
+func (p *ExecutionProgress) Finished() bool {
+	execution := TaskExecution{Status: p.Status}
+	return execution.IsFinished()
+}
+
+// ProgressFromExecution 由执行记录生成进度快照（没有实时速率）
+func ProgressFromExecution(execution *TaskExecution) *ExecutionProgress {
+	progress := &ExecutionProgress{
+		ExecutionID:     execution.ID,
+		TaskID:          execution.TaskID,
+		Status:          execution.Status,
+		Phase:           execution.Phase,
+		RecordsRead:     execution.RecordsRead,
+		RecordsWritten:  execution.RecordsWritten,
+		RecordsRejected: execution.RecordsRejected,
+		BytesRead:       execution.BytesRead,
+		BytesWritten:    execution.BytesWritten,
+		TotalRecords:    execution.TotalRecords,
+		StartTime:       execution.StartTime,
+		UpdatedAt:       execution.UpdatedAt,
+	}
+	if progress.Phase == "" {
+		progress.Phase = PhaseQueued
+	}
+	if execution.IsFinished() {
+		progress.Phase = PhaseFinished
+	}
+	if execution.Status == ExecutionStatusSuccess {
+		progress.Percent = 100
+	} else {
+		progress.Percent = Percent(execution.RecordsRead, execution.TotalRecords)
+	}
+	return progress
+}
+
+// Percent 计算百分比，不超过 100
+func Percent(done, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	percent := float64(done) / float64(total) * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
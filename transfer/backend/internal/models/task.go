@@ -0,0 +1,181 @@
+package models
+
+import (
+	"time"
+)
+
+// 任务类型
+const (
+	TaskTypeImport = "import"
+	TaskTypeExport = "export"
+	TaskTypeSync   = "sync"
+)
+
+// 任务状态
+const (
+	TaskStatusPending   = "pending"
+	TaskStatusRunning   = "running"
+	TaskStatusSuccess   = "success"
+	TaskStatusFailed    = "failed"
+	TaskStatusPaused    = "paused"
+	TaskStatusCancelled = "cancelled"
+)
+
+// 写入模式
+const (
+	WriteModeAppend    = "append"
+	WriteModeOverwrite = "overwrite"
+	WriteModeUpsert    = "upsert" // 按主键插入或更新，sync 任务固定使用
+)
+
+// 调度重叠策略：上一次执行尚未结束时如何处理新的调度
+const (
+	OverlapSkip           = "skip"            // 跳过本次调度，记录为 skipped
+	OverlapQueue          = "queue"           // 排队，等上一次结束后执行
+	OverlapCancelPrevious = "cancel_previous" // 取消上一次执行后执行本次
+)
+
+// 错过调度的补跑策略（服务停机期间未触发的调度）
+const (
+	CatchUpNone   = "none"   // 不补跑
+	CatchUpLatest = "latest" // 只补跑最近一次
+	CatchUpAll    = "all"    // 逐次补跑（受 SCHEDULER_MAX_CATCHUP 限制）
+)
+
+// TransferTask 传输任务（对应 transfer.tasks 表）
+type TransferTask struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"not null;default:0;index" json:"tenant_id"`
+	Name      string    `gorm:"size:255;not null" json:"name"`
+	Type      string    `gorm:"size:50;not null;index:idx_tasks_type" json:"type"`
+	SourceID  uint      `json:"source_id"`
+	TargetID  uint      `json:"target_id"`
+	Config    JSONMap   `gorm:"type:jsonb;not null" json:"config"`
+	Schedule  string    `gorm:"size:100" json:"schedule,omitempty"`
+	Status    string    `gorm:"size:20;default:'pending';index:idx_tasks_status" json:"status"`
+	Progress  float64   `gorm:"type:numeric(5,2);default:0" json:"progress"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 调度配置
+	OverlapPolicy   string     `gorm:"size:20;default:'skip'" json:"overlap_policy"`
+	CatchUp         string     `gorm:"size:20;default:'latest'" json:"catch_up"`
+	LastScheduledAt *time.Time `json:"last_scheduled_at,omitempty"` // 最近一次已处理的调度时间
+	NextRunAt       *time.Time `json:"next_run_at,omitempty"`
+
+	// 增量同步高水位（sync 任务上次成功同步到的水位列值）
+	Watermark          string     `gorm:"type:text" json:"watermark,omitempty"`
+	WatermarkUpdatedAt *time.Time `json:"watermark_updated_at,omitempty"`
+}
+
+func (TransferTask) TableName() string {
+	return "tasks"
+}
+
+// TaskConfig 任务配置（tasks.config 的结构化表示）
+type TaskConfig struct {
+	Source    EndpointConfig `json:"source"`
+	Target    EndpointConfig `json:"target"`
+	BatchSize int            `json:"batch_size,omitempty"`
+	WriteMode string         `json:"write_mode,omitempty"` // append / overwrite / upsert
+	Sync      *SyncConfig    `json:"sync,omitempty"`
+
+	// Transforms 按顺序对每行执行的转换步骤，写入目标端前生效
+	Transforms []TransformStep `json:"transforms,omitempty"`
+
+	// ErrorHandling 行级错误处理，未配置时任意一行失败即整个执行失败
+	ErrorHandling *ErrorHandlingConfig `json:"error_handling,omitempty"`
+}
+
+// ErrorHandlingConfig 错误预算：失败的行被拒绝并写入死信，超过预算时执行失败
+type ErrorHandlingConfig struct {
+	MaxErrors       int64             `json:"max_errors,omitempty"`        // 允许拒绝的行数上限
+	MaxErrorPercent float64           `json:"max_error_percent,omitempty"` // 拒绝行占已读取行的百分比上限
+	DeadLetter      *DeadLetterConfig `json:"dead_letter,omitempty"`
+}
+
+// DeadLetterConfig 死信输出位置：对象存储（bucket + path 前缀）或数据库表
+type DeadLetterConfig struct {
+	ResourceID uint           `json:"resource_id"`
+	Endpoint   EndpointConfig `json:"endpoint"`
+}
+
+// 转换步骤类型
+const (
+	TransformRename  = "rename"  // 重命名列：column -> to
+	TransformCast    = "cast"    // 类型转换：to 为 string/int/float/bool/timestamp
+	TransformDefault = "default" // 空值填充 value
+	TransformReplace = "replace" // 正则替换：pattern -> replacement
+	TransformTrim    = "trim"    // 去除首尾空白
+	TransformHash    = "hash"    // 哈希：algorithm 为 md5/sha1/sha256（默认），可加 salt
+	TransformMask    = "mask"    // 脱敏：保留 keep_prefix/keep_suffix 个字符，其余替换为 mask_char
+	TransformDrop    = "drop"    // 删除列
+	TransformCompute = "compute" // 计算列：按 expression 计算 column 的值（不存在时新增）
+	TransformFilter  = "filter"  // 行过滤：expression 为 false 的行被丢弃
+)
+
+// TransformStep 单个转换步骤，按 type 使用不同的参数
+type TransformStep struct {
+	Type        string      `json:"type"`
+	Column      string      `json:"column,omitempty"`
+	To          string      `json:"to,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+	Pattern     string      `json:"pattern,omitempty"`
+	Replacement string      `json:"replacement,omitempty"`
+	Algorithm   string      `json:"algorithm,omitempty"`
+	Salt        string      `json:"salt,omitempty"`
+	KeepPrefix  int         `json:"keep_prefix,omitempty"`
+	KeepSuffix  int         `json:"keep_suffix,omitempty"`
+	MaskChar    string      `json:"mask_char,omitempty"`
+	Expression  string      `json:"expression,omitempty"`
+}
+
+// SyncConfig 增量同步配置（sync 任务）
+type SyncConfig struct {
+	WatermarkColumn string   `json:"watermark_column"`      // 单调递增的水位列，如 updated_at、id
+	KeyColumns      []string `json:"key_columns,omitempty"` // upsert 主键，未指定时从 Meta 扫描的字段信息中获取
+}
+
+// EndpointConfig 源端/目标端的数据定位
+type EndpointConfig struct {
+	Schema string `json:"schema,omitempty"`
+	Table  string `json:"table,omitempty"`
+	Bucket string `json:"bucket,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Format string `json:"format,omitempty"` // 对象文件格式：csv（默认）、jsonl、parquet
+
+	File      *FileOptions     `json:"file,omitempty"`      // 对象文件的格式选项
+	Partition *PartitionConfig `json:"partition,omitempty"` // 写入对象存储时按列分区输出，此时 path 为目录
+}
+
+// FileOptions 对象文件的格式选项
+type FileOptions struct {
+	Delimiter   string `json:"delimiter,omitempty"`    // CSV 分隔符，默认 ","
+	Quote       string `json:"quote,omitempty"`        // CSV 引号字符，默认 "
+	Encoding    string `json:"encoding,omitempty"`     // CSV/JSONL 字符编码，默认 utf-8，支持 gbk、gb18030 等
+	InferSchema *bool  `json:"infer_schema,omitempty"` // 读取 CSV/JSONL 时按样本推断列类型，默认 true
+	Compression string `json:"compression,omitempty"`  // Parquet 压缩算法：snappy（默认）、gzip、zstd、none
+}
+
+// PartitionConfig 分区输出配置，文件写入 <path>/<列>=<值>/part-0001.<格式>
+type PartitionConfig struct {
+	Columns        []string `json:"columns,omitempty"`           // 分区列，不写入文件内容
+	TimeFormat     string   `json:"time_format,omitempty"`       // 时间类型分区值的格式（Go layout），默认 2006-01-02
+	MaxRowsPerFile int64    `json:"max_rows_per_file,omitempty"` // 单个文件的最大行数，超过后写入下一个 part 文件
+}
+
+// ParseConfig 解析任务配置
+func (t *TransferTask) ParseConfig() (*TaskConfig, error) {
+	var cfg TaskConfig
+	if err := t.Config.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if t.Type == TaskTypeSync {
+		cfg.WriteMode = WriteModeUpsert
+	}
+	if cfg.WriteMode == "" {
+		cfg.WriteMode = WriteModeAppend
+	}
+	return &cfg, nil
+}
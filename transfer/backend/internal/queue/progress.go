@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// progressTTL 进度快照的保留时间，Worker 异常退出后快照自动过期
+const progressTTL = 10 * time.Minute
+
+func (q *Queue) progressKey(executionID uint) string {
+	return fmt.Sprintf("%s:progress:%d", q.name, executionID)
+}
+
+// PublishProgress 保存最新的进度快照并通知订阅者
+func (q *Queue) PublishProgress(ctx context.Context, progress *models.ExecutionProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	key := q.progressKey(progress.ExecutionID)
+	pipe := q.client.TxPipeline()
+	pipe.Set(ctx, key, data, progressTTL)
+	pipe.Publish(ctx, key, data)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetProgress 获取最新的进度快照，不存在时返回 nil
+func (q *Queue) GetProgress(ctx context.Context, executionID uint) (*models.ExecutionProgress, error) {
+	data, err := q.client.Get(ctx, q.progressKey(executionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var progress models.ExecutionProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// SubscribeProgress 订阅执行的进度快照，ctx 取消或调用 close 后停止
+func (q *Queue) SubscribeProgress(ctx context.Context, executionID uint) (<-chan *models.ExecutionProgress, func() error) {
+	pubsub := q.client.Subscribe(ctx, q.progressKey(executionID))
+	updates := make(chan *models.ExecutionProgress, 1)
+
+	go func() {
+		defer close(updates)
+		for message := range pubsub.Channel() {
+			var progress models.ExecutionProgress
+			if err := json.Unmarshal([]byte(message.Payload), &progress); err != nil {
+				continue
+			}
+			// 只保留最新的快照，消费慢时丢弃旧的
+			select {
+			case <-updates:
+			default:
+			}
+			select {
+			case updates <- &progress:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, pubsub.Close
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrEmpty 表示在等待时间内没有取到任务
+var ErrEmpty = errors.New("queue is empty")
+
+// Queue 基于 Redis List 的执行队列，延迟任务（重试）存放在 Sorted Set 中
+type Queue struct {
+	client     *redis.Client
+	name       string
+	delayedKey string
+}
+
+// NewQueue 创建执行队列
+func NewQueue(client *redis.Client, name string) *Queue {
+	return &Queue{
+		client:     client,
+		name:       name,
+		delayedKey: name + ":delayed",
+	}
+}
+
+// NewRedisClient 创建 Redis 客户端并检查连通性
+func NewRedisClient(addr, password string, db int) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis %s: %w", addr, err)
+	}
+	return client, nil
+}
+
+// Enqueue 将执行 ID 放入队列
+func (q *Queue) Enqueue(ctx context.Context, executionID uint) error {
+	return q.client.LPush(ctx, q.name, executionID).Err()
+}
+
+// EnqueueDelayed 在 delay 之后将执行 ID 放入队列
+func (q *Queue) EnqueueDelayed(ctx context.Context, executionID uint, delay time.Duration) error {
+	return q.client.ZAdd(ctx, q.delayedKey, redis.Z{
+		Score:  float64(time.Now().Add(delay).Unix()),
+		Member: executionID,
+	}).Err()
+}
+
+// Dequeue 阻塞获取一个执行 ID，超时返回 ErrEmpty
+func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (uint, error) {
+	result, err := q.client.BRPop(ctx, timeout, q.name).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrEmpty
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.ParseUint(result[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid execution id in queue: %s", result[1])
+	}
+	return uint(id), nil
+}
+
+// PromoteDelayed 将到期的延迟任务移入就绪队列
+func (q *Queue) PromoteDelayed(ctx context.Context) (int, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	members, err := q.client.ZRangeByScore(ctx, q.delayedKey, &redis.ZRangeBy{Min: "-inf", Max: now}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	promoted := 0
+	for _, member := range members {
+		// ZRem 成功才入队，避免多个 Worker 重复搬运
+		removed, err := q.client.ZRem(ctx, q.delayedKey, member).Result()
+		if err != nil {
+			return promoted, err
+		}
+		if removed == 0 {
+			continue
+		}
+		if err := q.client.LPush(ctx, q.name, member).Err(); err != nil {
+			return promoted, err
+		}
+		promoted++
+	}
+	return promoted, nil
+}
+
+// Len 返回就绪队列长度
+func (q *Queue) Len(ctx context.Context) (int64, error) {
+	return q.client.LLen(ctx, q.name).Result()
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/addp/transfer/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CheckpointRepository struct {
+	db *gorm.DB
+}
+
+func NewCheckpointRepository(db *gorm.DB) *CheckpointRepository {
+	return &CheckpointRepository{db: db}
+}
+
+// Get 查询任务分区的断点，不存在时返回 nil
+func (r *CheckpointRepository) Get(taskID uint, partition string) (*models.TaskCheckpoint, error) {
+	var checkpoint models.TaskCheckpoint
+	err := r.db.Where("task_id = ? AND partition = ?", taskID, partition).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// ListByTask 查询任务的所有断点
+func (r *CheckpointRepository) ListByTask(taskID uint) ([]models.TaskCheckpoint, error) {
+	var checkpoints []models.TaskCheckpoint
+	err := r.db.Where("task_id = ?", taskID).Order("partition ASC").Find(&checkpoints).Error
+	return checkpoints, err
+}
+
+// Save 写入或更新断点
+func (r *CheckpointRepository) Save(checkpoint *models.TaskCheckpoint) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "task_id"}, {Name: "partition"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"execution_id", "position", "writer_state",
+			"records_read", "records_written", "bytes_read", "bytes_written", "updated_at",
+		}),
+	}).Create(checkpoint).Error
+}
+
+// DeleteByTask 清除任务的断点
+func (r *CheckpointRepository) DeleteByTask(taskID uint) error {
+	return r.db.Where("task_id = ?", taskID).Delete(&models.TaskCheckpoint{}).Error
+}
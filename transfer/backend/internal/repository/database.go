@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/addp/transfer/internal/config"
+	"github.com/addp/transfer/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// InitDatabase 初始化数据库连接
+func InitDatabase(cfg *config.Config) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable search_path=%s",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBName,
+		cfg.DBSchema,
+	)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Warn),
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// 确保 transfer schema 存在
+	if err := db.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", cfg.DBSchema)).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	// 设置连接池
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(50)
+
+	if err := autoMigrate(db); err != nil {
+		return nil, fmt.Errorf("failed to auto migrate: %w", err)
+	}
+
+	log.Println("Database connected and migrated successfully")
+	return db, nil
+}
+
+// autoMigrate 自动迁移所有表
+func autoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.TransferTask{},
+		&models.TaskExecution{},
+		&models.TaskCheckpoint{},
+	)
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExecutionRepository struct {
+	db *gorm.DB
+}
+
+func NewExecutionRepository(db *gorm.DB) *ExecutionRepository {
+	return &ExecutionRepository{db: db}
+}
+
+func (r *ExecutionRepository) Create(execution *models.TaskExecution) error {
+	return r.db.Create(execution).Error
+}
+
+func (r *ExecutionRepository) GetByID(id uint) (*models.TaskExecution, error) {
+	var execution models.TaskExecution
+	if err := r.db.First(&execution, id).Error; err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// GetByIDAndTenant 查询指定租户的执行记录（tenantID 为 0 时不做租户过滤）
+func (r *ExecutionRepository) GetByIDAndTenant(id, tenantID uint) (*models.TaskExecution, error) {
+	var execution models.TaskExecution
+	query := r.db.Where("id = ?", id)
+	if tenantID > 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	if err := query.First(&execution).Error; err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+func (r *ExecutionRepository) ListByTask(taskID uint, offset, limit int) ([]models.TaskExecution, int64, error) {
+	var executions []models.TaskExecution
+	var total int64
+
+	query := r.db.Model(&models.TaskExecution{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&executions).Error
+	return executions, total, err
+}
+
+// ListActive 查询排队中或运行中的执行记录
+func (r *ExecutionRepository) ListActive(tenantID uint) ([]models.TaskExecution, error) {
+	var executions []models.TaskExecution
+	query := r.db.Where("status IN ?", []string{models.ExecutionStatusQueued, models.ExecutionStatusRunning})
+	if tenantID > 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	err := query.Order("id ASC").Find(&executions).Error
+	return executions, err
+}
+
+// GetActiveByTask 查询任务当前排队中或运行中的执行记录
+func (r *ExecutionRepository) GetActiveByTask(taskID uint) ([]models.TaskExecution, error) {
+	var executions []models.TaskExecution
+	err := r.db.Where("task_id = ? AND status IN ?", taskID,
+		[]string{models.ExecutionStatusQueued, models.ExecutionStatusRunning}).
+		Order("id ASC").
+		Find(&executions).Error
+	return executions, err
+}
+
+// GetLatestByTask 查询任务最近一次执行
+func (r *ExecutionRepository) GetLatestByTask(taskID uint) (*models.TaskExecution, error) {
+	var execution models.TaskExecution
+	if err := r.db.Where("task_id = ?", taskID).Order("id DESC").First(&execution).Error; err != nil {
+		return nil, err
+	}
+	return &execution, nil
+}
+
+// Claim 将排队中的执行标记为运行中，返回是否抢占成功（多 Worker 竞争时保证只执行一次）
+// 同一任务已有运行中的执行时不抢占，保证同一任务串行执行
+func (r *ExecutionRepository) Claim(id uint, workerID string) (bool, error) {
+	claimed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var execution models.TaskExecution
+		if err := tx.Select("id", "task_id").Where("id = ? AND status = ?", id, models.ExecutionStatusQueued).
+			First(&execution).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		// 锁住任务行使同一任务的抢占串行执行，否则并发的抢占可能同时通过下面的 NOT EXISTS 检查
+		var task models.TransferTask
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&task, execution.TaskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.TaskExecution{}).
+			Where("id = ? AND status = ?", id, models.ExecutionStatusQueued).
+			Where("NOT EXISTS (SELECT 1 FROM task_executions running WHERE running.task_id = task_executions.task_id AND running.status = ?)",
+				models.ExecutionStatusRunning).
+			Updates(map[string]interface{}{
+				"status":       models.ExecutionStatusRunning,
+				"worker_id":    workerID,
+				"start_time":   gorm.Expr("COALESCE(start_time, ?)", now),
+				"heartbeat_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected == 1
+		return nil
+	})
+	return claimed, err
+}
+
+// Heartbeat 更新心跳与读写计数，返回当前的停止请求
+func (r *ExecutionRepository) Heartbeat(id uint, progress *models.ExecutionProgress) (string, error) {
+	if err := r.db.Model(&models.TaskExecution{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"heartbeat_at":     time.Now(),
+		"phase":            progress.Phase,
+		"total_records":    progress.TotalRecords,
+		"records_read":     progress.RecordsRead,
+		"records_written":  progress.RecordsWritten,
+		"records_rejected": progress.RecordsRejected,
+		"bytes_read":       progress.BytesRead,
+		"bytes_written":    progress.BytesWritten,
+	}).Error; err != nil {
+		return "", err
+	}
+
+	var stopRequest string
+	err := r.db.Model(&models.TaskExecution{}).Where("id = ?", id).Select("stop_request").Scan(&stopRequest).Error
+	return stopRequest, err
+}
+
+// Finish 结束执行并写入最终状态
+func (r *ExecutionRepository) Finish(execution *models.TaskExecution) error {
+	now := time.Now()
+	execution.EndTime = &now
+	return r.db.Model(execution).Updates(map[string]interface{}{
+		"status":           execution.Status,
+		"end_time":         now,
+		"phase":            execution.Phase,
+		"total_records":    execution.TotalRecords,
+		"records_read":     execution.RecordsRead,
+		"records_written":  execution.RecordsWritten,
+		"records_rejected": execution.RecordsRejected,
+		"bytes_read":       execution.BytesRead,
+		"bytes_written":    execution.BytesWritten,
+		"error_msg":        execution.ErrorMsg,
+	}).Error
+}
+
+// UpdateStatus 直接更新执行状态（用于取消排队中的执行）
+func (r *ExecutionRepository) UpdateStatus(id uint, fromStatus, toStatus string) (bool, error) {
+	updates := map[string]interface{}{"status": toStatus}
+	if toStatus != models.ExecutionStatusQueued {
+		updates["end_time"] = time.Now()
+	}
+	result := r.db.Model(&models.TaskExecution{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Requeue 将执行重新置为排队状态（重试或回收失联执行）
+func (r *ExecutionRepository) Requeue(id uint, attempt int, errMsg string) error {
+	return r.db.Model(&models.TaskExecution{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.ExecutionStatusQueued,
+		"attempt":      attempt,
+		"error_msg":    errMsg,
+		"worker_id":    "",
+		"stop_request": "",
+		"heartbeat_at": nil,
+	}).Error
+}
+
+// RequestStop 向运行中的执行发出停止请求
+func (r *ExecutionRepository) RequestStop(id uint, request string) error {
+	return r.db.Model(&models.TaskExecution{}).
+		Where("id = ? AND status = ?", id, models.ExecutionStatusRunning).
+		Update("stop_request", request).Error
+}
+
+// AppendLog 追加执行日志
+func (r *ExecutionRepository) AppendLog(id uint, line string) error {
+	return r.db.Model(&models.TaskExecution{}).Where("id = ?", id).
+		Update("logs", gorm.Expr("COALESCE(logs, '') || ?", line)).Error
+}
+
+// ListStale 查询心跳超时的运行中执行（Worker 崩溃或失联）
+func (r *ExecutionRepository) ListStale(before time.Time) ([]models.TaskExecution, error) {
+	var executions []models.TaskExecution
+	err := r.db.Where("status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)", models.ExecutionStatusRunning, before).
+		Find(&executions).Error
+	return executions, err
+}
+
+// CountRunning 统计运行中的执行数
+func (r *ExecutionRepository) CountRunning() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TaskExecution{}).Where("status = ?", models.ExecutionStatusRunning).Count(&count).Error
+	return count, err
+}
+
+// CountByTenant 统计执行总数和写入记录数
+func (r *ExecutionRepository) CountByTenant(tenantID uint) (int64, int64, error) {
+	type row struct {
+		Total   int64
+		Written int64
+	}
+	var result row
+
+	query := r.db.Model(&models.TaskExecution{})
+	if tenantID > 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	err := query.Select("COUNT(*) AS total, COALESCE(SUM(records_written), 0) AS written").Scan(&result).Error
+	return result.Total, result.Written, err
+}
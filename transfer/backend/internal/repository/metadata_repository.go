@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/addp/transfer/internal/models"
+	"gorm.io/gorm"
+)
+
+// MetadataRepository 只读访问 Meta 模块扫描的表结构（同库的 metadata schema）
+type MetadataRepository struct {
+	db     *gorm.DB
+	schema string
+}
+
+func NewMetadataRepository(db *gorm.DB, schema string) *MetadataRepository {
+	if schema == "" {
+		schema = "metadata"
+	}
+	return &MetadataRepository{db: db, schema: schema}
+}
+
+// GetTable 查询资源下表的字段信息，schema 为空时只按表名匹配；未扫描时返回 nil
+func (r *MetadataRepository) GetTable(resourceID, tenantID uint, schema, table string) (*models.TableMeta, error) {
+	type row struct {
+		Name       string
+		Attributes []byte
+		RowCount   *int64
+	}
+	var result row
+
+	query := r.db.Table(fmt.Sprintf("%s.meta_item AS i", r.schema)).
+		Select("i.name, i.attributes, i.row_count").
+		Joins(fmt.Sprintf("JOIN %s.meta_resource AS r ON r.id = i.res_id", r.schema)).
+		Where("r.resource_id = ? AND i.item_type = ? AND i.name = ?", resourceID, "table", table).
+		Where("i.deleted_at IS NULL AND r.deleted_at IS NULL")
+	if tenantID > 0 {
+		query = query.Where("i.tenant_id = ?", tenantID)
+	}
+	if schema != "" {
+		query = query.Where("i.attributes->>'schema' = ?", schema)
+	}
+
+	err := query.Order("i.id ASC").Limit(1).Take(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &models.TableMeta{Name: result.Name}
+	if len(result.Attributes) > 0 {
+		if err := json.Unmarshal(result.Attributes, meta); err != nil {
+			return nil, fmt.Errorf("invalid metadata of table %s: %w", table, err)
+		}
+	}
+	meta.Name = result.Name
+	if result.RowCount != nil && *result.RowCount > 0 {
+		meta.RowCount = *result.RowCount
+	}
+	return meta, nil
+}
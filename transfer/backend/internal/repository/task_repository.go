@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"gorm.io/gorm"
+)
+
+type TaskRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskRepository(db *gorm.DB) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+func (r *TaskRepository) Create(task *models.TransferTask) error {
+	return r.db.Create(task).Error
+}
+
+func (r *TaskRepository) GetByID(id uint) (*models.TransferTask, error) {
+	var task models.TransferTask
+	if err := r.db.First(&task, id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetByIDAndTenant 查询指定租户的任务（tenantID 为 0 时不做租户过滤）
+func (r *TaskRepository) GetByIDAndTenant(id, tenantID uint) (*models.TransferTask, error) {
+	var task models.TransferTask
+	query := r.db.Where("id = ?", id)
+	if tenantID > 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	if err := query.First(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *TaskRepository) List(tenantID uint, q models.TaskListQuery) ([]models.TransferTask, int64, error) {
+	var tasks []models.TransferTask
+	var total int64
+
+	query := r.db.Model(&models.TransferTask{})
+	if tenantID > 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Type != "" {
+		query = query.Where("type = ?", q.Type)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (q.Page - 1) * q.PageSize
+	err := query.Order("id DESC").Offset(offset).Limit(q.PageSize).Find(&tasks).Error
+	return tasks, total, err
+}
+
+func (r *TaskRepository) Update(task *models.TransferTask) error {
+	return r.db.Save(task).Error
+}
+
+// UpdateStatus 更新任务状态和进度
+func (r *TaskRepository) UpdateStatus(id uint, status string, progress *float64) error {
+	updates := map[string]interface{}{"status": status}
+	if progress != nil {
+		updates["progress"] = *progress
+	}
+	return r.db.Model(&models.TransferTask{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListScheduled 查询配置了调度表达式的任务
+func (r *TaskRepository) ListScheduled() ([]models.TransferTask, error) {
+	var tasks []models.TransferTask
+	err := r.db.Where("schedule IS NOT NULL AND schedule <> ''").Order("id ASC").Find(&tasks).Error
+	return tasks, err
+}
+
+// UpdateSchedule 记录最近一次已处理的调度时间和下次执行时间
+func (r *TaskRepository) UpdateSchedule(id uint, lastScheduledAt, nextRunAt *time.Time) error {
+	return r.db.Model(&models.TransferTask{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_scheduled_at": lastScheduledAt,
+		"next_run_at":       nextRunAt,
+	}).Error
+}
+
+// UpdateWatermark 记录增量同步成功后的高水位
+func (r *TaskRepository) UpdateWatermark(id uint, watermark string) error {
+	return r.db.Model(&models.TransferTask{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"watermark":            watermark,
+		"watermark_updated_at": time.Now(),
+	}).Error
+}
+
+func (r *TaskRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", id).Delete(&models.TaskCheckpoint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", id).Delete(&models.TaskExecution{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TransferTask{}, id).Error
+	})
+}
+
+// CountByStatus 按状态统计任务数
+func (r *TaskRepository) CountByStatus(tenantID uint) (map[string]int64, error) {
+	type row struct {
+		Status string
+		Count  int64
+	}
+	var rows []row
+
+	query := r.db.Model(&models.TransferTask{})
+	if tenantID > 0 {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	if err := query.Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		result[r.Status] = r.Count
+	}
+	return result, nil
+}
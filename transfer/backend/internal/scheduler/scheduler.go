@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/repository"
+	"github.com/addp/transfer/internal/service"
+)
+
+// maxOnTimeRuns 单次检查中正常触发的调度数上限（高频表达式在两个检查周期内可能有多次到期）
+const maxOnTimeRuns = 10
+
+// Scheduler 按任务的 Cron 表达式定时触发执行
+//
+// 每次检查时计算上次处理时间之后所有到期的调度时间：
+// 距今不超过两个检查周期的视为正常触发，更早的视为服务停机期间错过的调度，按任务的 catch_up 策略补跑。
+// 多实例部署时同一计划时间由 (task_id, scheduled_at) 唯一索引去重。
+type Scheduler struct {
+	taskRepo    *repository.TaskRepository
+	taskService *service.TaskService
+	interval    time.Duration
+	maxCatchUp  int
+}
+
+func NewScheduler(taskRepo *repository.TaskRepository, taskService *service.TaskService, interval time.Duration, maxCatchUp int) *Scheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if maxCatchUp <= 0 {
+		maxCatchUp = 1
+	}
+	return &Scheduler{
+		taskRepo:    taskRepo,
+		taskService: taskService,
+		interval:    interval,
+		maxCatchUp:  maxCatchUp,
+	}
+}
+
+// Run 启动调度循环，直到 ctx 取消
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Scheduler started (interval %s)", s.interval)
+
+	// 启动时立即检查一次，补跑停机期间错过的调度
+	s.tick(ctx, time.Now())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Scheduler stopped")
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	tasks, err := s.taskRepo.ListScheduled()
+	if err != nil {
+		log.Printf("Scheduler: failed to list scheduled tasks: %v", err)
+		return
+	}
+
+	for i := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		s.evaluate(ctx, &tasks[i], now)
+	}
+}
+
+// evaluate 处理单个任务的到期调度
+func (s *Scheduler) evaluate(ctx context.Context, task *models.TransferTask, now time.Time) {
+	schedule, err := service.ParseSchedule(task.Schedule)
+	if err != nil {
+		log.Printf("Scheduler: task %d has invalid schedule %q: %v", task.ID, task.Schedule, err)
+		return
+	}
+
+	last := task.CreatedAt
+	if task.LastScheduledAt != nil {
+		last = *task.LastScheduledAt
+	}
+
+	due, total := dueTimes(schedule.Next, last, now, s.maxCatchUp+maxOnTimeRuns)
+	next := schedule.Next(now)
+	if len(due) == 0 {
+		if task.NextRunAt == nil || !task.NextRunAt.Equal(next) {
+			s.taskRepo.UpdateSchedule(task.ID, &last, &next)
+		}
+		return
+	}
+
+	for _, scheduledAt := range s.selectRuns(task, due, total, now) {
+		execution, err := s.taskService.TriggerScheduled(ctx, task, scheduledAt)
+		switch {
+		case errors.Is(err, service.ErrAlreadyScheduled):
+			// 其他实例已触发
+		case err != nil:
+			log.Printf("Scheduler: failed to trigger task %d at %s: %v", task.ID, scheduledAt.Format(time.RFC3339), err)
+			// 保留未处理的调度时间，下次检查时重试
+			return
+		default:
+			log.Printf("Scheduler: task %d scheduled at %s -> execution %d (%s)",
+				task.ID, scheduledAt.Format(time.RFC3339), execution.ID, execution.Status)
+		}
+	}
+
+	latest := due[len(due)-1]
+	if err := s.taskRepo.UpdateSchedule(task.ID, &latest, &next); err != nil {
+		log.Printf("Scheduler: failed to update schedule of task %d: %v", task.ID, err)
+	}
+}
+
+// selectRuns 按补跑策略从到期的调度时间中选出需要触发的
+func (s *Scheduler) selectRuns(task *models.TransferTask, due []time.Time, total int, now time.Time) []time.Time {
+	threshold := now.Add(-2 * s.interval)
+
+	var missed, onTime []time.Time
+	for _, t := range due {
+		if t.Before(threshold) {
+			missed = append(missed, t)
+		} else {
+			onTime = append(onTime, t)
+		}
+	}
+	if len(missed) > 0 {
+		log.Printf("Scheduler: task %d missed %d run(s), catch_up=%s", task.ID, total-len(onTime), task.CatchUp)
+	}
+
+	switch task.CatchUp {
+	case models.CatchUpNone:
+		return onTime
+	case models.CatchUpAll:
+		if len(missed) > s.maxCatchUp {
+			missed = missed[len(missed)-s.maxCatchUp:]
+		}
+		return append(missed, onTime...)
+	default:
+		if len(onTime) > 0 || len(missed) == 0 {
+			return onTime
+		}
+		return missed[len(missed)-1:]
+	}
+}
+
+// dueTimes 返回 (after, now] 区间内最近的 limit 个调度时间及到期总数
+func dueTimes(next func(time.Time) time.Time, after, now time.Time, limit int) ([]time.Time, int) {
+	var due []time.Time
+	total := 0
+	for t := next(after); !t.IsZero() && !t.After(now); t = next(t) {
+		total++
+		due = append(due, t)
+		if len(due) > limit {
+			due = due[1:]
+		}
+	}
+	return due, total
+}
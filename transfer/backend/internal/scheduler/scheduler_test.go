@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/service"
+)
+
+func at(hour, minute, second int) time.Time {
+	return time.Date(2026, 1, 1, hour, minute, second, 0, time.UTC)
+}
+
+func mustSchedule(t *testing.T, expr string) func(time.Time) time.Time {
+	t.Helper()
+	schedule, err := service.ParseSchedule(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schedule.Next
+}
+
+func TestDueTimes(t *testing.T) {
+	cases := []struct {
+		name      string
+		expr      string
+		after     time.Time
+		now       time.Time
+		limit     int
+		want      []time.Time
+		wantTotal int
+	}{
+		{"nothing due", "* * * * *", at(12, 0, 0), at(12, 0, 10), 5, nil, 0},
+		{"one due", "* * * * *", at(11, 59, 0), at(12, 0, 10), 5, []time.Time{at(12, 0, 0)}, 1},
+		{"due exactly now", "* * * * *", at(11, 59, 0), at(12, 0, 0), 5, []time.Time{at(12, 0, 0)}, 1},
+		{"keeps the latest within limit", "* * * * *", at(11, 50, 0), at(12, 0, 10), 3,
+			[]time.Time{at(11, 58, 0), at(11, 59, 0), at(12, 0, 0)}, 10},
+		{"hourly", "0 * * * *", at(9, 0, 0), at(12, 30, 0), 5,
+			[]time.Time{at(10, 0, 0), at(11, 0, 0), at(12, 0, 0)}, 3},
+		{"never fires", "0 0 30 2 *", at(0, 0, 0), at(23, 0, 0), 5, nil, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, total := dueTimes(mustSchedule(t, tc.expr), tc.after, tc.now, tc.limit)
+			if !reflect.DeepEqual(got, tc.want) || total != tc.wantTotal {
+				t.Fatalf("dueTimes = %v (total %d), want %v (total %d)", got, total, tc.want, tc.wantTotal)
+			}
+		})
+	}
+}
+
+// TestSelectRuns 固定当前时间，检查停机前后各补跑策略触发的调度时间
+func TestSelectRuns(t *testing.T) {
+	s := &Scheduler{interval: 30 * time.Second, maxCatchUp: 3}
+
+	cases := []struct {
+		name    string
+		expr    string
+		last    time.Time
+		now     time.Time
+		catchUp string
+		want    []time.Time
+	}{
+		// 正常运行：只有刚到期的调度，各策略都触发
+		{"on time none", "* * * * *", at(11, 59, 0), at(12, 0, 10), models.CatchUpNone, []time.Time{at(12, 0, 0)}},
+		{"on time latest", "* * * * *", at(11, 59, 0), at(12, 0, 10), models.CatchUpLatest, []time.Time{at(12, 0, 0)}},
+		{"on time all", "* * * * *", at(11, 59, 0), at(12, 0, 10), models.CatchUpAll, []time.Time{at(12, 0, 0)}},
+
+		// 停机 10 分钟后恢复：11:51–11:59 错过，12:00 正常到期
+		{"missed with on time none", "* * * * *", at(11, 50, 0), at(12, 0, 10), models.CatchUpNone, []time.Time{at(12, 0, 0)}},
+		{"missed with on time latest", "* * * * *", at(11, 50, 0), at(12, 0, 10), models.CatchUpLatest, []time.Time{at(12, 0, 0)}},
+		{"missed with on time all", "* * * * *", at(11, 50, 0), at(12, 0, 10), models.CatchUpAll,
+			[]time.Time{at(11, 57, 0), at(11, 58, 0), at(11, 59, 0), at(12, 0, 0)}},
+
+		// 每小时执行，停机期间错过 10:00–12:00，当前没有正常到期的调度
+		{"only missed none", "0 * * * *", at(9, 0, 0), at(12, 30, 0), models.CatchUpNone, nil},
+		{"only missed latest", "0 * * * *", at(9, 0, 0), at(12, 30, 0), models.CatchUpLatest, []time.Time{at(12, 0, 0)}},
+		{"only missed all", "0 * * * *", at(9, 0, 0), at(12, 30, 0), models.CatchUpAll,
+			[]time.Time{at(10, 0, 0), at(11, 0, 0), at(12, 0, 0)}},
+		{"only missed all over limit", "0 * * * *", at(6, 0, 0), at(12, 30, 0), models.CatchUpAll,
+			[]time.Time{at(10, 0, 0), at(11, 0, 0), at(12, 0, 0)}},
+		{"unknown policy behaves as latest", "0 * * * *", at(9, 0, 0), at(12, 30, 0), "", []time.Time{at(12, 0, 0)}},
+
+		// 距今不超过两个检查周期的仍视为正常触发
+		{"within two intervals is on time", "* * * * *", at(11, 59, 0), at(12, 0, 59), models.CatchUpNone, []time.Time{at(12, 0, 0)}},
+		{"older than two intervals is missed", "* * * * *", at(11, 59, 0), at(12, 1, 1), models.CatchUpNone, []time.Time{at(12, 1, 0)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			due, total := dueTimes(mustSchedule(t, tc.expr), tc.last, tc.now, s.maxCatchUp+maxOnTimeRuns)
+			task := &models.TransferTask{CatchUp: tc.catchUp}
+			got := s.selectRuns(task, due, total, tc.now)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("selectRuns = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"log"
+
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/repository"
+)
+
+// MetadataService 获取 Meta 模块扫描的表结构
+type MetadataService struct {
+	metadataRepo *repository.MetadataRepository
+}
+
+func NewMetadataService(metadataRepo *repository.MetadataRepository) *MetadataService {
+	return &MetadataService{metadataRepo: metadataRepo}
+}
+
+// GetTable 获取端点对应表的字段信息，未扫描或查询失败时返回 nil
+func (s *MetadataService) GetTable(resourceID, tenantID uint, endpoint models.EndpointConfig) *models.TableMeta {
+	if endpoint.Table == "" {
+		return nil
+	}
+	meta, err := s.metadataRepo.GetTable(resourceID, tenantID, endpoint.Schema, endpoint.Table)
+	if err != nil {
+		log.Printf("Failed to load metadata of %s.%s (resource %d): %v", endpoint.Schema, endpoint.Table, resourceID, err)
+		return nil
+	}
+	return meta
+}
+
+// PrimaryKeys 获取表的主键列，优先使用目标表的扫描结果，其次使用源表
+func (s *MetadataService) PrimaryKeys(tenantID, targetID uint, target models.EndpointConfig, sourceID uint, source models.EndpointConfig) []string {
+	if meta := s.GetTable(targetID, tenantID, target); meta != nil {
+		if keys := meta.PrimaryKeys(); len(keys) > 0 {
+			return keys
+		}
+	}
+	if meta := s.GetTable(sourceID, tenantID, source); meta != nil {
+		return meta.PrimaryKeys()
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/addp/common/authz"
+	commonClient "github.com/addp/common/client"
+	commonModels "github.com/addp/common/models"
+)
+
+// ErrInternalKeyMissing 未配置内部 API Key 时无法获取解密后的连接信息
+var ErrInternalKeyMissing = errors.New("internal API key not configured, cannot load resource credentials")
+
+// ResourceService 通过 System 内部 API 获取资源（连接信息仅在内存中解密）
+type ResourceService struct {
+	systemURL      string
+	internalClient *commonClient.SystemClient
+}
+
+func NewResourceService(systemURL, internalKey string) *ResourceService {
+	// 默认从环境变量读取，便于本地降级
+	if systemURL == "" {
+		systemURL = os.Getenv("SYSTEM_SERVICE_URL")
+		if systemURL == "" {
+			systemURL = "http://localhost:8080"
+		}
+	}
+	if internalKey == "" {
+		internalKey = os.Getenv("INTERNAL_API_KEY")
+	}
+
+	var internalClient *commonClient.SystemClient
+	if internalKey != "" {
+		internalClient = commonClient.NewSystemClientWithInternalKey(systemURL, internalKey)
+	}
+
+	return &ResourceService{
+		systemURL:      systemURL,
+		internalClient: internalClient,
+	}
+}
+
+// GetResource 获取资源详情（tenantID 为 0 时不做租户校验）；
+// userID 不为 0 时要求该用户对资源有 connect 级别的访问权限（由 System 的资源授权决定）
+func (s *ResourceService) GetResource(resourceID, tenantID, userID uint) (*commonModels.Resource, error) {
+	if s.internalClient == nil {
+		return nil, ErrInternalKeyMissing
+	}
+
+	resource, err := s.internalClient.GetResourceForUser(resourceID, userID, authz.AccessConnect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource %d from System API: %w", resourceID, err)
+	}
+	if tenantID > 0 && resource.TenantID != tenantID {
+		return nil, fmt.Errorf("resource %d not found or access denied", resourceID)
+	}
+	if !resource.IsActive {
+		return nil, fmt.Errorf("resource %d is inactive", resourceID)
+	}
+	return resource, nil
+}
@@ -0,0 +1,672 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	commonModels "github.com/addp/common/models"
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/queue"
+	"github.com/addp/transfer/internal/repository"
+	"github.com/addp/transfer/internal/transformer"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrExecutionNotFound  = errors.New("execution not found")
+	ErrTaskRunning        = errors.New("task has an active execution")
+	ErrInvalidTaskState   = errors.New("operation not allowed in current task state")
+	ErrInvalidTaskConfig  = errors.New("invalid task config")
+	ErrQueueNotConfigured = errors.New("task queue not configured")
+	ErrAlreadyScheduled   = errors.New("scheduled run already triggered")
+)
+
+// TaskService 传输任务管理
+type TaskService struct {
+	taskRepo        *repository.TaskRepository
+	executionRepo   *repository.ExecutionRepository
+	checkpointRepo  *repository.CheckpointRepository
+	resourceService *ResourceService
+	metadataService *MetadataService
+	queue           *queue.Queue
+}
+
+func NewTaskService(taskRepo *repository.TaskRepository, executionRepo *repository.ExecutionRepository, checkpointRepo *repository.CheckpointRepository,
+	resourceService *ResourceService, metadataService *MetadataService, q *queue.Queue) *TaskService {
+	return &TaskService{
+		taskRepo:        taskRepo,
+		executionRepo:   executionRepo,
+		checkpointRepo:  checkpointRepo,
+		resourceService: resourceService,
+		metadataService: metadataService,
+		queue:           q,
+	}
+}
+
+// validateEndpoint 校验源端/目标端配置与资源类型匹配
+func validateEndpoint(role string, resource *commonModels.Resource, endpoint models.EndpointConfig) error {
+	kind, _ := connector.KindOf(resource.ResourceType)
+	switch kind {
+	case connector.KindDatabase:
+		if endpoint.Table == "" {
+			return fmt.Errorf("%w: %s.table is required for %s resource", ErrInvalidTaskConfig, role, resource.ResourceType)
+		}
+	case connector.KindObjectStorage:
+		if endpoint.Bucket == "" || endpoint.Path == "" {
+			return fmt.Errorf("%w: %s.bucket and %s.path are required for object storage", ErrInvalidTaskConfig, role, role)
+		}
+		if err := connector.ValidateFileEndpoint(endpoint, role != "source"); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidTaskConfig, role, err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported %s resource type %s", ErrInvalidTaskConfig, role, resource.ResourceType)
+	}
+	return nil
+}
+
+// ParseSchedule 解析标准 5 段 Cron 表达式（支持 @daily 等描述符和 CRON_TZ= 前缀）
+func ParseSchedule(expr string) (cron.Schedule, error) {
+	return cron.ParseStandard(expr)
+}
+
+// applySchedule 校验调度配置，调度表达式变化时从当前时间开始计算下次执行
+func applySchedule(task *models.TransferTask, scheduleChanged bool) error {
+	if task.OverlapPolicy == "" {
+		task.OverlapPolicy = models.OverlapSkip
+	}
+	if task.CatchUp == "" {
+		task.CatchUp = models.CatchUpLatest
+	}
+	if !scheduleChanged {
+		return nil
+	}
+
+	if task.Schedule == "" {
+		task.LastScheduledAt = nil
+		task.NextRunAt = nil
+		return nil
+	}
+
+	schedule, err := ParseSchedule(task.Schedule)
+	if err != nil {
+		return fmt.Errorf("%w: invalid schedule %q: %v", ErrInvalidTaskConfig, task.Schedule, err)
+	}
+	now := time.Now()
+	next := schedule.Next(now)
+	task.LastScheduledAt = &now
+	task.NextRunAt = &next
+	return nil
+}
+
+// validateTask 校验任务配置及 userID 对源/目标资源的访问权限
+func (s *TaskService) validateTask(task *models.TransferTask, userID uint) error {
+	cfg, err := task.ParseConfig()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTaskConfig, err)
+	}
+	switch cfg.WriteMode {
+	case models.WriteModeAppend, models.WriteModeOverwrite, models.WriteModeUpsert:
+	default:
+		return fmt.Errorf("%w: unsupported write_mode %s", ErrInvalidTaskConfig, cfg.WriteMode)
+	}
+	if task.Type == models.TaskTypeSync && (cfg.Sync == nil || strings.TrimSpace(cfg.Sync.WatermarkColumn) == "") {
+		return fmt.Errorf("%w: sync.watermark_column is required for sync task", ErrInvalidTaskConfig)
+	}
+	if cfg.BatchSize < 0 {
+		return fmt.Errorf("%w: batch_size must be positive", ErrInvalidTaskConfig)
+	}
+
+	source, err := s.resourceService.GetResource(task.SourceID, task.TenantID, userID)
+	if err != nil {
+		return err
+	}
+	target, err := s.resourceService.GetResource(task.TargetID, task.TenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateEndpoint("source", source, cfg.Source); err != nil {
+		return err
+	}
+	if err := validateEndpoint("target", target, cfg.Target); err != nil {
+		return err
+	}
+
+	// 增量读取依赖水位列过滤，upsert 依赖主键冲突处理，都只支持数据库
+	if task.Type == models.TaskTypeSync {
+		if kind, _ := connector.KindOf(source.ResourceType); kind != connector.KindDatabase {
+			return fmt.Errorf("%w: sync task requires a database source", ErrInvalidTaskConfig)
+		}
+	}
+	if cfg.WriteMode == models.WriteModeUpsert {
+		if kind, _ := connector.KindOf(target.ResourceType); kind != connector.KindDatabase {
+			return fmt.Errorf("%w: write_mode upsert requires a database target", ErrInvalidTaskConfig)
+		}
+	}
+
+	if err := s.validateErrorHandling(task, cfg.ErrorHandling, userID); err != nil {
+		return err
+	}
+
+	if len(cfg.Transforms) > 0 {
+		// 源表已被 Meta 扫描时按字段列表校验列名，否则只校验步骤定义
+		var fields []string
+		if meta := s.metadataService.GetTable(task.SourceID, task.TenantID, cfg.Source); meta != nil {
+			fields = meta.FieldNames()
+		}
+		if err := transformer.Validate(cfg.Transforms, fields); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTaskConfig, err)
+		}
+	}
+	return nil
+}
+
+// validateErrorHandling 校验错误预算和死信输出位置
+func (s *TaskService) validateErrorHandling(task *models.TransferTask, cfg *models.ErrorHandlingConfig, userID uint) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.MaxErrors < 0 || cfg.MaxErrorPercent < 0 || cfg.MaxErrorPercent > 100 {
+		return fmt.Errorf("%w: max_errors must not be negative and max_error_percent must be within 0-100", ErrInvalidTaskConfig)
+	}
+	if cfg.MaxErrors == 0 && cfg.MaxErrorPercent == 0 {
+		return fmt.Errorf("%w: error_handling requires max_errors or max_error_percent", ErrInvalidTaskConfig)
+	}
+
+	if cfg.DeadLetter == nil {
+		return nil
+	}
+	if cfg.DeadLetter.ResourceID == 0 {
+		return fmt.Errorf("%w: error_handling.dead_letter.resource_id is required", ErrInvalidTaskConfig)
+	}
+	resource, err := s.resourceService.GetResource(cfg.DeadLetter.ResourceID, task.TenantID, userID)
+	if err != nil {
+		return err
+	}
+	return validateEndpoint("dead_letter", resource, cfg.DeadLetter.Endpoint)
+}
+
+func (s *TaskService) Create(req *models.TaskCreateRequest, tenantID, userID uint) (*models.TransferTask, error) {
+	task := &models.TransferTask{
+		TenantID:  tenantID,
+		Name:      req.Name,
+		Type:      req.Type,
+		SourceID:  req.SourceID,
+		TargetID:  req.TargetID,
+		Config:    req.Config,
+		Schedule:  strings.TrimSpace(req.Schedule),
+		Status:    models.TaskStatusPending,
+		CreatedBy: userID,
+
+		OverlapPolicy: req.OverlapPolicy,
+		CatchUp:       req.CatchUp,
+	}
+
+	if err := applySchedule(task, true); err != nil {
+		return nil, err
+	}
+	if err := s.validateTask(task, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.taskRepo.Create(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *TaskService) GetByID(id, tenantID uint) (*models.TransferTask, error) {
+	task, err := s.taskRepo.GetByIDAndTenant(id, tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *TaskService) List(tenantID uint, q models.TaskListQuery) ([]models.TransferTask, int64, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 200 {
+		q.PageSize = 20
+	}
+	return s.taskRepo.List(tenantID, q)
+}
+
+func (s *TaskService) Update(id uint, req *models.TaskUpdateRequest, tenantID, userID uint) (*models.TransferTask, error) {
+	task, err := s.GetByID(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureNoActiveExecution(task.ID); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		task.Name = *req.Name
+	}
+	if req.SourceID != nil {
+		task.SourceID = *req.SourceID
+	}
+	if req.TargetID != nil {
+		task.TargetID = *req.TargetID
+	}
+	if req.Config != nil {
+		task.Config = *req.Config
+	}
+	scheduleChanged := false
+	if req.Schedule != nil {
+		schedule := strings.TrimSpace(*req.Schedule)
+		scheduleChanged = schedule != task.Schedule
+		task.Schedule = schedule
+	}
+	if req.OverlapPolicy != nil {
+		task.OverlapPolicy = *req.OverlapPolicy
+	}
+	if req.CatchUp != nil {
+		task.CatchUp = *req.CatchUp
+	}
+
+	if err := applySchedule(task, scheduleChanged); err != nil {
+		return nil, err
+	}
+	if err := s.validateTask(task, userID); err != nil {
+		return nil, err
+	}
+
+	// 源/目标或传输配置变化后需要重新全量同步
+	if req.SourceID != nil || req.TargetID != nil || req.Config != nil {
+		task.Watermark = ""
+		task.WatermarkUpdatedAt = nil
+	}
+
+	if err := s.taskRepo.Update(task); err != nil {
+		return nil, err
+	}
+
+	// 源/目标或传输配置变化后原断点不再有效
+	if req.SourceID != nil || req.TargetID != nil || req.Config != nil {
+		if err := s.checkpointRepo.DeleteByTask(task.ID); err != nil {
+			return nil, err
+		}
+	}
+	return task, nil
+}
+
+func (s *TaskService) Delete(id, tenantID uint) error {
+	task, err := s.GetByID(id, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureNoActiveExecution(task.ID); err != nil {
+		return err
+	}
+	return s.taskRepo.Delete(task.ID)
+}
+
+func (s *TaskService) ensureNoActiveExecution(taskID uint) error {
+	active, err := s.executionRepo.GetActiveByTask(taskID)
+	if err != nil {
+		return err
+	}
+	if len(active) > 0 {
+		return ErrTaskRunning
+	}
+	return nil
+}
+
+// Run 手动触发任务执行
+func (s *TaskService) Run(ctx context.Context, id, tenantID uint) (*models.TaskExecution, error) {
+	task, err := s.GetByID(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueueExecution(ctx, task, models.TriggerManual)
+}
+
+// Resume 恢复已暂停的任务
+func (s *TaskService) Resume(ctx context.Context, id, tenantID uint) (*models.TaskExecution, error) {
+	task, err := s.GetByID(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != models.TaskStatusPaused {
+		return nil, ErrInvalidTaskState
+	}
+	return s.enqueueExecution(ctx, task, models.TriggerResume)
+}
+
+// enqueueExecution 创建执行记录并放入队列
+func (s *TaskService) enqueueExecution(ctx context.Context, task *models.TransferTask, trigger string) (*models.TaskExecution, error) {
+	if s.queue == nil {
+		return nil, ErrQueueNotConfigured
+	}
+	if err := s.ensureNoActiveExecution(task.ID); err != nil {
+		return nil, err
+	}
+
+	execution := &models.TaskExecution{
+		TaskID:      task.ID,
+		TenantID:    task.TenantID,
+		Status:      models.ExecutionStatusQueued,
+		TriggerType: trigger,
+		Attempt:     1,
+	}
+	if err := s.submit(ctx, execution); err != nil {
+		return nil, err
+	}
+
+	progress := 0.0
+	if err := s.taskRepo.UpdateStatus(task.ID, models.TaskStatusPending, &progress); err != nil {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// submit 写入排队中的执行记录并放入队列
+func (s *TaskService) submit(ctx context.Context, execution *models.TaskExecution) error {
+	if err := s.executionRepo.Create(execution); err != nil {
+		return err
+	}
+
+	if err := s.queue.Enqueue(ctx, execution.ID); err != nil {
+		s.executionRepo.UpdateStatus(execution.ID, models.ExecutionStatusQueued, models.ExecutionStatusFailed)
+		return fmt.Errorf("failed to enqueue execution: %w", err)
+	}
+	return nil
+}
+
+// TriggerScheduled 按计划时间触发任务，上一次执行未结束时按任务的重叠策略处理
+// 同一计划时间只会生成一条执行记录（多实例调度时返回 ErrAlreadyScheduled）
+func (s *TaskService) TriggerScheduled(ctx context.Context, task *models.TransferTask, scheduledAt time.Time) (*models.TaskExecution, error) {
+	if s.queue == nil {
+		return nil, ErrQueueNotConfigured
+	}
+
+	active, err := s.executionRepo.GetActiveByTask(task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	execution := &models.TaskExecution{
+		TaskID:      task.ID,
+		TenantID:    task.TenantID,
+		Status:      models.ExecutionStatusQueued,
+		TriggerType: models.TriggerSchedule,
+		Attempt:     1,
+		ScheduledAt: &scheduledAt,
+	}
+
+	switch resolveOverlap(task.OverlapPolicy, len(active)) {
+	case overlapCancelPrevious:
+		for _, previous := range active {
+			if err := s.stopExecution(&previous, models.StopRequestCancel, models.ExecutionStatusCancelled); err != nil {
+				return nil, err
+			}
+		}
+	case overlapSkip:
+		now := time.Now()
+		execution.Status = models.ExecutionStatusSkipped
+		execution.EndTime = &now
+		execution.ErrorMsg = fmt.Sprintf("previous execution %d is still %s", active[0].ID, active[0].Status)
+		if err := s.createScheduled(execution); err != nil {
+			return nil, err
+		}
+		return execution, nil
+	}
+
+	if err := s.createScheduled(execution); err != nil {
+		return nil, err
+	}
+	if err := s.queue.Enqueue(ctx, execution.ID); err != nil {
+		s.executionRepo.UpdateStatus(execution.ID, models.ExecutionStatusQueued, models.ExecutionStatusFailed)
+		return nil, fmt.Errorf("failed to enqueue execution: %w", err)
+	}
+
+	if len(active) == 0 {
+		progress := 0.0
+		if err := s.taskRepo.UpdateStatus(task.ID, models.TaskStatusPending, &progress); err != nil {
+			return nil, err
+		}
+	}
+	return execution, nil
+}
+
+// 调度触发时本次执行的处理方式
+const (
+	overlapRun            = iota // 没有未结束的执行，直接排队
+	overlapQueue                 // 排队，Worker 会等上一次执行结束后再抢占本次执行
+	overlapCancelPrevious        // 取消未结束的执行后排队
+	overlapSkip                  // 跳过本次调度，记录为 skipped
+)
+
+// resolveOverlap 按重叠策略决定本次调度的处理方式，active 为未结束的执行数；未知策略按 skip 处理
+func resolveOverlap(policy string, active int) int {
+	if active == 0 {
+		return overlapRun
+	}
+	switch policy {
+	case models.OverlapQueue:
+		return overlapQueue
+	case models.OverlapCancelPrevious:
+		return overlapCancelPrevious
+	default:
+		return overlapSkip
+	}
+}
+
+func (s *TaskService) createScheduled(execution *models.TaskExecution) error {
+	if err := s.executionRepo.Create(execution); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyScheduled
+		}
+		return err
+	}
+	return nil
+}
+
+// Pause 暂停任务：排队中的执行直接暂停，运行中的执行通知 Worker 停止
+func (s *TaskService) Pause(id, tenantID uint) error {
+	return s.stop(id, tenantID, models.StopRequestPause, models.ExecutionStatusPaused, models.TaskStatusPaused)
+}
+
+// Cancel 取消任务当前的执行
+func (s *TaskService) Cancel(id, tenantID uint) error {
+	return s.stop(id, tenantID, models.StopRequestCancel, models.ExecutionStatusCancelled, models.TaskStatusCancelled)
+}
+
+func (s *TaskService) stop(id, tenantID uint, request, executionStatus, taskStatus string) error {
+	task, err := s.GetByID(id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	active, err := s.executionRepo.GetActiveByTask(task.ID)
+	if err != nil {
+		return err
+	}
+	if len(active) == 0 && request == models.StopRequestCancel {
+		return ErrInvalidTaskState
+	}
+
+	for _, execution := range active {
+		if err := s.stopExecution(&execution, request, executionStatus); err != nil {
+			return err
+		}
+	}
+
+	return s.taskRepo.UpdateStatus(task.ID, taskStatus, nil)
+}
+
+// stopExecution 排队中的执行直接更新状态，运行中的执行通知 Worker 停止
+func (s *TaskService) stopExecution(execution *models.TaskExecution, request, executionStatus string) error {
+	switch execution.Status {
+	case models.ExecutionStatusQueued:
+		// Worker 取出后会发现状态已变化并跳过
+		_, err := s.executionRepo.UpdateStatus(execution.ID, models.ExecutionStatusQueued, executionStatus)
+		return err
+	case models.ExecutionStatusRunning:
+		return s.executionRepo.RequestStop(execution.ID, request)
+	}
+	return nil
+}
+
+// ListExecutions 获取任务的执行历史
+func (s *TaskService) ListExecutions(taskID, tenantID uint, page, pageSize int) ([]models.TaskExecution, int64, error) {
+	if _, err := s.GetByID(taskID, tenantID); err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 200 {
+		pageSize = 20
+	}
+	return s.executionRepo.ListByTask(taskID, (page-1)*pageSize, pageSize)
+}
+
+func (s *TaskService) GetExecution(id, tenantID uint) (*models.TaskExecution, error) {
+	execution, err := s.executionRepo.GetByIDAndTenant(id, tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExecutionNotFound
+		}
+		return nil, err
+	}
+	return execution, nil
+}
+
+func (s *TaskService) GetExecutionLogs(id, tenantID uint) (*models.ExecutionLogsResponse, error) {
+	execution, err := s.GetExecution(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ExecutionLogsResponse{
+		ExecutionID: execution.ID,
+		Status:      execution.Status,
+		Logs:        execution.Logs,
+	}, nil
+}
+
+// GetExecutionProgress 获取执行进度：运行中使用 Worker 发布的实时快照，否则由执行记录生成
+func (s *TaskService) GetExecutionProgress(ctx context.Context, id, tenantID uint) (*models.ExecutionProgress, error) {
+	execution, err := s.GetExecution(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if execution.Status == models.ExecutionStatusRunning {
+		progress, err := s.queue.GetProgress(ctx, execution.ID)
+		if err != nil {
+			log.Printf("Failed to get progress of execution %d: %v", execution.ID, err)
+		}
+		if progress != nil && !progress.Finished() {
+			return progress, nil
+		}
+	}
+	return models.ProgressFromExecution(execution), nil
+}
+
+// SubscribeExecutionProgress 订阅执行的实时进度，返回的 close 用于取消订阅
+func (s *TaskService) SubscribeExecutionProgress(ctx context.Context, id, tenantID uint) (<-chan *models.ExecutionProgress, func() error, error) {
+	execution, err := s.GetExecution(id, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	updates, closeFn := s.queue.SubscribeProgress(ctx, execution.ID)
+	return updates, closeFn, nil
+}
+
+// RetryExecution 重新执行失败或已取消的执行
+func (s *TaskService) RetryExecution(ctx context.Context, id, tenantID uint) (*models.TaskExecution, error) {
+	execution, err := s.GetExecution(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if execution.Status != models.ExecutionStatusFailed && execution.Status != models.ExecutionStatusCancelled {
+		return nil, ErrInvalidTaskState
+	}
+
+	task, err := s.GetByID(execution.TaskID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueueExecution(ctx, task, models.TriggerRetry)
+}
+
+// ListRunning 获取排队中和运行中的执行
+func (s *TaskService) ListRunning(tenantID uint) ([]models.TaskExecution, error) {
+	return s.executionRepo.ListActive(tenantID)
+}
+
+// GetProgress 获取任务进度及最近一次执行
+func (s *TaskService) GetProgress(id, tenantID uint) (map[string]interface{}, error) {
+	task, err := s.GetByID(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"task_id":  task.ID,
+		"status":   task.Status,
+		"progress": task.Progress,
+	}
+
+	latest, err := s.executionRepo.GetLatestByTask(task.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil {
+		result["execution"] = latest
+	}
+
+	checkpoints, err := s.checkpointRepo.ListByTask(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) > 0 {
+		result["checkpoints"] = checkpoints
+	}
+	return result, nil
+}
+
+// GetStatistics 获取任务统计信息
+func (s *TaskService) GetStatistics(tenantID uint) (*models.TaskStatistics, error) {
+	byStatus, err := s.taskRepo.CountByStatus(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, count := range byStatus {
+		total += count
+	}
+
+	executions, written, err := s.executionRepo.CountByTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	running, err := s.executionRepo.ListActive(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskStatistics{
+		TotalTasks:      total,
+		TasksByStatus:   byStatus,
+		TotalExecutions: executions,
+		RunningCount:    int64(len(running)),
+		RecordsWritten:  written,
+	}, nil
+}
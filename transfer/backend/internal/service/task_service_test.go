@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+func TestResolveOverlap(t *testing.T) {
+	cases := []struct {
+		policy string
+		active int
+		want   int
+	}{
+		{models.OverlapSkip, 0, overlapRun},
+		{models.OverlapQueue, 0, overlapRun},
+		{models.OverlapCancelPrevious, 0, overlapRun},
+		{models.OverlapSkip, 1, overlapSkip},
+		{models.OverlapQueue, 1, overlapQueue},
+		{models.OverlapQueue, 2, overlapQueue},
+		{models.OverlapCancelPrevious, 2, overlapCancelPrevious},
+		{"", 1, overlapSkip},
+		{"unknown", 1, overlapSkip},
+	}
+	for _, tc := range cases {
+		if got := resolveOverlap(tc.policy, tc.active); got != tc.want {
+			t.Errorf("resolveOverlap(%q, %d) = %d, want %d", tc.policy, tc.active, got, tc.want)
+		}
+	}
+}
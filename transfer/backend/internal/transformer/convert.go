@@ -0,0 +1,159 @@
+package transformer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts 字符串转时间时依次尝试的格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// converter 返回类型转换函数及转换后的列类型
+func converter(to string) (func(interface{}) (interface{}, error), string, error) {
+	switch strings.ToLower(to) {
+	case "string":
+		return func(v interface{}) (interface{}, error) {
+			if v == nil {
+				return nil, nil
+			}
+			return toString(v), nil
+		}, "TEXT", nil
+	case "int":
+		return toInt, "BIGINT", nil
+	case "float":
+		return toFloat, "DOUBLE", nil
+	case "bool":
+		return toBool, "BOOLEAN", nil
+	case "timestamp":
+		return toTime, "TIMESTAMP", nil
+	case "":
+		return nil, "", fmt.Errorf("to is required")
+	default:
+		return nil, "", fmt.Errorf("unsupported cast type %q", to)
+	}
+}
+
+// toString 与文件格式的编码一致：二进制为 base64，时间为 RFC3339
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func toInt(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case uint64:
+		if value > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows int", value)
+		}
+		return int64(value), nil
+	case float64:
+		if value != math.Trunc(value) {
+			return nil, fmt.Errorf("%v is not an integer", value)
+		}
+		return int64(value), nil
+	case bool:
+		if value {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	default:
+		s := strings.TrimSpace(toString(v))
+		if s == "" {
+			return nil, nil
+		}
+		return strconv.ParseInt(s, 10, 64)
+	}
+}
+
+func toFloat(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return value, nil
+	case float32:
+		return float64(value), nil
+	case int64:
+		return float64(value), nil
+	case int:
+		return float64(value), nil
+	default:
+		s := strings.TrimSpace(toString(v))
+		if s == "" {
+			return nil, nil
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+}
+
+func toBool(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return value, nil
+	case int64:
+		return value != 0, nil
+	case int:
+		return value != 0, nil
+	case float64:
+		return value != 0, nil
+	default:
+		s := strings.TrimSpace(toString(v))
+		if s == "" {
+			return nil, nil
+		}
+		return strconv.ParseBool(s)
+	}
+}
+
+// toTime 整数按 Unix 秒转换
+func toTime(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return value, nil
+	case int64:
+		return time.Unix(value, 0), nil
+	case int:
+		return time.Unix(int64(value), 0), nil
+	default:
+		s := strings.TrimSpace(toString(v))
+		if s == "" {
+			return nil, nil
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot parse %q as timestamp", s)
+	}
+}
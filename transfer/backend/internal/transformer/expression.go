@@ -0,0 +1,70 @@
+package transformer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// expression 编译后的表达式，以当前行的列名作为变量
+//
+// 语法见 https://expr-lang.org ，例如 amount * 100、status != "deleted"、upper(name)。
+type expression struct {
+	program *vm.Program
+	names   []string
+}
+
+// compileExpression 编译表达式并校验引用的列存在
+func (c *compiler) compileExpression(source string) (*expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, errors.New("expression is required")
+	}
+	program, err := expr.Compile(source, expr.AllowUndefinedVariables())
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %v", err)
+	}
+
+	names := c.names()
+	if c.strict {
+		known := make(map[string]bool, len(names))
+		for _, name := range names {
+			known[name] = true
+		}
+		collector := &identifierCollector{declared: map[string]bool{}}
+		node := program.Node()
+		ast.Walk(&node, collector)
+		for _, name := range collector.names {
+			if !known[name] && !collector.declared[name] {
+				return nil, fmt.Errorf("expression references unknown column %s", name)
+			}
+		}
+	}
+	return &expression{program: program, names: names}, nil
+}
+
+func (e *expression) eval(row []interface{}) (interface{}, error) {
+	env := make(map[string]interface{}, len(e.names))
+	for i, name := range e.names {
+		env[name] = row[i]
+	}
+	return expr.Run(e.program, env)
+}
+
+// identifierCollector 收集表达式引用的变量名（排除 let 声明的变量）
+type identifierCollector struct {
+	names    []string
+	declared map[string]bool
+}
+
+func (v *identifierCollector) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		v.names = append(v.names, n.Value)
+	case *ast.VariableDeclaratorNode:
+		v.declared[n.Name] = true
+	}
+}
@@ -0,0 +1,152 @@
+package transformer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+)
+
+var testColumns = []connector.Column{{Name: "id"}, {Name: "amount"}, {Name: "name"}}
+
+func TestExpressionCompileErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		step models.TransformStep
+		want string
+	}{
+		{"syntax error", models.TransformStep{Type: models.TransformCompute, Column: "total", Expression: "amount *"}, "invalid expression"},
+		{"unbalanced parenthesis", models.TransformStep{Type: models.TransformFilter, Expression: "(amount > 1"}, "invalid expression"},
+		{"empty expression", models.TransformStep{Type: models.TransformFilter, Expression: "  "}, "expression is required"},
+		{"unknown column", models.TransformStep{Type: models.TransformCompute, Column: "total", Expression: "price * 2"}, "unknown column price"},
+		{"missing target column", models.TransformStep{Type: models.TransformCompute, Expression: "amount * 2"}, "column is required"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New([]models.TransformStep{tc.step}, testColumns)
+			if !errors.Is(err, ErrInvalidStep) {
+				t.Fatalf("err = %v, want ErrInvalidStep", err)
+			}
+			if !strings.Contains(err.Error(), "transforms[0]") || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %q, want step index and %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestExpressionValidateWithoutFields(t *testing.T) {
+	// 元数据未扫描时不检查列名，但仍检查语法
+	if err := Validate([]models.TransformStep{{Type: models.TransformFilter, Expression: "price > 0"}}, nil); err != nil {
+		t.Fatalf("Validate without fields: %v", err)
+	}
+	if err := Validate([]models.TransformStep{{Type: models.TransformFilter, Expression: "price >"}}, nil); !errors.Is(err, ErrInvalidStep) {
+		t.Fatalf("Validate syntax error = %v, want ErrInvalidStep", err)
+	}
+	// let 声明的变量不是列
+	if err := Validate([]models.TransformStep{{Type: models.TransformCompute, Column: "x", Expression: "let d = amount * 2; d + 1"}}, []string{"amount"}); err != nil {
+		t.Fatalf("Validate let: %v", err)
+	}
+}
+
+func TestExpressionRuntimeErrors(t *testing.T) {
+	pipeline, err := New([]models.TransformStep{
+		{Type: models.TransformCompute, Column: "doubled", Expression: "amount * 2"},
+	}, testColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := &connector.Batch{Columns: testColumns, Rows: [][]interface{}{
+		{int64(1), int64(10), "a"},
+		{int64(2), "oops", "b"},
+		{int64(3), 1.5, "c"},
+	}}
+
+	// 未配置错误处理时整批失败，错误指出行号
+	if _, err := pipeline.Apply(batch); err == nil || !strings.Contains(err.Error(), "row 2: compute doubled") {
+		t.Fatalf("Apply err = %v, want row 2 compute error", err)
+	}
+
+	// 逐行处理时失败的行与原因一起返回，其余行照常输出
+	out, rowErrors := pipeline.ApplyEach(batch)
+	if len(rowErrors) != 1 || rowErrors[0].Row != 1 || !strings.Contains(rowErrors[0].Err.Error(), "compute doubled") {
+		t.Fatalf("rowErrors = %v, want one error for row index 1", rowErrors)
+	}
+	want := [][]interface{}{
+		{int64(1), int64(10), "a", 20},
+		{int64(3), 1.5, "c", 3.0},
+	}
+	if !reflect.DeepEqual(out.Rows, want) {
+		t.Fatalf("rows = %#v, want %#v", out.Rows, want)
+	}
+	// 源批次不被修改，拒绝的行按原值写入死信
+	if !reflect.DeepEqual(batch.Rows[1], []interface{}{int64(2), "oops", "b"}) {
+		t.Fatalf("source row modified: %#v", batch.Rows[1])
+	}
+}
+
+func TestFilterNonBoolResult(t *testing.T) {
+	pipeline, err := New([]models.TransformStep{{Type: models.TransformFilter, Expression: "amount"}}, testColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pipeline.ApplyRow([]interface{}{int64(1), int64(10), "a"})
+	if err == nil || !strings.Contains(err.Error(), "expected bool") {
+		t.Fatalf("err = %v, want non-bool filter error", err)
+	}
+}
+
+func TestExpressionNilField(t *testing.T) {
+	cases := []struct {
+		name       string
+		step       models.TransformStep
+		wantValue  interface{}
+		wantKeep   bool
+		wantErrSub string
+	}{
+		{"coalesce", models.TransformStep{Type: models.TransformCompute, Column: "amount", Expression: "amount ?? 0"}, 0, true, ""},
+		{"nil comparison", models.TransformStep{Type: models.TransformCompute, Column: "missing", Expression: "amount == nil"}, true, true, ""},
+		{"filter keeps nil", models.TransformStep{Type: models.TransformFilter, Expression: "amount == nil || amount > 0"}, nil, true, ""},
+		{"filter drops nil", models.TransformStep{Type: models.TransformFilter, Expression: "amount != nil"}, nil, false, ""},
+		{"arithmetic on nil", models.TransformStep{Type: models.TransformCompute, Column: "doubled", Expression: "amount * 2"}, nil, false, "compute doubled"},
+		{"function on nil", models.TransformStep{Type: models.TransformCompute, Column: "upper", Expression: "upper(name)"}, nil, false, "compute upper"},
+		{"filter comparing nil", models.TransformStep{Type: models.TransformFilter, Expression: "amount > 0"}, nil, false, "filter"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pipeline, err := New([]models.TransformStep{tc.step}, testColumns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row := []interface{}{int64(1), nil, nil}
+			result, keep, err := pipeline.ApplyRow(row)
+			if tc.wantErrSub != "" {
+				// 空值参与运算是行级错误，不会 panic，由调用方拒绝该行
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSub) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErrSub)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keep != tc.wantKeep {
+				t.Fatalf("keep = %v, want %v", keep, tc.wantKeep)
+			}
+			if keep && tc.step.Type == models.TransformCompute {
+				index := len(result) - 1
+				if tc.step.Column == "amount" {
+					index = 1
+				}
+				if !reflect.DeepEqual(result[index], tc.wantValue) {
+					t.Fatalf("%s = %#v, want %#v", tc.step.Column, result[index], tc.wantValue)
+				}
+			}
+			if row[1] != nil {
+				t.Fatalf("input row modified: %#v", row)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package transformer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+)
+
+// ErrInvalidStep 转换步骤定义不合法
+var ErrInvalidStep = errors.New("invalid transform step")
+
+// stepFunc 对一行执行转换，返回 false 表示该行被过滤
+type stepFunc func(row []interface{}) ([]interface{}, bool, error)
+
+// Pipeline 编译后的转换流程，按步骤顺序逐行处理批次
+type Pipeline struct {
+	steps   []stepFunc
+	columns []connector.Column
+}
+
+// New 根据源端列编译转换步骤
+func New(steps []models.TransformStep, columns []connector.Column) (*Pipeline, error) {
+	return compile(steps, columns, true)
+}
+
+// Validate 按源端字段列表校验转换步骤；fields 为空（元数据未扫描）时只校验步骤参数和表达式语法
+func Validate(steps []models.TransformStep, fields []string) error {
+	columns := make([]connector.Column, len(fields))
+	for i, name := range fields {
+		columns[i] = connector.Column{Name: name}
+	}
+	_, err := compile(steps, columns, len(fields) > 0)
+	return err
+}
+
+// Columns 返回转换后的列
+func (p *Pipeline) Columns() []connector.Column {
+	return p.columns
+}
+
+// Apply 转换批次，被过滤的行不会输出
+func (p *Pipeline) Apply(batch *connector.Batch) (*connector.Batch, error) {
+	out := &connector.Batch{
+		Columns:  p.columns,
+		Rows:     make([][]interface{}, 0, batch.Len()),
+		Position: batch.Position,
+	}
+	for i, row := range batch.Rows {
+		result, keep, err := p.ApplyRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		if keep {
+			out.Rows = append(out.Rows, result)
+		}
+	}
+	return out, nil
+}
+
+// ApplyEach 转换批次，转换失败的行不会中断处理，而是与原因一起返回
+func (p *Pipeline) ApplyEach(batch *connector.Batch) (*connector.Batch, []connector.RowError) {
+	out := &connector.Batch{
+		Columns:  p.columns,
+		Rows:     make([][]interface{}, 0, batch.Len()),
+		Position: batch.Position,
+	}
+	var rowErrors []connector.RowError
+	for i, row := range batch.Rows {
+		result, keep, err := p.ApplyRow(row)
+		if err != nil {
+			rowErrors = append(rowErrors, connector.RowError{Row: i, Err: err})
+			continue
+		}
+		if keep {
+			out.Rows = append(out.Rows, result)
+		}
+	}
+	return out, rowErrors
+}
+
+// ApplyRow 转换单行，不修改传入的行
+func (p *Pipeline) ApplyRow(row []interface{}) ([]interface{}, bool, error) {
+	result := append(make([]interface{}, 0, len(p.columns)), row...)
+	for _, step := range p.steps {
+		var keep bool
+		var err error
+		if result, keep, err = step(result); err != nil || !keep {
+			return nil, false, err
+		}
+	}
+	return result, true, nil
+}
+
+// compiler 编译期间跟踪每一步之后的列布局
+type compiler struct {
+	columns []connector.Column
+	strict  bool
+}
+
+func compile(steps []models.TransformStep, columns []connector.Column, strict bool) (*Pipeline, error) {
+	c := &compiler{columns: append([]connector.Column(nil), columns...), strict: strict}
+	pipeline := &Pipeline{}
+	for i, step := range steps {
+		fn, err := c.compileStep(step)
+		if err != nil {
+			return nil, fmt.Errorf("%w: transforms[%d] (%s): %v", ErrInvalidStep, i, step.Type, err)
+		}
+		if fn != nil {
+			pipeline.steps = append(pipeline.steps, fn)
+		}
+	}
+	pipeline.columns = c.columns
+	return pipeline, nil
+}
+
+// index 查找列位置；非严格模式下（源端字段未知）视为存在
+func (c *compiler) index(name string) (int, error) {
+	if name == "" {
+		return -1, errors.New("column is required")
+	}
+	for i, col := range c.columns {
+		if col.Name == name {
+			return i, nil
+		}
+	}
+	if !c.strict {
+		c.columns = append(c.columns, connector.Column{Name: name})
+		return len(c.columns) - 1, nil
+	}
+	return -1, fmt.Errorf("column %s not found", name)
+}
+
+func (c *compiler) exists(name string) bool {
+	for _, col := range c.columns {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiler) names() []string {
+	names := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+func (c *compiler) compileStep(step models.TransformStep) (stepFunc, error) {
+	switch step.Type {
+	case models.TransformRename:
+		return c.rename(step)
+	case models.TransformCast:
+		return c.cast(step)
+	case models.TransformDefault:
+		return c.defaultFill(step)
+	case models.TransformReplace:
+		return c.replace(step)
+	case models.TransformTrim:
+		return c.trim(step)
+	case models.TransformHash:
+		return c.hash(step)
+	case models.TransformMask:
+		return c.mask(step)
+	case models.TransformDrop:
+		return c.drop(step)
+	case models.TransformCompute:
+		return c.compute(step)
+	case models.TransformFilter:
+		return c.filter(step)
+	default:
+		return nil, fmt.Errorf("unsupported type %q", step.Type)
+	}
+}
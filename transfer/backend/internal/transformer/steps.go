@@ -0,0 +1,251 @@
+package transformer
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+)
+
+// rename 只改变列名，不需要逐行处理
+func (c *compiler) rename(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	if step.To == "" {
+		return nil, errors.New("to is required")
+	}
+	if step.To != step.Column && c.exists(step.To) {
+		return nil, fmt.Errorf("column %s already exists", step.To)
+	}
+	c.columns[index].Name = step.To
+	return nil, nil
+}
+
+func (c *compiler) cast(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	convert, databaseType, err := converter(step.To)
+	if err != nil {
+		return nil, err
+	}
+	c.columns[index].DatabaseType = databaseType
+	c.columns[index].Binary = false
+
+	column, to := step.Column, step.To
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		value, err := convert(row[index])
+		if err != nil {
+			return nil, false, fmt.Errorf("cast %s to %s: %w", column, to, err)
+		}
+		row[index] = value
+		return row, true, nil
+	}, nil
+}
+
+func (c *compiler) defaultFill(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	if step.Value == nil {
+		return nil, errors.New("value is required")
+	}
+
+	value := step.Value
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		if row[index] == nil {
+			row[index] = value
+		}
+		return row, true, nil
+	}, nil
+}
+
+func (c *compiler) replace(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	if step.Pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	re, err := regexp.Compile(step.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	c.columns[index].Binary = false
+
+	replacement := step.Replacement
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		if row[index] != nil {
+			row[index] = re.ReplaceAllString(toString(row[index]), replacement)
+		}
+		return row, true, nil
+	}, nil
+}
+
+func (c *compiler) trim(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		if s, ok := row[index].(string); ok {
+			row[index] = strings.TrimSpace(s)
+		}
+		return row, true, nil
+	}, nil
+}
+
+// hash 输出十六进制摘要，salt 拼接在原值之前
+func (c *compiler) hash(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(step.Algorithm) {
+	case "", "sha256":
+		newHash = sha256.New
+	case "sha1":
+		newHash = sha1.New
+	case "md5":
+		newHash = md5.New
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", step.Algorithm)
+	}
+	c.columns[index].DatabaseType = "TEXT"
+	c.columns[index].Binary = false
+
+	salt := []byte(step.Salt)
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		if row[index] == nil {
+			return row, true, nil
+		}
+		h := newHash()
+		h.Write(salt)
+		if b, ok := row[index].([]byte); ok {
+			h.Write(b)
+		} else {
+			h.Write([]byte(toString(row[index])))
+		}
+		row[index] = hex.EncodeToString(h.Sum(nil))
+		return row, true, nil
+	}, nil
+}
+
+// mask 保留首尾指定个数的字符，长度不足时全部替换
+func (c *compiler) mask(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	if step.KeepPrefix < 0 || step.KeepSuffix < 0 {
+		return nil, errors.New("keep_prefix and keep_suffix must not be negative")
+	}
+	maskChar := "*"
+	if step.MaskChar != "" {
+		if utf8.RuneCountInString(step.MaskChar) != 1 {
+			return nil, errors.New("mask_char must be a single character")
+		}
+		maskChar = step.MaskChar
+	}
+	c.columns[index].Binary = false
+
+	prefix, suffix := step.KeepPrefix, step.KeepSuffix
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		if row[index] == nil {
+			return row, true, nil
+		}
+		runes := []rune(toString(row[index]))
+		if len(runes) <= prefix+suffix {
+			row[index] = strings.Repeat(maskChar, len(runes))
+		} else {
+			row[index] = string(runes[:prefix]) +
+				strings.Repeat(maskChar, len(runes)-prefix-suffix) +
+				string(runes[len(runes)-suffix:])
+		}
+		return row, true, nil
+	}, nil
+}
+
+func (c *compiler) drop(step models.TransformStep) (stepFunc, error) {
+	index, err := c.index(step.Column)
+	if err != nil {
+		return nil, err
+	}
+	c.columns = append(c.columns[:index:index], c.columns[index+1:]...)
+	if c.strict && len(c.columns) == 0 {
+		return nil, errors.New("cannot drop the last column")
+	}
+
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		return append(row[:index], row[index+1:]...), true, nil
+	}, nil
+}
+
+// compute 按表达式计算列值，列不存在时追加到末尾
+func (c *compiler) compute(step models.TransformStep) (stepFunc, error) {
+	if step.Column == "" {
+		return nil, errors.New("column is required")
+	}
+	expression, err := c.compileExpression(step.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, col := range c.columns {
+		if col.Name == step.Column {
+			index = i
+		}
+	}
+	if index < 0 {
+		c.columns = append(c.columns, connector.Column{Name: step.Column})
+	} else {
+		c.columns[index] = connector.Column{Name: step.Column}
+	}
+
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		value, err := expression.eval(row)
+		if err != nil {
+			return nil, false, fmt.Errorf("compute %s: %w", step.Column, err)
+		}
+		if index < 0 {
+			return append(row, value), true, nil
+		}
+		row[index] = value
+		return row, true, nil
+	}, nil
+}
+
+func (c *compiler) filter(step models.TransformStep) (stepFunc, error) {
+	expression, err := c.compileExpression(step.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return func(row []interface{}) ([]interface{}, bool, error) {
+		value, err := expression.eval(row)
+		if err != nil {
+			return nil, false, fmt.Errorf("filter: %w", err)
+		}
+		keep, ok := value.(bool)
+		if !ok {
+			return nil, false, fmt.Errorf("filter expression returned %T, expected bool", value)
+		}
+		return row, keep, nil
+	}, nil
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+)
+
+// ErrErrorBudgetExceeded 拒绝的行数超过任务的错误预算，执行失败且不自动重试
+var ErrErrorBudgetExceeded = errors.New("error budget exceeded")
+
+// minRowsForPercent 读取行数达到该值后才在执行过程中按百分比检查预算，避免开头的少量失败直接中止执行
+const minRowsForPercent = 1000
+
+// 行被拒绝的阶段
+const (
+	stageTransform = "transform"
+	stageWrite     = "write"
+)
+
+// deadLetterColumns 死信记录的列，原始行以 JSON 保存，不同任务的死信可以写入同一张表
+var deadLetterColumns = []connector.Column{
+	{Name: "task_id", DatabaseType: "BIGINT"},
+	{Name: "execution_id", DatabaseType: "BIGINT"},
+	{Name: "stage", DatabaseType: "VARCHAR"},
+	{Name: "error", DatabaseType: "TEXT"},
+	{Name: "row_data", DatabaseType: "JSON"},
+	{Name: "rejected_at", DatabaseType: "TIMESTAMPTZ"},
+}
+
+// rejector 处理被拒绝的行：写入死信、累计计数并检查错误预算
+type rejector struct {
+	cfg       *models.ErrorHandlingConfig
+	task      *models.TransferTask
+	execution *models.TaskExecution
+	stats     *Stats
+	logf      Logger
+	sink      connector.Connector // 死信输出，未配置时只计数
+	sinkName  string
+	writer    connector.Writer // 死信表的写入器，首次拒绝时打开
+}
+
+// newRejector 未配置错误处理时返回 nil，任意一行失败即整个执行失败
+func (e *Executor) newRejector(task *models.TransferTask, execution *models.TaskExecution, cfg *models.TaskConfig, stats *Stats, logf Logger) (*rejector, error) {
+	if cfg.ErrorHandling == nil {
+		return nil, nil
+	}
+	r := &rejector{cfg: cfg.ErrorHandling, task: task, execution: execution, stats: stats, logf: logf}
+	if deadLetter := cfg.ErrorHandling.DeadLetter; deadLetter != nil {
+		sink, name, err := e.openConnector(task, deadLetter.ResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("dead letter: %w", err)
+		}
+		r.sink, r.sinkName = sink, name
+	}
+	return r, nil
+}
+
+// reject 记录批次中失败的行
+func (r *rejector) reject(ctx context.Context, stage string, batch *connector.Batch, rowErrors []connector.RowError) error {
+	if len(rowErrors) == 0 {
+		return nil
+	}
+
+	if r.sink != nil {
+		records := &connector.Batch{Columns: deadLetterColumns, Rows: make([][]interface{}, 0, len(rowErrors))}
+		now := time.Now()
+		for _, rowError := range rowErrors {
+			records.Rows = append(records.Rows, []interface{}{
+				int64(r.task.ID), int64(r.execution.ID), stage, rowError.Err.Error(),
+				rowJSON(batch.Columns, batch.Rows[rowError.Row]), now,
+			})
+		}
+		if err := r.writeDeadLetters(ctx, stage, records); err != nil {
+			return fmt.Errorf("failed to write dead letters to %s: %w", r.sinkName, err)
+		}
+	}
+
+	rejected := r.stats.RecordsRejected.Add(int64(len(rowErrors)))
+	transferRows.WithLabelValues(r.task.Type, "rejected").Add(float64(len(rowErrors)))
+	r.logf("%d row(s) rejected at %s stage (total %d): %v", len(rowErrors), stage, rejected, rowErrors[0].Err)
+	return r.checkBudget(false)
+}
+
+// writeDeadLetters 数据库表追加写入；对象存储不支持追加，每个批次写入一个对象，
+// 对象名由已读取行数决定，续传重复处理同一批次时覆盖同一个对象
+func (r *rejector) writeDeadLetters(ctx context.Context, stage string, records *connector.Batch) error {
+	endpoint := r.cfg.DeadLetter.Endpoint
+	if r.sink.Kind() == connector.KindObjectStorage {
+		format := endpoint.Format
+		if format == "" {
+			format = connector.FormatCSV
+		}
+		endpoint.Partition = nil
+		endpoint.Path = fmt.Sprintf("%s/task_%d/execution_%d/%s_%012d.%s", strings.TrimRight(endpoint.Path, "/"),
+			r.task.ID, r.execution.ID, stage, r.stats.RecordsRead.Load(), format)
+
+		writer, err := r.sink.OpenWriter(ctx, endpoint, deadLetterColumns, connector.WriteOptions{WriteMode: models.WriteModeOverwrite})
+		if err != nil {
+			return err
+		}
+		defer writer.Close()
+		if err := writer.Write(ctx, records); err != nil {
+			return err
+		}
+		return writer.Commit(ctx)
+	}
+
+	if r.writer == nil {
+		writer, err := r.sink.OpenWriter(ctx, endpoint, deadLetterColumns, connector.WriteOptions{WriteMode: models.WriteModeAppend})
+		if err != nil {
+			return err
+		}
+		r.writer = writer
+	}
+	return r.writer.Write(ctx, records)
+}
+
+// checkBudget 检查拒绝行数是否超过预算，final 表示执行已读完全部数据
+func (r *rejector) checkBudget(final bool) error {
+	rejected := r.stats.RecordsRejected.Load()
+	if rejected == 0 {
+		return nil
+	}
+	if limit := r.cfg.MaxErrors; limit > 0 && rejected > limit {
+		return fmt.Errorf("%w: %d rows rejected, max_errors=%d", ErrErrorBudgetExceeded, rejected, limit)
+	}
+
+	read := r.stats.RecordsRead.Load()
+	if limit := r.cfg.MaxErrorPercent; limit > 0 && read > 0 && (final || read >= minRowsForPercent) {
+		if percent := float64(rejected) * 100 / float64(read); percent > limit {
+			return fmt.Errorf("%w: %d of %d rows rejected (%.2f%%), max_error_percent=%g",
+				ErrErrorBudgetExceeded, rejected, read, percent, limit)
+		}
+	}
+	return nil
+}
+
+func (r *rejector) close() {
+	if r.writer != nil {
+		r.writer.Close()
+	}
+	if r.sink != nil {
+		r.sink.Close()
+	}
+}
+
+// rowJSON 将行按列名序列化为 JSON 文本
+func rowJSON(columns []connector.Column, row []interface{}) string {
+	data := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		if i < len(row) {
+			data[col.Name] = row[i]
+		}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		// 无法序列化的值（如 NaN）按文本保存
+		for name, value := range data {
+			data[name] = fmt.Sprint(value)
+		}
+		raw, _ = json.Marshal(data)
+	}
+	return string(raw)
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/transformer"
+)
+
+// recordingSink 记录写入的死信的数据库连接器
+type recordingSink struct {
+	endpoint models.EndpointConfig
+	opts     connector.WriteOptions
+	batches  []*connector.Batch
+	opened   int
+}
+
+func (s *recordingSink) Kind() string { return connector.KindDatabase }
+
+func (s *recordingSink) OpenReader(ctx context.Context, endpoint models.EndpointConfig, opts connector.ReadOptions) (connector.Reader, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *recordingSink) OpenWriter(ctx context.Context, endpoint models.EndpointConfig, columns []connector.Column, opts connector.WriteOptions) (connector.Writer, error) {
+	s.endpoint, s.opts = endpoint, opts
+	s.opened++
+	return &recordingWriter{sink: s}, nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+type recordingWriter struct {
+	sink *recordingSink
+}
+
+func (w *recordingWriter) Write(ctx context.Context, batch *connector.Batch) error {
+	w.sink.batches = append(w.sink.batches, batch)
+	return nil
+}
+
+func (w *recordingWriter) State() (*connector.WriterState, bool) { return nil, true }
+func (w *recordingWriter) Commit(ctx context.Context) error      { return nil }
+func (w *recordingWriter) Close() error                          { return nil }
+
+func newTestRejector(cfg *models.ErrorHandlingConfig, sink connector.Connector) (*rejector, *Stats) {
+	stats := &Stats{}
+	r := &rejector{
+		cfg:       cfg,
+		task:      &models.TransferTask{ID: 7, Type: models.TaskTypeImport},
+		execution: &models.TaskExecution{ID: 11},
+		stats:     stats,
+		logf:      func(string, ...interface{}) {},
+	}
+	if sink != nil {
+		r.sink, r.sinkName = sink, "dead-letter"
+	}
+	return r, stats
+}
+
+// TestTransformRuntimeErrorRejected 表达式运行时错误的行写入死信，其余行照常输出
+func TestTransformRuntimeErrorRejected(t *testing.T) {
+	columns := []connector.Column{{Name: "id"}, {Name: "amount"}}
+	pipeline, err := transformer.New([]models.TransformStep{
+		{Type: models.TransformCompute, Column: "doubled", Expression: "amount * 2"},
+	}, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &connector.Batch{Columns: columns, Rows: [][]interface{}{
+		{int64(1), int64(10)},
+		{int64(2), nil},
+		{int64(3), "oops"},
+		{int64(4), int64(5)},
+	}}
+
+	sink := &recordingSink{}
+	deadLetter := &models.DeadLetterConfig{ResourceID: 1, Endpoint: models.EndpointConfig{Table: "dead_letters"}}
+	rej, stats := newTestRejector(&models.ErrorHandlingConfig{MaxErrors: 10, DeadLetter: deadLetter}, sink)
+	stats.RecordsRead.Store(int64(source.Len()))
+
+	out, rowErrors := pipeline.ApplyEach(source)
+	if err := rej.reject(context.Background(), stageTransform, source, rowErrors); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	if out.Len() != 2 || out.Rows[0][0] != int64(1) || out.Rows[1][0] != int64(4) {
+		t.Fatalf("transformed rows = %#v, want ids 1 and 4", out.Rows)
+	}
+	if got := stats.RecordsRejected.Load(); got != 2 {
+		t.Fatalf("RecordsRejected = %d, want 2", got)
+	}
+	if sink.opened != 1 || sink.endpoint.Table != "dead_letters" || sink.opts.WriteMode != models.WriteModeAppend {
+		t.Fatalf("dead letter writer opened %d time(s) with %+v %+v", sink.opened, sink.endpoint, sink.opts)
+	}
+	if len(sink.batches) != 1 || sink.batches[0].Len() != 2 {
+		t.Fatalf("dead letter batches = %#v, want one batch of 2 rows", sink.batches)
+	}
+
+	wantRows := []string{`{"amount":null,"id":2}`, `{"amount":"oops","id":3}`}
+	for i, record := range sink.batches[0].Rows {
+		if record[0] != int64(7) || record[1] != int64(11) || record[2] != stageTransform {
+			t.Fatalf("dead letter %d = %#v", i, record[:3])
+		}
+		if message := record[3].(string); !strings.Contains(message, "compute doubled") {
+			t.Fatalf("dead letter %d error = %q", i, message)
+		}
+		if record[4] != wantRows[i] {
+			t.Fatalf("dead letter %d row_data = %s, want %s", i, record[4], wantRows[i])
+		}
+	}
+
+	// 同一执行后续的拒绝复用已打开的写入器
+	if err := rej.reject(context.Background(), stageTransform, source, rowErrors); err != nil {
+		t.Fatal(err)
+	}
+	if sink.opened != 1 || len(sink.batches) != 2 {
+		t.Fatalf("opened = %d, batches = %d", sink.opened, len(sink.batches))
+	}
+}
+
+func TestRejectErrorBudget(t *testing.T) {
+	columns := []connector.Column{{Name: "amount"}}
+	pipeline, err := transformer.New([]models.TransformStep{
+		{Type: models.TransformFilter, Expression: "amount > 0"},
+	}, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &connector.Batch{Columns: columns, Rows: [][]interface{}{{int64(1)}, {nil}, {"x"}}}
+
+	// 未配置死信时只计数
+	rej, stats := newTestRejector(&models.ErrorHandlingConfig{MaxErrors: 1}, nil)
+	stats.RecordsRead.Store(int64(source.Len()))
+	_, rowErrors := pipeline.ApplyEach(source)
+	if len(rowErrors) != 2 {
+		t.Fatalf("rowErrors = %v, want 2", rowErrors)
+	}
+	err = rej.reject(context.Background(), stageTransform, source, rowErrors)
+	if !errors.Is(err, ErrErrorBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrErrorBudgetExceeded", err)
+	}
+
+	// 按百分比的预算在读完前需要足够的样本，读完时按最终比例检查
+	rej, stats = newTestRejector(&models.ErrorHandlingConfig{MaxErrorPercent: 50}, nil)
+	stats.RecordsRead.Store(int64(source.Len()))
+	if err := rej.reject(context.Background(), stageTransform, source, rowErrors); err != nil {
+		t.Fatalf("percent budget checked before enough rows were read: %v", err)
+	}
+	if err := rej.checkBudget(true); !errors.Is(err, ErrErrorBudgetExceeded) {
+		t.Fatalf("final check err = %v, want ErrErrorBudgetExceeded", err)
+	}
+}
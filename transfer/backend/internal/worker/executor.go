@@ -0,0 +1,389 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/addp/transfer/internal/connector"
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/repository"
+	"github.com/addp/transfer/internal/service"
+	"github.com/addp/transfer/internal/transformer"
+)
+
+// Logger 执行日志输出
+type Logger func(format string, args ...interface{})
+
+// Executor 负责执行单个传输任务
+type Executor struct {
+	resourceService *service.ResourceService
+	metadataService *service.MetadataService
+	taskRepo        *repository.TaskRepository
+	checkpointRepo  *repository.CheckpointRepository
+	batchSize       int
+}
+
+func NewExecutor(resourceService *service.ResourceService, metadataService *service.MetadataService, taskRepo *repository.TaskRepository,
+	checkpointRepo *repository.CheckpointRepository, batchSize int) *Executor {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &Executor{
+		resourceService: resourceService,
+		metadataService: metadataService,
+		taskRepo:        taskRepo,
+		checkpointRepo:  checkpointRepo,
+		batchSize:       batchSize,
+	}
+}
+
+// openConnector 通过 System 内部 API 获取资源并创建连接器，任务按创建者的资源授权运行
+func (e *Executor) openConnector(task *models.TransferTask, resourceID uint) (connector.Connector, string, error) {
+	resource, err := e.resourceService.GetResource(resourceID, task.TenantID, task.CreatedBy)
+	if err != nil {
+		return nil, "", err
+	}
+	conn, err := connector.New(resource)
+	if err != nil {
+		return nil, "", err
+	}
+	return conn, fmt.Sprintf("%s(%s)", resource.Name, resource.ResourceType), nil
+}
+
+// loadCheckpoint 加载可续传的断点
+// 同一执行的重试/回收，以及 resume、retry 触发的新执行会从断点继续，其余触发方式从头开始
+func (e *Executor) loadCheckpoint(task *models.TransferTask, execution *models.TaskExecution) (*models.TaskCheckpoint, error) {
+	checkpoint, err := e.checkpointRepo.Get(task.ID, models.DefaultPartition)
+	if err != nil || checkpoint == nil {
+		return nil, err
+	}
+
+	resumable := checkpoint.ExecutionID == execution.ID ||
+		execution.TriggerType == models.TriggerResume ||
+		execution.TriggerType == models.TriggerRetry
+	if !resumable {
+		return nil, e.checkpointRepo.DeleteByTask(task.ID)
+	}
+	return checkpoint, nil
+}
+
+// Execute 执行任务，ctx 被取消时在批次边界停止
+func (e *Executor) Execute(ctx context.Context, task *models.TransferTask, execution *models.TaskExecution, stats *Stats, logf Logger) error {
+	cfg, err := task.ParseConfig()
+	if err != nil {
+		return fmt.Errorf("invalid task config: %w", err)
+	}
+
+	stats.SetPhase(models.PhasePreparing)
+	checkpoint, err := e.loadCheckpoint(task, execution)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	err = e.transfer(ctx, task, execution, cfg, checkpoint, stats, logf)
+	if checkpoint != nil && errors.Is(err, connector.ErrResumeUnavailable) {
+		logf("checkpoint discarded: %v, restart from beginning", err)
+		if err := e.checkpointRepo.DeleteByTask(task.ID); err != nil {
+			return err
+		}
+		stats.Reset()
+		err = e.transfer(ctx, task, execution, cfg, nil, stats, logf)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 成功后清除断点，下次执行从头开始
+	return e.checkpointRepo.DeleteByTask(task.ID)
+}
+
+func (e *Executor) transfer(ctx context.Context, task *models.TransferTask, execution *models.TaskExecution, cfg *models.TaskConfig,
+	checkpoint *models.TaskCheckpoint, stats *Stats, logf Logger) error {
+	source, sourceName, err := e.openConnector(task, task.SourceID)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	defer source.Close()
+
+	target, targetName, err := e.openConnector(task, task.TargetID)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer target.Close()
+
+	batchSize := e.batchSize
+	if cfg.BatchSize > 0 {
+		batchSize = cfg.BatchSize
+	}
+	logf("transfer %s -> %s, batch_size=%d, write_mode=%s", sourceName, targetName, batchSize, cfg.WriteMode)
+
+	readOpts := connector.ReadOptions{BatchSize: batchSize}
+	writeOpts := connector.WriteOptions{WriteMode: cfg.WriteMode}
+	if cfg.WriteMode == models.WriteModeUpsert {
+		writeOpts.KeyColumns = e.upsertKeys(task, cfg)
+	}
+	if task.Type == models.TaskTypeSync && cfg.Sync != nil {
+		readOpts.Incremental = &connector.Incremental{Column: cfg.Sync.WatermarkColumn, After: task.Watermark}
+		logf("incremental sync on %s after %q, keys=%v", cfg.Sync.WatermarkColumn, task.Watermark, writeOpts.KeyColumns)
+	}
+	if checkpoint != nil {
+		var position connector.Position
+		var state connector.WriterState
+		if err := checkpoint.Position.Decode(&position); err != nil {
+			return fmt.Errorf("%w: %v", connector.ErrResumeUnavailable, err)
+		}
+		if err := checkpoint.WriterState.Decode(&state); err != nil {
+			return fmt.Errorf("%w: %v", connector.ErrResumeUnavailable, err)
+		}
+		readOpts.Resume = &position
+		writeOpts.Resuming = true
+		writeOpts.Resume = &state
+
+		stats.RecordsRead.Store(checkpoint.RecordsRead)
+		stats.RecordsWritten.Store(checkpoint.RecordsWritten)
+		stats.RecordsRejected.Store(checkpoint.RecordsRejected)
+		stats.BytesRead.Store(checkpoint.BytesRead)
+		stats.BytesWritten.Store(checkpoint.BytesWritten)
+		logf("resume from checkpoint of execution %d: offset=%d keys=%v written=%d",
+			checkpoint.ExecutionID, position.Offset, position.Keys, checkpoint.RecordsWritten)
+	}
+
+	reader, err := source.OpenReader(ctx, cfg.Source, readOpts)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	defer reader.Close()
+
+	sourceMeta := e.metadataService.GetTable(task.SourceID, task.TenantID, cfg.Source)
+	if err := e.estimateTotal(ctx, reader, sourceMeta, readOpts, stats, logf); err != nil {
+		return err
+	}
+
+	columns := reader.Columns()
+	var pipeline *transformer.Pipeline
+	if len(cfg.Transforms) > 0 {
+		if pipeline, err = transformer.New(cfg.Transforms, columns); err != nil {
+			return err
+		}
+		columns = pipeline.Columns()
+		logf("transform pipeline: %d step(s)", len(cfg.Transforms))
+	}
+	if sourceMeta != nil {
+		writeOpts.Fields = targetFields(sourceMeta, reader.Columns(), columns)
+	}
+
+	writer, err := target.OpenWriter(ctx, cfg.Target, columns, writeOpts)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer writer.Close()
+
+	rej, err := e.newRejector(task, execution, cfg, stats, logf)
+	if err != nil {
+		return err
+	}
+	if rej != nil {
+		defer rej.close()
+	}
+
+	sized, _ := reader.(connector.SizedReader)
+	stats.SetPhase(models.PhaseTransferring)
+
+	for {
+		batch, err := reader.Read(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		stats.RecordsRead.Add(int64(batch.Len()))
+		stats.BytesRead.Add(batch.Size())
+		transferRows.WithLabelValues(task.Type, "read").Add(float64(batch.Len()))
+		transferBytes.WithLabelValues(task.Type, "read").Add(float64(batch.Size()))
+		if sized != nil {
+			read, _ := sized.SourceBytes()
+			stats.SourceBytesRead.Store(read)
+		}
+
+		if pipeline != nil {
+			if rej == nil {
+				if batch, err = pipeline.Apply(batch); err != nil {
+					return fmt.Errorf("transform: %w", err)
+				}
+			} else {
+				source := batch
+				var rowErrors []connector.RowError
+				batch, rowErrors = pipeline.ApplyEach(source)
+				if err := rej.reject(ctx, stageTransform, source, rowErrors); err != nil {
+					return err
+				}
+			}
+		}
+
+		written, err := e.write(ctx, writer, batch, rej)
+		if err != nil {
+			return err
+		}
+		stats.RecordsWritten.Add(int64(written))
+		stats.BytesWritten.Add(batch.Size())
+		transferRows.WithLabelValues(task.Type, "written").Add(float64(written))
+		transferBytes.WithLabelValues(task.Type, "written").Add(float64(batch.Size()))
+
+		if err := e.saveCheckpoint(task, execution, batch, writer, stats); err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	if rej != nil {
+		if err := rej.checkBudget(true); err != nil {
+			return err
+		}
+	}
+	stats.SetPhase(models.PhaseCommitting)
+	if err := writer.Commit(ctx); err != nil {
+		return err
+	}
+
+	logf("transfer finished: read=%d written=%d rejected=%d",
+		stats.RecordsRead.Load(), stats.RecordsWritten.Load(), stats.RecordsRejected.Load())
+
+	// 增量同步成功后推进水位，下次只读取之后变化的行
+	if wr, ok := reader.(connector.WatermarkReader); ok && readOpts.Incremental != nil {
+		if watermark := wr.HighWatermark(); watermark != task.Watermark {
+			if err := e.taskRepo.UpdateWatermark(task.ID, watermark); err != nil {
+				return fmt.Errorf("failed to save watermark: %w", err)
+			}
+			logf("watermark advanced to %q", watermark)
+		}
+	}
+	return nil
+}
+
+// estimateTotal 预估源端总量用于计算进度：
+// 全量读取表时优先使用 Meta 扫描的行数，避免对大表执行 COUNT；对象存储使用对象大小
+func (e *Executor) estimateTotal(ctx context.Context, reader connector.Reader, meta *models.TableMeta,
+	readOpts connector.ReadOptions, stats *Stats, logf Logger) error {
+	if sized, ok := reader.(connector.SizedReader); ok {
+		_, total := sized.SourceBytes()
+		stats.TotalBytes.Store(total)
+	}
+
+	if meta != nil && meta.RowCount > 0 && readOpts.Incremental == nil {
+		stats.TotalRecords.Store(meta.RowCount)
+		logf("source rows (from metadata): %d", meta.RowCount)
+		return nil
+	}
+
+	total, err := reader.EstimateCount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count source rows: %w", err)
+	}
+	stats.TotalRecords.Store(total)
+	if total > 0 {
+		logf("source rows: %d", total)
+	}
+	return nil
+}
+
+// targetFields 选出转换后仍保持原类型的源端字段，作为目标端建表的依据
+func targetFields(meta *models.TableMeta, sourceColumns, columns []connector.Column) []models.FieldMeta {
+	sourceTypes := make(map[string]string, len(sourceColumns))
+	for _, col := range sourceColumns {
+		sourceTypes[col.Name] = col.DatabaseType
+	}
+
+	var fields []models.FieldMeta
+	for _, col := range columns {
+		field, ok := meta.Field(col.Name)
+		if !ok {
+			continue
+		}
+		// 被 cast/hash/compute 等步骤改变类型的列按转换后的类型建表
+		if sourceType, ok := sourceTypes[col.Name]; ok && sourceType == col.DatabaseType {
+			fields = append(fields, *field)
+		}
+	}
+
+	// 主键列被删除或改变类型时不再沿用源表主键
+	included := 0
+	for _, field := range fields {
+		if field.IsPrimaryKey {
+			included++
+		}
+	}
+	if included != len(meta.PrimaryKeys()) {
+		for i := range fields {
+			fields[i].IsPrimaryKey = false
+		}
+	}
+	return fields
+}
+
+// upsertKeys 获取 upsert 的主键列：任务配置优先，其次是 Meta 扫描的字段信息，都没有时由连接器读取目标表主键
+func (e *Executor) upsertKeys(task *models.TransferTask, cfg *models.TaskConfig) []string {
+	if cfg.Sync != nil && len(cfg.Sync.KeyColumns) > 0 {
+		return cfg.Sync.KeyColumns
+	}
+	return e.metadataService.PrimaryKeys(task.TenantID, task.TargetID, cfg.Target, task.SourceID, cfg.Source)
+}
+
+// write 写入批次并返回写入的行数
+// 配置了错误处理时，批量写入失败后逐行写入，失败的行被拒绝而不中断执行
+func (e *Executor) write(ctx context.Context, writer connector.Writer, batch *connector.Batch, rej *rejector) (int, error) {
+	err := writer.Write(ctx, batch)
+	if err == nil {
+		return batch.Len(), nil
+	}
+	rowWriter, ok := writer.(connector.RowWriter)
+	if rej == nil || !ok || ctx.Err() != nil {
+		return 0, err
+	}
+
+	rowErrors, err := rowWriter.WriteEach(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+	if err := rej.reject(ctx, stageWrite, batch, rowErrors); err != nil {
+		return 0, err
+	}
+	return batch.Len() - len(rowErrors), nil
+}
+
+// saveCheckpoint 批次持久化到目标端后记录断点
+func (e *Executor) saveCheckpoint(task *models.TransferTask, execution *models.TaskExecution, batch *connector.Batch, writer connector.Writer, stats *Stats) error {
+	state, durable := writer.State()
+	if !durable || batch.Position == nil {
+		return nil
+	}
+
+	position, err := models.ToJSONMap(batch.Position)
+	if err != nil {
+		return err
+	}
+	writerState := models.JSONMap{}
+	if state != nil {
+		if writerState, err = models.ToJSONMap(state); err != nil {
+			return err
+		}
+	}
+
+	return e.checkpointRepo.Save(&models.TaskCheckpoint{
+		TaskID:          task.ID,
+		Partition:       models.DefaultPartition,
+		ExecutionID:     execution.ID,
+		Position:        position,
+		WriterState:     writerState,
+		RecordsRead:     stats.RecordsRead.Load(),
+		RecordsWritten:  stats.RecordsWritten.Load(),
+		RecordsRejected: stats.RecordsRejected.Load(),
+		BytesRead:       stats.BytesRead.Load(),
+		BytesWritten:    stats.BytesWritten.Load(),
+	})
+}
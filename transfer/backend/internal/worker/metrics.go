@@ -0,0 +1,21 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/addp/common/metrics"
+)
+
+var (
+	transferRows = metrics.NewCounterVec("transfer_rows_total",
+		"读取、写入和拒绝（写入死信）的行数", "task_type", "direction")
+	transferBytes = metrics.NewCounterVec("transfer_bytes_total",
+		"读取和写入的字节数", "task_type", "direction")
+	executionDuration = metrics.NewHistogramVec("transfer_execution_duration_seconds",
+		"任务执行耗时（秒），status 为 queued 表示失败后重新排队", []float64{1, 5, 15, 60, 300, 900, 1800, 3600, 10800}, "task_type", "status")
+)
+
+// observeExecution 记录一次执行的结果和耗时
+func observeExecution(taskType, status string, duration time.Duration) {
+	executionDuration.WithLabelValues(taskType, status).Observe(duration.Seconds())
+}
@@ -0,0 +1,329 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/addp/transfer/internal/config"
+	"github.com/addp/transfer/internal/models"
+	"github.com/addp/transfer/internal/queue"
+	"github.com/addp/transfer/internal/repository"
+)
+
+// errStopRequested 执行被用户暂停或取消
+var errStopRequested = errors.New("stop requested")
+
+// Pool 从队列消费执行任务的 Worker 池
+type Pool struct {
+	cfg           *config.Config
+	queue         *queue.Queue
+	taskRepo      *repository.TaskRepository
+	executionRepo *repository.ExecutionRepository
+	executor      *Executor
+	workerID      string
+}
+
+func NewPool(cfg *config.Config, q *queue.Queue, taskRepo *repository.TaskRepository, executionRepo *repository.ExecutionRepository, executor *Executor) *Pool {
+	hostname, _ := os.Hostname()
+	return &Pool{
+		cfg:           cfg,
+		queue:         q,
+		taskRepo:      taskRepo,
+		executionRepo: executionRepo,
+		executor:      executor,
+		workerID:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+	}
+}
+
+// Run 启动消费协程，ctx 取消后等待正在执行的任务结束
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.promoteLoop(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		p.reapLoop(ctx)
+	}()
+
+	for i := 0; i < p.cfg.WorkerCount; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			p.consume(ctx, index)
+		}(i)
+	}
+
+	log.Printf("Worker %s started with %d consumers", p.workerID, p.cfg.WorkerCount)
+	wg.Wait()
+	log.Printf("Worker %s stopped", p.workerID)
+}
+
+func (p *Pool) consume(ctx context.Context, index int) {
+	for ctx.Err() == nil {
+		executionID, err := p.queue.Dequeue(ctx, 5*time.Second)
+		if err != nil {
+			if !errors.Is(err, queue.ErrEmpty) && ctx.Err() == nil {
+				log.Printf("[consumer %d] dequeue failed: %v", index, err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		// 集群内并发上限，超出时延迟重新入队
+		if p.cfg.ConcurrentTasks > 0 {
+			running, err := p.executionRepo.CountRunning()
+			if err == nil && running >= int64(p.cfg.ConcurrentTasks) {
+				if err := p.queue.EnqueueDelayed(ctx, executionID, 5*time.Second); err != nil {
+					log.Printf("[consumer %d] failed to delay execution %d: %v", index, executionID, err)
+				}
+				continue
+			}
+		}
+
+		p.process(ctx, executionID)
+	}
+}
+
+// promoteLoop 定期将到期的重试任务移入就绪队列
+func (p *Pool) promoteLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.queue.PromoteDelayed(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to promote delayed executions: %v", err)
+			}
+		}
+	}
+}
+
+// reapLoop 回收心跳超时的执行（Worker 崩溃后重新排队）
+func (p *Pool) reapLoop(ctx context.Context) {
+	interval := p.heartbeatInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stale, err := p.executionRepo.ListStale(time.Now().Add(-3 * interval))
+			if err != nil {
+				log.Printf("Failed to list stale executions: %v", err)
+				continue
+			}
+			for _, execution := range stale {
+				p.appendLog(execution.ID, "worker %s lost heartbeat, requeue", execution.WorkerID)
+				if err := p.executionRepo.Requeue(execution.ID, execution.Attempt, "worker heartbeat timeout"); err != nil {
+					log.Printf("Failed to requeue stale execution %d: %v", execution.ID, err)
+					continue
+				}
+				if err := p.queue.Enqueue(ctx, execution.ID); err != nil {
+					log.Printf("Failed to enqueue stale execution %d: %v", execution.ID, err)
+				}
+			}
+		}
+	}
+}
+
+// process 执行单个任务
+func (p *Pool) process(ctx context.Context, executionID uint) {
+	claimed, err := p.executionRepo.Claim(executionID, p.workerID)
+	if err != nil {
+		log.Printf("Failed to claim execution %d: %v", executionID, err)
+		return
+	}
+	if !claimed {
+		// 仍在排队说明同一任务的上一次执行尚未结束，稍后再试；否则已被取消或其他 Worker 抢占
+		if execution, err := p.executionRepo.GetByID(executionID); err == nil && execution.Status == models.ExecutionStatusQueued {
+			if err := p.queue.EnqueueDelayed(ctx, executionID, 5*time.Second); err != nil {
+				log.Printf("Failed to delay execution %d: %v", executionID, err)
+			}
+		}
+		return
+	}
+
+	execution, err := p.executionRepo.GetByID(executionID)
+	if err != nil {
+		log.Printf("Failed to load execution %d: %v", executionID, err)
+		return
+	}
+	task, err := p.taskRepo.GetByID(execution.TaskID)
+	if err != nil {
+		execution.Status = models.ExecutionStatusFailed
+		execution.ErrorMsg = fmt.Sprintf("task %d not found", execution.TaskID)
+		p.executionRepo.Finish(execution)
+		return
+	}
+
+	p.taskRepo.UpdateStatus(task.ID, models.TaskStatusRunning, nil)
+	p.appendLog(execution.ID, "execution started on %s (attempt %d)", p.workerID, execution.Attempt)
+
+	runCtx, cancel := context.WithTimeout(context.Background(), p.cfg.ExecutionTimeout)
+	defer cancel()
+
+	stats := &Stats{}
+	tracker := newProgressTracker(execution, stats)
+	var stopRequest string
+	var stopMu sync.Mutex
+	done := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(p.heartbeatInterval())
+		defer ticker.Stop()
+		progressTicker := time.NewTicker(p.progressInterval())
+		defer progressTicker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				// Worker 退出时中断执行，执行会被其他 Worker 回收
+				cancel()
+				return
+			case now := <-progressTicker.C:
+				// 实时进度只发布到 Redis，数据库中的计数随心跳更新
+				if err := p.queue.PublishProgress(ctx, tracker.snapshot(now)); err != nil && ctx.Err() == nil {
+					log.Printf("Failed to publish progress of execution %d: %v", execution.ID, err)
+				}
+			case now := <-ticker.C:
+				request, err := p.executionRepo.Heartbeat(execution.ID, tracker.snapshot(now))
+				if err != nil {
+					log.Printf("Heartbeat failed for execution %d: %v", execution.ID, err)
+					continue
+				}
+				progress := stats.Progress()
+				p.taskRepo.UpdateStatus(task.ID, models.TaskStatusRunning, &progress)
+				if request != "" {
+					stopMu.Lock()
+					stopRequest = request
+					stopMu.Unlock()
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	logf := func(format string, args ...interface{}) {
+		p.appendLog(execution.ID, format, args...)
+	}
+	started := time.Now()
+	execErr := p.executor.Execute(runCtx, task, execution, stats, logf)
+	close(done)
+	<-heartbeatDone
+
+	stopMu.Lock()
+	request := stopRequest
+	stopMu.Unlock()
+	if execErr != nil && request != "" {
+		execErr = errStopRequested
+	}
+
+	execution.Phase = stats.Phase()
+	execution.TotalRecords = stats.TotalRecords.Load()
+	execution.RecordsRead = stats.RecordsRead.Load()
+	execution.RecordsWritten = stats.RecordsWritten.Load()
+	execution.RecordsRejected = stats.RecordsRejected.Load()
+	execution.BytesRead = stats.BytesRead.Load()
+	execution.BytesWritten = stats.BytesWritten.Load()
+
+	switch {
+	case execErr == nil:
+		execution.Status = models.ExecutionStatusSuccess
+		p.appendLog(execution.ID, "execution succeeded")
+		p.executionRepo.Finish(execution)
+		progress := 100.0
+		p.taskRepo.UpdateStatus(task.ID, models.TaskStatusSuccess, &progress)
+
+	case errors.Is(execErr, errStopRequested):
+		execution.Status = models.ExecutionStatusCancelled
+		taskStatus := models.TaskStatusCancelled
+		if request == models.StopRequestPause {
+			execution.Status = models.ExecutionStatusPaused
+			taskStatus = models.TaskStatusPaused
+		}
+		p.appendLog(execution.ID, "execution stopped by %s request", request)
+		p.executionRepo.Finish(execution)
+		p.taskRepo.UpdateStatus(task.ID, taskStatus, nil)
+
+	case ctx.Err() != nil:
+		// Worker 正在退出，交回队列由其他 Worker 重新执行
+		p.appendLog(execution.ID, "worker shutting down, requeue execution")
+		if err := p.executionRepo.Requeue(execution.ID, execution.Attempt, execErr.Error()); err == nil {
+			p.queue.Enqueue(context.Background(), execution.ID)
+		}
+
+	case execution.Attempt < p.cfg.MaxRetries && !errors.Is(execErr, ErrErrorBudgetExceeded):
+		p.appendLog(execution.ID, "attempt %d failed: %v, retry in %s", execution.Attempt, execErr, p.cfg.RetryDelay)
+		if err := p.executionRepo.Requeue(execution.ID, execution.Attempt+1, execErr.Error()); err != nil {
+			log.Printf("Failed to requeue execution %d: %v", execution.ID, err)
+			return
+		}
+		if err := p.queue.EnqueueDelayed(context.Background(), execution.ID, p.cfg.RetryDelay); err != nil {
+			log.Printf("Failed to schedule retry for execution %d: %v", execution.ID, err)
+		}
+		p.taskRepo.UpdateStatus(task.ID, models.TaskStatusPending, nil)
+
+	default:
+		execution.Status = models.ExecutionStatusFailed
+		execution.ErrorMsg = execErr.Error()
+		p.appendLog(execution.ID, "execution failed: %v", execErr)
+		p.executionRepo.Finish(execution)
+		p.taskRepo.UpdateStatus(task.ID, models.TaskStatusFailed, nil)
+	}
+
+	if !execution.IsFinished() {
+		// 已重新排队等待执行
+		execution.Status = models.ExecutionStatusQueued
+	}
+	observeExecution(task.Type, execution.Status, time.Since(started))
+	p.publishFinal(execution)
+}
+
+func (p *Pool) progressInterval() time.Duration {
+	if p.cfg.ProgressInterval <= 0 {
+		return time.Second
+	}
+	return p.cfg.ProgressInterval
+}
+
+// publishFinal 发布执行结束（或重新排队）时的进度，通知订阅者
+func (p *Pool) publishFinal(execution *models.TaskExecution) {
+	progress := models.ProgressFromExecution(execution)
+	progress.UpdatedAt = time.Now()
+	if err := p.queue.PublishProgress(context.Background(), progress); err != nil {
+		log.Printf("Failed to publish progress of execution %d: %v", execution.ID, err)
+	}
+}
+
+func (p *Pool) heartbeatInterval() time.Duration {
+	if p.cfg.HeartbeatInterval <= 0 {
+		return 10 * time.Second
+	}
+	return p.cfg.HeartbeatInterval
+}
+
+// appendLog 追加带时间戳的执行日志
+func (p *Pool) appendLog(executionID uint, format string, args ...interface{}) {
+	line := fmt.Sprintf("[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+	if err := p.executionRepo.AppendLog(executionID, line); err != nil {
+		log.Printf("Failed to append log for execution %d: %v", executionID, err)
+	}
+}
@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// rateSmoothing 吞吐量的指数平滑系数，越大越接近瞬时速率
+const rateSmoothing = 0.3
+
+// progressTracker 根据相邻两次采样计算吞吐量和剩余时间
+type progressTracker struct {
+	execution *models.TaskExecution
+	stats     *Stats
+
+	lastAt          time.Time
+	lastRecords     int64
+	lastBytes       int64
+	lastSourceBytes int64
+	rowsPerSecond   float64
+	bytesPerSecond  float64
+	sourceRate      float64 // 源对象字节/秒，用于按字节估算剩余时间
+}
+
+func newProgressTracker(execution *models.TaskExecution, stats *Stats) *progressTracker {
+	return &progressTracker{execution: execution, stats: stats, lastAt: time.Now()}
+}
+
+// snapshot 采样并生成进度快照
+func (t *progressTracker) snapshot(now time.Time) *models.ExecutionProgress {
+	records := t.stats.RecordsRead.Load()
+	bytes := t.stats.BytesRead.Load()
+	sourceBytes := t.stats.SourceBytesRead.Load()
+
+	if elapsed := now.Sub(t.lastAt).Seconds(); elapsed > 0 {
+		t.rowsPerSecond = smoothRate(t.rowsPerSecond, float64(records-t.lastRecords)/elapsed)
+		t.bytesPerSecond = smoothRate(t.bytesPerSecond, float64(bytes-t.lastBytes)/elapsed)
+		t.sourceRate = smoothRate(t.sourceRate, float64(sourceBytes-t.lastSourceBytes)/elapsed)
+		t.lastAt, t.lastRecords, t.lastBytes, t.lastSourceBytes = now, records, bytes, sourceBytes
+	}
+
+	progress := &models.ExecutionProgress{
+		ExecutionID:     t.execution.ID,
+		TaskID:          t.execution.TaskID,
+		Status:          models.ExecutionStatusRunning,
+		Phase:           t.stats.Phase(),
+		RecordsRead:     records,
+		RecordsWritten:  t.stats.RecordsWritten.Load(),
+		RecordsRejected: t.stats.RecordsRejected.Load(),
+		BytesRead:       bytes,
+		BytesWritten:    t.stats.BytesWritten.Load(),
+		TotalRecords:    t.stats.TotalRecords.Load(),
+		TotalBytes:      t.stats.TotalBytes.Load(),
+		SourceBytesRead: sourceBytes,
+		Percent:         t.stats.Progress(),
+		RowsPerSecond:   t.rowsPerSecond,
+		BytesPerSecond:  t.bytesPerSecond,
+		StartTime:       t.execution.StartTime,
+		UpdatedAt:       now,
+	}
+
+	switch {
+	case progress.TotalRecords > 0 && t.rowsPerSecond > 0:
+		progress.ETASeconds = eta(progress.TotalRecords-records, t.rowsPerSecond)
+	case progress.TotalBytes > 0 && t.sourceRate > 0:
+		progress.ETASeconds = eta(progress.TotalBytes-sourceBytes, t.sourceRate)
+	}
+	return progress
+}
+
+func smoothRate(previous, current float64) float64 {
+	if previous == 0 {
+		return current
+	}
+	return previous*(1-rateSmoothing) + current*rateSmoothing
+}
+
+func eta(remaining int64, rate float64) *int64 {
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := int64(float64(remaining) / rate)
+	return &seconds
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"sync/atomic"
+
+	"github.com/addp/transfer/internal/models"
+)
+
+// Stats 执行过程中的读写计数（由执行协程更新，心跳协程读取）
+type Stats struct {
+	RecordsRead     atomic.Int64
+	RecordsWritten  atomic.Int64
+	RecordsRejected atomic.Int64 // 被拒绝（写入死信）的行数
+	BytesRead       atomic.Int64
+	BytesWritten    atomic.Int64
+	TotalRecords    atomic.Int64 // 预估总行数，未知时为 0
+	TotalBytes      atomic.Int64 // 源对象大小，行数未知时用于计算进度
+	SourceBytesRead atomic.Int64 // 已读取的源对象字节数
+
+	phase atomic.Value
+}
+
+// SetPhase 设置当前执行阶段
+func (s *Stats) SetPhase(phase string) {
+	s.phase.Store(phase)
+}
+
+// Phase 返回当前执行阶段
+func (s *Stats) Phase() string {
+	if phase, ok := s.phase.Load().(string); ok {
+		return phase
+	}
+	return models.PhasePreparing
+}
+
+// Progress 按已读取行数（或源对象字节数）计算进度百分比
+func (s *Stats) Progress() float64 {
+	if total := s.TotalRecords.Load(); total > 0 {
+		return models.Percent(s.RecordsRead.Load(), total)
+	}
+	return models.Percent(s.SourceBytesRead.Load(), s.TotalBytes.Load())
+}
+
+// Reset 清零计数（断点失效从头开始时使用）
+func (s *Stats) Reset() {
+	s.RecordsRead.Store(0)
+	s.RecordsWritten.Store(0)
+	s.RecordsRejected.Store(0)
+	s.BytesRead.Store(0)
+	s.BytesWritten.Store(0)
+	s.TotalRecords.Store(0)
+	s.TotalBytes.Store(0)
+	s.SourceBytesRead.Store(0)
+}